@@ -2,9 +2,12 @@ package app
 
 import (
 	"backend/internal/config"
+	"backend/internal/domain/entities"
 	"backend/internal/handlers"
 	v1 "backend/internal/handlers/v1"
+	"backend/internal/infrastructure/repositories/minio"
 	"backend/internal/infrastructure/repositories/postgres"
+	"backend/internal/service/account"
 	"backend/internal/service/auth"
 	"backend/internal/service/avatar"
 	"backend/internal/service/crud"
@@ -12,12 +15,17 @@ import (
 	"backend/internal/service/nutricion"
 	"backend/internal/service/recomendation"
 	"backend/internal/service/workouts"
+	"backend/pkg/JWT"
 	"backend/pkg/ai"
+	"backend/pkg/apple"
 	"backend/pkg/cache"
 	"backend/pkg/logging"
 	notifapns "backend/pkg/notifications/apns"
+	notiffcm "backend/pkg/notifications/fcm"
 	notifsg "backend/pkg/notifications/sendgrid"
 	notiftwilio "backend/pkg/notifications/twilio"
+	"backend/pkg/password"
+	"backend/pkg/templates"
 	"context"
 	"errors"
 	"fmt"
@@ -58,6 +66,10 @@ func NewApp(configPaths ...string) *App {
 
 	logger := logging.GetLoggerFromContext(ctx)
 
+	if err := JWT.Configure(cfg.JWT); err != nil {
+		logger.Fatalf("Failed to configure JWT signing keys: %v", err)
+	}
+
 	db, err := initDB(cfg.Postgres)
 	if err != nil {
 		logger.Fatalf("Failed to init db: %v", err)
@@ -75,7 +87,7 @@ func NewApp(configPaths ...string) *App {
 	if cfg.Redis.Addr != "" {
 		rc, rerr := cache.NewClient(cfg.Redis)
 		if rerr != nil {
-			logger.Warnf("Redis unavailable (%v) — running without AI cache", rerr)
+			logger.Warnf("Redis unavailable (%v) — running without AI cache, auth attempt counters fall back to Postgres", rerr)
 		} else {
 			redisClient = rc
 			closers = append(closers, redisClient)
@@ -88,22 +100,55 @@ func NewApp(configPaths ...string) *App {
 	userWeightRepository := postgres.NewUserWeightRepository(db)
 	exercisesRepository := postgres.NewExerciseRepository(db)
 	tasksRepository := postgres.NewTasksRepository(db)
+	taskAttemptsRepository := postgres.NewTaskAttemptsRepository(db)
+	recurringTasksRepository := postgres.NewRecurringTasksRepository(db)
 	workoutsRepository := postgres.NewWorkoutRepository(db)
 	workoutsExerciseRepository := postgres.NewWorkoutsExerciseRepository(db)
 	userDevicesRepository := postgres.NewUserDevicesRepository(db)
 	userCaloriesRepository := postgres.NewUserCaloriesRepository(db)
 	userRefreshTokensRepository := postgres.NewUserRefreshTokensRepository(db)
 	userVerificationCodesRepository := postgres.NewUserVerificationCodesRepository(db)
+	userRecoveryCodesRepository := postgres.NewUserRecoveryCodesRepository(db)
+	userPersonalTokensRepository := postgres.NewUserPersonalTokensRepository(db)
+	auditEventsRepository := postgres.NewAuditEventsRepository(db)
 	userFoodRepository := postgres.NewUserFoodRepository(db)
 	userRecommendationsRepository := postgres.NewUserRecommendationsRepository(db)
+	accountRepository := postgres.NewAccountRepository(db)
+	notificationPreferencesRepository := postgres.NewNotificationPreferencesRepository(db)
+	liveActivitiesRepository := postgres.NewLiveActivitiesRepository(db)
+
+	var authAttemptsStore auth.AttemptsStore = postgres.NewAuthAttemptsRepository(db)
+	if redisClient != nil {
+		authAttemptsStore = redisClient
+	}
+
+	var appleVerifier auth.AppleVerifier
+	if v, aerr := apple.NewVerifier(cfg.Apple); aerr == nil {
+		appleVerifier = v
+	} else {
+		logger.Warnf("Sign in with Apple disabled: %v", aerr)
+	}
+
+	passwordPolicy, err := password.NewPolicy(cfg.Password)
+	if err != nil {
+		logger.Fatalf("Failed to init password policy: %v", err)
+	}
 
 	authService := auth.NewService(&auth.Config{
 		TransactionManager:          transactionManager,
 		UserInfoRepository:          userInfoRepository,
 		UserRefreshTokensRepository: userRefreshTokensRepository,
 		VerificationCodesRepository: userVerificationCodesRepository,
+		RecoveryCodesRepository:     userRecoveryCodesRepository,
+		PersonalTokensRepository:    userPersonalTokensRepository,
 		TasksRepository:             tasksRepository,
+		AttemptsStore:               authAttemptsStore,
+		AppleVerifier:               appleVerifier,
+		AuditEventsRepository:       auditEventsRepository,
+		PasswordPolicy:              passwordPolicy,
 	})
+	JWT.ConfigurePersonalTokens(authService)
+	JWT.ConfigureSessions(authService, cfg.JWT.SessionCacheTTL)
 
 	crudService := crud.NewService(&crud.Config{
 		TransactionManager:         transactionManager,
@@ -111,12 +156,18 @@ func NewApp(configPaths ...string) *App {
 		UserParamsRepository:       userParamsRepository,
 		UserWeightRepository:       userWeightRepository,
 		TasksRepository:            tasksRepository,
+		TaskAttemptsRepository:     taskAttemptsRepository,
+		RecurringTasksRepository:   recurringTasksRepository,
 		ExercisesRepository:        exercisesRepository,
 		WorkoutsRepository:         workoutsRepository,
 		WorkoutsExerciseRepository: workoutsExerciseRepository,
 		UserDevicesRepository:      userDevicesRepository,
 		UserCaloriesRepository:     userCaloriesRepository,
+		AuditEventsRepository:      auditEventsRepository,
 		Log:                        logger,
+
+		NotificationPreferencesRepository: notificationPreferencesRepository,
+		LiveActivitiesRepository:          liveActivitiesRepository,
 	})
 
 	avatarService := avatar.NewService(avatar.Config{
@@ -126,6 +177,16 @@ func NewApp(configPaths ...string) *App {
 		PublicURL:  cfg.Minio.PublicURL,
 	})
 
+	accountService := account.NewService(&account.Config{
+		TransactionManager:  transactionManager,
+		UserInfoRepository:  userInfoRepository,
+		AccountRepository:   accountRepository,
+		TasksRepository:     tasksRepository,
+		ObjectStorage:       minio.NewObjectStorage(s3, cfg.Minio.Bucket),
+		DeletionGracePeriod: cfg.AppConfig.AccountConfig.DeletionGracePeriod,
+		ExportLinkTTL:       cfg.AppConfig.AccountConfig.ExportLinkTTL,
+	})
+
 	workoutService := workouts.NewService(&workouts.Config{
 		TransactionManager:        transactionManager,
 		TasksRepository:           tasksRepository,
@@ -139,6 +200,9 @@ func NewApp(configPaths ...string) *App {
 		UserFoodRepository:        userFoodRepository,
 		WorkoutPullUserInterval:   cfg.AppConfig.WorkoutsConfig.WorkoutPullUserInterval,
 		LimitGenerateWorkouts:     cfg.AppConfig.WorkoutsConfig.LimitGenerateWorkouts,
+		NotificationDedupWindow:   cfg.AppConfig.NotificationDedupWindow,
+
+		NotificationPreferencesRepository: notificationPreferencesRepository,
 	})
 	workers = append(workers, workoutService)
 
@@ -154,7 +218,8 @@ func NewApp(configPaths ...string) *App {
 		FromPhone:  cfg.Twilio.FromPhone,
 	})
 
-	var pushClient executor.PushClient
+	pushClients := make(map[string]executor.PushClient)
+	var liveActivityClient executor.LiveActivityClient
 	if cfg.APNs.KeyPath != "" {
 		apnsClient, err := notifapns.NewClient(notifapns.Config{
 			KeyPath:  cfg.APNs.KeyPath,
@@ -166,17 +231,69 @@ func NewApp(configPaths ...string) *App {
 		if err != nil {
 			logger.Fatalf("Failed to init APNs client: %v", err)
 		}
-		pushClient = apnsClient
+		pushClients[entities.DevicePlatformIOS] = apnsClient
+		liveActivityClient = apnsClient
 	}
+	if cfg.FCM.CredentialsPath != "" {
+		fcmClient, err := notiffcm.NewClient(notiffcm.Config{
+			CredentialsPath: cfg.FCM.CredentialsPath,
+			ProjectID:       cfg.FCM.ProjectID,
+			Endpoint:        cfg.FCM.Endpoint,
+		})
+		if err != nil {
+			logger.Fatalf("Failed to init FCM client: %v", err)
+		}
+		pushClients[entities.DevicePlatformAndroid] = fcmClient
+	}
+
+	templateIDs := make([]string, 0, len(entities.NotificationTemplates))
+	for _, id := range entities.NotificationTemplates {
+		templateIDs = append(templateIDs, id.String())
+	}
+	renderer, err := templates.New(templateIDs...)
+	if err != nil {
+		logger.Fatalf("Failed to load notification templates: %v", err)
+	}
+
+	typeWorkers := make(map[entities.TaskType]int, len(cfg.AppConfig.ExecutorConfig.TypeWorkers))
+	for typ, size := range cfg.AppConfig.ExecutorConfig.TypeWorkers {
+		typeWorkers[entities.TaskType(typ)] = size
+	}
+
+	taskHandlers := executor.NewRegistry()
+	executor.RegisterNotificationHandlers(taskHandlers, executor.NotificationsConfig{
+		UserInfoRepository:    userInfoRepository,
+		UserDevicesRepository: userDevicesRepository,
+		TasksRepository:       tasksRepository,
+		PreferencesRepository: notificationPreferencesRepository,
+		EmailClient:           emailClient,
+		SMSClient:             smsClient,
+		PushClients:           pushClients,
+		Templates:             renderer,
+	})
+	executor.RegisterLiveActivityHandlers(taskHandlers, executor.LiveActivityConfig{
+		Repository: liveActivitiesRepository,
+		Client:     liveActivityClient,
+	})
+	accountService.RegisterTaskHandlers(taskHandlers)
+	authService.RegisterTaskHandlers(taskHandlers)
 
 	executorService := executor.NewService(&executor.Config{
-		TransactionManager: transactionManager,
-		TasksRepository:    tasksRepository,
-		UserInfoRepository: userInfoRepository,
-		EmailClient:        emailClient,
-		SMSClient:          smsClient,
-		PushClient:         pushClient,
-		QueryDelay:         cfg.AppConfig.TasksTrackingDuration,
+		TasksRepository:        tasksRepository,
+		TaskAttemptsRepository: taskAttemptsRepository,
+		TasksListener:          postgres.NewTasksListener(db),
+		Handlers:               taskHandlers,
+		QueryDelay:             cfg.AppConfig.TasksTrackingDuration,
+		BatchSize:              cfg.AppConfig.ExecutorConfig.BatchSize,
+		LeaseTimeout:           cfg.AppConfig.ExecutorConfig.LeaseTimeout,
+		Workers:                cfg.AppConfig.ExecutorConfig.Workers,
+		TypeWorkers:            typeWorkers,
+		HistoryRetention:       cfg.AppConfig.ExecutorConfig.HistoryRetention,
+
+		TransactionManager:       transactionManager,
+		RecurringTasksRepository: recurringTasksRepository,
+		UserTimezonesRepository:  userInfoRepository,
+		MisfireGrace:             cfg.AppConfig.ExecutorConfig.MisfireGrace,
 	})
 	workers = append(workers, executorService)
 
@@ -197,6 +314,8 @@ func NewApp(configPaths ...string) *App {
 		TasksRepository:          tasksRepository,
 		AIClient:                 aiClient,
 		RecommendationCache:      redisClient,
+		PreferencesRepository:    notificationPreferencesRepository,
+		PushDedupWindow:          cfg.AppConfig.NotificationDedupWindow,
 	})
 
 	validator := validator.New()
@@ -216,6 +335,8 @@ func NewApp(configPaths ...string) *App {
 			NutritionService:      nutritionService,
 			RecommendationService: recommendationService,
 			EmailService:          emailClient,
+			Templates:             renderer,
+			AccountService:        accountService,
 			Validator:             *validator,
 			Log:                   logger,
 		}),
@@ -3,8 +3,11 @@ package config
 import (
 	"backend/internal/infrastructure/repositories/minio"
 	"backend/internal/infrastructure/repositories/postgres"
+	"backend/pkg/JWT"
+	"backend/pkg/apple"
 	"backend/pkg/cache"
 	"backend/pkg/logging"
+	"backend/pkg/password"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -28,6 +31,12 @@ type AppConfig struct {
 	GracefulTimeout       time.Duration    `yaml:"graceful_timeout" env:"GRACEFUL_TIMEOUT" envDefault:"5s"`
 	TasksTrackingDuration time.Duration    `yaml:"tasks_tracking_duration" env:"TASKS_TRACKING_DURATION" envDefault:"13s"`
 	WorkoutsConfig        WorkoutsConfig   `yaml:"workouts_config" env-prefix:"WORKOUTS_CONFIG_"`
+	AccountConfig         AccountConfig    `yaml:"account" env-prefix:"ACCOUNT_"`
+	ExecutorConfig        ExecutorConfig   `yaml:"executor" env-prefix:"EXECUTOR_"`
+
+	// NotificationDedupWindow — сколько повторная постановка того же уведомления (о той же тренировке,
+	// «Совет дня» на устройство) ничего не создаёт. 0 — сутки.
+	NotificationDedupWindow time.Duration `yaml:"notification_dedup_window" env:"NOTIFICATION_DEDUP_WINDOW"`
 }
 
 type WorkoutsConfig struct {
@@ -35,6 +44,26 @@ type WorkoutsConfig struct {
 	LimitGenerateWorkouts   int           `yaml:"limit_generate_workouts,omitempty" env:"LIMIT_GENERATE_WORKS" envDefault:"3"`
 }
 
+// AccountConfig — удаление и выгрузка аккаунта. Нулевые значения заменяются значениями по умолчанию
+// из пакета account: 7 дней на отмену удаления и 72 часа жизни ссылки на архив.
+type AccountConfig struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env:"DELETION_GRACE_PERIOD"`
+	ExportLinkTTL       time.Duration `yaml:"export_link_ttl" env:"EXPORT_LINK_TTL"`
+}
+
+// ExecutorConfig — исполнитель фоновых задач. Нулевые значения заменяются значениями по умолчанию
+// из пакета executor: пачки по 10 задач, аренда на 5 минут, 4 воркера в общем пуле, история попыток хранится 30 дней,
+// срабатывание расписания выполняется с опозданием не больше чем на час.
+// TypeWorkers — отдельные пулы для типов задач, например "export_user_data_task: 1".
+type ExecutorConfig struct {
+	BatchSize        int            `yaml:"batch_size" env:"BATCH_SIZE"`
+	LeaseTimeout     time.Duration  `yaml:"lease_timeout" env:"LEASE_TIMEOUT"`
+	Workers          int            `yaml:"workers" env:"WORKERS"`
+	TypeWorkers      map[string]int `yaml:"type_workers" env:"TYPE_WORKERS"`
+	HistoryRetention time.Duration  `yaml:"history_retention" env:"HISTORY_RETENTION"`
+	MisfireGrace     time.Duration  `yaml:"misfire_grace" env:"MISFIRE_GRACE"`
+}
+
 type SendGridConfig struct {
 	APIKey    string `yaml:"api_key" env:"API_KEY"`
 	FromEmail string `yaml:"from_email" env:"FROM_EMAIL"`
@@ -55,6 +84,14 @@ type APNsConfig struct {
 	Sandbox  bool   `yaml:"sandbox" env:"SANDBOX" envDefault:"true"`
 }
 
+// FCMConfig — Firebase Cloud Messaging для Android. ProjectID по умолчанию берётся из ключа
+// сервисного аккаунта, Endpoint заменяет https://fcm.googleapis.com (локальная заглушка).
+type FCMConfig struct {
+	CredentialsPath string `yaml:"credentials_path" env:"CREDENTIALS_PATH"`
+	ProjectID       string `yaml:"project_id" env:"PROJECT_ID"`
+	Endpoint        string `yaml:"endpoint" env:"ENDPOINT"`
+}
+
 type OpenAIConfig struct {
 	APIKey string `yaml:"api_key" env:"API_KEY"`
 }
@@ -62,12 +99,16 @@ type OpenAIConfig struct {
 type Config struct {
 	AppConfig AppConfig       `yaml:"app"`
 	Log       logging.Config  `yaml:"sage" env:"SAGE_"`
+	JWT       JWT.Config      `yaml:"jwt" env-prefix:"JWT_"`
 	Postgres  postgres.Config `yaml:"postgres" env-prefix:"POSTGRES_"`
 	Minio     minio.Config    `yaml:"minio" env-prefix:"MINIO_"`
 	Redis     cache.Config    `yaml:"redis" env-prefix:"REDIS_"`
 	SendGrid  SendGridConfig  `yaml:"sendgrid" env-prefix:"SENDGRID_"`
 	Twilio    TwilioConfig    `yaml:"twilio" env-prefix:"TWILIO_"`
 	APNs      APNsConfig      `yaml:"apns" env-prefix:"APNS_"`
+	FCM       FCMConfig       `yaml:"fcm" env-prefix:"FCM_"`
+	Apple     apple.Config    `yaml:"apple" env-prefix:"APPLE_"`
+	Password  password.Config `yaml:"password" env-prefix:"PASSWORD_"`
 	OpenAI    OpenAIConfig    `yaml:"openai" env-prefix:"OPENAI_"`
 }
 
@@ -0,0 +1,159 @@
+package entities
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEventType — тип события журнала безопасности.
+type AuditEventType string
+
+func (t AuditEventType) String() string {
+	return string(t)
+}
+
+const (
+	AuditEventRegister                 AuditEventType = "auth.register"
+	AuditEventLogin                    AuditEventType = "auth.login"
+	AuditEventLoginFailed              AuditEventType = "auth.login_failed"
+	AuditEventLoginCodeRequested       AuditEventType = "auth.login_code_requested"
+	AuditEventMFAChallenge             AuditEventType = "auth.mfa_challenge"
+	AuditEventMFAFailed                AuditEventType = "auth.mfa_failed"
+	AuditEventTokenRefresh             AuditEventType = "auth.token_refresh"
+	AuditEventRefreshTokenReuse        AuditEventType = "auth.refresh_token_reuse"
+	AuditEventLogout                   AuditEventType = "auth.logout"
+	AuditEventSessionRevoked           AuditEventType = "auth.session_revoked"
+	AuditEventOtherSessionsRevoked     AuditEventType = "auth.other_sessions_revoked"
+	AuditEventPasswordResetRequested   AuditEventType = "auth.password_reset_requested"
+	AuditEventPasswordReset            AuditEventType = "auth.password_reset"
+	AuditEventPasswordResetFailed      AuditEventType = "auth.password_reset_failed"
+	AuditEventEmailVerified            AuditEventType = "auth.email_verified"
+	AuditEventPhoneVerified            AuditEventType = "auth.phone_verified"
+	AuditEventContactChangeRequested   AuditEventType = "auth.contact_change_requested"
+	AuditEventContactChanged           AuditEventType = "auth.contact_changed"
+	AuditEventMFAEnabled               AuditEventType = "auth.mfa_enabled"
+	AuditEventMFADisabled              AuditEventType = "auth.mfa_disabled"
+	AuditEventRecoveryCodesRegenerated AuditEventType = "auth.recovery_codes_regenerated"
+	AuditEventAppleLinked              AuditEventType = "auth.apple_linked"
+	AuditEventPersonalTokenCreated     AuditEventType = "auth.personal_token_created"
+	AuditEventPersonalTokenRevoked     AuditEventType = "auth.personal_token_revoked"
+	AuditEventProfileUpdated           AuditEventType = "profile.updated"
+	AuditEventRoleChanged              AuditEventType = "profile.role_changed"
+	AuditEventParamsCreated            AuditEventType = "params.created"
+	AuditEventParamsUpdated            AuditEventType = "params.updated"
+	AuditEventParamsDeleted            AuditEventType = "params.deleted"
+	AuditEventDeviceRegistered         AuditEventType = "device.registered"
+	AuditEventDeviceDeleted            AuditEventType = "device.deleted"
+)
+
+// AuditRedacted подставляется вместо значения секретного поля (пароля) в AuditChange.
+const AuditRedacted = "[redacted]"
+
+// AuditChange — значение поля до и после изменения.
+type AuditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditEvent — запись журнала безопасности. Записи только добавляются, изменить или удалить их нельзя.
+// UserID — чей аккаунт затронут (nil, если пользователь не найден, например при входе с неизвестным ником),
+// ActorID — кто выполнил действие: сам пользователь или, например, администратор.
+type AuditEvent struct {
+	id        uuid.UUID
+	userID    *uuid.UUID
+	actorID   *uuid.UUID
+	eventType AuditEventType
+	ip        string
+	userAgent string
+	changes   map[string]AuditChange
+	details   map[string]string
+	createdAt time.Time
+}
+
+func (e *AuditEvent) ID() uuid.UUID                   { return e.id }
+func (e *AuditEvent) UserID() *uuid.UUID              { return e.userID }
+func (e *AuditEvent) ActorID() *uuid.UUID             { return e.actorID }
+func (e *AuditEvent) Type() AuditEventType            { return e.eventType }
+func (e *AuditEvent) IP() string                      { return e.ip }
+func (e *AuditEvent) UserAgent() string               { return e.userAgent }
+func (e *AuditEvent) Changes() map[string]AuditChange { return e.changes }
+func (e *AuditEvent) Details() map[string]string      { return e.details }
+func (e *AuditEvent) CreatedAt() time.Time            { return e.createdAt }
+
+type AuditEventInitSpec struct {
+	UserID    *uuid.UUID
+	ActorID   *uuid.UUID
+	Type      AuditEventType
+	IP        string
+	UserAgent string
+	Changes   map[string]AuditChange
+	Details   map[string]string
+}
+
+type AuditEventRestoreSpec struct {
+	ID        uuid.UUID
+	UserID    *uuid.UUID
+	ActorID   *uuid.UUID
+	Type      AuditEventType
+	IP        string
+	UserAgent string
+	Changes   map[string]AuditChange
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+type AuditEventOption func(e *AuditEvent)
+
+func NewAuditEvent(opt AuditEventOption) *AuditEvent {
+	e := new(AuditEvent)
+	opt(e)
+	return e
+}
+
+func WithAuditEventInitSpec(s AuditEventInitSpec) AuditEventOption {
+	return func(e *AuditEvent) {
+		e.id = uuid.New()
+		e.userID = s.UserID
+		e.actorID = s.ActorID
+		e.eventType = s.Type
+		e.ip = s.IP
+		e.userAgent = s.UserAgent
+		e.changes = s.Changes
+		e.details = s.Details
+		e.createdAt = time.Now()
+	}
+}
+
+func WithAuditEventRestoreSpec(s AuditEventRestoreSpec) AuditEventOption {
+	return func(e *AuditEvent) {
+		e.id = s.ID
+		e.userID = s.UserID
+		e.actorID = s.ActorID
+		e.eventType = s.Type
+		e.ip = s.IP
+		e.userAgent = s.UserAgent
+		e.changes = s.Changes
+		e.details = s.Details
+		e.createdAt = s.CreatedAt
+	}
+}
+
+// DiffAuditFields сравнивает снимки полей до и после изменения и возвращает только изменившиеся.
+// Снимки строятся методами AuditFields сущностей.
+func DiffAuditFields(before, after map[string]any) map[string]AuditChange {
+	changes := make(map[string]AuditChange)
+	for k, newValue := range after {
+		oldValue := before[k]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[k] = AuditChange{Old: oldValue, New: newValue}
+		}
+	}
+	for k, oldValue := range before {
+		if _, ok := after[k]; !ok {
+			changes[k] = AuditChange{Old: oldValue, New: nil}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LiveActivity — Live Activity тренировки на устройстве пользователя. PushToken выдаёт iOS при запуске
+// активности, по нему APNs обновляет экран блокировки, пока приложение приостановлено.
+type LiveActivity struct {
+	id        uuid.UUID
+	userID    uuid.UUID
+	workoutID uuid.UUID
+	pushToken string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func (a *LiveActivity) ID() uuid.UUID        { return a.id }
+func (a *LiveActivity) UserID() uuid.UUID    { return a.userID }
+func (a *LiveActivity) WorkoutID() uuid.UUID { return a.workoutID }
+func (a *LiveActivity) PushToken() string    { return a.pushToken }
+func (a *LiveActivity) CreatedAt() time.Time { return a.createdAt }
+func (a *LiveActivity) UpdatedAt() time.Time { return a.updatedAt }
+
+type LiveActivityInitSpec struct {
+	UserID    uuid.UUID
+	WorkoutID uuid.UUID
+	PushToken string
+}
+
+type LiveActivityRestoreSpec struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	WorkoutID uuid.UUID
+	PushToken string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewLiveActivity(spec LiveActivityInitSpec) *LiveActivity {
+	now := time.Now()
+	return &LiveActivity{
+		id:        uuid.New(),
+		userID:    spec.UserID,
+		workoutID: spec.WorkoutID,
+		pushToken: spec.PushToken,
+		createdAt: now,
+		updatedAt: now,
+	}
+}
+
+func RestoreLiveActivity(spec LiveActivityRestoreSpec) *LiveActivity {
+	return &LiveActivity{
+		id:        spec.ID,
+		userID:    spec.UserID,
+		workoutID: spec.WorkoutID,
+		pushToken: spec.PushToken,
+		createdAt: spec.CreatedAt,
+		updatedAt: spec.UpdatedAt,
+	}
+}
+
+// LiveActivityState — content-state Live Activity тренировки, поля совпадают с ContentState виджета
+// в iOS-клиенте. Таймер на экране блокировки считается от ElapsedSeconds и времени обновления.
+type LiveActivityState struct {
+	Status              WorkoutsStatus `json:"status"`
+	CurrentExerciseID   *uuid.UUID     `json:"current_exercise_id,omitempty"`
+	CurrentExerciseName string         `json:"current_exercise_name,omitempty"`
+	CurrentExerciseReps int            `json:"current_exercise_reps,omitempty"`
+	ExerciseIndex       int            `json:"exercise_index"`
+	ExercisesTotal      int            `json:"exercises_total"`
+	CompletedExercises  int            `json:"completed_exercises"`
+	ElapsedSeconds      int64          `json:"elapsed_seconds"`
+}
+
+// Ended сообщает, что тренировка завершена и активность нужно закрыть.
+func (s LiveActivityState) Ended() bool {
+	return s.Status == WorkoutStatusDone || s.Status == WorkoutStatusFailed
+}
+
+// NewLiveActivityState собирает состояние тренировки. Текущее упражнение — выполняемое, а если такого нет,
+// первое ожидающее по порядку; ExerciseIndex считается с 1, 0 — текущего упражнения нет.
+// Название упражнения заполняет вызывающий.
+func NewLiveActivityState(w *Workout, exercises []*WorkoutsExercise) LiveActivityState {
+	ordered := slices.Clone(exercises)
+	slices.SortStableFunc(ordered, func(a, b *WorkoutsExercise) int { return a.OrderIndex() - b.OrderIndex() })
+
+	state := LiveActivityState{
+		Status:         w.Status(),
+		ExercisesTotal: len(ordered),
+		ElapsedSeconds: w.Duration(),
+	}
+
+	current := -1
+	for i, we := range ordered {
+		switch we.Status() {
+		case ExerciseStatusCompleted:
+			state.CompletedExercises++
+		case ExerciseStatusInProgress:
+			if current == -1 || ordered[current].Status() != ExerciseStatusInProgress {
+				current = i
+			}
+		case ExerciseStatusPending:
+			if current == -1 {
+				current = i
+			}
+		}
+	}
+
+	if current != -1 && !state.Ended() {
+		id := ordered[current].ExerciseID()
+		state.CurrentExerciseID = &id
+		state.CurrentExerciseReps = ordered[current].ModifyReps()
+		state.ExerciseIndex = current + 1
+	}
+
+	return state
+}
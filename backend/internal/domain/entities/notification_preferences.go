@@ -0,0 +1,208 @@
+package entities
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannel — канал доставки уведомлений.
+type NotificationChannel string
+
+func (c NotificationChannel) String() string {
+	return string(c)
+}
+
+const (
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelSMS   NotificationChannel = "sms"
+	NotificationChannelPush  NotificationChannel = "push"
+)
+
+var NotificationChannels = []NotificationChannel{
+	NotificationChannelEmail,
+	NotificationChannelSMS,
+	NotificationChannelPush,
+}
+
+// NotificationCategory — о чём уведомление. Задачи без категории (коды подтверждения) настройкам не подчиняются.
+type NotificationCategory string
+
+func (c NotificationCategory) String() string {
+	return string(c)
+}
+
+const (
+	NotificationCategoryWorkouts        NotificationCategory = "workouts"
+	NotificationCategoryRecommendations NotificationCategory = "recommendations"
+	NotificationCategoryReminders       NotificationCategory = "reminders"
+	// NotificationCategorySecurity — удаление аккаунта, выгрузка данных. Email этой категории не выключается,
+	// тихие часы на неё не действуют.
+	NotificationCategorySecurity NotificationCategory = "security"
+)
+
+var NotificationCategories = []NotificationCategory{
+	NotificationCategoryWorkouts,
+	NotificationCategoryRecommendations,
+	NotificationCategoryReminders,
+	NotificationCategorySecurity,
+}
+
+// NotificationMatrix — включённость канала для категории. Отсутствующая пара считается включённой.
+type NotificationMatrix map[NotificationCategory]map[NotificationChannel]bool
+
+// QuietHours — тихие часы в минутах от полуночи по часовому поясу пользователя, [From, To).
+// From > To — интервал через полночь, например 22:00–07:00.
+type QuietHours struct {
+	From int
+	To   int
+}
+
+func (q QuietHours) Validate() error {
+	if q.From < 0 || q.From >= 24*60 || q.To < 0 || q.To >= 24*60 {
+		return fmt.Errorf("quiet hours must be within a day")
+	}
+	if q.From == q.To {
+		return fmt.Errorf("quiet hours must not be empty")
+	}
+	return nil
+}
+
+// End возвращает конец тихих часов, если t в них попадает. t должно быть в поясе пользователя.
+func (q QuietHours) End(t time.Time) (time.Time, bool) {
+	minute := t.Hour()*60 + t.Minute()
+
+	var inside bool
+	if q.From < q.To {
+		inside = minute >= q.From && minute < q.To
+	} else {
+		inside = minute >= q.From || minute < q.To
+	}
+	if !inside {
+		return time.Time{}, false
+	}
+
+	end := time.Date(t.Year(), t.Month(), t.Day(), q.To/60, q.To%60, 0, 0, t.Location())
+	if !end.After(t) {
+		end = time.Date(t.Year(), t.Month(), t.Day()+1, q.To/60, q.To%60, 0, 0, t.Location())
+	}
+	return end, true
+}
+
+// NotificationPreferences — настройки уведомлений пользователя. Пока пользователь их не менял,
+// разрешено всё и тихих часов нет. Методы можно вызывать у nil — тогда тоже разрешено всё.
+type NotificationPreferences struct {
+	userID     uuid.UUID
+	timezone   string
+	channels   NotificationMatrix
+	quietHours *QuietHours
+	updatedAt  time.Time
+}
+
+func (p *NotificationPreferences) UserID() uuid.UUID       { return p.userID }
+func (p *NotificationPreferences) Timezone() string        { return p.timezone }
+func (p *NotificationPreferences) QuietHours() *QuietHours { return p.quietHours }
+func (p *NotificationPreferences) UpdatedAt() time.Time    { return p.updatedAt }
+
+// Channels возвращает полную матрицу категорий и каналов.
+func (p *NotificationPreferences) Channels() NotificationMatrix {
+	m := make(NotificationMatrix, len(NotificationCategories))
+	for _, cat := range NotificationCategories {
+		m[cat] = make(map[NotificationChannel]bool, len(NotificationChannels))
+		for _, ch := range NotificationChannels {
+			m[cat][ch] = p.Allows(cat, ch)
+		}
+	}
+	return m
+}
+
+// Allows сообщает, можно ли отправлять уведомления категории по каналу.
+func (p *NotificationPreferences) Allows(cat NotificationCategory, ch NotificationChannel) bool {
+	if p == nil || cat == "" || (cat == NotificationCategorySecurity && ch == NotificationChannelEmail) {
+		return true
+	}
+	if enabled, ok := p.channels[cat][ch]; ok {
+		return enabled
+	}
+	return true
+}
+
+// DeliverAt возвращает, когда можно доставить уведомление категории: now или конец тихих часов.
+func (p *NotificationPreferences) DeliverAt(cat NotificationCategory, now time.Time) time.Time {
+	if p == nil || p.quietHours == nil || cat == "" || cat == NotificationCategorySecurity {
+		return now
+	}
+
+	loc, err := time.LoadLocation(p.timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	if end, ok := p.quietHours.End(now.In(loc)); ok {
+		return end
+	}
+	return now
+}
+
+// NotificationPreferencesUpdateParams заменяет настройки целиком: отсутствующие в Channels пары
+// включаются, QuietHours nil — тихие часы выключены.
+type NotificationPreferencesUpdateParams struct {
+	Channels   NotificationMatrix
+	QuietHours *QuietHours
+}
+
+func (p NotificationPreferencesUpdateParams) Validate() error {
+	for cat, channels := range p.Channels {
+		if !slices.Contains(NotificationCategories, cat) {
+			return fmt.Errorf("unknown notification category %q", cat)
+		}
+		for ch, enabled := range channels {
+			if !slices.Contains(NotificationChannels, ch) {
+				return fmt.Errorf("unknown notification channel %q", ch)
+			}
+			if cat == NotificationCategorySecurity && ch == NotificationChannelEmail && !enabled {
+				return fmt.Errorf("security email notifications cannot be disabled")
+			}
+		}
+	}
+	if p.QuietHours != nil {
+		return p.QuietHours.Validate()
+	}
+	return nil
+}
+
+func (p *NotificationPreferences) Update(params NotificationPreferencesUpdateParams) {
+	p.channels = make(NotificationMatrix, len(params.Channels))
+	for cat, channels := range params.Channels {
+		p.channels[cat] = make(map[NotificationChannel]bool, len(channels))
+		for ch, enabled := range channels {
+			p.channels[cat][ch] = enabled
+		}
+	}
+	p.quietHours = params.QuietHours
+	p.updatedAt = time.Now()
+}
+
+type NotificationPreferencesRestoreSpec struct {
+	UserID     uuid.UUID
+	Timezone   string
+	Channels   NotificationMatrix
+	QuietHours *QuietHours
+	UpdatedAt  time.Time
+}
+
+func NewNotificationPreferences(s NotificationPreferencesRestoreSpec) *NotificationPreferences {
+	timezone := s.Timezone
+	if timezone == "" {
+		timezone = DefaultUserTimezone
+	}
+
+	return &NotificationPreferences{
+		userID:     s.UserID,
+		timezone:   timezone,
+		channels:   s.Channels,
+		quietHours: s.QuietHours,
+		updatedAt:  s.UpdatedAt,
+	}
+}
@@ -0,0 +1,148 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurringTaskScope — для кого срабатывает расписание.
+type RecurringTaskScope string
+
+func (s RecurringTaskScope) String() string {
+	return string(s)
+}
+
+const (
+	// RecurringTaskScopeGlobal — одна задача на срабатывание, расписание считается в поясе Timezone.
+	RecurringTaskScopeGlobal RecurringTaskScope = "global"
+	// RecurringTaskScopePerUser — по задаче каждому пользователю, расписание считается в его часовом поясе.
+	RecurringTaskScopePerUser RecurringTaskScope = "per_user"
+)
+
+// RecurringTask — расписание в формате cron, по которому исполнитель создаёт задачи типа TypeNm
+// с нагрузкой Attribute. Для per_user в нагрузку добавляется user_id.
+type RecurringTask struct {
+	id          uuid.UUID
+	name        string
+	cron        string
+	typeNm      TaskType
+	attribute   json.RawMessage
+	scope       RecurringTaskScope
+	timezone    string
+	maxAttempts int
+	enabled     bool
+	// checkedUntil — до какого момента срабатывания уже обработаны
+	checkedUntil time.Time
+	lastRunAt    *time.Time
+	createdAt    time.Time
+	updatedAt    time.Time
+}
+
+func (r *RecurringTask) ID() uuid.UUID              { return r.id }
+func (r *RecurringTask) Name() string               { return r.name }
+func (r *RecurringTask) Cron() string               { return r.cron }
+func (r *RecurringTask) TypeNm() TaskType           { return r.typeNm }
+func (r *RecurringTask) Attribute() json.RawMessage { return r.attribute }
+func (r *RecurringTask) Scope() RecurringTaskScope  { return r.scope }
+func (r *RecurringTask) Timezone() string           { return r.timezone }
+func (r *RecurringTask) MaxAttempts() int           { return r.maxAttempts }
+func (r *RecurringTask) IsEnabled() bool            { return r.enabled }
+func (r *RecurringTask) CheckedUntil() time.Time    { return r.checkedUntil }
+func (r *RecurringTask) LastRunAt() *time.Time      { return r.lastRunAt }
+func (r *RecurringTask) CreatedAt() time.Time       { return r.createdAt }
+func (r *RecurringTask) UpdatedAt() time.Time       { return r.updatedAt }
+
+func (r *RecurringTask) IsPerUser() bool {
+	return r.scope == RecurringTaskScopePerUser
+}
+
+// MarkChecked отмечает, что срабатывания до until обработаны. Назад отметка не двигается,
+// даже если часы экземпляра отстают.
+func (r *RecurringTask) MarkChecked(until time.Time) {
+	if until.After(r.checkedUntil) {
+		r.checkedUntil = until
+		r.updatedAt = time.Now()
+	}
+}
+
+// MarkRun отмечает срабатывание, по которому созданы задачи.
+func (r *RecurringTask) MarkRun(at time.Time) {
+	r.lastRunAt = &at
+	r.updatedAt = time.Now()
+}
+
+type RecurringTaskUpdateParams struct {
+	Cron        *string
+	Attribute   json.RawMessage
+	Timezone    *string
+	MaxAttempts *int
+	Enabled     *bool
+}
+
+// Update применяет изменения. Включённое заново расписание не догоняет срабатывания, пропущенные,
+// пока оно было выключено.
+func (r *RecurringTask) Update(p RecurringTaskUpdateParams) {
+	if p.Cron != nil {
+		r.cron = *p.Cron
+	}
+	if p.Attribute != nil {
+		r.attribute = p.Attribute
+	}
+	if p.Timezone != nil {
+		r.timezone = *p.Timezone
+	}
+	if p.MaxAttempts != nil {
+		r.maxAttempts = *p.MaxAttempts
+	}
+	if p.Enabled != nil {
+		if *p.Enabled && !r.enabled {
+			r.MarkChecked(time.Now())
+		}
+		r.enabled = *p.Enabled
+	}
+	r.updatedAt = time.Now()
+}
+
+type RecurringTaskRestoreSpec struct {
+	ID           uuid.UUID
+	Name         string
+	Cron         string
+	TypeNm       TaskType
+	Attribute    json.RawMessage
+	Scope        RecurringTaskScope
+	Timezone     string
+	MaxAttempts  int
+	Enabled      bool
+	CheckedUntil time.Time
+	LastRunAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type RecurringTaskOption func(r *RecurringTask)
+
+func NewRecurringTask(opt RecurringTaskOption) *RecurringTask {
+	r := new(RecurringTask)
+	opt(r)
+	return r
+}
+
+func WithRecurringTaskRestoreSpec(s RecurringTaskRestoreSpec) RecurringTaskOption {
+	return func(r *RecurringTask) {
+		r.id = s.ID
+		r.name = s.Name
+		r.cron = s.Cron
+		r.typeNm = s.TypeNm
+		r.attribute = s.Attribute
+		r.scope = s.Scope
+		r.timezone = s.Timezone
+		r.maxAttempts = s.MaxAttempts
+		r.enabled = s.Enabled
+		r.checkedUntil = s.CheckedUntil
+		r.lastRunAt = s.LastRunAt
+		r.createdAt = s.CreatedAt
+		r.updatedAt = s.UpdatedAt
+	}
+}
@@ -0,0 +1,107 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskAttemptOutcome — чем закончилась попытка выполнить задачу.
+type TaskAttemptOutcome string
+
+func (o TaskAttemptOutcome) String() string {
+	return string(o)
+}
+
+const (
+	TaskAttemptSucceeded TaskAttemptOutcome = "succeeded"
+	// TaskAttemptFailed — попытка неудачна, задача будет повторена
+	TaskAttemptFailed TaskAttemptOutcome = "failed"
+	// TaskAttemptDeadLettered — попытка неудачна, и задача ушла в dead-letter
+	TaskAttemptDeadLettered TaskAttemptOutcome = "dead_lettered"
+)
+
+// TaskAttempt — запись истории выполнения задачи. Записи только добавляются и переживают саму задачу:
+// успешная задача удаляется из очереди, а её история остаётся.
+type TaskAttempt struct {
+	id        uuid.UUID
+	taskID    uuid.UUID
+	taskType  TaskType
+	attempt   int
+	owner     string
+	outcome   TaskAttemptOutcome
+	error     string
+	startedAt time.Time
+	duration  time.Duration
+}
+
+func (a *TaskAttempt) ID() uuid.UUID               { return a.id }
+func (a *TaskAttempt) TaskID() uuid.UUID           { return a.taskID }
+func (a *TaskAttempt) TaskType() TaskType          { return a.taskType }
+func (a *TaskAttempt) Attempt() int                { return a.attempt }
+func (a *TaskAttempt) Owner() string               { return a.owner }
+func (a *TaskAttempt) Outcome() TaskAttemptOutcome { return a.outcome }
+func (a *TaskAttempt) Error() string               { return a.error }
+func (a *TaskAttempt) StartedAt() time.Time        { return a.startedAt }
+func (a *TaskAttempt) Duration() time.Duration     { return a.duration }
+
+// TaskAttemptInitSpec — попытка, которую только что выполнил исполнитель Owner.
+// Attempt — номер попытки с 1, Err — ошибка обработчика, nil для успешной.
+type TaskAttemptInitSpec struct {
+	Task      *Task
+	Attempt   int
+	Owner     string
+	Outcome   TaskAttemptOutcome
+	Err       error
+	StartedAt time.Time
+}
+
+type TaskAttemptRestoreSpec struct {
+	ID        uuid.UUID
+	TaskID    uuid.UUID
+	TaskType  TaskType
+	Attempt   int
+	Owner     string
+	Outcome   TaskAttemptOutcome
+	Error     string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+type TaskAttemptOption func(a *TaskAttempt)
+
+func NewTaskAttempt(opt TaskAttemptOption) *TaskAttempt {
+	a := new(TaskAttempt)
+	opt(a)
+	return a
+}
+
+func WithTaskAttemptInitSpec(s TaskAttemptInitSpec) TaskAttemptOption {
+	return func(a *TaskAttempt) {
+		a.id = uuid.New()
+		a.taskID = s.Task.UUID()
+		a.taskType = s.Task.TypeNm()
+		a.attempt = s.Attempt
+		a.owner = s.Owner
+		a.outcome = s.Outcome
+		if s.Err != nil {
+			a.error = s.Err.Error()
+		}
+		a.startedAt = s.StartedAt
+		a.duration = time.Since(s.StartedAt)
+	}
+}
+
+func WithTaskAttemptRestoreSpec(s TaskAttemptRestoreSpec) TaskAttemptOption {
+	return func(a *TaskAttempt) {
+		a.id = s.ID
+		a.taskID = s.TaskID
+		a.taskType = s.TaskType
+		a.attempt = s.Attempt
+		a.owner = s.Owner
+		a.outcome = s.Outcome
+		a.error = s.Error
+		a.startedAt = s.StartedAt
+		a.duration = s.Duration
+	}
+}
@@ -0,0 +1,299 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskPayload — полезная нагрузка задачи. У каждого типа задачи своя схема, она объявляется через NewTaskKind.
+type TaskPayload interface {
+	// Redacted возвращает копию без секретов для ответов API; nil — не показывать вовсе.
+	Redacted() TaskPayload
+}
+
+// TaskKind связывает тип задачи со схемой её полезной нагрузки P: задачу этого типа нельзя создать
+// с чужой схемой, а обработчик получает уже разобранную P.
+type TaskKind[P TaskPayload] struct {
+	typ TaskType
+}
+
+var taskPayloadDecoders = map[TaskType]func(raw []byte) (TaskPayload, error){}
+
+// NewTaskKind объявляет тип задачи со схемой P. Вызывается при инициализации пакета, один раз на тип.
+func NewTaskKind[P TaskPayload](typ TaskType) TaskKind[P] {
+	if _, ok := taskPayloadDecoders[typ]; ok {
+		panic(fmt.Sprintf("task kind %q declared twice", typ))
+	}
+
+	taskPayloadDecoders[typ] = func(raw []byte) (TaskPayload, error) {
+		var p P
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	return TaskKind[P]{typ: typ}
+}
+
+func (k TaskKind[P]) Type() TaskType {
+	return k.typ
+}
+
+// TaskSchedule — параметры запуска новой задачи.
+type TaskSchedule struct {
+	// MaxAttempts — 0: лимит возьмётся из политики обработчика этого типа.
+	MaxAttempts int
+	// RetryAt — время первого запуска, нулевое значение — как можно скорее.
+	RetryAt time.Time
+	// DedupKey — ключ логического события, например "workout_ready:<id>:<устройство>": повторная постановка
+	// задачи этого типа с тем же ключом в течение DedupWindow ничего не создаёт. Пустой — без дедупликации.
+	DedupKey string
+	// DedupWindow — 0: DefaultTaskDedupWindow.
+	DedupWindow time.Duration
+}
+
+func (k TaskKind[P]) NewTask(p P, s TaskSchedule) *Task {
+	return NewTask(WithTaskInitSpec(TaskInitSpec{
+		TypeNm:      k.typ,
+		MaxAttempts: s.MaxAttempts,
+		Payload:     p,
+		RetryAt:     s.RetryAt,
+		DedupKey:    s.DedupKey,
+		DedupWindow: s.DedupWindow,
+	}))
+}
+
+// Payload возвращает полезную нагрузку задачи этого типа.
+func (k TaskKind[P]) Payload(t *Task) (P, error) {
+	p, ok := t.Payload().(P)
+	if !ok || t.TypeNm() != k.typ {
+		var zero P
+		return zero, fmt.Errorf("task %s: payload %T does not match kind %q", t.UUID(), t.Payload(), k.typ)
+	}
+
+	return p, nil
+}
+
+// DecodeTaskPayload разбирает сохранённую полезную нагрузку по схеме типа. Для типов, которых этот
+// экземпляр не знает, данные сохраняются как есть в RawTaskPayload.
+func DecodeTaskPayload(typ TaskType, raw []byte) (TaskPayload, error) {
+	decode, ok := taskPayloadDecoders[typ]
+	if !ok {
+		return RawTaskPayload(raw), nil
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = []byte("{}")
+	}
+
+	return decode(raw)
+}
+
+// RawTaskPayload — нагрузка задачи неизвестного типа. Сохраняется без изменений и не показывается в API.
+type RawTaskPayload json.RawMessage
+
+func (p RawTaskPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+func (p RawTaskPayload) Redacted() TaskPayload {
+	return nil
+}
+
+var (
+	TaskKindSendCodeOnEmail       = NewTaskKind[EmailTaskPayload](TaskTypeSendCodeOnEmail)
+	TaskKindSendNotificationEmail = NewTaskKind[EmailTaskPayload](TaskTypeSendNotificationEmail)
+	TaskKindSendCodeOnPhone       = NewTaskKind[SMSTaskPayload](TaskTypeSendCodeOnPhone)
+	TaskKindSendNotificationPhone = NewTaskKind[SMSTaskPayload](TaskTypeSendNotificationPhone)
+	TaskKindSendPushNotification  = NewTaskKind[PushTaskPayload](TaskTypeSendPushNotification)
+	TaskKindDeleteAccount         = NewTaskKind[AccountTaskPayload](TaskTypeDeleteAccount)
+	TaskKindExportUserData        = NewTaskKind[AccountTaskPayload](TaskTypeExportUserData)
+	TaskKindSendReminder          = NewTaskKind[ReminderTaskPayload](TaskTypeSendReminder)
+	TaskKindCleanupExpiredAuth    = NewTaskKind[CleanupTaskPayload](TaskTypeCleanupExpiredAuth)
+	TaskKindSendLiveActivity      = NewTaskKind[LiveActivityTaskPayload](TaskTypeSendLiveActivity)
+)
+
+// NotificationTemplate — шаблон текста уведомления (pkg/templates). Текст рендерится при отправке
+// на языке получателя из переменных задачи.
+type NotificationTemplate string
+
+func (t NotificationTemplate) String() string {
+	return string(t)
+}
+
+// Переменные шаблонов перечислены в комментариях; code подставляется из поля Code задачи.
+const (
+	NotificationTemplateVerificationCode     NotificationTemplate = "verification_code"      // code
+	NotificationTemplatePasswordResetCode    NotificationTemplate = "password_reset_code"    // code
+	NotificationTemplateLoginCode            NotificationTemplate = "login_code"             // code
+	NotificationTemplateEmailChangeCode      NotificationTemplate = "email_change_code"      // code
+	NotificationTemplatePhoneChangeCode      NotificationTemplate = "phone_change_code"      // code
+	NotificationTemplateEmailChangeRequested NotificationTemplate = "email_change_requested" // new_email
+	NotificationTemplatePhoneChangeRequested NotificationTemplate = "phone_change_requested"
+	NotificationTemplateWorkoutReady         NotificationTemplate = "workout_ready"
+	NotificationTemplateRecommendationTip    NotificationTemplate = "recommendation_tip" // tip
+	NotificationTemplateMealReminder         NotificationTemplate = "meal_reminder"
+	NotificationTemplateAccountDeletion      NotificationTemplate = "account_deletion_scheduled" // deletion_at
+	NotificationTemplateDataExportReady      NotificationTemplate = "data_export_ready"          // link, expires_at
+	NotificationTemplateFeedback             NotificationTemplate = "feedback"                   // message, email
+)
+
+var NotificationTemplates = []NotificationTemplate{
+	NotificationTemplateVerificationCode,
+	NotificationTemplatePasswordResetCode,
+	NotificationTemplateLoginCode,
+	NotificationTemplateEmailChangeCode,
+	NotificationTemplatePhoneChangeCode,
+	NotificationTemplateEmailChangeRequested,
+	NotificationTemplatePhoneChangeRequested,
+	NotificationTemplateWorkoutReady,
+	NotificationTemplateRecommendationTip,
+	NotificationTemplateMealReminder,
+	NotificationTemplateAccountDeletion,
+	NotificationTemplateDataExportReady,
+	NotificationTemplateFeedback,
+}
+
+// EmailTaskPayload — письмо. Если задан Template, тема и текст рендерятся из шаблона с переменными Vars,
+// иначе отправляются Subject и Body как есть. Code — одноразовый код, в API он вырезается.
+// Category — категория уведомления для настроек пользователя; у кодов пустая.
+type EmailTaskPayload struct {
+	UserID   uuid.UUID            `json:"user_id"`
+	Email    string               `json:"email"`
+	Template NotificationTemplate `json:"template,omitempty"`
+	Vars     map[string]string    `json:"vars,omitempty"`
+	Subject  string               `json:"subject,omitempty"`
+	Body     string               `json:"body,omitempty"`
+	Code     string               `json:"code,omitempty"`
+	Category NotificationCategory `json:"category,omitempty"`
+}
+
+func (p EmailTaskPayload) Redacted() TaskPayload {
+	if p.Code != "" {
+		p.Subject = redactTaskCode(p.Subject, p.Code)
+		p.Body = redactTaskCode(p.Body, p.Code)
+		p.Code = taskPayloadRedactedValue
+	}
+	return p
+}
+
+// SMSTaskPayload — SMS. Текст рендерится из Template, как у EmailTaskPayload, или берётся из Body.
+type SMSTaskPayload struct {
+	UserID   uuid.UUID            `json:"user_id"`
+	Phone    string               `json:"phone"`
+	Template NotificationTemplate `json:"template,omitempty"`
+	Vars     map[string]string    `json:"vars,omitempty"`
+	Body     string               `json:"body,omitempty"`
+	Code     string               `json:"code,omitempty"`
+	Category NotificationCategory `json:"category,omitempty"`
+}
+
+func (p SMSTaskPayload) Redacted() TaskPayload {
+	if p.Code != "" {
+		p.Body = redactTaskCode(p.Body, p.Code)
+		p.Code = taskPayloadRedactedValue
+	}
+	return p
+}
+
+// PushOptions — параметры push сверх заголовка и текста. ActionCategory — категория действий
+// уведомления в приложении (не путать с Category настроек), Data — данные для перехода по нажатию,
+// ContentAvailable без текста — фоновый push без показа пользователю.
+type PushOptions struct {
+	Badge             *int              `json:"badge,omitempty"`
+	ActionCategory    string            `json:"action_category,omitempty"`
+	ThreadID          string            `json:"thread_id,omitempty"`
+	CollapseID        string            `json:"collapse_id,omitempty"`
+	InterruptionLevel string            `json:"interruption_level,omitempty"`
+	ContentAvailable  bool              `json:"content_available,omitempty"`
+	Data              map[string]string `json:"data,omitempty"`
+}
+
+// PushTaskPayload — push на одно устройство. Заголовок и текст рендерятся из Template или берутся
+// из Title и Body. Platform выбирает провайдера, пустая — ios: так созданы задачи до поддержки Android.
+// Токен устройства в API не показывается.
+type PushTaskPayload struct {
+	UserID      uuid.UUID            `json:"user_id"`
+	DeviceToken string               `json:"device_token"`
+	Platform    string               `json:"platform,omitempty"`
+	Template    NotificationTemplate `json:"template,omitempty"`
+	Vars        map[string]string    `json:"vars,omitempty"`
+	Title       string               `json:"title,omitempty"`
+	Body        string               `json:"body,omitempty"`
+	Category    NotificationCategory `json:"category,omitempty"`
+
+	PushOptions
+}
+
+func (p PushTaskPayload) Redacted() TaskPayload {
+	if p.DeviceToken != "" {
+		p.DeviceToken = taskPayloadRedactedValue
+	}
+	return p
+}
+
+// AccountTaskPayload — удаление или выгрузка аккаунта.
+type AccountTaskPayload struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (p AccountTaskPayload) Redacted() TaskPayload {
+	return p
+}
+
+// ReminderTaskPayload — напоминание пользователю по расписанию, уходит push на все его устройства.
+// Template и Vars передаются в push как есть; без шаблона отправляются Title и Body.
+type ReminderTaskPayload struct {
+	UserID   uuid.UUID            `json:"user_id"`
+	Template NotificationTemplate `json:"template,omitempty"`
+	Vars     map[string]string    `json:"vars,omitempty"`
+	Title    string               `json:"title,omitempty"`
+	Body     string               `json:"body,omitempty"`
+
+	PushOptions
+}
+
+func (p ReminderTaskPayload) Redacted() TaskPayload {
+	return p
+}
+
+// CleanupTaskPayload — периодическая очистка устаревших записей. KeepDays — сколько дней
+// записи хранятся после истечения срока, 0 — удаляются сразу.
+type CleanupTaskPayload struct {
+	KeepDays int `json:"keep_days,omitempty"`
+}
+
+func (p CleanupTaskPayload) Redacted() TaskPayload {
+	return p
+}
+
+// LiveActivityTaskPayload — обновление Live Activity тренировки на одном устройстве. State и Timestamp
+// фиксируются при постановке: iOS отбрасывает обновление старше уже показанного, поэтому запоздавший
+// повтор не затрёт более свежее состояние. Токен активности в API не показывается.
+type LiveActivityTaskPayload struct {
+	UserID    uuid.UUID         `json:"user_id"`
+	WorkoutID uuid.UUID         `json:"workout_id"`
+	PushToken string            `json:"push_token"`
+	State     LiveActivityState `json:"state"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+func (p LiveActivityTaskPayload) Redacted() TaskPayload {
+	if p.PushToken != "" {
+		p.PushToken = taskPayloadRedactedValue
+	}
+	return p
+}
+
+const taskPayloadRedactedValue = "***"
+
+func redactTaskCode(s, code string) string {
+	return strings.ReplaceAll(s, code, taskPayloadRedactedValue)
+}
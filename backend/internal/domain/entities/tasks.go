@@ -15,7 +15,9 @@ func (t TaskState) String() string {
 
 const (
 	TaskStateRunning TaskState = "running"
-	TaskStateFailed  TaskState = "failed"
+	// TaskStateFailed — dead-letter: задача больше не выполняется, причина в FailureReason.
+	// Вернуть её в очередь можно только перезапуском.
+	TaskStateFailed TaskState = "failed"
 )
 
 type TaskType string
@@ -30,17 +32,33 @@ const (
 	TaskTypeSendNotificationEmail TaskType = "send_notification_email_task"
 	TaskTypeSendNotificationPhone TaskType = "send_notification_phone_task"
 	TaskTypeSendPushNotification  TaskType = "send_push_notification_task"
+	TaskTypeDeleteAccount         TaskType = "delete_account_task"
+	TaskTypeExportUserData        TaskType = "export_user_data_task"
+	TaskTypeSendReminder          TaskType = "send_reminder_task"
+	TaskTypeCleanupExpiredAuth    TaskType = "cleanup_expired_auth_task"
+	TaskTypeSendLiveActivity      TaskType = "send_live_activity_task"
 )
 
+// UserRetryable сообщает, можно ли пользователю самому перезапустить упавшую задачу этого типа.
+// Коды подтверждения, SMS и письма (в том числе уведомления безопасности) ограничены своими
+// лимитами, а удаление аккаунта — своим сроком, поэтому их перезапускает только администратор.
+func (t TaskType) UserRetryable() bool {
+	switch t {
+	case TaskTypeSendPushNotification, TaskTypeSendReminder, TaskTypeExportUserData:
+		return true
+	default:
+		return false
+	}
+}
+
 type TaskMessage string
 
 func (t TaskMessage) String() string {
 	return string(t)
 }
 
-const (
-	TaskMessageSendAuthomaticGeneratedWorkout TaskMessage = "Новая тренировка автоматически сгенерирована и уже доступна в вашем профиле!"
-)
+// DefaultTaskDedupWindow — сколько действует ключ дедупликации, если окно не задано.
+const DefaultTaskDedupWindow = 24 * time.Hour
 
 type Task struct {
 	uuid        uuid.UUID
@@ -52,10 +70,13 @@ type Task struct {
 	retryAt     time.Time
 	createdAt   time.Time
 	updatedAt   time.Time
-	attribute   any
-
-	calculateBackoffFn  func(n int) int
-	baseBackoffDuration time.Duration
+	payload     TaskPayload
+	// failureReason — почему задача попала в dead-letter
+	failureReason string
+	// dedupKey — ключ дедупликации: пока он действует (до dedupUntil), вторая задача того же типа
+	// с тем же ключом не создаётся. Пустой — задача создаётся всегда.
+	dedupKey   string
+	dedupUntil time.Time
 }
 
 func (t *Task) IsLimitAttemptsExceeded() bool {
@@ -106,39 +127,57 @@ func (t *Task) UpdatedAt() time.Time {
 	return t.updatedAt
 }
 
-func (t *Task) Attribute() any {
-	return t.attribute
+func (t *Task) Payload() TaskPayload {
+	return t.payload
+}
+
+func (t *Task) FailureReason() string {
+	return t.failureReason
+}
+
+func (t *Task) DedupKey() string {
+	return t.dedupKey
 }
 
-func (t *Task) Failed() {
+func (t *Task) DedupUntil() time.Time {
+	return t.dedupUntil
+}
+
+// Failed переводит задачу в dead-letter с причиной.
+func (t *Task) Failed(reason string) {
 	t.state = TaskStateFailed
+	t.failureReason = reason
 	t.updatedAt = time.Now()
 }
 
-func (t *Task) CalculateNextRetryAt() {
+// ScheduleRetry засчитывает неудачную попытку и откладывает следующую по политике backoff.
+func (t *Task) ScheduleRetry(backoff TaskBackoff) {
 	t.attempts++
+	t.retryAt = time.Now().Add(backoff(t.attempts))
+	t.updatedAt = time.Now()
+}
 
-	if t.attempts > 1 {
-		t.retryAt = t.retryAt.Add(t.baseBackoffDuration * time.Duration(t.calculateBackoffFn(t.attempts)))
-	}
-
-	t.retryAt = time.Now().Add(t.baseBackoffDuration)
+// Postpone переносит следующий запуск на until, не засчитывая попытку.
+func (t *Task) Postpone(until time.Time) {
+	t.retryAt = until
 	t.updatedAt = time.Now()
 }
 
-func (t *Task) SetState(s TaskState) {
-	t.state = s
+// SetMaxAttempts задаёт лимит попыток задаче, созданной без своего лимита.
+func (t *Task) SetMaxAttempts(n int) {
+	t.maxAttempts = n
 	t.updatedAt = time.Now()
 }
 
-func (t *Task) SetAttribute(a any) {
-	t.attribute = a
+func (t *Task) SetState(s TaskState) {
+	t.state = s
 	t.updatedAt = time.Now()
 }
 
 func (t *Task) Restart() {
 	t.state = TaskStateRunning
 	t.attempts = 0
+	t.failureReason = ""
 	t.retryAt = time.Now()
 	t.updatedAt = time.Now()
 }
@@ -157,51 +196,45 @@ func NewTask(opt TaskOption) *Task {
 
 func WithTaskInitSpec(s TaskInitSpec) TaskOption {
 	return func(t *Task) {
-		switch s.TypeNm {
-		case TaskTypeSendCodeOnEmail, TaskTypeSendCodeOnPhone:
-			t.calculateBackoffFn = fibonacciBackoffCalculate
-			t.baseBackoffDuration = 20 * time.Second
-		case TaskTypeSendNotificationEmail, TaskTypeSendNotificationPhone:
-			t.calculateBackoffFn = exponentialBackoffWithJitterCalculate
-			t.baseBackoffDuration = 10 * time.Second
-		default:
-			t.calculateBackoffFn = linearBackoffCalculate
-			t.baseBackoffDuration = 20 * time.Second
-		}
-
 		t.uuid = uuid.New()
 		t.typeNm = s.TypeNm
 		t.state = TaskStateRunning
 		t.maxAttempts = s.MaxAttempts
 		t.attempts = 0
 		t.retryAt = time.Now()
+		if !s.RetryAt.IsZero() {
+			t.retryAt = s.RetryAt
+		}
 		t.createdAt = time.Now()
 		t.updatedAt = time.Now()
-		t.attribute = s.Attribute
+		t.payload = s.Payload
+		if s.DedupKey != "" {
+			window := s.DedupWindow
+			if window <= 0 {
+				window = DefaultTaskDedupWindow
+			}
+			t.dedupKey = s.DedupKey
+			t.dedupUntil = t.createdAt.Add(window)
+		}
 	}
 }
 
+// TaskInitSpec — новая задача. Обычно создаётся через TaskKind.NewTask, который проверяет схему Payload.
 type TaskInitSpec struct {
-	TypeNm      TaskType
-	Message     TaskMessage
+	TypeNm  TaskType
+	Message TaskMessage
+	// MaxAttempts — 0: лимит возьмётся из политики обработчика этого типа.
 	MaxAttempts int
-	Attribute   any
+	Payload     TaskPayload
+	// RetryAt — время первого запуска, нулевое значение — как можно скорее.
+	RetryAt time.Time
+	// DedupKey — ключ дедупликации в пределах типа, DedupWindow — сколько он действует (0 — DefaultTaskDedupWindow).
+	DedupKey    string
+	DedupWindow time.Duration
 }
 
 func WithTaskRestoreSpec(s TaskRestoreSpecification) TaskOption {
 	return func(t *Task) {
-		switch s.TypeNm {
-		case TaskTypeSendCodeOnEmail, TaskTypeSendCodeOnPhone:
-			t.calculateBackoffFn = exponentialBackoffCalculate
-			t.baseBackoffDuration = 20 * time.Second
-		case TaskTypeSendNotificationEmail, TaskTypeSendNotificationPhone:
-			t.calculateBackoffFn = exponentialBackoffWithJitterCalculate
-			t.baseBackoffDuration = 10 * time.Second
-		default:
-			t.calculateBackoffFn = linearBackoffCalculate
-			t.baseBackoffDuration = 20 * time.Second
-		}
-
 		t.uuid = s.UUID
 		t.typeNm = s.TypeNm
 		//t.message = s.Message
@@ -211,57 +244,60 @@ func WithTaskRestoreSpec(s TaskRestoreSpecification) TaskOption {
 		t.retryAt = s.RetryAt
 		t.createdAt = s.CreatedAt
 		t.updatedAt = s.UpdatedAt
-		t.attribute = s.Attribute
+		t.payload = s.Payload
+		t.failureReason = s.FailureReason
+		t.dedupKey = s.DedupKey
 	}
 }
 
 type TaskRestoreSpecification struct {
-	UUID        uuid.UUID
-	TypeNm      TaskType
-	ClusterNm   string
-	State       TaskState
-	MaxAttempts int
-	Attempts    int
-	RetryAt     time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Attribute   any
-}
-
-func fibonacciBackoffCalculate(n int) int {
-	if n <= 1 {
-		return n
-	}
-
-	a, b := 0, 1
-	for i := 2; i <= n; i++ {
-		a, b = b, a+b
+	UUID          uuid.UUID
+	TypeNm        TaskType
+	ClusterNm     string
+	State         TaskState
+	MaxAttempts   int
+	Attempts      int
+	RetryAt       time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Payload       TaskPayload
+	FailureReason string
+	DedupKey      string
+}
+
+// TaskBackoff возвращает паузу перед следующей попыткой после неудачной попытки номер attempt (с 1).
+type TaskBackoff func(attempt int) time.Duration
+
+// LinearBackoff — base, 2×base, 3×base…
+func LinearBackoff(base time.Duration) TaskBackoff {
+	return func(attempt int) time.Duration {
+		return base * time.Duration(attempt)
 	}
-
-	return b
 }
 
-func exponentialBackoffCalculate(n int) int {
-	return 1 << (n - 1)
-}
+// FibonacciBackoff — base, base, 2×base, 3×base, 5×base…
+func FibonacciBackoff(base time.Duration) TaskBackoff {
+	return func(attempt int) time.Duration {
+		a, b := 0, 1
+		for i := 1; i < attempt; i++ {
+			a, b = b, a+b
+		}
 
-func linearBackoffCalculate(n int) int {
-	return n
+		return base * time.Duration(b)
+	}
 }
 
-func exponentialBackoffWithJitterCalculate(n int) int {
-	return 1<<(n-1) + rand.Intn(3)
+// ExponentialBackoff — base, 2×base, 4×base…
+func ExponentialBackoff(base time.Duration) TaskBackoff {
+	return func(attempt int) time.Duration {
+		return base * time.Duration(1<<(attempt-1))
+	}
 }
 
-type TaskAttribute struct {
-	UserID      uuid.UUID `json:"user_id"`
-	Method      string    `json:"method"`
-	Email       string    `json:"email,omitempty"`
-	Phone       string    `json:"phone,omitempty"`
-	Code        string    `json:"code,omitempty"`
-	Subject     string    `json:"subject,omitempty"`
-	Body        string    `json:"body,omitempty"`
-	Message     string    `json:"message,omitempty"`
-	DeviceToken string    `json:"device_token,omitempty"`
-	Title       string    `json:"title,omitempty"`
+// ExponentialJitterBackoff — как ExponentialBackoff плюс случайные 0–2×base, чтобы повторы массовой
+// рассылки не приходили к провайдеру одной волной.
+func ExponentialJitterBackoff(base time.Duration) TaskBackoff {
+	return func(attempt int) time.Duration {
+		return base * time.Duration(1<<(attempt-1)+rand.Intn(3))
+	}
 }
@@ -6,6 +6,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// Платформы устройств: по платформе выбирается провайдер push — APNs для iOS, FCM для Android.
+const (
+	DevicePlatformIOS     = "ios"
+	DevicePlatformAndroid = "android"
+)
+
 type UserDevice struct {
 	id          uuid.UUID
 	userID      uuid.UUID
@@ -1,10 +1,43 @@
 package entities
 
 import (
+	"backend/internal/errors"
+	"fmt"
 	"github.com/google/uuid"
 	"time"
 )
 
+type UserRole string
+
+// DefaultUserTimezone — часовой пояс пользователя, который его не указал.
+const DefaultUserTimezone = "UTC"
+
+// DefaultUserLocale — язык уведомлений пользователя, который его не указал.
+const DefaultUserLocale = "ru"
+
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleCoach UserRole = "coach"
+	UserRoleAdmin UserRole = "admin"
+)
+
+func (r UserRole) String() string {
+	return string(r)
+}
+
+func ToUserRole(s string) (UserRole, error) {
+	switch s {
+	case UserRoleUser.String():
+		return UserRoleUser, nil
+	case UserRoleCoach.String():
+		return UserRoleCoach, nil
+	case UserRoleAdmin.String():
+		return UserRoleAdmin, nil
+	default:
+		return "", fmt.Errorf("%w : %s", errors.ErrUnknownUserRole, s)
+	}
+}
+
 type UserInfo struct {
 	id              uuid.UUID
 	username        string
@@ -13,9 +46,20 @@ type UserInfo struct {
 	password        string
 	email           string
 	phone           string
+	role            UserRole
 	createdAt       time.Time
 	emailVerifiedAt *time.Time
 	phoneVerifiedAt *time.Time
+	totpSecret      string
+	totpEnabledAt   *time.Time
+	totpLastStep    int64
+	appleSub        string
+	// timezone — часовой пояс IANA, по нему срабатывают пользовательские расписания
+	timezone string
+	// locale — язык уведомлений (ru, en)
+	locale string
+
+	deletionScheduledAt *time.Time
 }
 
 func (u *UserInfo) ID() uuid.UUID {
@@ -46,6 +90,10 @@ func (u *UserInfo) Phone() string {
 	return u.phone
 }
 
+func (u *UserInfo) Role() UserRole {
+	return u.role
+}
+
 func (u *UserInfo) CreatedAt() time.Time {
 	return u.createdAt
 }
@@ -66,6 +114,120 @@ func (u *UserInfo) IsPhoneVerified() bool {
 	return u.phoneVerifiedAt != nil
 }
 
+// TOTPSecret — base32-секрет TOTP. Непустой при незавершённой настройке или включённой 2FA.
+func (u *UserInfo) TOTPSecret() string {
+	return u.totpSecret
+}
+
+func (u *UserInfo) TOTPEnabledAt() *time.Time {
+	return u.totpEnabledAt
+}
+
+// TOTPLastStep — шаг последнего принятого TOTP-кода, защищает от повторного ввода того же кода.
+func (u *UserInfo) TOTPLastStep() int64 {
+	return u.totpLastStep
+}
+
+func (u *UserInfo) IsTOTPEnabled() bool {
+	return u.totpEnabledAt != nil
+}
+
+// StartTOTPEnrollment сохраняет новый секрет. 2FA включится только после подтверждения кодом.
+func (u *UserInfo) StartTOTPEnrollment(secret string) {
+	u.totpSecret = secret
+	u.totpEnabledAt = nil
+	u.totpLastStep = 0
+}
+
+func (u *UserInfo) EnableTOTP(step int64) {
+	now := time.Now()
+	u.totpEnabledAt = &now
+	u.totpLastStep = step
+}
+
+func (u *UserInfo) UseTOTPStep(step int64) {
+	u.totpLastStep = step
+}
+
+func (u *UserInfo) DisableTOTP() {
+	u.totpSecret = ""
+	u.totpEnabledAt = nil
+	u.totpLastStep = 0
+}
+
+// AppleSub — идентификатор пользователя в Sign in with Apple (claim sub), пустой, если Apple не привязан.
+func (u *UserInfo) AppleSub() string {
+	return u.appleSub
+}
+
+func (u *UserInfo) LinkApple(sub string) {
+	u.appleSub = sub
+}
+
+// Timezone — часовой пояс пользователя в формате IANA (Europe/Moscow), по умолчанию UTC.
+func (u *UserInfo) Timezone() string {
+	if u.timezone == "" {
+		return DefaultUserTimezone
+	}
+	return u.timezone
+}
+
+// Locale — язык, на котором пользователю приходят уведомления, по умолчанию ru.
+func (u *UserInfo) Locale() string {
+	if u.locale == "" {
+		return DefaultUserLocale
+	}
+	return u.locale
+}
+
+// Location возвращает часовой пояс пользователя; неизвестный пояс считается UTC.
+func (u *UserInfo) Location() *time.Location {
+	loc, err := time.LoadLocation(u.Timezone())
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DeletionScheduledAt — когда аккаунт будет удалён безвозвратно, nil — удаление не запланировано.
+func (u *UserInfo) DeletionScheduledAt() *time.Time {
+	return u.deletionScheduledAt
+}
+
+func (u *UserInfo) IsDeletionScheduled() bool {
+	return u.deletionScheduledAt != nil
+}
+
+// IsDeletionDue сообщает, что удаление запланировано и срок отмены истёк.
+func (u *UserInfo) IsDeletionDue(now time.Time) bool {
+	return u.deletionScheduledAt != nil && !now.Before(*u.deletionScheduledAt)
+}
+
+func (u *UserInfo) ScheduleDeletion(at time.Time) {
+	u.deletionScheduledAt = &at
+}
+
+func (u *UserInfo) CancelDeletion() {
+	u.deletionScheduledAt = nil
+}
+
+// AuditFields — снимок полей профиля для журнала безопасности (см. DiffAuditFields).
+// Хэш пароля и секрет TOTP в снимок не попадают.
+func (u *UserInfo) AuditFields() map[string]any {
+	return map[string]any{
+		"username":          u.username,
+		"name":              u.name,
+		"surname":           u.surname,
+		"email":             u.email,
+		"phone":             u.phone,
+		"role":              u.role.String(),
+		"email_verified_at": u.emailVerifiedAt,
+		"phone_verified_at": u.phoneVerifiedAt,
+		"timezone":          u.Timezone(),
+		"locale":            u.Locale(),
+	}
+}
+
 type UserInfoOption func(u *UserInfo)
 
 func NewUserInfo(opt UserInfoOption) *UserInfo {
@@ -84,9 +246,18 @@ type UserInfoRestoreSpec struct {
 	Password        string
 	Email           string
 	Phone           string
+	Role            UserRole
 	CreatedAt       time.Time
 	EmailVerifiedAt *time.Time
 	PhoneVerifiedAt *time.Time
+	TOTPSecret      string
+	TOTPEnabledAt   *time.Time
+	TOTPLastStep    int64
+	AppleSub        string
+	Timezone        string
+	Locale          string
+
+	DeletionScheduledAt *time.Time
 }
 
 type UserInfoInitSpec struct {
@@ -98,10 +269,18 @@ type UserInfoInitSpec struct {
 	Email     string
 	Phone     string
 	CreatedAt time.Time
+	// AppleSub и EmailVerifiedAt заполняются при регистрации через Sign in with Apple.
+	AppleSub        string
+	EmailVerifiedAt *time.Time
+	// Timezone — пустой: UTC
+	Timezone string
+	// Locale — пустой: DefaultUserLocale
+	Locale string
 }
 
+// UserAuthInitSpec — данные входа по паролю. Login — ник, подтверждённый email или подтверждённый телефон.
 type UserAuthInitSpec struct {
-	Username string
+	Login    string
 	Password string
 }
 
@@ -114,9 +293,17 @@ func WithUserInfoRestoreSpec(spec UserInfoRestoreSpec) UserInfoOption {
 		u.password = spec.Password
 		u.email = spec.Email
 		u.phone = spec.Phone
+		u.role = spec.Role
 		u.createdAt = spec.CreatedAt
 		u.emailVerifiedAt = spec.EmailVerifiedAt
 		u.phoneVerifiedAt = spec.PhoneVerifiedAt
+		u.totpSecret = spec.TOTPSecret
+		u.totpEnabledAt = spec.TOTPEnabledAt
+		u.totpLastStep = spec.TOTPLastStep
+		u.appleSub = spec.AppleSub
+		u.timezone = spec.Timezone
+		u.locale = spec.Locale
+		u.deletionScheduledAt = spec.DeletionScheduledAt
 	}
 }
 
@@ -129,7 +316,18 @@ func WithUserInfoInitSpec(s UserInfoInitSpec) UserInfoOption {
 		u.password = s.Password
 		u.email = s.Email
 		u.phone = s.Phone
+		u.role = UserRoleUser
 		u.createdAt = s.CreatedAt
+		u.appleSub = s.AppleSub
+		u.emailVerifiedAt = s.EmailVerifiedAt
+		u.timezone = s.Timezone
+		if u.timezone == "" {
+			u.timezone = DefaultUserTimezone
+		}
+		u.locale = s.Locale
+		if u.locale == "" {
+			u.locale = DefaultUserLocale
+		}
 	}
 }
 
@@ -140,10 +338,15 @@ type UserInfoUpdateParams struct {
 	Email           *string
 	Phone           *string
 	Password        *string
+	Role            *UserRole
 	EmailVerifiedAt *time.Time
 	PhoneVerifiedAt *time.Time
+	Timezone        *string
+	Locale          *string
 }
 
+// Update применяет изменения профиля. Новый email или телефон сбрасывает отметку о подтверждении,
+// если вместе с ним не передано новое время подтверждения.
 func (ui *UserInfo) Update(p UserInfoUpdateParams) {
 	if p.Username != nil {
 		ui.username = *p.Username
@@ -155,18 +358,33 @@ func (ui *UserInfo) Update(p UserInfoUpdateParams) {
 		ui.surname = *p.Surname
 	}
 	if p.Email != nil {
+		if *p.Email != ui.email {
+			ui.emailVerifiedAt = nil
+		}
 		ui.email = *p.Email
 	}
 	if p.Phone != nil {
+		if *p.Phone != ui.phone {
+			ui.phoneVerifiedAt = nil
+		}
 		ui.phone = *p.Phone
 	}
 	if p.Password != nil {
 		ui.password = *p.Password
 	}
+	if p.Role != nil {
+		ui.role = *p.Role
+	}
 	if p.EmailVerifiedAt != nil {
 		ui.emailVerifiedAt = p.EmailVerifiedAt
 	}
 	if p.PhoneVerifiedAt != nil {
 		ui.phoneVerifiedAt = p.PhoneVerifiedAt
 	}
+	if p.Timezone != nil {
+		ui.timezone = *p.Timezone
+	}
+	if p.Locale != nil {
+		ui.locale = *p.Locale
+	}
 }
@@ -145,6 +145,19 @@ func (u *UserParams) TargetCaloriesDaily() int {
 	return u.targetCaloriesDaily
 }
 
+// AuditFields — снимок параметров для журнала безопасности (см. DiffAuditFields).
+func (u *UserParams) AuditFields() map[string]any {
+	return map[string]any{
+		"height":                u.height,
+		"photo":                 u.photo,
+		"wants":                 string(u.wants),
+		"lifestyle":             string(u.lifestyle),
+		"target_weight":         u.targetWeight,
+		"target_workouts_weeks": u.targetWorkoutsWeeks,
+		"target_calories_daily": u.targetCaloriesDaily,
+	}
+}
+
 type UserParamsOption func(u *UserParams)
 
 func NewUserParams(opt UserParamsOption) *UserParams {
@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalTokenScope — право персонального токена в формате <раздел>:<read|write>.
+// read открывает GET-запросы раздела, write — изменяющие.
+type PersonalTokenScope string
+
+func (s PersonalTokenScope) String() string {
+	return string(s)
+}
+
+const (
+	PersonalTokenScopeProfileRead          PersonalTokenScope = "profile:read"
+	PersonalTokenScopeProfileWrite         PersonalTokenScope = "profile:write"
+	PersonalTokenScopeWeightRead           PersonalTokenScope = "weight:read"
+	PersonalTokenScopeWeightWrite          PersonalTokenScope = "weight:write"
+	PersonalTokenScopeCaloriesRead         PersonalTokenScope = "calories:read"
+	PersonalTokenScopeCaloriesWrite        PersonalTokenScope = "calories:write"
+	PersonalTokenScopeNutritionRead        PersonalTokenScope = "nutrition:read"
+	PersonalTokenScopeNutritionWrite       PersonalTokenScope = "nutrition:write"
+	PersonalTokenScopeWorkoutsRead         PersonalTokenScope = "workouts:read"
+	PersonalTokenScopeWorkoutsWrite        PersonalTokenScope = "workouts:write"
+	PersonalTokenScopeRecommendationsRead  PersonalTokenScope = "recommendations:read"
+	PersonalTokenScopeRecommendationsWrite PersonalTokenScope = "recommendations:write"
+)
+
+// PersonalTokenScopes — все права, которые можно выдать токену.
+var PersonalTokenScopes = []PersonalTokenScope{
+	PersonalTokenScopeProfileRead,
+	PersonalTokenScopeProfileWrite,
+	PersonalTokenScopeWeightRead,
+	PersonalTokenScopeWeightWrite,
+	PersonalTokenScopeCaloriesRead,
+	PersonalTokenScopeCaloriesWrite,
+	PersonalTokenScopeNutritionRead,
+	PersonalTokenScopeNutritionWrite,
+	PersonalTokenScopeWorkoutsRead,
+	PersonalTokenScopeWorkoutsWrite,
+	PersonalTokenScopeRecommendationsRead,
+	PersonalTokenScopeRecommendationsWrite,
+}
+
+func IsKnownPersonalTokenScope(s string) bool {
+	return slices.Contains(PersonalTokenScopes, PersonalTokenScope(s))
+}
+
+// UserPersonalToken — персональный токен доступа для скриптов и интеграций. Хранится только хэш,
+// само значение показывается один раз при создании.
+type UserPersonalToken struct {
+	id         uuid.UUID
+	userID     uuid.UUID
+	name       string
+	tokenHash  string
+	scopes     []PersonalTokenScope
+	expiresAt  *time.Time
+	lastUsedAt *time.Time
+	lastUsedIP string
+	createdAt  time.Time
+}
+
+func (t *UserPersonalToken) ID() uuid.UUID                { return t.id }
+func (t *UserPersonalToken) UserID() uuid.UUID            { return t.userID }
+func (t *UserPersonalToken) Name() string                 { return t.name }
+func (t *UserPersonalToken) TokenHash() string            { return t.tokenHash }
+func (t *UserPersonalToken) Scopes() []PersonalTokenScope { return t.scopes }
+func (t *UserPersonalToken) ExpiresAt() *time.Time        { return t.expiresAt }
+func (t *UserPersonalToken) LastUsedAt() *time.Time       { return t.lastUsedAt }
+func (t *UserPersonalToken) LastUsedIP() string           { return t.lastUsedIP }
+func (t *UserPersonalToken) CreatedAt() time.Time         { return t.createdAt }
+
+// IsExpired — токен без срока действия не истекает никогда.
+func (t *UserPersonalToken) IsExpired(now time.Time) bool {
+	return t.expiresAt != nil && !now.Before(*t.expiresAt)
+}
+
+type UserPersonalTokenInitSpec struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	TokenHash string
+	Scopes    []PersonalTokenScope
+	ExpiresAt *time.Time
+}
+
+type UserPersonalTokenRestoreSpec struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []PersonalTokenScope
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string
+	CreatedAt  time.Time
+}
+
+type UserPersonalTokenOption func(t *UserPersonalToken)
+
+func NewUserPersonalToken(opt UserPersonalTokenOption) *UserPersonalToken {
+	t := new(UserPersonalToken)
+	opt(t)
+	return t
+}
+
+func WithUserPersonalTokenInitSpec(s UserPersonalTokenInitSpec) UserPersonalTokenOption {
+	return func(t *UserPersonalToken) {
+		t.id = s.ID
+		t.userID = s.UserID
+		t.name = s.Name
+		t.tokenHash = s.TokenHash
+		t.scopes = s.Scopes
+		t.expiresAt = s.ExpiresAt
+		t.createdAt = time.Now()
+	}
+}
+
+func WithUserPersonalTokenRestoreSpec(s UserPersonalTokenRestoreSpec) UserPersonalTokenOption {
+	return func(t *UserPersonalToken) {
+		t.id = s.ID
+		t.userID = s.UserID
+		t.name = s.Name
+		t.tokenHash = s.TokenHash
+		t.scopes = s.Scopes
+		t.expiresAt = s.ExpiresAt
+		t.lastUsedAt = s.LastUsedAt
+		t.lastUsedIP = s.LastUsedIP
+		t.createdAt = s.CreatedAt
+	}
+}
@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// UserRecoveryCode — одноразовый код восстановления для входа без TOTP-приложения. Хранится только хэш.
+type UserRecoveryCode struct {
+	id        uuid.UUID
+	userID    uuid.UUID
+	codeHash  string
+	usedAt    *time.Time
+	createdAt time.Time
+}
+
+func (c *UserRecoveryCode) ID() uuid.UUID        { return c.id }
+func (c *UserRecoveryCode) UserID() uuid.UUID    { return c.userID }
+func (c *UserRecoveryCode) CodeHash() string     { return c.codeHash }
+func (c *UserRecoveryCode) UsedAt() *time.Time   { return c.usedAt }
+func (c *UserRecoveryCode) CreatedAt() time.Time { return c.createdAt }
+
+type UserRecoveryCodeOption func(c *UserRecoveryCode)
+
+func NewUserRecoveryCode(opt UserRecoveryCodeOption) *UserRecoveryCode {
+	c := new(UserRecoveryCode)
+	opt(c)
+	return c
+}
+
+type UserRecoveryCodeInitSpec struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func WithUserRecoveryCodeInitSpec(s UserRecoveryCodeInitSpec) UserRecoveryCodeOption {
+	return func(c *UserRecoveryCode) {
+		c.id = s.ID
+		c.userID = s.UserID
+		c.codeHash = s.CodeHash
+		c.createdAt = time.Now()
+	}
+}
@@ -5,34 +5,69 @@ import (
 	"time"
 )
 
+// UserRefreshToken — refresh-токен и одновременно сессия пользователя на конкретном устройстве.
+// При ротации меняется только хэш и срок жизни, ID сессии остаётся прежним.
 type UserRefreshToken struct {
-	id        uuid.UUID
-	userID    uuid.UUID
-	tokenHash string
-	expiresAt time.Time
-	createdAt time.Time
+	id         uuid.UUID
+	userID     uuid.UUID
+	tokenHash  string
+	deviceName string
+	platform   string
+	ip         string
+	userAgent  string
+	expiresAt  time.Time
+	createdAt  time.Time
+	lastUsedAt time.Time
 }
 
-func (t *UserRefreshToken) ID() uuid.UUID        { return t.id }
+func (t *UserRefreshToken) ID() uuid.UUID         { return t.id }
 func (t *UserRefreshToken) UserID() uuid.UUID     { return t.userID }
 func (t *UserRefreshToken) TokenHash() string     { return t.tokenHash }
+func (t *UserRefreshToken) DeviceName() string    { return t.deviceName }
+func (t *UserRefreshToken) Platform() string      { return t.platform }
+func (t *UserRefreshToken) IP() string            { return t.ip }
+func (t *UserRefreshToken) UserAgent() string     { return t.userAgent }
 func (t *UserRefreshToken) ExpiresAt() time.Time  { return t.expiresAt }
 func (t *UserRefreshToken) CreatedAt() time.Time  { return t.createdAt }
+func (t *UserRefreshToken) LastUsedAt() time.Time { return t.lastUsedAt }
 func (t *UserRefreshToken) IsExpired() bool       { return time.Now().After(t.expiresAt) }
 
+// Rotate заменяет хэш токена и продлевает сессию. IP и User-Agent обновляются,
+// если клиент их передал: так в списке сессий видно, откуда устройство заходило последним.
+func (t *UserRefreshToken) Rotate(tokenHash string, expiresAt time.Time, ip, userAgent string) {
+	t.tokenHash = tokenHash
+	t.expiresAt = expiresAt
+	if ip != "" {
+		t.ip = ip
+	}
+	if userAgent != "" {
+		t.userAgent = userAgent
+	}
+	t.lastUsedAt = time.Now()
+}
+
 type UserRefreshTokenInitSpec struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	TokenHash string
-	ExpiresAt time.Time
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  string
+	DeviceName string
+	Platform   string
+	IP         string
+	UserAgent  string
+	ExpiresAt  time.Time
 }
 
 type UserRefreshTokenRestoreSpec struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	TokenHash string
-	ExpiresAt time.Time
-	CreatedAt time.Time
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  string
+	DeviceName string
+	Platform   string
+	IP         string
+	UserAgent  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt time.Time
 }
 
 type UserRefreshTokenOption func(t *UserRefreshToken)
@@ -48,8 +83,13 @@ func WithUserRefreshTokenInitSpec(s UserRefreshTokenInitSpec) UserRefreshTokenOp
 		t.id = s.ID
 		t.userID = s.UserID
 		t.tokenHash = s.TokenHash
+		t.deviceName = s.DeviceName
+		t.platform = s.Platform
+		t.ip = s.IP
+		t.userAgent = s.UserAgent
 		t.expiresAt = s.ExpiresAt
 		t.createdAt = time.Now()
+		t.lastUsedAt = t.createdAt
 	}
 }
 
@@ -58,7 +98,12 @@ func WithUserRefreshTokenRestoreSpec(s UserRefreshTokenRestoreSpec) UserRefreshT
 		t.id = s.ID
 		t.userID = s.UserID
 		t.tokenHash = s.TokenHash
+		t.deviceName = s.DeviceName
+		t.platform = s.Platform
+		t.ip = s.IP
+		t.userAgent = s.UserAgent
 		t.expiresAt = s.ExpiresAt
 		t.createdAt = s.CreatedAt
+		t.lastUsedAt = s.LastUsedAt
 	}
 }
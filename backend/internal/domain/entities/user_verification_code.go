@@ -11,6 +11,10 @@ const (
 	VerificationCodeEmail    VerificationCodeType = "email"
 	VerificationCodePhone    VerificationCodeType = "phone"
 	VerificationCodeRecover  VerificationCodeType = "recover"
+	VerificationCodeLogin    VerificationCodeType = "login"
+	// EmailChange и PhoneChange подтверждают новый адрес: он хранится в Target кода, пока код не введён.
+	VerificationCodeEmailChange VerificationCodeType = "email_change"
+	VerificationCodePhoneChange VerificationCodeType = "phone_change"
 )
 
 type UserVerificationCode struct {
@@ -18,6 +22,7 @@ type UserVerificationCode struct {
 	userID    uuid.UUID
 	codeHash  string
 	codeType  VerificationCodeType
+	target    string
 	expiresAt time.Time
 	usedAt    *time.Time
 	createdAt time.Time
@@ -27,6 +32,9 @@ func (c *UserVerificationCode) ID() uuid.UUID                 { return c.id }
 func (c *UserVerificationCode) UserID() uuid.UUID              { return c.userID }
 func (c *UserVerificationCode) CodeHash() string               { return c.codeHash }
 func (c *UserVerificationCode) CodeType() VerificationCodeType { return c.codeType }
+// Target — адрес или телефон, на который отправлен код.
+func (c *UserVerificationCode) Target() string { return c.target }
+
 func (c *UserVerificationCode) ExpiresAt() time.Time           { return c.expiresAt }
 func (c *UserVerificationCode) UsedAt() *time.Time             { return c.usedAt }
 func (c *UserVerificationCode) CreatedAt() time.Time           { return c.createdAt }
@@ -51,6 +59,7 @@ type UserVerificationCodeInitSpec struct {
 	UserID    uuid.UUID
 	CodeHash  string
 	CodeType  VerificationCodeType
+	Target    string
 	ExpiresAt time.Time
 }
 
@@ -59,6 +68,7 @@ type UserVerificationCodeRestoreSpec struct {
 	UserID    uuid.UUID
 	CodeHash  string
 	CodeType  VerificationCodeType
+	Target    string
 	ExpiresAt time.Time
 	UsedAt    *time.Time
 	CreatedAt time.Time
@@ -70,6 +80,7 @@ func WithUserVerificationCodeInitSpec(s UserVerificationCodeInitSpec) UserVerifi
 		c.userID = s.UserID
 		c.codeHash = s.CodeHash
 		c.codeType = s.CodeType
+		c.target = s.Target
 		c.expiresAt = s.ExpiresAt
 		c.createdAt = time.Now()
 	}
@@ -81,6 +92,7 @@ func WithUserVerificationCodeRestoreSpec(s UserVerificationCodeRestoreSpec) User
 		c.userID = s.UserID
 		c.codeHash = s.CodeHash
 		c.codeType = s.CodeType
+		c.target = s.Target
 		c.expiresAt = s.ExpiresAt
 		c.usedAt = s.UsedAt
 		c.createdAt = s.CreatedAt
@@ -0,0 +1,36 @@
+package dto
+
+// AccountExportSection — раздел выгрузки данных пользователя. В архиве каждый раздел лежит
+// двумя файлами: <раздел>.json и <раздел>.csv.
+type AccountExportSection string
+
+func (s AccountExportSection) String() string {
+	return string(s)
+}
+
+const (
+	AccountExportProfile          AccountExportSection = "profile"
+	AccountExportParams           AccountExportSection = "params"
+	AccountExportWeight           AccountExportSection = "weight"
+	AccountExportCalories         AccountExportSection = "calories"
+	AccountExportFood             AccountExportSection = "food"
+	AccountExportWorkouts         AccountExportSection = "workouts"
+	AccountExportWorkoutExercises AccountExportSection = "workout_exercises"
+	AccountExportRecommendations  AccountExportSection = "recommendations"
+	AccountExportDevices          AccountExportSection = "devices"
+	AccountExportSessions         AccountExportSection = "sessions"
+)
+
+// AccountExportSections — разделы в том порядке, в котором они попадают в архив.
+var AccountExportSections = []AccountExportSection{
+	AccountExportProfile,
+	AccountExportParams,
+	AccountExportWeight,
+	AccountExportCalories,
+	AccountExportFood,
+	AccountExportWorkouts,
+	AccountExportWorkoutExercises,
+	AccountExportRecommendations,
+	AccountExportDevices,
+	AccountExportSessions,
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"backend/internal/domain/entities"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditEventFilter struct {
+	UserID      *uuid.UUID
+	ActorID     *uuid.UUID
+	Types       []entities.AuditEventType
+	IP          *string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       *int
+	Offset      *int
+}
+
+// RequestMetadataKey — ключ, под которым HTTP-слой кладёт RequestMetadata в gin.Context.
+// gin.Context отдаёт значения по строковым ключам через Value, поэтому сервисы читают их
+// из обычного context.Context, в том числе внутри транзакции.
+const RequestMetadataKey = "request_metadata"
+
+// RequestUserIDKey — ключ, под которым JWT.JWTAuthMiddleware кладёт ID пользователя из токена.
+// Метаданные запроса собираются до проверки токена, поэтому автор читается отдельно.
+const RequestUserIDKey = "user_id"
+
+// RequestMetadata — кто и откуда выполняет запрос, для журнала безопасности.
+// ActorID — пользователь из токена, nil для запросов без авторизации.
+type RequestMetadata struct {
+	IP        string
+	UserAgent string
+	ActorID   *uuid.UUID
+}
+
+// RequestMetadataFromContext возвращает метаданные запроса; вне HTTP-запроса (executor) — пустые.
+func RequestMetadataFromContext(ctx context.Context) RequestMetadata {
+	meta, _ := ctx.Value(RequestMetadataKey).(RequestMetadata)
+	if meta.ActorID == nil {
+		if s, ok := ctx.Value(RequestUserIDKey).(string); ok {
+			if id, err := uuid.Parse(s); err == nil {
+				meta.ActorID = &id
+			}
+		}
+	}
+	return meta
+}
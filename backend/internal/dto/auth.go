@@ -7,8 +7,19 @@ import (
 
 type UserRefreshTokenFilter struct {
 	ID        *uuid.UUID
+	ExcludeID *uuid.UUID
 	UserID    *uuid.UUID
 	TokenHash *string
+	// RotatedTokenHash ищет сессию (семейство), в истории которой есть уже ротированный токен с этим хэшем.
+	RotatedTokenHash *string
+}
+
+// SessionMetadata — сведения о клиенте, которые сохраняются вместе с refresh-токеном.
+type SessionMetadata struct {
+	DeviceName string
+	Platform   string
+	IP         string
+	UserAgent  string
 }
 
 type UserVerificationCodeFilter struct {
@@ -0,0 +1,9 @@
+package dto
+
+import "github.com/google/uuid"
+
+type LiveActivityFilter struct {
+	UserID    *uuid.UUID
+	WorkoutID *uuid.UUID
+	PushToken *string
+}
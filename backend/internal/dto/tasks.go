@@ -2,16 +2,47 @@ package dto
 
 import (
 	"backend/internal/domain/entities"
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type TasksFilter struct {
 	IDs            []uuid.UUID
+	UserID         *uuid.UUID
 	Types          []entities.TaskType
+	ExcludeTypes   []entities.TaskType
 	Message        *string
 	Attempts       *int
 	RetryAt        *time.Time
 	States         []entities.TaskState
 	ClusterIsReady *bool
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	Limit          *int
+	Offset         *int
+}
+
+type TaskAttemptsFilter struct {
+	TaskID      *uuid.UUID
+	Types       []entities.TaskType
+	Outcomes    []entities.TaskAttemptOutcome
+	StartedFrom *time.Time
+	StartedTo   *time.Time
+	Limit       *int
+	Offset      *int
+}
+
+type RecurringTasksFilter struct {
+	Name    *string
+	Enabled *bool
+}
+
+// UserTasksSpec — задачи одного типа для всех пользователей из часовых поясов Timezones,
+// кроме тех, чей аккаунт ждёт удаления. В нагрузку Attribute добавляется user_id.
+type UserTasksSpec struct {
+	TypeNm      entities.TaskType
+	MaxAttempts int
+	Attribute   json.RawMessage
+	Timezones   []string
 }
@@ -13,5 +13,10 @@ type UserInfoFilter struct {
 	Password  *string
 	Email     *string
 	Phone     *string
+	Role      *string
+	AppleSub  *string
 	CreatedAt *time.Time
+	// EmailVerified/PhoneVerified — отбор по наличию email_verified_at/phone_verified_at.
+	EmailVerified *bool
+	PhoneVerified *bool
 }
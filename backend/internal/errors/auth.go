@@ -1,10 +1,65 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
-	ErrTokenExpired                = errors.New("token is expired")
-	ErrInvalidVerificationCode     = errors.New("verification code is invalid")
-	ErrVerificationCodeExpired     = errors.New("verification code is expired")
-	ErrVerificationCodeAlreadyUsed = errors.New("verification code already used")
+	ErrTokenExpired                  = errors.New("token is expired")
+	ErrInvalidVerificationCode       = errors.New("verification code is invalid")
+	ErrVerificationCodeExpired       = errors.New("verification code is expired")
+	ErrVerificationCodeAlreadyUsed   = errors.New("verification code already used")
+	ErrVerificationCodeAttemptsLimit = errors.New("verification code invalidated after too many attempts")
+	ErrSessionNotFound               = errors.New("session not found")
+	ErrRefreshTokenReused            = errors.New("refresh token reuse detected")
+	ErrTooManyAttempts               = errors.New("too many attempts")
+	ErrMFAAlreadyEnabled             = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled                 = errors.New("two-factor authentication is not enabled")
+	ErrMFAEnrollmentNotStarted       = errors.New("two-factor enrollment not started")
+	ErrInvalidMFACode                = errors.New("two-factor code is invalid")
+	ErrInvalidMFAChallenge           = errors.New("mfa challenge is invalid or expired")
+	ErrAppleSignInDisabled           = errors.New("sign in with apple is not configured")
+	ErrInvalidAppleToken             = errors.New("apple identity token is invalid")
+	ErrAppleEmailRequired            = errors.New("apple identity token has no email, request the email scope")
+	ErrAppleAccountConflict          = errors.New("account with this email already exists, sign in with password and verify the email first")
+	ErrContactUnchanged              = errors.New("new value matches the current one")
+	ErrContactTaken                  = errors.New("email or phone is already used by another account")
+	ErrPersonalTokenNotFound         = errors.New("personal token not found")
+	ErrInvalidPersonalToken          = errors.New("personal token is invalid or expired")
+	ErrPersonalTokenNameInvalid      = errors.New("personal token name must be 1-100 characters")
+	ErrPersonalTokenScopesRequired   = errors.New("personal token needs at least one scope")
+	ErrUnknownPersonalTokenScope     = errors.New("unknown personal token scope")
+	ErrPersonalTokenExpiryInPast     = errors.New("personal token expiry must be in the future")
+	ErrPersonalTokensLimit           = errors.New("too many personal tokens, revoke unused ones first")
+	ErrWeakPassword                  = errors.New("password does not meet the password policy")
 )
+
+// TooManyAttemptsError — превышен лимит попыток. RetryAfter — через сколько можно повторить запрос.
+// errors.Is(err, ErrTooManyAttempts) для неё истинно.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyAttemptsError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// PasswordPolicyError — новый пароль не прошёл политику. Reason — какое правило нарушено, текст для пользователя.
+// errors.Is(err, ErrWeakPassword) для неё истинно.
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, e.Reason)
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
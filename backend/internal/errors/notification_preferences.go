@@ -0,0 +1,5 @@
+package errors
+
+import "errors"
+
+var ErrInvalidNotificationPreferences = errors.New("invalid notification preferences")
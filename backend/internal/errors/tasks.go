@@ -0,0 +1,15 @@
+package errors
+
+import "errors"
+
+var (
+	ErrRecurringTaskNotFound = errors.New("recurring task not found")
+	ErrInvalidRecurringTask  = errors.New("invalid recurring task")
+	// ErrTaskLocked — задачу сейчас выполняет исполнитель, менять её можно после окончания аренды.
+	ErrTaskLocked = errors.New("task is being processed")
+	// ErrTaskNotRestartable — пользователь перезапускает задачу не из dead-letter или тип, который
+	// перезапускает только администратор.
+	ErrTaskNotRestartable = errors.New("task can not be restarted")
+	// ErrTaskLeaseLost — аренда задачи истекла и её забрал другой экземпляр: результат попытки не записан.
+	ErrTaskLeaseLost = errors.New("task lease lost")
+)
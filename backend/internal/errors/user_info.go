@@ -8,4 +8,8 @@ var (
 	ErrInvalidCredentials     = errors.New("password is incorrect")
 	ErrUserInfoAlreadyExists  = errors.New("user info with id already exist")
 	ErrUserInfoAlreadyDeleted = errors.New("user info with id already deleted")
+	ErrUnknownUserRole        = errors.New("unknown user role")
+	ErrContactChangeRequired  = errors.New("email and phone are changed via /auth/change-email and /auth/change-phone")
+	ErrDeletionNotScheduled   = errors.New("account deletion is not scheduled")
+	ErrExportEmailRequired    = errors.New("verified email is required to receive the data export")
 )
@@ -4,4 +4,5 @@ import "errors"
 
 var (
 	ErrUnknownWorkoutsLevel = errors.New("unknown workouts type of field level")
+	ErrWorkoutNotFound      = errors.New("workout not found")
 )
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	docsSwag "backend/docs"
+	"backend/pkg/JWT"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -10,6 +11,7 @@ import (
 
 const (
 	readinessProbeName = "/healthcheck"
+	jwksEndpoint       = "/.well-known/jwks.json"
 	apiEndpoint        = "/api/v1"
 )
 
@@ -28,6 +30,8 @@ func Register(router *gin.RouterGroup, host string, controllers ...HTTPControlle
 		})
 	})
 
+	router.GET(jwksEndpoint, JWT.JWKSHandler())
+
 	docsSwag.SwaggerInfo.Host = host
 	docsSwag.SwaggerInfo.BasePath = apiEndpoint
 
@@ -0,0 +1,510 @@
+package v1
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"backend/internal/handlers/v1/models"
+	"backend/pkg/JWT"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// registerAdminHandlers регистрирует административные ручки. Вся группа /admin доступна только роли admin.
+func (a *API) registerAdminHandlers(router *gin.RouterGroup) {
+	admin := router.Group("/admin", JWT.RequireRoles(entities.UserRoleAdmin))
+	admin.PATCH("/users/:uuid/role", a.updateUserRole)
+
+	tasks := admin.Group("/tasks")
+	tasks.GET("", a.adminListTasks)
+	tasks.DELETE("", a.adminPurgeFailedTasks)
+	tasks.POST("/retry", a.adminRetryFailedTasks)
+	tasks.GET("/:uuid", a.adminGetTask)
+	tasks.DELETE("/:uuid", a.adminDeleteTask)
+	tasks.POST("/:uuid/restart", a.adminRestartTask)
+
+	admin.GET("/task-attempts", a.adminListTaskAttempts)
+
+	admin.GET("/recurring-tasks", a.adminListRecurringTasks)
+	admin.PATCH("/recurring-tasks/:name", a.adminUpdateRecurringTask)
+
+	admin.GET("/audit-events", a.adminListAuditEvents)
+}
+
+const (
+	adminTasksDefaultLimit = 50
+	adminTasksMaxLimit     = 200
+)
+
+// parsePagination читает limit и offset из query. limit по умолчанию defaultLimit и не больше maxLimit.
+// ok = false — параметры неверны, ответ 400 уже отправлен.
+func parsePagination(ctx *gin.Context, defaultLimit, maxLimit int) (limit, offset int, ok bool) {
+	limit = defaultLimit
+	if l := ctx.Query("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v <= 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'limit', must be a positive integer"})
+			return 0, 0, false
+		}
+		limit = min(v, maxLimit)
+	}
+
+	if o := ctx.Query("offset"); o != "" {
+		v, err := strconv.Atoi(o)
+		if err != nil || v < 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'offset', must be a non-negative integer"})
+			return 0, 0, false
+		}
+		offset = v
+	}
+
+	return limit, offset, true
+}
+
+// parseTimeRange читает необязательные границы from и to (RFC3339) из query.
+// ok = false — формат неверен, ответ 400 уже отправлен.
+func parseTimeRange(ctx *gin.Context) (from, to *time.Time, ok bool) {
+	if fromStr := ctx.Query("from"); fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' format, use RFC3339"})
+			return nil, nil, false
+		}
+		from = &t
+	}
+	if toStr := ctx.Query("to"); toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'to' format, use RFC3339"})
+			return nil, nil, false
+		}
+		to = &t
+	}
+	return from, to, true
+}
+
+// updateUserRole назначает роль пользователю
+// @Summary Назначение роли пользователю
+// @Description Меняет роль пользователя (user, coach, admin). Новая роль попадёт в токен при следующем входе или обновлении токена
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string true "ID пользователя"
+// @Param request body models.UserRoleUpdateRequestModel true "Новая роль"
+// @Success 200 {object} models.SuccessResponse "Роль обновлена"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат ID или роли"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{uuid}/role [patch]
+func (a *API) updateUserRole(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("uuid"))
+	if err != nil {
+		a.log.Errorf("admin error: update user role: invalid uuid: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
+		return
+	}
+
+	var m models.UserRoleUpdateRequestModel
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		a.log.Errorf("admin error: update user role: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationErrors(ctx, err, "update user role")
+		return
+	}
+
+	if err := a.CRUDService.UpdateInfoUser(ctx, dto.UserInfoFilter{ID: &userID}, m.ToParam()); err != nil {
+		a.log.Errorf("admin error: update user role: %s", err.Error())
+		if errors.Is(err, errs.ErrUserInfoNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to update user role"})
+		return
+	}
+
+	a.log.Infof("admin: update user role: success")
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role updated"})
+}
+
+// adminListTasks возвращает задачи всех пользователей
+// @Summary Список задач всех пользователей
+// @Description Глобальный просмотр очереди executor'а с фильтрами и пагинацией. Коды подтверждения и токены устройств скрыты
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param type   query string false "Тип задачи (можно передать несколько раз)"
+// @Param state  query string false "Состояние задачи: running, failed (можно передать несколько раз)"
+// @Param from   query string false "Создана не раньше (RFC3339)"
+// @Param to     query string false "Создана не позже (RFC3339)"
+// @Param limit  query int    false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int    false "Смещение"
+// @Success 200 {object} models.TasksListResponse "Список задач"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат параметров"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/tasks [get]
+func (a *API) adminListTasks(ctx *gin.Context) {
+	limit, offset, ok := parsePagination(ctx, adminTasksDefaultLimit, adminTasksMaxLimit)
+	if !ok {
+		return
+	}
+
+	f := dto.TasksFilter{Limit: &limit, Offset: &offset}
+
+	for _, t := range ctx.QueryArray("type") {
+		f.Types = append(f.Types, entities.TaskType(t))
+	}
+
+	for _, st := range ctx.QueryArray("state") {
+		state := entities.TaskState(st)
+		if state != entities.TaskStateRunning && state != entities.TaskStateFailed {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'state'", "details": st})
+			return
+		}
+		f.States = append(f.States, state)
+	}
+
+	if f.CreatedFrom, f.CreatedTo, ok = parseTimeRange(ctx); !ok {
+		return
+	}
+
+	tasks, err := a.CRUDService.ListTasks(ctx, f)
+	if err != nil {
+		a.log.Errorf("admin error: list tasks: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to list tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TasksListResponse{
+		Tasks:  models.NewTasksResponse(tasks),
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// adminGetTask возвращает любую задачу по ID
+// @Summary Получение любой задачи по ID
+// @Description Возвращает задачу любого пользователя по её UUID. Коды подтверждения и токены устройств скрыты
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "ID задачи"
+// @Success 200 {object} models.TaskResponse "Задача"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} models.ErrorResponse "Задача не найдена"
+// @Router /admin/tasks/{uuid} [get]
+func (a *API) adminGetTask(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("uuid"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+
+	task, err := a.CRUDService.GetTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{taskID}})
+	if err != nil {
+		a.log.Errorf("admin error: get task: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewTaskResponse(task))
+}
+
+// adminRestartTask перезапускает любую задачу
+// @Summary Перезапуск любой задачи
+// @Description Сбрасывает счётчик попыток задачи любого пользователя и переводит её обратно в running
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "ID задачи"
+// @Success 200 {object} models.SuccessResponse "Задача перезапущена"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} models.ErrorResponse "Задача сейчас выполняется"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/tasks/{uuid}/restart [post]
+func (a *API) adminRestartTask(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("uuid"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
+		return
+	}
+
+	if err = a.CRUDService.RestartTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{taskID}}); err != nil {
+		if errors.Is(err, errs.ErrTaskLocked) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "task is being processed, try again later"})
+			return
+		}
+		a.log.Errorf("admin error: restart task: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to restart task"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task restarted"})
+}
+
+// adminDeleteTask удаляет любую задачу
+// @Summary Удаление любой задачи
+// @Description Удаляет задачу любого пользователя из очереди executor'а
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "ID задачи"
+// @Success 200 {object} models.SuccessResponse "Задача удалена"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} models.ErrorResponse "Задача сейчас выполняется"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/tasks/{uuid} [delete]
+func (a *API) adminDeleteTask(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("uuid"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
+		return
+	}
+
+	if err = a.CRUDService.DeleteTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{taskID}}); err != nil {
+		if errors.Is(err, errs.ErrTaskLocked) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "task is being processed, try again later"})
+			return
+		}
+		a.log.Errorf("admin error: delete task: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
+}
+
+// parseFailedTasksFilter читает фильтр массовых операций над dead-letter: тип и период создания,
+// как у GET /admin/tasks. ok = false — параметры неверны, ответ 400 уже отправлен.
+func parseFailedTasksFilter(ctx *gin.Context) (f dto.TasksFilter, ok bool) {
+	for _, t := range ctx.QueryArray("type") {
+		f.Types = append(f.Types, entities.TaskType(t))
+	}
+
+	if f.CreatedFrom, f.CreatedTo, ok = parseTimeRange(ctx); !ok {
+		return dto.TasksFilter{}, false
+	}
+
+	return f, true
+}
+
+// adminRetryFailedTasks перезапускает задачи из dead-letter
+// @Summary Массовый перезапуск упавших задач
+// @Description Возвращает в очередь все задачи в состоянии failed с указанным типом и периодом создания: счётчик попыток и причина сбрасываются. Без фильтров перезапускаются все упавшие задачи
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Тип задачи (можно передать несколько раз)"
+// @Param from query string false "Создана не раньше (RFC3339)"
+// @Param to   query string false "Создана не позже (RFC3339)"
+// @Success 200 {object} models.TasksBulkResponse "Число перезапущенных задач"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат параметров"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/tasks/retry [post]
+func (a *API) adminRetryFailedTasks(ctx *gin.Context) {
+	f, ok := parseFailedTasksFilter(ctx)
+	if !ok {
+		return
+	}
+
+	n, err := a.CRUDService.RetryFailedTasks(ctx, f)
+	if err != nil {
+		a.log.Errorf("admin error: retry failed tasks: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to retry tasks"})
+		return
+	}
+
+	a.log.Infof("admin: retry failed tasks: %d restarted", n)
+	ctx.JSON(http.StatusOK, models.TasksBulkResponse{Affected: n})
+}
+
+// adminPurgeFailedTasks удаляет задачи из dead-letter
+// @Summary Массовое удаление упавших задач
+// @Description Удаляет все задачи в состоянии failed с указанным типом и периодом создания. Задачи в очереди не затрагиваются, история попыток сохраняется
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Тип задачи (можно передать несколько раз)"
+// @Param from query string false "Создана не раньше (RFC3339)"
+// @Param to   query string false "Создана не позже (RFC3339)"
+// @Success 200 {object} models.TasksBulkResponse "Число удалённых задач"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат параметров"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/tasks [delete]
+func (a *API) adminPurgeFailedTasks(ctx *gin.Context) {
+	f, ok := parseFailedTasksFilter(ctx)
+	if !ok {
+		return
+	}
+
+	n, err := a.CRUDService.PurgeFailedTasks(ctx, f)
+	if err != nil {
+		a.log.Errorf("admin error: purge failed tasks: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to purge tasks"})
+		return
+	}
+
+	a.log.Infof("admin: purge failed tasks: %d deleted", n)
+	ctx.JSON(http.StatusOK, models.TasksBulkResponse{Affected: n})
+}
+
+// adminListTaskAttempts возвращает историю выполнения задач
+// @Summary История выполнения задач
+// @Description Попытки выполнения задач от новых к старым: время, длительность, экземпляр и ошибка. История хранится и для уже выполненных и удалённых задач
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param task_id query string false "ID задачи"
+// @Param type    query string false "Тип задачи (можно передать несколько раз)"
+// @Param outcome query string false "Результат: succeeded, failed, dead_lettered (можно передать несколько раз)"
+// @Param from    query string false "Начата не раньше (RFC3339)"
+// @Param to      query string false "Начата не позже (RFC3339)"
+// @Param limit   query int    false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset  query int    false "Смещение"
+// @Success 200 {object} models.TaskAttemptsListResponse "Попытки"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат параметров"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/task-attempts [get]
+func (a *API) adminListTaskAttempts(ctx *gin.Context) {
+	limit, offset, ok := parsePagination(ctx, adminTasksDefaultLimit, adminTasksMaxLimit)
+	if !ok {
+		return
+	}
+
+	f := dto.TaskAttemptsFilter{Limit: &limit, Offset: &offset}
+
+	if raw := ctx.Query("task_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'task_id' format"})
+			return
+		}
+		f.TaskID = &id
+	}
+
+	for _, t := range ctx.QueryArray("type") {
+		f.Types = append(f.Types, entities.TaskType(t))
+	}
+
+	for _, o := range ctx.QueryArray("outcome") {
+		outcome := entities.TaskAttemptOutcome(o)
+		switch outcome {
+		case entities.TaskAttemptSucceeded, entities.TaskAttemptFailed, entities.TaskAttemptDeadLettered:
+		default:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'outcome'", "details": o})
+			return
+		}
+		f.Outcomes = append(f.Outcomes, outcome)
+	}
+
+	if f.StartedFrom, f.StartedTo, ok = parseTimeRange(ctx); !ok {
+		return
+	}
+
+	attempts, err := a.CRUDService.ListTaskAttempts(ctx, f)
+	if err != nil {
+		a.log.Errorf("admin error: list task attempts: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to list task attempts"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TaskAttemptsListResponse{
+		Attempts: models.NewTaskAttemptsResponse(attempts),
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
+
+// adminListRecurringTasks возвращает расписания задач
+// @Summary Расписания задач
+// @Description Расписания в формате cron, по которым executor создаёт задачи: очистка истёкших сессий, напоминания и т.п.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.RecurringTasksListResponse "Расписания"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/recurring-tasks [get]
+func (a *API) adminListRecurringTasks(ctx *gin.Context) {
+	list, err := a.CRUDService.ListRecurringTasks(ctx, dto.RecurringTasksFilter{})
+	if err != nil {
+		a.log.Errorf("admin error: list recurring tasks: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to list recurring tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewRecurringTasksListResponse(list))
+}
+
+// adminUpdateRecurringTask меняет расписание задач
+// @Summary Изменение расписания задач
+// @Description Меняет cron, часовой пояс, шаблон нагрузки, лимит попыток или включает и выключает расписание. Включённое заново расписание не догоняет пропущенные срабатывания
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Имя расписания"
+// @Param request body models.RecurringTaskUpdateRequest true "Изменения"
+// @Success 200 {object} models.RecurringTaskResponse "Расписание"
+// @Failure 400 {object} models.ErrorResponse "Неверный cron, часовой пояс или нагрузка"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} models.ErrorResponse "Расписание не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/recurring-tasks/{name} [patch]
+func (a *API) adminUpdateRecurringTask(ctx *gin.Context) {
+	var m models.RecurringTaskUpdateRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		a.log.Errorf("admin error: update recurring task: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationErrors(ctx, err, "update recurring task")
+		return
+	}
+
+	rt, err := a.CRUDService.UpdateRecurringTask(ctx, ctx.Param("name"), m.ToParam())
+	if err != nil {
+		a.log.Errorf("admin error: update recurring task: %s", err.Error())
+		switch {
+		case errors.Is(err, errs.ErrRecurringTaskNotFound):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "recurring task not found"})
+		case errors.Is(err, errs.ErrInvalidRecurringTask):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid recurring task", "details": err.Error()})
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to update recurring task"})
+		}
+		return
+	}
+
+	a.log.Infof("admin: update recurring task %s: success", rt.Name())
+	ctx.JSON(http.StatusOK, models.NewRecurringTaskResponse(rt))
+}
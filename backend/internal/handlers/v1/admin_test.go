@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"backend/internal/service/crud"
+	"backend/internal/service/crud/mocks"
+	"backend/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newAdminRouter поднимает административные ручки поверх настоящего crud.Service.
+// Авторизация заменена записью роли admin в контекст.
+func newAdminRouter(userRepo crud.UserInfoRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	log := logging.GetLoggerFromContext(context.Background())
+	api := NewHandlers(Config{
+		CRUDService: crud.NewService(&crud.Config{
+			TransactionManager: passThroughTxManager{},
+			UserInfoRepository: userRepo,
+			Log:                log,
+		}),
+		Validator: *validator.New(),
+		Log:       log,
+	})
+
+	r := gin.New()
+	api.registerAdminHandlers(r.Group("", func(c *gin.Context) {
+		c.Set("role", entities.UserRoleAdmin.String())
+		c.Next()
+	}))
+	return r
+}
+
+func TestUpdateUserRole_UnknownUser_NotFound(t *testing.T) {
+	userID := uuid.New()
+	userRepo := mocks.NewUserInfoRepository(t)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).
+		Return(nil, fmt.Errorf("get user info: %w", errs.ErrUserInfoNotFound))
+
+	w := httptest.NewRecorder()
+	newAdminRouter(userRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPatch,
+		"/admin/users/"+userID.String()+"/role", strings.NewReader(`{"role": "coach"}`)))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error": "user not found"}`, w.Body.String())
+}
+
+func TestUpdateUserRole_RepositoryError_InternalError(t *testing.T) {
+	userID := uuid.New()
+	userRepo := mocks.NewUserInfoRepository(t)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).
+		Return(nil, errors.New("db error"))
+
+	w := httptest.NewRecorder()
+	newAdminRouter(userRepo).ServeHTTP(w, httptest.NewRequest(http.MethodPatch,
+		"/admin/users/"+userID.String()+"/role", strings.NewReader(`{"role": "coach"}`)))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -8,6 +8,7 @@ import (
 	"backend/pkg/JWT"
 	"backend/pkg/ai"
 	"backend/pkg/logging"
+	"backend/pkg/templates"
 	"context"
 	"errors"
 	"fmt"
@@ -24,12 +25,32 @@ import (
 type (
 	AuthService interface {
 		Register(ctx context.Context, ua entities.UserInfoInitSpec) error
-		Login(ctx context.Context, ui entities.UserAuthInitSpec) (auth.TokenPair, error)
-		Refresh(ctx context.Context, rawToken string) (auth.TokenPair, error)
+		Login(ctx context.Context, ui entities.UserAuthInitSpec, meta dto.SessionMetadata) (auth.LoginResult, error)
+		VerifyMFA(ctx context.Context, mfaToken, code string, meta dto.SessionMetadata) (auth.TokenPair, error)
+		SignInWithApple(ctx context.Context, p auth.AppleSignInParams, meta dto.SessionMetadata) (auth.LoginResult, error)
+		SendLoginCode(ctx context.Context, login, ip string) error
+		LoginWithCode(ctx context.Context, login, code string, meta dto.SessionMetadata) (auth.LoginResult, error)
+		Refresh(ctx context.Context, rawToken string, meta dto.SessionMetadata) (auth.TokenPair, error)
+		ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.UserRefreshToken, error)
+		RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+		Logout(ctx context.Context, userID, sessionID uuid.UUID) error
+		RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID) error
 		SendVerificationCode(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType) error
 		VerifyCode(ctx context.Context, userID uuid.UUID, code string, codeType entities.VerificationCodeType) error
-		SendRecoveryCode(ctx context.Context, email string) error
-		ResetPassword(ctx context.Context, email, code, newPassword string) error
+		RequestContactChange(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType, value string) error
+		ConfirmContactChange(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType, code string) error
+		SendRecoveryCode(ctx context.Context, email, ip string) error
+		ResetPassword(ctx context.Context, email, code, newPassword, ip string) error
+
+		GetMFAStatus(ctx context.Context, userID uuid.UUID) (auth.MFAStatus, error)
+		EnrollTOTP(ctx context.Context, userID uuid.UUID) (auth.TOTPEnrollment, error)
+		ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+		DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+
+		CreatePersonalToken(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (auth.CreatedPersonalToken, error)
+		ListPersonalTokens(ctx context.Context, userID uuid.UUID) ([]*entities.UserPersonalToken, error)
+		RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error
 	}
 
 	UserStatisticsService interface {
@@ -44,7 +65,6 @@ type (
 		GetInfoUser(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error)
 		CreateInfoUser(ctx context.Context, info entities.UserInfoInitSpec) error
 		UpdateInfoUser(ctx context.Context, f dto.UserInfoFilter, info entities.UserInfoUpdateParams) error
-		DeleteInfoUser(ctx context.Context, f dto.UserInfoFilter) error
 
 		GetParamsUser(ctx context.Context, f dto.UserParamsFilter, withBlock bool) (*entities.UserParams, error)
 		CreateParamsUser(ctx context.Context, params entities.UserParamsInitSpec) error
@@ -73,20 +93,32 @@ type (
 		UpdateWorkoutByFilter(ctx context.Context, f dto.WorkoutsFilter, params entities.WorkoutUpdateParams) error
 		DeleteWorkout(ctx context.Context, f dto.WorkoutsFilter) error
 
-		GetTask(ctx context.Context, id uuid.UUID) (*entities.Task, error)
-		DeleteTask(ctx context.Context, id uuid.UUID) error
+		GetTask(ctx context.Context, f dto.TasksFilter) (*entities.Task, error)
+		DeleteTask(ctx context.Context, f dto.TasksFilter) error
 		ListTasks(ctx context.Context, filter dto.TasksFilter) ([]*entities.Task, error)
-		RestartTask(ctx context.Context, id uuid.UUID) error
+		RestartTask(ctx context.Context, f dto.TasksFilter) error
+		RestartUserTask(ctx context.Context, userID, taskID uuid.UUID) error
+		RetryFailedTasks(ctx context.Context, f dto.TasksFilter) (int, error)
+		PurgeFailedTasks(ctx context.Context, f dto.TasksFilter) (int, error)
+		ListTaskAttempts(ctx context.Context, f dto.TaskAttemptsFilter) ([]*entities.TaskAttempt, error)
+		ListRecurringTasks(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error)
+		UpdateRecurringTask(ctx context.Context, name string, p entities.RecurringTaskUpdateParams) (*entities.RecurringTask, error)
 
 		RegisterUserDevice(ctx context.Context, spec entities.UserDeviceInitSpec) error
+		RegisterLiveActivity(ctx context.Context, spec entities.LiveActivityInitSpec) error
 		ListUserDevices(ctx context.Context, userID uuid.UUID) ([]*entities.UserDevice, error)
 		DeleteUserDevice(ctx context.Context, id, userID uuid.UUID) error
 
+		GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error)
+		UpdateNotificationPreferences(ctx context.Context, userID uuid.UUID, p entities.NotificationPreferencesUpdateParams) (*entities.NotificationPreferences, error)
+
 		CreateUserCalories(ctx context.Context, spec entities.UserCaloriesInitSpec) error
 		GetUserCalories(ctx context.Context, f dto.UserCaloriesFilter) (*entities.UserCalories, error)
 		ListUserCalories(ctx context.Context, f dto.UserCaloriesFilter) ([]*entities.UserCalories, error)
 		UpdateUserCalories(ctx context.Context, f dto.UserCaloriesFilter, params entities.UserCaloriesUpdateParams) error
 		DeleteUserCalories(ctx context.Context, id, userID uuid.UUID) error
+
+		ListAuditEvents(ctx context.Context, f dto.AuditEventFilter) ([]*entities.AuditEvent, error)
 	}
 
 	AvatarService interface {
@@ -115,6 +147,16 @@ type (
 	EmailService interface {
 		SendEmail(to, subject, body string) error
 	}
+
+	TemplateRenderer interface {
+		Render(id, locale string, vars map[string]string) (templates.Message, error)
+	}
+
+	AccountService interface {
+		ScheduleDeletion(ctx context.Context, userID uuid.UUID) (time.Time, error)
+		CancelDeletion(ctx context.Context, userID uuid.UUID) error
+		RequestExport(ctx context.Context, userID uuid.UUID) error
+	}
 )
 
 type Config struct {
@@ -126,6 +168,8 @@ type Config struct {
 	NutritionService      NutritionService
 	RecommendationService RecommendationService
 	EmailService          EmailService
+	Templates             TemplateRenderer
+	AccountService        AccountService
 	Validator             validator.Validate
 	Log                   logging.Entry
 }
@@ -139,6 +183,8 @@ type API struct {
 	nutritionService      NutritionService
 	recommendationService RecommendationService
 	emailService          EmailService
+	templates             TemplateRenderer
+	accountService        AccountService
 	validator             validator.Validate
 	log                   logging.Entry
 }
@@ -153,16 +199,19 @@ func NewHandlers(c Config) *API {
 		nutritionService:      c.NutritionService,
 		recommendationService: c.RecommendationService,
 		emailService:          c.EmailService,
+		templates:             c.Templates,
+		accountService:        c.AccountService,
 		validator:             c.Validator,
 		log:                   c.Log,
 	}
 }
 
-func (a *API) RegisterHandlers(r *gin.RouterGroup) {
+func (a *API) RegisterHandlers(router *gin.RouterGroup) {
+	r := router.Group("", requestMetadataMiddleware())
 	a.registerAuthHandlers(r)
 	a.registerFeedbackHandlers(r)
 
-	protected := r.Group("", JWT.JWTAuthMiddleware())
+	protected := r.Group("", JWT.JWTAuthMiddleware(), a.personalTokenScopeMiddleware(r.BasePath()))
 	a.registerExerciseHandlers(protected)
 	a.registerWorkoutsHandlers(protected)
 	a.registerUserInfoHandlers(protected)
@@ -171,9 +220,24 @@ func (a *API) RegisterHandlers(r *gin.RouterGroup) {
 	a.registerTasksHandlers(protected)
 	a.registerAvatarsHandlers(protected)
 	a.registerUserDevicesHandlers(protected)
+	a.registerNotificationPreferencesHandlers(protected)
 	a.registerUserCaloriesHandlers(protected)
 	a.registerNutritionHandlers(protected)
 	a.registerRecommendationsHandlers(protected)
+	a.registerSecurityEventsHandlers(protected)
+	a.registerAdminHandlers(protected)
+}
+
+// requestMetadataMiddleware сохраняет IP и User-Agent запроса для журнала безопасности
+// (см. dto.RequestMetadataFromContext).
+func requestMetadataMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(dto.RequestMetadataKey, dto.RequestMetadata{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Next()
+	}
 }
 
 func (a *API) checkPhone(ctx *gin.Context, phone string) error {
@@ -201,7 +265,7 @@ func (a *API) handleValidationAuthFields(c *gin.Context, err error, typeMethod s
 		tag := fe.Tag()
 
 		switch tag {
-		case "required":
+		case "required", "required_without":
 			out[field] = field + " is required"
 		case "min":
 			out[field] = field + " is too short"
@@ -259,6 +323,30 @@ func (a *API) handleValidationErrors(c *gin.Context, err error, contextKey strin
 	c.AbortWithStatusJSON(http.StatusBadRequest, response)
 }
 
+// getSessionIDFromContext возвращает ID сессии из claim'а sid access-токена.
+func (a *API) getSessionIDFromContext(ctx *gin.Context) (uuid.UUID, error) {
+	sid := ctx.GetString("session_id")
+	if sid == "" {
+		a.log.Errorf("missing session_id in context")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "token is not bound to a session, log in again",
+		})
+		return uuid.Nil, fmt.Errorf("missing session_id")
+	}
+
+	sessionID, err := uuid.Parse(sid)
+	if err != nil {
+		a.log.Errorf("invalid session_id format: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid session_id format",
+			"details": err.Error(),
+		})
+		return uuid.Nil, fmt.Errorf("invalid session_id")
+	}
+
+	return sessionID, nil
+}
+
 func (a *API) getUserIDFromContext(ctx *gin.Context) (uuid.UUID, error) {
 	userIDRaw, ok := ctx.Get("user_id")
 	if !ok {
@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/handlers/v1/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (a *API) registerSecurityEventsHandlers(router *gin.RouterGroup) {
+	router.GET("/user/security-events", a.listSecurityEvents)
+}
+
+const (
+	auditEventsDefaultLimit = 50
+	auditEventsMaxLimit     = 200
+)
+
+// listSecurityEvents возвращает журнал безопасности текущего пользователя
+// @Summary Журнал безопасности
+// @Description Входы, неудачные попытки входа, сбросы пароля, подтверждения, обновления токенов и изменения профиля — от новых к старым. С персональным токеном недоступно
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Param type   query string false "Тип события, например auth.login (можно передать несколько раз)"
+// @Param from   query string false "Не раньше (RFC3339)"
+// @Param to     query string false "Не позже (RFC3339)"
+// @Param limit  query int    false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int    false "Смещение"
+// @Success 200 {object} models.AuditEventsListResponse "События"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат параметров"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/security-events [get]
+func (a *API) listSecurityEvents(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	f, ok := parseAuditEventFilter(ctx)
+	if !ok {
+		return
+	}
+	f.UserID = &userID
+
+	a.respondAuditEvents(ctx, f)
+}
+
+// adminListAuditEvents ищет события журнала безопасности всех пользователей
+// @Summary Поиск по журналу безопасности
+// @Description Поиск событий для расследования инцидентов: по пользователю, автору действия, типу, IP и времени
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param user_id  query string false "Чей аккаунт затронут"
+// @Param actor_id query string false "Кто выполнил действие"
+// @Param type     query string false "Тип события (можно передать несколько раз)"
+// @Param ip       query string false "IP клиента"
+// @Param from     query string false "Не раньше (RFC3339)"
+// @Param to       query string false "Не позже (RFC3339)"
+// @Param limit    query int    false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset   query int    false "Смещение"
+// @Success 200 {object} models.AuditEventsListResponse "События"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат параметров"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/audit-events [get]
+func (a *API) adminListAuditEvents(ctx *gin.Context) {
+	f, ok := parseAuditEventFilter(ctx)
+	if !ok {
+		return
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **uuid.UUID
+	}{{"user_id", &f.UserID}, {"actor_id", &f.ActorID}} {
+		raw := ctx.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid '" + param.name + "' format"})
+			return
+		}
+		*param.target = &id
+	}
+
+	if ip := ctx.Query("ip"); ip != "" {
+		f.IP = &ip
+	}
+
+	a.respondAuditEvents(ctx, f)
+}
+
+// parseAuditEventFilter читает общие для обеих ручек параметры: тип, период и пагинацию.
+func parseAuditEventFilter(ctx *gin.Context) (dto.AuditEventFilter, bool) {
+	limit, offset, ok := parsePagination(ctx, auditEventsDefaultLimit, auditEventsMaxLimit)
+	if !ok {
+		return dto.AuditEventFilter{}, false
+	}
+
+	f := dto.AuditEventFilter{Limit: &limit, Offset: &offset}
+	for _, t := range ctx.QueryArray("type") {
+		f.Types = append(f.Types, entities.AuditEventType(t))
+	}
+	if f.CreatedFrom, f.CreatedTo, ok = parseTimeRange(ctx); !ok {
+		return dto.AuditEventFilter{}, false
+	}
+
+	return f, true
+}
+
+func (a *API) respondAuditEvents(ctx *gin.Context, f dto.AuditEventFilter) {
+	events, err := a.CRUDService.ListAuditEvents(ctx, f)
+	if err != nil {
+		a.log.Errorf("audit error: list events: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to list security events"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.AuditEventsListResponse{
+		Events: models.NewAuditEventsResponse(events),
+		Limit:  *f.Limit,
+		Offset: *f.Offset,
+	})
+}
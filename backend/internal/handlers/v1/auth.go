@@ -2,8 +2,12 @@ package v1
 
 import (
 	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
 	"backend/internal/handlers/v1/models"
 	"backend/pkg/JWT"
+	"errors"
+	"math"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,14 +19,33 @@ func (a *API) registerAuthHandlers(router *gin.RouterGroup) {
 	group := router.Group("/auth")
 	group.POST("/register", a.register)
 	group.POST("/login", a.login)
+	group.POST("/login/code", a.sendLoginCode)
+	group.POST("/login/code/verify", a.loginWithCode)
+	group.POST("/apple", a.signInWithApple)
 	group.POST("/refresh", a.refresh)
 	group.POST("/recover", a.sendRecoveryCode)
 	group.POST("/reset-password", a.resetPassword)
+	group.POST("/mfa/verify", a.verifyMFA)
 
-	protected := group.Group("", JWT.JWTAuthMiddleware())
+	protected := group.Group("", JWT.JWTAuthMiddleware(), a.personalTokenScopeMiddleware(router.BasePath()))
 	protected.POST("/verify-email", a.verifyEmail)
 	protected.POST("/verify-phone", a.verifyPhone)
 	protected.POST("/send-verification", a.sendVerificationCode)
+	protected.POST("/change-email", a.requestEmailChange)
+	protected.POST("/change-phone", a.requestPhoneChange)
+	protected.POST("/confirm-change", a.confirmContactChange)
+	protected.POST("/logout", a.logout)
+	protected.GET("/sessions", a.listSessions)
+	protected.DELETE("/sessions", a.revokeOtherSessions)
+	protected.DELETE("/sessions/:id", a.revokeSession)
+	protected.GET("/mfa", a.getMFAStatus)
+	protected.POST("/mfa/totp", a.enrollTOTP)
+	protected.POST("/mfa/totp/confirm", a.confirmTOTP)
+	protected.DELETE("/mfa/totp", a.disableTOTP)
+	protected.POST("/mfa/recovery-codes", a.regenerateRecoveryCodes)
+	protected.GET("/tokens", a.listPersonalTokens)
+	protected.POST("/tokens", a.createPersonalToken)
+	protected.DELETE("/tokens/:id", a.revokePersonalToken)
 }
 
 // register обрабатывает регистрацию пользователя
@@ -33,7 +56,7 @@ func (a *API) registerAuthHandlers(router *gin.RouterGroup) {
 // @Produce json
 // @Param request body models.RegisterRequestModel true "Данные для регистрации"
 // @Success 201 {object} models.SuccessResponse	"Пользователь успешно зарегистрирован"
-// @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации или пароль не прошёл политику"
 // @Failure 409 {object} models.ErrorResponse "Пользователь уже существует"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /auth/register [post]
@@ -59,6 +82,9 @@ func (a *API) register(ctx *gin.Context) {
 
 	if err := a.authService.Register(ctx, r.ToSpec()); err != nil {
 		a.log.Errorf("%s: %v", "auth error", err.Error())
+		if a.handleWeakPassword(ctx, err) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"auth error": err.Error()})
 		return
 	}
@@ -68,15 +94,19 @@ func (a *API) register(ctx *gin.Context) {
 
 // login обрабатывает вход пользователя
 // @Summary Аутентификация пользователя
-// @Description Вход пользователя в систему и получение пары токенов
+// @Description Вход пользователя в систему и получение пары токенов. login — ник, подтверждённый email или подтверждённый телефон
+// @Description (поле username принимается для старых клиентов). Каждый вход открывает новую сессию; device_name и platform сохраняются в ней.
+// @Description Если у пользователя включена 2FA, вместо токенов возвращается mfa_token для /auth/mfa/verify
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body models.LoginRequestModel true "Данные для входа"
 // @Success 200 {object} models.TokenPairModel "Успешная аутентификация"
+// @Success 202 {object} models.MFARequiredResponse "Пароль верный, требуется второй фактор"
 // @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
 // @Failure 401 {object} models.ErrorResponse "Неверные учетные данные"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
 // @Router /auth/login [post]
 func (a *API) login(ctx *gin.Context) {
 	var m models.LoginRequestModel
@@ -91,14 +121,151 @@ func (a *API) login(ctx *gin.Context) {
 		return
 	}
 
-	pair, err := a.authService.Login(ctx, m.ToSpec())
+	res, err := a.authService.Login(ctx, m.ToSpec(), models.NewSessionMetadata(ctx, m.DeviceName, m.Platform))
 	if err != nil {
 		a.log.Errorf("%s: %v", "auth error", err.Error())
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth error": err.Error()})
 		return
 	}
+	if res.MFARequired {
+		a.log.Info("auth: login: second factor required")
+		ctx.JSON(http.StatusAccepted, models.NewMFARequiredResponse(res.MFAToken))
+		return
+	}
 	a.log.Info("auth: login: success")
-	ctx.JSON(http.StatusOK, models.NewTokenPairModel(pair))
+	ctx.JSON(http.StatusOK, models.NewTokenPairModel(res.Tokens))
+}
+
+// sendLoginCode отправляет код для входа без пароля
+// @Summary Код для входа без пароля
+// @Description Отправляет 6-значный код на email (вход по email), в SMS (вход по телефону) или, для ника, на подтверждённый email,
+// @Description а если его нет — на подтверждённый телефон. Всегда отвечает 200, не раскрывая, существует ли пользователь
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.SendLoginCodeRequest true "Ник, email или телефон"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Router /auth/login/code [post]
+func (a *API) sendLoginCode(ctx *gin.Context) {
+	var m models.SendLoginCodeRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "login-code")
+		return
+	}
+
+	if err := a.authService.SendLoginCode(ctx, m.Login, ctx.ClientIP()); err != nil {
+		a.log.Errorf("auth: send login code: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "If the account exists, a login code has been sent"})
+}
+
+// loginWithCode обрабатывает вход по одноразовому коду
+// @Summary Вход по коду
+// @Description Обменивает код из /auth/login/code на пару токенов. Если у пользователя включена 2FA, вместо токенов возвращается mfa_token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginWithCodeRequest true "Ник, email или телефон и код"
+// @Success 200 {object} models.TokenPairModel "Успешная аутентификация"
+// @Success 202 {object} models.MFARequiredResponse "Требуется второй фактор"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
+// @Failure 401 {object} models.ErrorResponse "Неверный, просроченный или использованный код"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Router /auth/login/code/verify [post]
+func (a *API) loginWithCode(ctx *gin.Context) {
+	var m models.LoginWithCodeRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "login-code-verify")
+		return
+	}
+
+	res, err := a.authService.LoginWithCode(ctx, m.Login, m.Code, models.NewSessionMetadata(ctx, m.DeviceName, m.Platform))
+	if err != nil {
+		a.log.Errorf("auth: login with code: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth error": err.Error()})
+		return
+	}
+	if res.MFARequired {
+		a.log.Info("auth: login with code: second factor required")
+		ctx.JSON(http.StatusAccepted, models.NewMFARequiredResponse(res.MFAToken))
+		return
+	}
+	a.log.Info("auth: login with code: success")
+	ctx.JSON(http.StatusOK, models.NewTokenPairModel(res.Tokens))
+}
+
+// signInWithApple обрабатывает вход через Apple
+// @Summary Вход через Apple
+// @Description Проверяет identity token Sign in with Apple и выдаёт пару токенов. Пользователь ищется по Apple ID;
+// @Description если не найден — привязывается аккаунт с тем же подтверждённым email или создаётся новый.
+// @Description name и surname Apple передаёт приложению только при первой авторизации — их стоит отправить сразу.
+// @Description Если у пользователя включена 2FA, вместо токенов возвращается mfa_token для /auth/mfa/verify
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.AppleSignInRequest true "Identity token и данные устройства"
+// @Success 200 {object} models.TokenPairModel "Успешная аутентификация"
+// @Success 202 {object} models.MFARequiredResponse "Требуется второй фактор"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации или в токене нет email"
+// @Failure 401 {object} models.ErrorResponse "Недействительный identity token"
+// @Failure 409 {object} models.ErrorResponse "Аккаунт с этим email уже есть и не может быть привязан"
+// @Failure 503 {object} models.ErrorResponse "Вход через Apple не настроен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/apple [post]
+func (a *API) signInWithApple(ctx *gin.Context) {
+	var m models.AppleSignInRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "apple")
+		return
+	}
+
+	res, err := a.authService.SignInWithApple(ctx, m.ToParams(), models.NewSessionMetadata(ctx, m.DeviceName, m.Platform))
+	if err != nil {
+		a.log.Errorf("auth: sign in with apple: %v", err)
+		switch {
+		case errors.Is(err, errs.ErrAppleSignInDisabled):
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"auth error": err.Error()})
+		case errors.Is(err, errs.ErrInvalidAppleToken):
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth error": errs.ErrInvalidAppleToken.Error()})
+		case errors.Is(err, errs.ErrAppleEmailRequired):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		case errors.Is(err, errs.ErrAppleAccountConflict):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"auth error": err.Error()})
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "failed to sign in with apple"})
+		}
+		return
+	}
+	if res.MFARequired {
+		a.log.Info("auth: apple: second factor required")
+		ctx.JSON(http.StatusAccepted, models.NewMFARequiredResponse(res.MFAToken))
+		return
+	}
+	a.log.Info("auth: apple: success")
+	ctx.JSON(http.StatusOK, models.NewTokenPairModel(res.Tokens))
 }
 
 // refresh обновляет пару токенов по refresh token
@@ -123,7 +290,7 @@ func (a *API) refresh(ctx *gin.Context) {
 		return
 	}
 
-	pair, err := a.authService.Refresh(ctx, m.RefreshToken)
+	pair, err := a.authService.Refresh(ctx, m.RefreshToken, models.NewSessionMetadata(ctx, "", ""))
 	if err != nil {
 		a.log.Errorf("auth: refresh: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth error": err.Error()})
@@ -143,6 +310,7 @@ func (a *API) refresh(ctx *gin.Context) {
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
 // @Router /auth/send-verification [post]
 func (a *API) sendVerificationCode(ctx *gin.Context) {
 	userIDRaw, ok := ctx.Get("user_id")
@@ -186,6 +354,9 @@ func (a *API) sendVerificationCode(ctx *gin.Context) {
 	codeType := entities.VerificationCodeType(m.CodeType)
 	if err := a.authService.SendVerificationCode(ctx, userID, codeType); err != nil {
 		a.log.Errorf("auth: send verification code: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": err.Error()})
 		return
 	}
@@ -202,6 +373,7 @@ func (a *API) sendVerificationCode(ctx *gin.Context) {
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
 // @Router /auth/verify-email [post]
 func (a *API) verifyEmail(ctx *gin.Context) {
 	userIDRaw, ok := ctx.Get("user_id")
@@ -244,6 +416,9 @@ func (a *API) verifyEmail(ctx *gin.Context) {
 
 	if err := a.authService.VerifyCode(ctx, userID, m.Code, entities.VerificationCodeEmail); err != nil {
 		a.log.Errorf("auth: verify email: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
 		return
 	}
@@ -260,6 +435,7 @@ func (a *API) verifyEmail(ctx *gin.Context) {
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
 // @Router /auth/verify-phone [post]
 func (a *API) verifyPhone(ctx *gin.Context) {
 	userID, err := a.getUserIDFromContext(ctx)
@@ -279,6 +455,9 @@ func (a *API) verifyPhone(ctx *gin.Context) {
 
 	if err := a.authService.VerifyCode(ctx, userID, m.Code, entities.VerificationCodePhone); err != nil {
 		a.log.Errorf("auth: verify phone: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
 		return
 	}
@@ -293,6 +472,7 @@ func (a *API) verifyPhone(ctx *gin.Context) {
 // @Param request body models.RecoverPasswordRequest true "Email"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
 // @Router /auth/recover [post]
 func (a *API) sendRecoveryCode(ctx *gin.Context) {
 	var m models.RecoverPasswordRequest
@@ -305,8 +485,13 @@ func (a *API) sendRecoveryCode(ctx *gin.Context) {
 		return
 	}
 
-	// Always respond 200 to avoid user enumeration
-	_ = a.authService.SendRecoveryCode(ctx, m.Email)
+	// Always respond 200 to avoid user enumeration; 429 is counted per email before lookup and reveals nothing
+	if err := a.authService.SendRecoveryCode(ctx, m.Email, ctx.ClientIP()); err != nil {
+		a.log.Errorf("auth: send recovery code: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
+	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "If the email exists, a recovery code has been sent"})
 }
 
@@ -317,7 +502,8 @@ func (a *API) sendRecoveryCode(ctx *gin.Context) {
 // @Produce json
 // @Param request body models.ResetPasswordRequest true "Данные для сброса"
 // @Success 200 {object} models.SuccessResponse
-// @Failure 400 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse "Неверный код или пароль не прошёл политику"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
 // @Router /auth/reset-password [post]
 func (a *API) resetPassword(ctx *gin.Context) {
 	var m models.ResetPasswordRequest
@@ -330,10 +516,36 @@ func (a *API) resetPassword(ctx *gin.Context) {
 		return
 	}
 
-	if err := a.authService.ResetPassword(ctx, m.Email, m.Code, m.NewPassword); err != nil {
+	if err := a.authService.ResetPassword(ctx, m.Email, m.Code, m.NewPassword, ctx.ClientIP()); err != nil {
 		a.log.Errorf("auth: reset password: %v", err)
+		if a.handleTooManyAttempts(ctx, err) || a.handleWeakPassword(ctx, err) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
 }
+
+// handleWeakPassword отвечает 400 с нарушенным правилом, если пароль не прошёл политику.
+func (a *API) handleWeakPassword(ctx *gin.Context, err error) bool {
+	var weak *errs.PasswordPolicyError
+	if !errors.As(err, &weak) {
+		return false
+	}
+
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": errs.ErrWeakPassword.Error(), "details": weak.Reason})
+	return true
+}
+
+// handleTooManyAttempts отвечает 429 с заголовком Retry-After, если сработал лимит попыток.
+func (a *API) handleTooManyAttempts(ctx *gin.Context, err error) bool {
+	var tooMany *errs.TooManyAttemptsError
+	if !errors.As(err, &tooMany) {
+		return false
+	}
+
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(tooMany.RetryAfter.Seconds()))))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"auth error": err.Error()})
+	return true
+}
@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
+	"backend/internal/handlers/v1/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestEmailChange начинает смену email
+// @Summary Смена email
+// @Description Отправляет код на новый email и уведомление на старый подтверждённый адрес.
+// @Description Email в профиле не меняется, пока код не введён в /auth/confirm-change
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.ChangeEmailRequest true "Новый email"
+// @Success 200 {object} models.SuccessResponse "Код отправлен"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации или адрес не изменился"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "Email занят другим пользователем"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/change-email [post]
+func (a *API) requestEmailChange(ctx *gin.Context) {
+	var m models.ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "change-email")
+		return
+	}
+
+	a.requestContactChange(ctx, entities.VerificationCodeEmailChange, m.Email)
+}
+
+// requestPhoneChange начинает смену телефона
+// @Summary Смена телефона
+// @Description Отправляет код в SMS на новый номер и уведомление на старый подтверждённый номер.
+// @Description Телефон в профиле не меняется, пока код не введён в /auth/confirm-change
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.ChangePhoneRequest true "Новый телефон"
+// @Success 200 {object} models.SuccessResponse "Код отправлен"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации или номер не изменился"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "Номер подтверждён другим пользователем"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/change-phone [post]
+func (a *API) requestPhoneChange(ctx *gin.Context) {
+	var m models.ChangePhoneRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "change-phone")
+		return
+	}
+
+	a.requestContactChange(ctx, entities.VerificationCodePhoneChange, m.Phone)
+}
+
+func (a *API) requestContactChange(ctx *gin.Context, codeType entities.VerificationCodeType, value string) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := a.authService.RequestContactChange(ctx, userID, codeType, value); err != nil {
+		a.log.Errorf("auth: request %s: %v", codeType, err)
+		a.handleContactChangeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Confirmation code sent to the new address"})
+}
+
+// confirmContactChange завершает смену email или телефона
+// @Summary Подтверждение смены email или телефона
+// @Description Проверяет код с нового адреса и сохраняет его в профиле уже подтверждённым
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.ConfirmContactChangeRequest true "Код и тип адреса"
+// @Success 200 {object} models.SuccessResponse "Адрес изменён"
+// @Failure 400 {object} models.ErrorResponse "Неверный, просроченный или использованный код"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "Адрес успели занять"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/confirm-change [post]
+func (a *API) confirmContactChange(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var m models.ConfirmContactChangeRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "confirm-change")
+		return
+	}
+
+	if err := a.authService.ConfirmContactChange(ctx, userID, m.ToCodeType(), m.Code); err != nil {
+		a.log.Errorf("auth: confirm contact change: %v", err)
+		a.handleContactChangeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Successfully changed"})
+}
+
+// handleContactChangeError переводит ошибки смены email и телефона в HTTP-статусы.
+func (a *API) handleContactChangeError(ctx *gin.Context, err error) {
+	if a.handleTooManyAttempts(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, errs.ErrContactTaken):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"auth error": err.Error()})
+	case errors.Is(err, errs.ErrContactUnchanged),
+		errors.Is(err, errs.ErrInvalidVerificationCode),
+		errors.Is(err, errs.ErrVerificationCodeExpired),
+		errors.Is(err, errs.ErrVerificationCodeAlreadyUsed),
+		errors.Is(err, errs.ErrVerificationCodeAttemptsLimit):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "internal error"})
+	}
+}
@@ -1,8 +1,10 @@
 package v1
 
 import (
+	"backend/internal/domain/entities"
 	"backend/internal/dto"
 	"backend/internal/handlers/v1/models"
+	"backend/pkg/JWT"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +14,13 @@ import (
 func (a *API) registerExerciseHandlers(router *gin.RouterGroup) {
 	exercises := router.Group("/exercises")
 	exercises.GET("/:uuid", a.getExercise)
-	exercises.DELETE("/:uuid", a.deleteExercise)
-	exercises.PATCH("/:uuid", a.updateExercise)
-	exercises.POST("/", a.createExercise)
 	exercises.GET("", a.getExercises)
+
+	// каталог упражнений общий для всех пользователей, изменять его могут только тренеры и администраторы
+	editors := exercises.Group("", JWT.RequireRoles(entities.UserRoleCoach, entities.UserRoleAdmin))
+	editors.DELETE("/:uuid", a.deleteExercise)
+	editors.PATCH("/:uuid", a.updateExercise)
+	editors.POST("/", a.createExercise)
 }
 
 // getExercise получает конкретное упражнение по ID
@@ -1,8 +1,9 @@
 package v1
 
 import (
+	"backend/internal/domain/entities"
 	"backend/internal/handlers/v1/models"
-	"fmt"
+	"backend/pkg/templates"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -32,20 +33,19 @@ func (a *API) sendFeedback(ctx *gin.Context) {
 		return
 	}
 
-	subject := "BodyFuel Feedback"
-	body := fmt.Sprintf("<h2>Новое сообщение обратной связи</h2>"+
-		"<p><strong>Сообщение:</strong></p>"+
-		"<p>%s</p>"+
-		"<hr>"+
-		"<p><em>Отправлено из приложения BodyFuel</em></p>", req.Message)
-
-	if req.Email != "" {
-		body += fmt.Sprintf("<p><strong>Email пользователя:</strong> %s</p>", req.Email)
+	msg, err := a.templates.Render(entities.NotificationTemplateFeedback.String(), templates.DefaultLocale, map[string]string{
+		"message": req.Message,
+		"email":   req.Email,
+	})
+	if err != nil {
+		a.log.Errorf("render feedback error: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to send feedback"})
+		return
 	}
 
 	adminEmail := "fantomrick228@gmail.com"
 
-	if err := a.emailService.SendEmail(adminEmail, subject, body); err != nil {
+	if err := a.emailService.SendEmail(adminEmail, msg.Subject, msg.HTML); err != nil {
 		a.log.Errorf("send feedback error: %s", err.Error())
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to send feedback"})
 		return
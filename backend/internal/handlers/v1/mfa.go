@@ -0,0 +1,248 @@
+package v1
+
+import (
+	errs "backend/internal/errors"
+	"backend/internal/handlers/v1/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// verifyMFA завершает вход пользователя с включённой 2FA
+// @Summary Второй шаг входа (2FA)
+// @Description Обменивает mfa_token из /auth/login и TOTP-код (или код восстановления) на пару токенов.
+// @Description mfa_token живёт 5 минут. device_name и platform можно не передавать — возьмутся из логина
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFAVerifyRequest true "MFA-токен и код"
+// @Success 200 {object} models.TokenPairModel "Успешная аутентификация"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
+// @Failure 401 {object} models.ErrorResponse "Неверный код или просроченный mfa_token"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/mfa/verify [post]
+func (a *API) verifyMFA(ctx *gin.Context) {
+	var m models.MFAVerifyRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "mfa-verify")
+		return
+	}
+
+	pair, err := a.authService.VerifyMFA(ctx, m.MFAToken, m.Code, models.NewSessionMetadata(ctx, m.DeviceName, m.Platform))
+	if err != nil {
+		a.log.Errorf("auth: verify mfa: %v", err)
+		if a.handleTooManyAttempts(ctx, err) {
+			return
+		}
+		if errors.Is(err, errs.ErrInvalidMFACode) || errors.Is(err, errs.ErrInvalidMFAChallenge) || errors.Is(err, errs.ErrMFANotEnabled) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth error": err.Error()})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "failed to verify second factor"})
+		return
+	}
+	a.log.Info("auth: login: success")
+	ctx.JSON(http.StatusOK, models.NewTokenPairModel(pair))
+}
+
+// getMFAStatus возвращает состояние 2FA
+// @Summary Состояние 2FA
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.MFAStatusResponse "Состояние 2FA"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/mfa [get]
+func (a *API) getMFAStatus(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	status, err := a.authService.GetMFAStatus(ctx, userID)
+	if err != nil {
+		a.log.Errorf("auth: mfa status: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "failed to get mfa status"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewMFAStatusResponse(status))
+}
+
+// enrollTOTP начинает подключение приложения-аутентификатора
+// @Summary Подключение TOTP
+// @Description Генерирует секрет и otpauth:// ссылку для QR-кода. 2FA включится после /auth/mfa/totp/confirm.
+// @Description Повторный вызов до подтверждения заменяет секрет
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.TOTPEnrollmentResponse "Секрет и ссылка для приложения"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "2FA уже включена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/mfa/totp [post]
+func (a *API) enrollTOTP(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	enrollment, err := a.authService.EnrollTOTP(ctx, userID)
+	if err != nil {
+		a.log.Errorf("auth: enroll totp: %v", err)
+		if errors.Is(err, errs.ErrMFAAlreadyEnabled) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"auth error": err.Error()})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "failed to start totp enrollment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewTOTPEnrollmentResponse(enrollment))
+}
+
+// confirmTOTP включает 2FA
+// @Summary Подтверждение TOTP
+// @Description Проверяет первый код из приложения, включает 2FA и возвращает 10 одноразовых кодов восстановления.
+// @Description Коды показываются один раз
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TOTPConfirmRequest true "Код из приложения"
+// @Success 200 {object} models.RecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {object} models.ErrorResponse "Неверный код или подключение не начато"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "2FA уже включена"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/mfa/totp/confirm [post]
+func (a *API) confirmTOTP(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var m models.TOTPConfirmRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "mfa-totp-confirm")
+		return
+	}
+
+	codes, err := a.authService.ConfirmTOTP(ctx, userID, m.Code)
+	if err != nil {
+		a.log.Errorf("auth: confirm totp: %v", err)
+		a.handleMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disableTOTP выключает 2FA
+// @Summary Отключение TOTP
+// @Description Выключает 2FA и удаляет коды восстановления. Нужен действующий TOTP-код или код восстановления
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.MFACodeRequest true "TOTP-код или код восстановления"
+// @Success 200 {object} models.SuccessResponse "2FA выключена"
+// @Failure 400 {object} models.ErrorResponse "Неверный код или 2FA не включена"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/mfa/totp [delete]
+func (a *API) disableTOTP(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var m models.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "mfa-totp-disable")
+		return
+	}
+
+	if err := a.authService.DisableTOTP(ctx, userID, m.Code); err != nil {
+		a.log.Errorf("auth: disable totp: %v", err)
+		a.handleMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// regenerateRecoveryCodes выпускает новые коды восстановления
+// @Summary Новые коды восстановления
+// @Description Заменяет все коды восстановления новым набором из 10 кодов. Нужен действующий TOTP-код или код восстановления
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.MFACodeRequest true "TOTP-код или код восстановления"
+// @Success 200 {object} models.RecoveryCodesResponse "Новые коды восстановления"
+// @Failure 400 {object} models.ErrorResponse "Неверный код или 2FA не включена"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 429 {object} models.ErrorResponse "Слишком много попыток, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/mfa/recovery-codes [post]
+func (a *API) regenerateRecoveryCodes(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var m models.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "mfa-recovery-codes")
+		return
+	}
+
+	codes, err := a.authService.RegenerateRecoveryCodes(ctx, userID, m.Code)
+	if err != nil {
+		a.log.Errorf("auth: regenerate recovery codes: %v", err)
+		a.handleMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleMFAError переводит ошибки управления 2FA в HTTP-статусы.
+func (a *API) handleMFAError(ctx *gin.Context, err error) {
+	if a.handleTooManyAttempts(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, errs.ErrMFAAlreadyEnabled):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"auth error": err.Error()})
+	case errors.Is(err, errs.ErrInvalidMFACode),
+		errors.Is(err, errs.ErrMFANotEnabled),
+		errors.Is(err, errs.ErrMFAEnrollmentNotStarted):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "internal error"})
+	}
+}
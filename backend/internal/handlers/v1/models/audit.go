@@ -0,0 +1,56 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditChangeResponse struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+type AuditEventResponse struct {
+	ID        uuid.UUID                      `json:"id"`
+	UserID    *uuid.UUID                     `json:"user_id,omitempty"`
+	ActorID   *uuid.UUID                     `json:"actor_id,omitempty"`
+	Type      string                         `json:"type"`
+	IP        string                         `json:"ip"`
+	UserAgent string                         `json:"user_agent"`
+	Changes   map[string]AuditChangeResponse `json:"changes,omitempty"`
+	Details   map[string]string              `json:"details,omitempty"`
+	CreatedAt time.Time                      `json:"created_at"`
+}
+
+type AuditEventsListResponse struct {
+	Events []AuditEventResponse `json:"events"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+func NewAuditEventsResponse(events []*entities.AuditEvent) []AuditEventResponse {
+	resp := make([]AuditEventResponse, len(events))
+	for i, e := range events {
+		var changes map[string]AuditChangeResponse
+		if len(e.Changes()) > 0 {
+			changes = make(map[string]AuditChangeResponse, len(e.Changes()))
+			for k, c := range e.Changes() {
+				changes[k] = AuditChangeResponse{Old: c.Old, New: c.New}
+			}
+		}
+		resp[i] = AuditEventResponse{
+			ID:        e.ID(),
+			UserID:    e.UserID(),
+			ActorID:   e.ActorID(),
+			Type:      e.Type().String(),
+			IP:        e.IP(),
+			UserAgent: e.UserAgent(),
+			Changes:   changes,
+			Details:   e.Details(),
+			CreatedAt: e.CreatedAt(),
+		}
+	}
+	return resp
+}
@@ -2,27 +2,67 @@ package models
 
 import (
 	"backend/internal/domain/entities"
+	"backend/internal/service/auth"
 	"github.com/google/uuid"
 	"time"
 )
 
+// LoginRequestModel — вход по паролю. login — ник, подтверждённый email или подтверждённый телефон;
+// username оставлен для старых версий приложения.
 type LoginRequestModel struct {
-	Username string `json:"username" validate:"required" form:"username"`
-	Password string `json:"password" validate:"required" form:"password"`
+	Login      string `json:"login" validate:"required_without=Username" form:"login"`
+	Username   string `json:"username,omitempty" validate:"required_without=Login" form:"username"`
+	Password   string `json:"password" validate:"required" form:"password"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=100" form:"device_name"`
+	Platform   string `json:"platform,omitempty" validate:"omitempty,oneof=ios android web" form:"platform"`
 }
 
 func (l *LoginRequestModel) ToSpec() entities.UserAuthInitSpec {
+	login := l.Login
+	if login == "" {
+		login = l.Username
+	}
+
 	return entities.UserAuthInitSpec{
-		Username: l.Username,
+		Login:    login,
 		Password: l.Password,
 	}
 }
 
+type SendLoginCodeRequest struct {
+	Login string `json:"login" validate:"required"`
+}
+
+type LoginWithCodeRequest struct {
+	Login      string `json:"login" validate:"required"`
+	Code       string `json:"code" validate:"required,len=6"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=100"`
+	Platform   string `json:"platform,omitempty" validate:"omitempty,oneof=ios android web"`
+}
+
+type AppleSignInRequest struct {
+	IdentityToken string `json:"identity_token" validate:"required"`
+	Nonce         string `json:"nonce,omitempty" validate:"omitempty,max=256"`
+	Name          string `json:"name,omitempty" validate:"omitempty,max=50"`
+	Surname       string `json:"surname,omitempty" validate:"omitempty,max=50"`
+	DeviceName    string `json:"device_name,omitempty" validate:"omitempty,max=100"`
+	Platform      string `json:"platform,omitempty" validate:"omitempty,oneof=ios android web"`
+}
+
+func (r *AppleSignInRequest) ToParams() auth.AppleSignInParams {
+	return auth.AppleSignInParams{
+		IdentityToken: r.IdentityToken,
+		Nonce:         r.Nonce,
+		Name:          r.Name,
+		Surname:       r.Surname,
+	}
+}
+
 type RegisterRequestModel struct {
 	Username string `json:"username" form:"username" validate:"required,min=3,max=32"`
 	Name     string `json:"name" form:"name" validate:"required,min=2,max=50"`
 	Surname  string `json:"surname" form:"surname" validate:"required,min=2,max=50"`
-	Password string `json:"password,omitempty" form:"password" validate:"required,min=6"`
+	Password string `json:"password,omitempty" form:"password" validate:"required"`
 	Email    string `json:"email" form:"email" validate:"required"`
 	Phone    string `json:"phone" form:"phone" validate:"required"`
 }
@@ -53,6 +93,27 @@ type VerifyCodeRequest struct {
 	CodeType string `json:"code_type" validate:"required,oneof=email phone"`
 }
 
+type ChangeEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" validate:"required,regex=^\\+?[0-9]{10,15}$"`
+}
+
+type ConfirmContactChangeRequest struct {
+	Code     string `json:"code" validate:"required,len=6"`
+	CodeType string `json:"code_type" validate:"required,oneof=email phone"`
+}
+
+// ToCodeType переводит тип адреса из запроса в тип кода смены.
+func (r *ConfirmContactChangeRequest) ToCodeType() entities.VerificationCodeType {
+	if r.CodeType == "phone" {
+		return entities.VerificationCodePhoneChange
+	}
+	return entities.VerificationCodeEmailChange
+}
+
 type RecoverPasswordRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
@@ -60,5 +121,5 @@ type RecoverPasswordRequest struct {
 type ResetPasswordRequest struct {
 	Email       string `json:"email" validate:"required,email"`
 	Code        string `json:"code" validate:"required,len=6"`
-	NewPassword string `json:"new_password" validate:"required,min=6"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
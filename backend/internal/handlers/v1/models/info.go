@@ -11,9 +11,16 @@ type UserInfoResponseModel struct {
 	Surname         string     `json:"surname"`
 	Email           string     `json:"email"`
 	Phone           string     `json:"phone"`
+	Role            string     `json:"role"`
 	CreatedAt       time.Time  `json:"created_at"`
 	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	// Timezone — часовой пояс IANA, по нему приходят ежедневные напоминания.
+	Timezone string `json:"timezone"`
+	// Locale — язык уведомлений.
+	Locale string `json:"locale"`
+	// DeletionScheduledAt — дата безвозвратного удаления аккаунта, null — удаление не запланировано.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
 }
 
 func NewUserInfoResponse(params *entities.UserInfo) UserInfoResponseModel {
@@ -23,24 +30,52 @@ func NewUserInfoResponse(params *entities.UserInfo) UserInfoResponseModel {
 		Surname:         params.Surname(),
 		Email:           params.Email(),
 		Phone:           params.Phone(),
+		Role:            params.Role().String(),
 		CreatedAt:       params.CreatedAt(),
 		EmailVerifiedAt: params.EmailVerifiedAt(),
 		PhoneVerifiedAt: params.PhoneVerifiedAt(),
+		Timezone:        params.Timezone(),
+		Locale:          params.Locale(),
+
+		DeletionScheduledAt: params.DeletionScheduledAt(),
 	}
 }
 
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
 type UserInfoUpdateRequestModel struct {
 	Name    *string `json:"name" form:"name" validate:"required,min=2,max=50"`
 	Surname *string `json:"surname" form:"surname" validate:"required,min=2,max=50"`
-	Email   *string `json:"email" form:"email" validate:"required"`
-	Phone   *string `json:"phone" form:"phone" validate:"required,regex=^\\+?[0-9]{10,15}$"`
+	// Email и Phone можно передать только без изменений: новый адрес подтверждается через /auth/change-email и /auth/change-phone.
+	Email *string `json:"email,omitempty" form:"email" validate:"omitempty"`
+	Phone *string `json:"phone,omitempty" form:"phone" validate:"omitempty,regex=^\\+?[0-9]{10,15}$"`
+	// Timezone — часовой пояс IANA, например Europe/Moscow. Не передан — не меняется.
+	Timezone *string `json:"timezone,omitempty" form:"timezone" validate:"omitempty,timezone"`
+	// Locale — язык уведомлений: ru или en. Не передан — не меняется.
+	Locale *string `json:"locale,omitempty" form:"locale" validate:"omitempty,oneof=ru en"`
 }
 
 func (u *UserInfoUpdateRequestModel) ToParam() entities.UserInfoUpdateParams {
 	return entities.UserInfoUpdateParams{
-		Name:    u.Name,
-		Surname: u.Surname,
-		Email:   u.Email,
-		Phone:   u.Phone,
+		Name:     u.Name,
+		Surname:  u.Surname,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Timezone: u.Timezone,
+		Locale:   u.Locale,
+	}
+}
+
+type UserRoleUpdateRequestModel struct {
+	Role string `json:"role" validate:"required,oneof=user coach admin"`
+}
+
+func (u *UserRoleUpdateRequestModel) ToParam() entities.UserInfoUpdateParams {
+	role := entities.UserRole(u.Role)
+
+	return entities.UserInfoUpdateParams{
+		Role: &role,
 	}
 }
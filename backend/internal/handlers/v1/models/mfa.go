@@ -0,0 +1,65 @@
+package models
+
+import (
+	"backend/internal/service/auth"
+	"backend/pkg/JWT"
+	"time"
+)
+
+// MFARequiredResponse — ответ логина для пользователя с включённой 2FA: токены выдаст /auth/mfa/verify.
+type MFARequiredResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func NewMFARequiredResponse(mfaToken string) MFARequiredResponse {
+	return MFARequiredResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   int(JWT.MFAChallengeTTL.Seconds()),
+	}
+}
+
+type MFAVerifyRequest struct {
+	MFAToken   string `json:"mfa_token" validate:"required"`
+	Code       string `json:"code" validate:"required,min=6,max=16"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=100"`
+	Platform   string `json:"platform,omitempty" validate:"omitempty,oneof=ios android web"`
+}
+
+// MFACodeRequest — TOTP-код из приложения или код восстановления.
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,min=6,max=16"`
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+type MFAStatusResponse struct {
+	TOTPEnabled       bool       `json:"totp_enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+func NewMFAStatusResponse(s auth.MFAStatus) MFAStatusResponse {
+	return MFAStatusResponse{
+		TOTPEnabled:       s.TOTPEnabled,
+		EnabledAt:         s.EnabledAt,
+		RecoveryCodesLeft: s.RecoveryCodesLeft,
+	}
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+func NewTOTPEnrollmentResponse(e auth.TOTPEnrollment) TOTPEnrollmentResponse {
+	return TOTPEnrollmentResponse{Secret: e.Secret, OTPAuthURI: e.URI}
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"fmt"
+	"time"
+)
+
+const quietHoursLayout = "15:04"
+
+// QuietHours — тихие часы в формате HH:MM по часовому поясу пользователя. From позже To — интервал через полночь.
+type QuietHours struct {
+	From string `json:"from" validate:"required" example:"22:00"`
+	To   string `json:"to" validate:"required" example:"07:00"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	// Channels — категория → канал → включён. Отсутствующие пары включены.
+	Channels   map[entities.NotificationCategory]map[entities.NotificationChannel]bool `json:"channels"`
+	QuietHours *QuietHours                                                             `json:"quiet_hours" validate:"omitempty"`
+}
+
+func (r UpdateNotificationPreferencesRequest) ToParams() (entities.NotificationPreferencesUpdateParams, error) {
+	params := entities.NotificationPreferencesUpdateParams{Channels: r.Channels}
+	if r.QuietHours == nil {
+		return params, nil
+	}
+
+	from, err := parseQuietHoursTime(r.QuietHours.From)
+	if err != nil {
+		return params, fmt.Errorf("quiet_hours.from: %w", err)
+	}
+	to, err := parseQuietHoursTime(r.QuietHours.To)
+	if err != nil {
+		return params, fmt.Errorf("quiet_hours.to: %w", err)
+	}
+	params.QuietHours = &entities.QuietHours{From: from, To: to}
+
+	return params, nil
+}
+
+func parseQuietHoursTime(s string) (int, error) {
+	t, err := time.Parse(quietHoursLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+type NotificationPreferencesResponse struct {
+	Channels   map[entities.NotificationCategory]map[entities.NotificationChannel]bool `json:"channels"`
+	QuietHours *QuietHours                                                             `json:"quiet_hours"`
+	Timezone   string                                                                  `json:"timezone" example:"Europe/Moscow"`
+}
+
+func NewNotificationPreferencesResponse(p *entities.NotificationPreferences) NotificationPreferencesResponse {
+	resp := NotificationPreferencesResponse{
+		Channels: p.Channels(),
+		Timezone: p.Timezone(),
+	}
+	if q := p.QuietHours(); q != nil {
+		resp.QuietHours = &QuietHours{
+			From: fmt.Sprintf("%02d:%02d", q.From/60, q.From%60),
+			To:   fmt.Sprintf("%02d:%02d", q.To/60, q.To%60),
+		}
+	}
+	return resp
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/service/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalTokenCreateRequest — expires_at можно не передавать, тогда токен бессрочный.
+type PersonalTokenCreateRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type PersonalTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// PersonalTokenCreatedResponse — token показывается только в этом ответе, сохранить его нужно сразу.
+type PersonalTokenCreatedResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token"`
+}
+
+func NewPersonalTokenResponse(t *entities.UserPersonalToken) PersonalTokenResponse {
+	scopes := make([]string, len(t.Scopes()))
+	for i, s := range t.Scopes() {
+		scopes[i] = s.String()
+	}
+
+	return PersonalTokenResponse{
+		ID:         t.ID(),
+		Name:       t.Name(),
+		Scopes:     scopes,
+		ExpiresAt:  t.ExpiresAt(),
+		LastUsedAt: t.LastUsedAt(),
+		LastUsedIP: t.LastUsedIP(),
+		CreatedAt:  t.CreatedAt(),
+	}
+}
+
+func NewPersonalTokensResponse(tokens []*entities.UserPersonalToken) []PersonalTokenResponse {
+	resp := make([]PersonalTokenResponse, len(tokens))
+	for i, t := range tokens {
+		resp[i] = NewPersonalTokenResponse(t)
+	}
+	return resp
+}
+
+func NewPersonalTokenCreatedResponse(c auth.CreatedPersonalToken) PersonalTokenCreatedResponse {
+	return PersonalTokenCreatedResponse{
+		PersonalTokenResponse: NewPersonalTokenResponse(c.Token),
+		Token:                 c.Raw,
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NewSessionMetadata собирает метаданные сессии из запроса: IP и User-Agent берутся из самого запроса,
+// имя устройства и платформа — из тела логина.
+func NewSessionMetadata(ctx *gin.Context, deviceName, platform string) dto.SessionMetadata {
+	return dto.SessionMetadata{
+		DeviceName: deviceName,
+		Platform:   platform,
+		IP:         ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+	}
+}
+
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceName string    `json:"device_name"`
+	Platform   string    `json:"platform"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+func NewSessionsResponse(sessions []*entities.UserRefreshToken, currentID uuid.UUID) []SessionResponse {
+	resp := make([]SessionResponse, len(sessions))
+	for i, s := range sessions {
+		resp[i] = SessionResponse{
+			ID:         s.ID(),
+			DeviceName: s.DeviceName(),
+			Platform:   s.Platform(),
+			IP:         s.IP(),
+			UserAgent:  s.UserAgent(),
+			CreatedAt:  s.CreatedAt(),
+			LastUsedAt: s.LastUsedAt(),
+			ExpiresAt:  s.ExpiresAt(),
+			Current:    s.ID() == currentID,
+		}
+	}
+	return resp
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"backend/internal/domain/entities"
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
@@ -19,29 +20,44 @@ type TaskCreateResponseModel struct {
 }
 
 type TaskResponse struct {
-	UUID        uuid.UUID `json:"uuid"`
-	TypeNm      string    `json:"type_nm"`
-	State       string    `json:"state"`
-	MaxAttempts int       `json:"max_attempts"`
-	Attempts    int       `json:"attempts"`
-	RetryAt     time.Time `json:"retry_at"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Attribute   any       `json:"attribute"`
+	UUID          uuid.UUID `json:"uuid"`
+	TypeNm        string    `json:"type_nm"`
+	State         string    `json:"state"`
+	MaxAttempts   int       `json:"max_attempts"`
+	Attempts      int       `json:"attempts"`
+	RetryAt       time.Time `json:"retry_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Attribute     any       `json:"attribute"`
+	FailureReason string    `json:"failure_reason,omitempty"`
 }
 
 func NewTaskResponse(t *entities.Task) TaskResponse {
 	return TaskResponse{
-		UUID:        t.UUID(),
-		TypeNm:      t.TypeNm().String(),
-		State:       t.State().String(),
-		MaxAttempts: t.MaxAttempts(),
-		Attempts:    t.Attempts(),
-		RetryAt:     t.RetryAt(),
-		CreatedAt:   t.CreatedAt(),
-		UpdatedAt:   t.UpdatedAt(),
-		Attribute:   t.Attribute(),
+		UUID:          t.UUID(),
+		TypeNm:        t.TypeNm().String(),
+		State:         t.State().String(),
+		MaxAttempts:   t.MaxAttempts(),
+		Attempts:      t.Attempts(),
+		RetryAt:       t.RetryAt(),
+		CreatedAt:     t.CreatedAt(),
+		UpdatedAt:     t.UpdatedAt(),
+		Attribute:     redactTaskPayload(t.Payload()),
+		FailureReason: t.FailureReason(),
+	}
+}
+
+// redactTaskPayload не даёт коду подтверждения и токенам устройств попасть в ответ API.
+// Нагрузка неизвестного типа не отдаётся вовсе.
+func redactTaskPayload(p entities.TaskPayload) any {
+	if p == nil {
+		return nil
+	}
+	if r := p.Redacted(); r != nil {
+		return r
 	}
+
+	return nil
 }
 
 func NewTasksResponse(tasks []*entities.Task) []TaskResponse {
@@ -51,3 +67,114 @@ func NewTasksResponse(tasks []*entities.Task) []TaskResponse {
 	}
 	return resp
 }
+
+type TasksListResponse struct {
+	Tasks  []TaskResponse `json:"tasks"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+type TaskAttemptResponse struct {
+	ID         uuid.UUID `json:"id"`
+	TaskID     uuid.UUID `json:"task_id"`
+	TypeNm     string    `json:"type_nm"`
+	Attempt    int       `json:"attempt"`
+	Owner      string    `json:"owner"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type TaskAttemptsListResponse struct {
+	Attempts []TaskAttemptResponse `json:"attempts"`
+	Limit    int                   `json:"limit"`
+	Offset   int                   `json:"offset"`
+}
+
+func NewTaskAttemptsResponse(attempts []*entities.TaskAttempt) []TaskAttemptResponse {
+	resp := make([]TaskAttemptResponse, len(attempts))
+	for i, a := range attempts {
+		resp[i] = TaskAttemptResponse{
+			ID:         a.ID(),
+			TaskID:     a.TaskID(),
+			TypeNm:     a.TaskType().String(),
+			Attempt:    a.Attempt(),
+			Owner:      a.Owner(),
+			Outcome:    a.Outcome().String(),
+			Error:      a.Error(),
+			StartedAt:  a.StartedAt(),
+			DurationMs: a.Duration().Milliseconds(),
+		}
+	}
+	return resp
+}
+
+// TasksBulkResponse — результат массового перезапуска или удаления задач.
+type TasksBulkResponse struct {
+	Affected int `json:"affected"`
+}
+
+// RecurringTaskResponse — расписание задач. Для scope=per_user cron считается в часовом поясе
+// каждого пользователя, поле timezone не используется.
+type RecurringTaskResponse struct {
+	Name         string          `json:"name"`
+	Cron         string          `json:"cron"`
+	TypeNm       string          `json:"type_nm"`
+	Attribute    json.RawMessage `json:"attribute" swaggertype:"object"`
+	Scope        string          `json:"scope"`
+	Timezone     string          `json:"timezone"`
+	MaxAttempts  int             `json:"max_attempts"`
+	Enabled      bool            `json:"enabled"`
+	CheckedUntil time.Time       `json:"checked_until"`
+	LastRunAt    *time.Time      `json:"last_run_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type RecurringTasksListResponse struct {
+	RecurringTasks []RecurringTaskResponse `json:"recurring_tasks"`
+}
+
+func NewRecurringTaskResponse(t *entities.RecurringTask) RecurringTaskResponse {
+	return RecurringTaskResponse{
+		Name:         t.Name(),
+		Cron:         t.Cron(),
+		TypeNm:       t.TypeNm().String(),
+		Attribute:    t.Attribute(),
+		Scope:        t.Scope().String(),
+		Timezone:     t.Timezone(),
+		MaxAttempts:  t.MaxAttempts(),
+		Enabled:      t.IsEnabled(),
+		CheckedUntil: t.CheckedUntil(),
+		LastRunAt:    t.LastRunAt(),
+		UpdatedAt:    t.UpdatedAt(),
+	}
+}
+
+func NewRecurringTasksListResponse(list []*entities.RecurringTask) RecurringTasksListResponse {
+	resp := make([]RecurringTaskResponse, len(list))
+	for i, t := range list {
+		resp[i] = NewRecurringTaskResponse(t)
+	}
+	return RecurringTasksListResponse{RecurringTasks: resp}
+}
+
+// RecurringTaskUpdateRequest — изменение расписания, переданные поля заменяются.
+// Attribute — шаблон нагрузки задачи целиком; для per_user user_id подставляется при срабатывании.
+type RecurringTaskUpdateRequest struct {
+	Cron        *string         `json:"cron,omitempty" validate:"omitempty,min=1,max=100"`
+	Attribute   json.RawMessage `json:"attribute,omitempty" swaggertype:"object"`
+	Timezone    *string         `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	MaxAttempts *int            `json:"max_attempts,omitempty" validate:"omitempty,min=0,max=100"`
+	Enabled     *bool           `json:"enabled,omitempty"`
+}
+
+func (r *RecurringTaskUpdateRequest) ToParam() entities.RecurringTaskUpdateParams {
+	return entities.RecurringTaskUpdateParams{
+		Cron:        r.Cron,
+		Attribute:   r.Attribute,
+		Timezone:    r.Timezone,
+		MaxAttempts: r.MaxAttempts,
+		Enabled:     r.Enabled,
+	}
+}
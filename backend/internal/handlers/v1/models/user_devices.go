@@ -12,6 +12,11 @@ type RegisterDeviceRequest struct {
 	Platform    string `json:"platform" validate:"required,oneof=ios android"`
 }
 
+type RegisterLiveActivityRequest struct {
+	WorkoutID string `json:"workout_id" validate:"required,uuid"`
+	PushToken string `json:"push_token" validate:"required"`
+}
+
 type UserDeviceResponse struct {
 	ID          uuid.UUID `json:"id"`
 	DeviceToken string    `json:"device_token"`
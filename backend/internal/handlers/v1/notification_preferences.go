@@ -0,0 +1,102 @@
+package v1
+
+import (
+	errs "backend/internal/errors"
+	"backend/internal/handlers/v1/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (a *API) registerNotificationPreferencesHandlers(router *gin.RouterGroup) {
+	prefs := router.Group("/user/notification-preferences")
+	prefs.GET("", a.getNotificationPreferences)
+	prefs.PUT("", a.updateNotificationPreferences)
+}
+
+// getNotificationPreferences возвращает настройки уведомлений пользователя
+// @Summary Настройки уведомлений
+// @Description Возвращает включённость каналов (email, sms, push) по категориям уведомлений и тихие часы. Пока пользователь ничего не менял, включено всё
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.NotificationPreferencesResponse "Настройки уведомлений"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/notification-preferences [get]
+func (a *API) getNotificationPreferences(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	prefs, err := a.CRUDService.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		a.log.Errorf("get notification preferences: %v", err)
+		if errors.Is(err, errs.ErrUserInfoNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to get notification preferences"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewNotificationPreferencesResponse(prefs))
+}
+
+// updateNotificationPreferences заменяет настройки уведомлений пользователя
+// @Summary Изменение настроек уведомлений
+// @Description Заменяет настройки целиком: отсутствующие пары категория/канал включаются, quiet_hours: null выключает тихие часы. Email категории security выключить нельзя, тихие часы на неё не действуют
+// @Tags Notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateNotificationPreferencesRequest true "Настройки уведомлений"
+// @Success 200 {object} models.NotificationPreferencesResponse "Сохранённые настройки"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/notification-preferences [put]
+func (a *API) updateNotificationPreferences(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		a.log.Errorf("update notification preferences: invalid request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := a.validator.Struct(req); err != nil {
+		a.handleValidationErrors(ctx, err, "update notification preferences")
+		return
+	}
+
+	params, err := req.ToParams()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid notification preferences", "details": err.Error()})
+		return
+	}
+
+	prefs, err := a.CRUDService.UpdateNotificationPreferences(ctx, userID, params)
+	if err != nil {
+		a.log.Errorf("update notification preferences: %v", err)
+		switch {
+		case errors.Is(err, errs.ErrInvalidNotificationPreferences):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid notification preferences", "details": err.Error()})
+		case errors.Is(err, errs.ErrUserInfoNotFound):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to update notification preferences"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewNotificationPreferencesResponse(prefs))
+}
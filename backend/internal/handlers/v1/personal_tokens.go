@@ -0,0 +1,203 @@
+package v1
+
+import (
+	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
+	"backend/internal/handlers/v1/models"
+	"backend/pkg/JWT"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// personalTokenRoute — раздел API, доступный персональному токену. GET и HEAD требуют права read,
+// остальные методы — write. exact — только сам путь без вложенных, methods ограничивает разрешённые
+// методы, пусто — любые.
+type personalTokenRoute struct {
+	prefix  string
+	exact   bool
+	read    entities.PersonalTokenScope
+	write   entities.PersonalTokenScope
+	methods []string
+}
+
+// personalTokenRoutes — всё, что не перечислено здесь (сессии, 2FA, сами токены, удаление аккаунта,
+// выгрузка, журнал безопасности, админка), с персональным токеном недоступно.
+var personalTokenRoutes = []personalTokenRoute{
+	{prefix: "/user/info", exact: true, read: entities.PersonalTokenScopeProfileRead, write: entities.PersonalTokenScopeProfileWrite,
+		methods: []string{http.MethodGet, http.MethodHead, http.MethodPatch}},
+	{prefix: "/user/params", read: entities.PersonalTokenScopeProfileRead, write: entities.PersonalTokenScopeProfileWrite},
+	{prefix: "/user/weight", read: entities.PersonalTokenScopeWeightRead, write: entities.PersonalTokenScopeWeightWrite},
+	{prefix: "/user/calories", read: entities.PersonalTokenScopeCaloriesRead, write: entities.PersonalTokenScopeCaloriesWrite},
+	{prefix: "/nutrition", read: entities.PersonalTokenScopeNutritionRead, write: entities.PersonalTokenScopeNutritionWrite},
+	{prefix: "/workouts", read: entities.PersonalTokenScopeWorkoutsRead, write: entities.PersonalTokenScopeWorkoutsWrite},
+	{prefix: "/exercises", read: entities.PersonalTokenScopeWorkoutsRead, write: entities.PersonalTokenScopeWorkoutsWrite},
+	{prefix: "/recommendations", read: entities.PersonalTokenScopeRecommendationsRead, write: entities.PersonalTokenScopeRecommendationsWrite},
+}
+
+// requiredPersonalTokenScope возвращает право, нужное для запроса method к маршруту path
+// (путь без базового префикса группы). ok = false — маршрут персональным токенам закрыт.
+func requiredPersonalTokenScope(method, path string) (entities.PersonalTokenScope, bool) {
+	for _, r := range personalTokenRoutes {
+		if path != r.prefix && (r.exact || !strings.HasPrefix(path, r.prefix+"/")) {
+			continue
+		}
+		if len(r.methods) > 0 && !slices.Contains(r.methods, method) {
+			return "", false
+		}
+		if method == http.MethodGet || method == http.MethodHead {
+			return r.read, true
+		}
+		return r.write, true
+	}
+	return "", false
+}
+
+// personalTokenScopeMiddleware проверяет права персонального токена для маршрутов группы с базовым
+// путём basePath. Запросы с JWT проходят без ограничений.
+func (a *API) personalTokenScopeMiddleware(basePath string) gin.HandlerFunc {
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	return func(ctx *gin.Context) {
+		scopes, ok := JWT.PersonalTokenScopes(ctx)
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		path := strings.TrimPrefix(ctx.FullPath(), basePath)
+		required, allowed := requiredPersonalTokenScope(ctx.Request.Method, path)
+		if !allowed {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "endpoint is not available for personal access tokens"})
+			return
+		}
+		if !slices.Contains(scopes, required.String()) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "personal token lacks scope " + required.String()})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// listPersonalTokens возвращает персональные токены пользователя
+// @Summary Список персональных токенов
+// @Description Возвращает персональные токены доступа без их значений: название, права, срок действия и последнее использование
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.PersonalTokenResponse "Список токенов"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Запрос с персональным токеном"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/tokens [get]
+func (a *API) listPersonalTokens(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	tokens, err := a.authService.ListPersonalTokens(ctx, userID)
+	if err != nil {
+		a.log.Errorf("auth: list personal tokens: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "failed to list personal tokens"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewPersonalTokensResponse(tokens))
+}
+
+// createPersonalToken выпускает персональный токен
+// @Summary Создание персонального токена
+// @Description Выпускает токен для скриптов и интеграций с правами scopes (например nutrition:read, workouts:write).
+// @Description Значение токена возвращается только в этом ответе. Токен передаётся в заголовке Authorization: Bearer bfp_...
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.PersonalTokenCreateRequest true "Название, права и срок действия"
+// @Success 201 {object} models.PersonalTokenCreatedResponse "Токен создан"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации, неизвестное право или срок в прошлом"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Запрос с персональным токеном"
+// @Failure 409 {object} models.ErrorResponse "Достигнут лимит токенов"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/tokens [post]
+func (a *API) createPersonalToken(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var m models.PersonalTokenCreateRequest
+	if err := ctx.ShouldBindJSON(&m); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		return
+	}
+	if err := a.validator.Struct(m); err != nil {
+		a.handleValidationAuthFields(ctx, err, "personal-token")
+		return
+	}
+
+	created, err := a.authService.CreatePersonalToken(ctx, userID, m.Name, m.Scopes, m.ExpiresAt)
+	if err != nil {
+		a.log.Errorf("auth: create personal token: %v", err)
+		switch {
+		case errors.Is(err, errs.ErrUnknownPersonalTokenScope),
+			errors.Is(err, errs.ErrPersonalTokenScopesRequired),
+			errors.Is(err, errs.ErrPersonalTokenNameInvalid),
+			errors.Is(err, errs.ErrPersonalTokenExpiryInPast):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"auth error": err.Error()})
+		case errors.Is(err, errs.ErrPersonalTokensLimit):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"auth error": errs.ErrPersonalTokensLimit.Error()})
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"auth error": "failed to create personal token"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.NewPersonalTokenCreatedResponse(created))
+}
+
+// revokePersonalToken отзывает персональный токен
+// @Summary Отзыв персонального токена
+// @Description Удаляет токен, запросы с ним сразу перестают проходить
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID токена"
+// @Success 200 {object} models.SuccessResponse "Токен отозван"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 403 {object} models.ErrorResponse "Запрос с персональным токеном"
+// @Failure 404 {object} models.ErrorResponse "Токен не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/tokens/{id} [delete]
+func (a *API) revokePersonalToken(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	tokenID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
+		return
+	}
+
+	if err := a.authService.RevokePersonalToken(ctx, userID, tokenID); err != nil {
+		a.log.Errorf("auth: revoke personal token: %v", err)
+		if errors.Is(err, errs.ErrPersonalTokenNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "personal token not found"})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke personal token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Personal token revoked"})
+}
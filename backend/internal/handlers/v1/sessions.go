@@ -0,0 +1,140 @@
+package v1
+
+import (
+	errs "backend/internal/errors"
+	"backend/internal/handlers/v1/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// listSessions возвращает активные сессии пользователя
+// @Summary Список сессий
+// @Description Возвращает активные сессии (refresh-токены) пользователя. Текущая сессия помечена current=true
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.SessionResponse "Список сессий"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/sessions [get]
+func (a *API) listSessions(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	// токены, выпущенные до появления сессий, не содержат sid — тогда просто ни одна сессия не будет текущей
+	currentID, _ := uuid.Parse(ctx.GetString("session_id"))
+
+	sessions, err := a.authService.ListSessions(ctx, userID)
+	if err != nil {
+		a.log.Errorf("auth: list sessions: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewSessionsResponse(sessions, currentID))
+}
+
+// revokeSession завершает сессию по ID
+// @Summary Завершение сессии
+// @Description Отзывает refresh-токен сессии. Access-токен этой сессии продолжит работать до истечения
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {object} models.SuccessResponse "Сессия завершена"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 404 {object} models.ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/sessions/{id} [delete]
+func (a *API) revokeSession(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
+		return
+	}
+
+	if err := a.authService.RevokeSession(ctx, userID, sessionID); err != nil {
+		a.log.Errorf("auth: revoke session: %v", err)
+		if errors.Is(err, errs.ErrSessionNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// revokeOtherSessions завершает все сессии, кроме текущей
+// @Summary Выход на всех остальных устройствах
+// @Description Отзывает refresh-токены всех сессий пользователя, кроме сессии текущего access-токена
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.SuccessResponse "Остальные сессии завершены"
+// @Failure 400 {object} models.ErrorResponse "Токен не привязан к сессии"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/sessions [delete]
+func (a *API) revokeOtherSessions(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	sessionID, err := a.getSessionIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := a.authService.RevokeOtherSessions(ctx, userID, sessionID); err != nil {
+		a.log.Errorf("auth: revoke other sessions: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked"})
+}
+
+// logout завершает текущую сессию
+// @Summary Выход
+// @Description Отзывает refresh-токен текущей сессии
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.SuccessResponse "Сессия завершена"
+// @Failure 400 {object} models.ErrorResponse "Токен не привязан к сессии"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/logout [post]
+func (a *API) logout(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	sessionID, err := a.getSessionIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := a.authService.Logout(ctx, userID, sessionID); err != nil && !errors.Is(err, errs.ErrSessionNotFound) {
+		a.log.Errorf("auth: logout: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
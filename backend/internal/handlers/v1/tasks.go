@@ -2,7 +2,10 @@ package v1
 
 import (
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"backend/internal/handlers/v1/models"
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +16,12 @@ func (a *API) registerTasksHandlers(router *gin.RouterGroup) {
 	task := router.Group("/tasks")
 	task.GET("", a.listTasks)
 	task.GET("/:uuid", a.getTask)
-	task.DELETE("/:uuid", a.deleteTask)
 	task.POST("/:uuid/restart", a.restartTask)
 }
 
-// getTask возвращает задачу по ID
+// getTask возвращает задачу текущего пользователя по ID
 // @Summary Получение задачи по ID
-// @Description Возвращает одну задачу по её UUID
+// @Description Возвращает одну задачу текущего пользователя по её UUID. Коды подтверждения и токены устройств скрыты
 // @Tags Tasks
 // @Security BearerAuth
 // @Produce json
@@ -30,13 +32,18 @@ func (a *API) registerTasksHandlers(router *gin.RouterGroup) {
 // @Failure 404 {object} models.ErrorResponse "Задача не найдена"
 // @Router /tasks/{uuid} [get]
 func (a *API) getTask(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
 	taskID, err := uuid.Parse(ctx.Param("uuid"))
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
 		return
 	}
 
-	task, err := a.CRUDService.GetTask(ctx, taskID)
+	task, err := a.CRUDService.GetTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{taskID}, UserID: &userID})
 	if err != nil {
 		a.log.Errorf("get task error: %s", err.Error())
 		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "task not found"})
@@ -46,9 +53,9 @@ func (a *API) getTask(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, models.NewTaskResponse(task))
 }
 
-// listTasks возвращает список задач в очереди
-// @Summary Список задач
-// @Description Возвращает список задач в очереди executor'а
+// listTasks возвращает задачи текущего пользователя
+// @Summary Список задач пользователя
+// @Description Возвращает задачи текущего пользователя в очереди executor'а. Коды подтверждения и токены устройств скрыты
 // @Tags Tasks
 // @Security BearerAuth
 // @Produce json
@@ -57,7 +64,12 @@ func (a *API) getTask(ctx *gin.Context) {
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /tasks [get]
 func (a *API) listTasks(ctx *gin.Context) {
-	tasks, err := a.CRUDService.ListTasks(ctx, dto.TasksFilter{})
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	tasks, err := a.CRUDService.ListTasks(ctx, dto.TasksFilter{UserID: &userID})
 	if err != nil {
 		a.log.Errorf("list tasks error: %s", err.Error())
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to list tasks"})
@@ -69,7 +81,8 @@ func (a *API) listTasks(ctx *gin.Context) {
 
 // restartTask перезапускает упавшую задачу
 // @Summary Перезапуск задачи
-// @Description Сбрасывает счётчик попыток и переводит задачу обратно в running
+// @Description Сбрасывает счётчик попыток упавшей (failed) задачи текущего пользователя и переводит её обратно в running.
+// @Description Перезапустить можно только push, напоминания и выгрузку данных: коды подтверждения, письма, SMS и удаление аккаунта перезапускает администратор
 // @Tags Tasks
 // @Security BearerAuth
 // @Produce json
@@ -77,44 +90,16 @@ func (a *API) listTasks(ctx *gin.Context) {
 // @Success 200 {object} models.SuccessResponse "Задача перезапущена"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
 // @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 404 {object} models.ErrorResponse "Задача не найдена"
+// @Failure 409 {object} models.ErrorResponse "Задача не в dead-letter или её тип нельзя перезапустить"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /tasks/{uuid}/restart [post]
 func (a *API) restartTask(ctx *gin.Context) {
-	id := ctx.Param("uuid")
-	if id == "" {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing task id in path"})
-		return
-	}
-
-	taskID, err := uuid.Parse(id)
+	userID, err := a.getUserIDFromContext(ctx)
 	if err != nil {
-		a.log.Errorf("restart task error: invalid uuid: %s", err.Error())
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
-		return
-	}
-
-	if err = a.CRUDService.RestartTask(ctx, taskID); err != nil {
-		a.log.Errorf("restart task error: %s", err.Error())
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to restart task"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Task restarted"})
-}
-
-// deleteTask удаляет задачу из очереди
-// @Summary Удаление задачи
-// @Description Удаляет задачу из очереди executor'а
-// @Tags Tasks
-// @Security BearerAuth
-// @Produce json
-// @Param uuid path string true "ID задачи"
-// @Success 200 {object} models.SuccessResponse "Задача удалена"
-// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
-// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
-// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
-// @Router /tasks/{uuid} [delete]
-func (a *API) deleteTask(ctx *gin.Context) {
 	id := ctx.Param("uuid")
 	if id == "" {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing task id in path"})
@@ -123,16 +108,25 @@ func (a *API) deleteTask(ctx *gin.Context) {
 
 	taskID, err := uuid.Parse(id)
 	if err != nil {
-		a.log.Errorf("delete task error: invalid uuid: %s", err.Error())
+		a.log.Errorf("restart task error: invalid uuid: %s", err.Error())
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id format", "details": err.Error()})
 		return
 	}
 
-	if err = a.CRUDService.DeleteTask(ctx, taskID); err != nil {
-		a.log.Errorf("delete task error: %s", err.Error())
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
+	if err = a.CRUDService.RestartUserTask(ctx, userID, taskID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case errors.Is(err, errs.ErrTaskNotRestartable):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "only failed push, reminder and export tasks can be restarted"})
+		case errors.Is(err, errs.ErrTaskLocked):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "task is being processed, try again later"})
+		default:
+			a.log.Errorf("restart task error: %s", err.Error())
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to restart task"})
+		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task restarted"})
 }
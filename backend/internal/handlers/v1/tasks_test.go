@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/service/crud"
+	"backend/pkg/logging"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTasksRepo — очередь задач в памяти: задача хранится вместе с владельцем, чтобы Get
+// фильтровал по UserID, как настоящий репозиторий.
+type fakeTasksRepo struct {
+	tasks   map[uuid.UUID]*entities.Task
+	owners  map[uuid.UUID]uuid.UUID
+	updated []uuid.UUID
+	deleted []uuid.UUID
+}
+
+func newFakeTasksRepo() *fakeTasksRepo {
+	return &fakeTasksRepo{tasks: map[uuid.UUID]*entities.Task{}, owners: map[uuid.UUID]uuid.UUID{}}
+}
+
+func (r *fakeTasksRepo) add(userID uuid.UUID, typ entities.TaskType, state entities.TaskState) uuid.UUID {
+	id := uuid.New()
+	r.tasks[id] = entities.NewTask(entities.WithTaskRestoreSpec(entities.TaskRestoreSpecification{
+		UUID:        id,
+		TypeNm:      typ,
+		State:       state,
+		MaxAttempts: 3,
+		Attempts:    3,
+		RetryAt:     time.Now(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}))
+	r.owners[id] = userID
+	return id
+}
+
+func (r *fakeTasksRepo) List(context.Context, dto.TasksFilter, bool) ([]*entities.Task, error) {
+	return nil, nil
+}
+
+func (r *fakeTasksRepo) Get(_ context.Context, f dto.TasksFilter, _ bool) (*entities.Task, error) {
+	for _, id := range f.IDs {
+		t, ok := r.tasks[id]
+		if ok && (f.UserID == nil || r.owners[id] == *f.UserID) {
+			return t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeTasksRepo) Create(context.Context, *entities.Task) error {
+	return nil
+}
+
+func (r *fakeTasksRepo) Update(_ context.Context, t *entities.Task) error {
+	r.updated = append(r.updated, t.UUID())
+	return nil
+}
+
+func (r *fakeTasksRepo) Delete(_ context.Context, ids []uuid.UUID) error {
+	r.deleted = append(r.deleted, ids...)
+	return nil
+}
+
+func (r *fakeTasksRepo) RestartByFilter(context.Context, dto.TasksFilter) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeTasksRepo) DeleteByFilter(context.Context, dto.TasksFilter) (int, error) {
+	return 0, nil
+}
+
+type passThroughTxManager struct{}
+
+func (passThroughTxManager) Do(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+// newTasksRouter поднимает пользовательские ручки задач поверх настоящего crud.Service.
+// Авторизация заменена записью userID в контекст.
+func newTasksRouter(repo *fakeTasksRepo, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	log := logging.GetLoggerFromContext(context.Background())
+	api := NewHandlers(Config{
+		CRUDService: crud.NewService(&crud.Config{
+			TransactionManager: passThroughTxManager{},
+			TasksRepository:    repo,
+			Log:                log,
+		}),
+		Log: log,
+	})
+
+	r := gin.New()
+	api.registerTasksHandlers(r.Group("", func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	}))
+	return r
+}
+
+func doTaskRequest(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestRestartTask_FailedPush_Restarted(t *testing.T) {
+	userID := uuid.New()
+	repo := newFakeTasksRepo()
+	id := repo.add(userID, entities.TaskTypeSendPushNotification, entities.TaskStateFailed)
+
+	w := doTaskRequest(newTasksRouter(repo, userID), http.MethodPost, "/tasks/"+id.String()+"/restart")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []uuid.UUID{id}, repo.updated)
+	assert.False(t, repo.tasks[id].IsFailed())
+}
+
+func TestRestartTask_RestrictedTypes_Conflict(t *testing.T) {
+	for _, typ := range []entities.TaskType{
+		entities.TaskTypeSendNotificationEmail, // уведомление безопасности
+		entities.TaskTypeSendCodeOnEmail,
+		entities.TaskTypeSendCodeOnPhone,
+		entities.TaskTypeSendNotificationPhone,
+		entities.TaskTypeDeleteAccount,
+	} {
+		t.Run(typ.String(), func(t *testing.T) {
+			userID := uuid.New()
+			repo := newFakeTasksRepo()
+			id := repo.add(userID, typ, entities.TaskStateFailed)
+
+			w := doTaskRequest(newTasksRouter(repo, userID), http.MethodPost, "/tasks/"+id.String()+"/restart")
+
+			assert.Equal(t, http.StatusConflict, w.Code)
+			assert.Empty(t, repo.updated)
+			assert.True(t, repo.tasks[id].IsFailed())
+		})
+	}
+}
+
+func TestRestartTask_RunningTask_Conflict(t *testing.T) {
+	userID := uuid.New()
+	repo := newFakeTasksRepo()
+	id := repo.add(userID, entities.TaskTypeSendReminder, entities.TaskStateRunning)
+
+	w := doTaskRequest(newTasksRouter(repo, userID), http.MethodPost, "/tasks/"+id.String()+"/restart")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Empty(t, repo.updated)
+}
+
+func TestRestartTask_ForeignTask_NotFound(t *testing.T) {
+	repo := newFakeTasksRepo()
+	id := repo.add(uuid.New(), entities.TaskTypeSendPushNotification, entities.TaskStateFailed)
+
+	w := doTaskRequest(newTasksRouter(repo, uuid.New()), http.MethodPost, "/tasks/"+id.String()+"/restart")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, repo.updated)
+}
+
+func TestDeleteTask_NotAvailableToUser(t *testing.T) {
+	for _, typ := range []entities.TaskType{
+		entities.TaskTypeSendNotificationEmail,
+		entities.TaskTypeDeleteAccount,
+	} {
+		t.Run(typ.String(), func(t *testing.T) {
+			userID := uuid.New()
+			repo := newFakeTasksRepo()
+			id := repo.add(userID, typ, entities.TaskStateRunning)
+
+			w := doTaskRequest(newTasksRouter(repo, userID), http.MethodDelete, "/tasks/"+id.String())
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Empty(t, repo.deleted)
+		})
+	}
+}
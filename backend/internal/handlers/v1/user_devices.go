@@ -2,7 +2,9 @@ package v1
 
 import (
 	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
 	"backend/internal/handlers/v1/models"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,13 +14,14 @@ import (
 func (a *API) registerUserDevicesHandlers(router *gin.RouterGroup) {
 	devices := router.Group("/user/devices")
 	devices.POST("", a.registerDevice)
+	devices.POST("/live-activities", a.registerLiveActivity)
 	devices.GET("", a.listDevices)
 	devices.DELETE("/:uuid", a.deleteDevice)
 }
 
 // registerDevice регистрирует device token пользователя для пуш-уведомлений
 // @Summary Регистрация device token
-// @Description Регистрирует или обновляет device token устройства для получения APNs push-уведомлений
+// @Description Регистрирует или обновляет device token устройства для получения push-уведомлений: APNs для ios, FCM для android
 // @Tags Devices
 // @Security BearerAuth
 // @Accept json
@@ -61,6 +64,56 @@ func (a *API) registerDevice(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Device registered successfully"})
 }
 
+// registerLiveActivity регистрирует push-токен Live Activity тренировки
+// @Summary Регистрация токена Live Activity
+// @Description Сохраняет push-токен Live Activity тренировки: при смене статуса тренировки или текущего упражнения на него приходят обновления через APNs
+// @Tags Devices
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.RegisterLiveActivityRequest true "ID тренировки и push-токен активности"
+// @Success 200 {object} models.SuccessResponse "Токен зарегистрирован"
+// @Failure 400 {object} models.ErrorResponse "Ошибка валидации"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 404 {object} models.ErrorResponse "Тренировка не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/devices/live-activities [post]
+func (a *API) registerLiveActivity(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	var req models.RegisterLiveActivityRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		a.log.Errorf("register live activity: invalid request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := a.validator.Struct(req); err != nil {
+		a.handleValidationErrors(ctx, err, "register live activity")
+		return
+	}
+
+	err = a.CRUDService.RegisterLiveActivity(ctx, entities.LiveActivityInitSpec{
+		UserID:    userID,
+		WorkoutID: uuid.MustParse(req.WorkoutID),
+		PushToken: req.PushToken,
+	})
+	switch {
+	case errors.Is(err, errs.ErrWorkoutNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "workout not found"})
+		return
+	case err != nil:
+		a.log.Errorf("register live activity: internal error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to register live activity"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Live activity registered successfully"})
+}
+
 // listDevices возвращает список зарегистрированных устройств пользователя
 // @Summary Список устройств пользователя
 // @Description Возвращает все зарегистрированные устройства для push-уведомлений
@@ -2,7 +2,9 @@ package v1
 
 import (
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"backend/internal/handlers/v1/models"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -11,64 +13,112 @@ import (
 func (a *API) registerUserInfoHandlers(router *gin.RouterGroup) {
 	user := router.Group("/user")
 	user.DELETE("/info", a.deleteUserInfo)
+	user.POST("/info/restore", a.restoreUserInfo)
+	user.POST("/export", a.exportUserData)
 	user.PATCH("/info", a.updateUserInfo)
 	user.GET("/info", a.getUserInfo)
 }
 
-// deleteUserInfo удаляет информацию о пользователе
-// @Summary Удаление информации пользователя
-// @Description Удаляет основную информацию о пользователе
+// deleteUserInfo планирует удаление аккаунта
+// @Summary Удаление аккаунта
+// @Description Планирует безвозвратное удаление аккаунта со всеми данными: тренировками, питанием, фото и аватаром.
+// @Description До наступления deletion_scheduled_at (по умолчанию через 7 дней) удаление отменяется через /user/info/restore.
+// @Description Повторный вызов возвращает уже назначенную дату
 // @Tags User Info
 // @Security BearerAuth
 // @Produce json
-// @Success 200 {object} models.SuccessResponse "Успешное удаление"
-// @Failure 400 {object} models.ErrorResponse "Неверный формат ID"
+// @Success 202 {object} models.AccountDeletionResponse "Удаление запланировано"
 // @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /user/info [delete]
 func (a *API) deleteUserInfo(ctx *gin.Context) {
-	userIDRaw, ok := ctx.Get("user_id")
-	if !ok {
-		a.log.Errorf("user info error: delete user info: missing user_id in context")
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error": "missing user_id in context",
-		})
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
 		return
 	}
 
-	userIDStr, ok := userIDRaw.(string)
-	if !ok {
-		a.log.Errorf("user info error: delete user info: invalid user_id type in context")
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid user_id type in context",
-		})
+	at, err := a.accountService.ScheduleDeletion(ctx, userID)
+	if err != nil {
+		a.log.Errorf("user info error: delete user info: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"user info error": "failed to schedule account deletion"})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	a.log.Infof("user info: delete user info: scheduled")
+	ctx.JSON(http.StatusAccepted, models.AccountDeletionResponse{DeletionScheduledAt: at})
+}
+
+// restoreUserInfo отменяет удаление аккаунта
+// @Summary Отмена удаления аккаунта
+// @Description Отменяет удаление, запланированное через DELETE /user/info, пока его срок не наступил
+// @Tags User Info
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.SuccessResponse "Удаление отменено"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "Удаление не запланировано"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/info/restore [post]
+func (a *API) restoreUserInfo(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
 	if err != nil {
-		a.log.Errorf("user info error: delete user info: invalid user_id format: %s", err.Error())
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid user_id format",
-			"details": err.Error(),
-		})
 		return
 	}
 
-	err = a.CRUDService.DeleteInfoUser(ctx, dto.UserInfoFilter{ID: &userID})
+	err = a.accountService.CancelDeletion(ctx, userID)
+	if errors.Is(err, errs.ErrDeletionNotScheduled) {
+		a.log.Errorf("user info error: restore user info: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"user info error": err.Error()})
+		return
+	}
 	if err != nil {
-		a.log.Errorf("user info error: internal error: %s", err.Error())
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"crud error: internal error": err.Error()})
+		a.log.Errorf("user info error: restore user info: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"user info error": "failed to cancel account deletion"})
 		return
 	}
 
-	a.log.Infof("user info: delete user info: success")
-	ctx.JSON(http.StatusOK, gin.H{"message": "Successfully deleted"})
+	a.log.Infof("user info: restore user info: success")
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
+
+// exportUserData запрашивает выгрузку данных
+// @Summary Выгрузка данных пользователя
+// @Description Ставит в очередь сборку ZIP-архива со всеми данными пользователя (JSON и CSV по каждому разделу, фото еды и аватар).
+// @Description Ссылка на скачивание придёт на подтверждённый email и действует 72 часа. Пока предыдущий запрос не обработан, новый не создаётся
+// @Tags User Info
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} models.SuccessResponse "Выгрузка поставлена в очередь"
+// @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "Email не подтверждён"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/export [post]
+func (a *API) exportUserData(ctx *gin.Context) {
+	userID, err := a.getUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	err = a.accountService.RequestExport(ctx, userID)
+	if errors.Is(err, errs.ErrExportEmailRequired) {
+		a.log.Errorf("user info error: export user data: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"user info error": err.Error()})
+		return
+	}
+	if err != nil {
+		a.log.Errorf("user info error: export user data: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"user info error": "failed to request data export"})
+		return
+	}
+
+	a.log.Infof("user info: export user data: queued")
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Data export requested, the download link will be sent by email"})
 }
 
 // updateUserInfo обновляет информацию о пользователе
 // @Summary Обновление информации пользователя
-// @Description Обновляет основную информацию о пользователе
+// @Description Обновляет основную информацию о пользователе. Email и телефон здесь не меняются:
+// @Description для этого /auth/change-email и /auth/change-phone с подтверждением нового адреса
 // @Tags User Info
 // @Security BearerAuth
 // @Accept json
@@ -77,6 +127,7 @@ func (a *API) deleteUserInfo(ctx *gin.Context) {
 // @Success 200 {object} models.SuccessResponse "Успешное обновление"
 // @Failure 400 {object} models.ErrorResponse "Ошибка валидации или неверный формат ID"
 // @Failure 401 {object} models.ErrorResponse "Отсутствует авторизация"
+// @Failure 409 {object} models.ErrorResponse "Попытка сменить email или телефон без подтверждения"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /user/info [patch]
 func (a *API) updateUserInfo(ctx *gin.Context) {
@@ -121,6 +172,11 @@ func (a *API) updateUserInfo(ctx *gin.Context) {
 	}
 
 	err = a.CRUDService.UpdateInfoUser(ctx, dto.UserInfoFilter{ID: &userID}, m.ToParam())
+	if errors.Is(err, errs.ErrContactChangeRequired) {
+		a.log.Errorf("user info error: update user info: %s", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"user info error": err.Error()})
+		return
+	}
 	if err != nil {
 		a.log.Errorf("user info error: internal error: %s", err.Error())
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"user info error: internal error": err.Error()})
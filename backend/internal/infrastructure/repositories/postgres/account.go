@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"backend/internal/dto"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Запросы выгрузки отдают строки целиком через to_jsonb, секреты вырезаются на стороне базы:
+// новые колонки попадают в выгрузку без правок кода, а хэши паролей и токенов — никогда.
+var accountExportQueries = map[dto.AccountExportSection]string{
+	dto.AccountExportProfile: `SELECT to_jsonb(t) - ARRAY['password', 'totp_secret', 'totp_last_step']
+		FROM bodyfuel.user_info t WHERE t.id = $1`,
+	dto.AccountExportParams: `SELECT to_jsonb(t) FROM bodyfuel.user_params t WHERE t.id_user = $1`,
+	dto.AccountExportWeight: `SELECT to_jsonb(t) FROM bodyfuel.user_weight t WHERE t.id_user = $1 ORDER BY t.date`,
+	dto.AccountExportCalories: `SELECT to_jsonb(t) FROM bodyfuel.user_calories t WHERE t.user_id = $1
+		ORDER BY t.date`,
+	dto.AccountExportFood: `SELECT to_jsonb(t) FROM bodyfuel.user_food t WHERE t.user_id = $1
+		ORDER BY t.date, t.created_at`,
+	dto.AccountExportWorkouts: `SELECT to_jsonb(t) FROM bodyfuel.workout t WHERE t.user_id = $1 ORDER BY t.created_at`,
+	dto.AccountExportWorkoutExercises: `SELECT to_jsonb(we) FROM bodyfuel.workouts_exercise we
+		JOIN bodyfuel.workout w ON w.id = we.workout_id
+		WHERE w.user_id = $1 ORDER BY w.created_at, we.created_at`,
+	dto.AccountExportRecommendations: `SELECT to_jsonb(t) FROM bodyfuel.user_recommendation t WHERE t.user_id = $1
+		ORDER BY t.generated_at`,
+	dto.AccountExportDevices: `SELECT to_jsonb(t) - 'device_token' FROM bodyfuel.user_devices t WHERE t.user_id = $1
+		ORDER BY t.created_at`,
+	dto.AccountExportSessions: `SELECT to_jsonb(t) - 'token_hash' FROM bodyfuel.user_refresh_tokens t WHERE t.user_id = $1
+		ORDER BY t.created_at`,
+}
+
+// queryAuditAnonymizeOn разрешает до конца транзакции обезличивать audit_event (см. 00021).
+const queryAuditAnonymizeOn = `SELECT set_config('bodyfuel.audit_anonymize', 'on', true)`
+
+// Задачи и тренировки удаляются явно: у задач нет внешнего ключа на пользователя, а тренировки
+// получили его только в 00011. Остальные таблицы чистит ON DELETE CASCADE от user_info.
+// Журнал безопасности не удаляется, а обезличивается: в событиях пользователя стираются IP,
+// User-Agent, изменения (там старые и новые email и телефон) и подробности; в событиях, где он
+// действовал над другим пользователем, — только его IP и User-Agent.
+var accountPurgeQueries = []string{
+	`UPDATE bodyfuel.audit_event SET ip = '', user_agent = '', changes = '{}', details = '{}'
+		WHERE user_id = $1`,
+	`UPDATE bodyfuel.audit_event SET ip = '', user_agent = ''
+		WHERE actor_id = $1 AND user_id IS DISTINCT FROM $1`,
+	`DELETE FROM bodyfuel.tasks WHERE attribute ->> 'user_id' = $1::text`,
+	`DELETE FROM bodyfuel.workouts_exercise WHERE workout_id IN (SELECT id FROM bodyfuel.workout WHERE user_id = $1)`,
+	`DELETE FROM bodyfuel.workout WHERE user_id = $1`,
+	`DELETE FROM bodyfuel.user_info WHERE id = $1`,
+}
+
+type AccountRepo struct {
+	getter dbClientGetter
+}
+
+func NewAccountRepository(db *sqlx.DB) *AccountRepo {
+	return &AccountRepo{getter: dbClientGetter{db: db}}
+}
+
+// ExportSection возвращает строки раздела выгрузки в виде JSON-объектов.
+func (r *AccountRepo) ExportSection(ctx context.Context, section dto.AccountExportSection, userID uuid.UUID) ([]json.RawMessage, error) {
+	query, ok := accountExportQueries[section]
+	if !ok {
+		return nil, fmt.Errorf("unknown export section %q", section)
+	}
+
+	var rows [][]byte
+	if err := r.getter.Get(ctx).SelectContext(ctx, &rows, query, userID); err != nil {
+		return nil, fmt.Errorf("export %s: %w", section, err)
+	}
+
+	result := make([]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// Purge безвозвратно удаляет все строки пользователя и обезличивает его события в журнале
+// безопасности. Вызывать в транзакции: вне её триггер audit_event отклонит обезличивание.
+func (r *AccountRepo) Purge(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.getter.Get(ctx).ExecContext(ctx, queryAuditAnonymizeOn); err != nil {
+		return fmt.Errorf("purge account: %w", err)
+	}
+
+	for _, query := range accountPurgeQueries {
+		if _, err := r.getter.Get(ctx).ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("purge account: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const queryInsertTestAuditEvent = `INSERT INTO bodyfuel.audit_event
+	(id, user_id, actor_id, event_type, ip, user_agent, changes, details)
+	VALUES ($1, $2, $3, $4, '10.0.0.1', 'curl/8.0', $5, '{"session_id": "s-1"}')`
+
+type testAuditRow struct {
+	IP        string `db:"ip"`
+	UserAgent string `db:"user_agent"`
+	Changes   string `db:"changes"`
+	Details   string `db:"details"`
+}
+
+func TestAccountRepo_Purge_AnonymizesAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewAccountRepository(db)
+	txm := NewTransactionManager(db)
+
+	userID, otherID := uuid.New(), uuid.New()
+	ownEvent, actorEvent := uuid.New(), uuid.New()
+	_, err := db.ExecContext(ctx, queryInsertTestAuditEvent, ownEvent, userID, userID, "auth.email_changed",
+		`{"email": {"old": "old@example.com", "new": "new@example.com"}}`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, queryInsertTestAuditEvent, actorEvent, otherID, userID, "admin.role_changed",
+		`{"role": {"old": "user", "new": "coach"}}`)
+	require.NoError(t, err)
+
+	// вне Purge журнал по-прежнему нельзя менять
+	_, err = db.ExecContext(ctx, `UPDATE bodyfuel.audit_event SET ip = '' WHERE id = $1`, ownEvent)
+	assert.ErrorContains(t, err, "append-only")
+
+	require.NoError(t, txm.Do(ctx, func(ctx context.Context) error {
+		return repo.Purge(ctx, userID)
+	}))
+
+	var own testAuditRow
+	require.NoError(t, db.GetContext(ctx, &own,
+		`SELECT ip, user_agent, changes::text AS changes, details::text AS details FROM bodyfuel.audit_event WHERE id = $1`, ownEvent))
+	assert.Equal(t, testAuditRow{Changes: "{}", Details: "{}"}, own)
+
+	// в событии над другим пользователем стираются только IP и User-Agent удалённого администратора
+	var actor testAuditRow
+	require.NoError(t, db.GetContext(ctx, &actor,
+		`SELECT ip, user_agent, changes::text AS changes, details::text AS details FROM bodyfuel.audit_event WHERE id = $1`, actorEvent))
+	assert.Empty(t, actor.IP)
+	assert.Empty(t, actor.UserAgent)
+	assert.JSONEq(t, `{"role": {"old": "user", "new": "coach"}}`, actor.Changes)
+
+	// удаление событий запрещено и при обезличивании
+	err = txm.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.getter.Get(ctx).ExecContext(ctx, queryAuditAnonymizeOn); err != nil {
+			return err
+		}
+		_, err := repo.getter.Get(ctx).ExecContext(ctx, `DELETE FROM bodyfuel.audit_event WHERE id = $1`, ownEvent)
+		return err
+	})
+	assert.ErrorContains(t, err, "append-only")
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+const queryCreateAuditEvent = `INSERT INTO bodyfuel.audit_event
+	(id, user_id, actor_id, event_type, ip, user_agent, changes, details, created_at)
+	VALUES (:id, :user_id, :actor_id, :event_type, :ip, :user_agent, :changes, :details, :created_at)`
+
+var auditEventColumns = []string{
+	"id", "user_id", "actor_id", "event_type", "ip", "user_agent", "changes", "details", "created_at",
+}
+
+// AuditEventsRepo пишет и читает журнал безопасности. Методов изменения и удаления нет намеренно:
+// таблица append-only, это же проверяет триггер в БД.
+type AuditEventsRepo struct {
+	getter dbClientGetter
+}
+
+func NewAuditEventsRepository(db *sqlx.DB) *AuditEventsRepo {
+	return &AuditEventsRepo{getter: dbClientGetter{db: db}}
+}
+
+func (r *AuditEventsRepo) Create(ctx context.Context, e *entities.AuditEvent) error {
+	row, err := models.NewAuditEventRow(e)
+	if err != nil {
+		return fmt.Errorf("create audit event: %w", err)
+	}
+
+	if _, err := r.getter.Get(ctx).NamedExecContext(ctx, queryCreateAuditEvent, row); err != nil {
+		return fmt.Errorf("create audit event: %w", err)
+	}
+	return nil
+}
+
+// List возвращает события по фильтру, новые — первыми.
+func (r *AuditEventsRepo) List(ctx context.Context, f dto.AuditEventFilter) ([]*entities.AuditEvent, error) {
+	q := psq.Select(auditEventColumns...).From("bodyfuel.audit_event").OrderBy("created_at DESC", "id")
+
+	if f.UserID != nil {
+		q = q.Where(sq.Eq{"user_id": *f.UserID})
+	}
+	if f.ActorID != nil {
+		q = q.Where(sq.Eq{"actor_id": *f.ActorID})
+	}
+	if len(f.Types) > 0 {
+		q = q.Where(sq.Eq{"event_type": f.Types})
+	}
+	if f.IP != nil {
+		q = q.Where(sq.Eq{"ip": *f.IP})
+	}
+	if f.CreatedFrom != nil {
+		q = q.Where(sq.GtOrEq{"created_at": *f.CreatedFrom})
+	}
+	if f.CreatedTo != nil {
+		q = q.Where(sq.LtOrEq{"created_at": *f.CreatedTo})
+	}
+	if f.Limit != nil {
+		q = q.Limit(uint64(*f.Limit))
+	}
+	if f.Offset != nil {
+		q = q.Offset(uint64(*f.Offset))
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.AuditEventRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("list audit events: %w", err)
+	}
+
+	result := make([]*entities.AuditEvent, len(rows))
+	for i := range rows {
+		e, err := rows[i].ToEntity()
+		if err != nil {
+			return nil, fmt.Errorf("to entity: %w", err)
+		}
+		result[i] = e
+	}
+	return result, nil
+}
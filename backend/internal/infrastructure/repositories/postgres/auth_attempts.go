@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// истёкший счётчик начинается заново, как ключ с TTL в Redis
+	queryIncrAuthAttempt = `INSERT INTO bodyfuel.auth_attempts (key, counter, expires_at)
+		VALUES ($1, 1, NOW() + $2 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET
+			counter    = CASE WHEN auth_attempts.expires_at <= NOW() THEN 1 ELSE auth_attempts.counter + 1 END,
+			expires_at = CASE WHEN auth_attempts.expires_at <= NOW() THEN EXCLUDED.expires_at ELSE auth_attempts.expires_at END
+		RETURNING counter`
+
+	queryAuthAttemptTTL = `SELECT GREATEST(EXTRACT(EPOCH FROM expires_at - NOW()) * 1000, 0)::BIGINT
+		FROM bodyfuel.auth_attempts WHERE key = $1`
+
+	queryDeleteAuthAttempts = `DELETE FROM bodyfuel.auth_attempts WHERE key = ANY($1)`
+
+	queryDeleteExpiredAuthAttempts = `DELETE FROM bodyfuel.auth_attempts WHERE expires_at <= NOW()`
+)
+
+// AuthAttemptsRepo — Postgres-замена Redis для счётчиков попыток auth.Service, когда Redis не настроен.
+type AuthAttemptsRepo struct {
+	getter dbClientGetter
+}
+
+func NewAuthAttemptsRepository(db *sqlx.DB) *AuthAttemptsRepo {
+	return &AuthAttemptsRepo{getter: dbClientGetter{db: db}}
+}
+
+func (r *AuthAttemptsRepo) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var counter int64
+	if err := r.getter.Get(ctx).GetContext(ctx, &counter, queryIncrAuthAttempt, key, ttl.Milliseconds()); err != nil {
+		return 0, fmt.Errorf("incr auth attempt: %w", err)
+	}
+
+	// счётчик создан заново — заодно подчищаем истёкшие, чтобы таблица не росла от перебора по IP
+	if counter == 1 {
+		if _, err := r.getter.Get(ctx).ExecContext(ctx, queryDeleteExpiredAuthAttempts); err != nil {
+			return 0, fmt.Errorf("delete expired auth attempts: %w", err)
+		}
+	}
+	return counter, nil
+}
+
+func (r *AuthAttemptsRepo) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ms int64
+	if err := r.getter.Get(ctx).GetContext(ctx, &ms, queryAuthAttemptTTL, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("get auth attempt ttl: %w", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (r *AuthAttemptsRepo) Del(ctx context.Context, keys ...string) error {
+	if _, err := r.getter.Get(ctx).ExecContext(ctx, queryDeleteAuthAttempts, keys); err != nil {
+		return fmt.Errorf("delete auth attempts: %w", err)
+	}
+	return nil
+}
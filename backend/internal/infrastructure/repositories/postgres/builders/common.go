@@ -7,7 +7,8 @@ type OrderByField string
 type OrderByDirection string
 
 const (
-	AscDirection OrderByDirection = "ASC"
+	AscDirection  OrderByDirection = "ASC"
+	DescDirection OrderByDirection = "DESC"
 )
 
 type OrderBy struct {
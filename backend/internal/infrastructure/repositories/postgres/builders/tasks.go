@@ -4,6 +4,7 @@ import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
 	"fmt"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -11,11 +12,15 @@ import (
 )
 
 type TasksFilterSpecification struct {
-	IDs     []uuid.UUID
-	TypeNms []string
-	States  []entities.TaskState
-	RetryAt *time.Time
-	Attempts *int
+	IDs         []uuid.UUID
+	UserID      *uuid.UUID
+	TypeNms     []string
+	ExcludeNms  []string
+	States      []entities.TaskState
+	RetryAt     *time.Time
+	Attempts    *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
 }
 
 func NewTasksFilterSpecification(f dto.TasksFilter) *TasksFilterSpecification {
@@ -24,12 +29,21 @@ func NewTasksFilterSpecification(f dto.TasksFilter) *TasksFilterSpecification {
 		types[i] = t.String()
 	}
 
+	exclude := make([]string, len(f.ExcludeTypes))
+	for i, t := range f.ExcludeTypes {
+		exclude[i] = t.String()
+	}
+
 	return &TasksFilterSpecification{
-		IDs:      f.IDs,
-		TypeNms:  types,
-		States:   f.States,
-		RetryAt:  f.RetryAt,
-		Attempts: f.Attempts,
+		IDs:         f.IDs,
+		UserID:      f.UserID,
+		TypeNms:     types,
+		ExcludeNms:  exclude,
+		States:      f.States,
+		RetryAt:     f.RetryAt,
+		Attempts:    f.Attempts,
+		CreatedFrom: f.CreatedFrom,
+		CreatedTo:   f.CreatedTo,
 	}
 }
 
@@ -40,10 +54,18 @@ func (s *TasksFilterSpecification) Predicates() []sq.Sqlizer {
 		predicates = append(predicates, sq.Eq{"t.task_id": s.IDs})
 	}
 
+	if s.UserID != nil {
+		predicates = append(predicates, sq.Expr("t.attribute->>'user_id' = ?", s.UserID.String()))
+	}
+
 	if len(s.TypeNms) != 0 {
 		predicates = append(predicates, sq.Eq{"t.task_type_nm": s.TypeNms})
 	}
 
+	if len(s.ExcludeNms) != 0 {
+		predicates = append(predicates, sq.NotEq{"t.task_type_nm": s.ExcludeNms})
+	}
+
 	if len(s.States) != 0 {
 		predicates = append(predicates, sq.Eq{"t.task_state": s.States})
 	}
@@ -56,6 +78,14 @@ func (s *TasksFilterSpecification) Predicates() []sq.Sqlizer {
 		predicates = append(predicates, sq.LtOrEq{"t.retry_at": *s.RetryAt})
 	}
 
+	if s.CreatedFrom != nil {
+		predicates = append(predicates, sq.GtOrEq{"t.created_at": *s.CreatedFrom})
+	}
+
+	if s.CreatedTo != nil {
+		predicates = append(predicates, sq.LtOrEq{"t.created_at": *s.CreatedTo})
+	}
+
 	return predicates
 }
 
@@ -64,7 +94,7 @@ type TasksSelectBuilder struct {
 	orderBy []string
 }
 
-var tasksSelectBuilder = newQueryBuilder().Select(
+var tasksColumns = []string{
 	"t.task_id",
 	"t.task_type_nm",
 	"t.task_state",
@@ -74,7 +104,11 @@ var tasksSelectBuilder = newQueryBuilder().Select(
 	"t.created_at",
 	"t.updated_at",
 	"t.attribute",
-).From("bodyfuel.tasks t")
+	"t.failure_reason",
+	"t.dedup_key",
+}
+
+var tasksSelectBuilder = newQueryBuilder().Select(tasksColumns...).From("bodyfuel.tasks t")
 
 func NewTasksSelectBuilder() *TasksSelectBuilder {
 	return &TasksSelectBuilder{b: tasksSelectBuilder}
@@ -112,21 +146,149 @@ func (b *TasksSelectBuilder) WithBlock() *TasksSelectBuilder {
 }
 
 func (b *TasksSelectBuilder) ToSql() (string, []interface{}, error) {
+	if len(b.orderBy) != 0 {
+		return b.b.OrderBy(b.orderBy...).ToSql()
+	}
 	return b.b.ToSql()
 }
 
+// TasksClaimBuilder захватывает пачку задач в аренду одним запросом: подзапрос с FOR UPDATE SKIP LOCKED
+// выбирает свободные строки, не дожидаясь чужих блокировок, а UPDATE записывает владельца и срок аренды
+// и возвращает захваченные задачи. Срок считается по часам БД, чтобы экземпляры не зависели от своих часов.
+type TasksClaimBuilder struct {
+	sub   sq.SelectBuilder
+	owner string
+	lease time.Duration
+}
+
+func NewTasksClaimBuilder(owner string, lease time.Duration) *TasksClaimBuilder {
+	return &TasksClaimBuilder{
+		// подзапрос собирается с плейсхолдерами "?": нумерацию $N проставит внешний UPDATE
+		sub: sq.Select("t.task_id").From("bodyfuel.tasks t").
+			Where("(t.locked_until IS NULL OR t.locked_until <= NOW())").
+			OrderBy("t.retry_at"),
+		owner: owner,
+		lease: lease,
+	}
+}
+
+func (b *TasksClaimBuilder) WithFilterSpecification(s *TasksFilterSpecification) *TasksClaimBuilder {
+	b.sub = ApplyFilter(b.sub, s)
+	return b
+}
+
+func (b *TasksClaimBuilder) Limit(limit int) *TasksClaimBuilder {
+	if limit > 0 {
+		b.sub = b.sub.Limit(uint64(limit))
+	}
+	return b
+}
+
+func (b *TasksClaimBuilder) ToSql() (string, []interface{}, error) {
+	return newQueryBuilder().Update("bodyfuel.tasks t").
+		Set("locked_by", b.owner).
+		Set("locked_until", sq.Expr("NOW() + make_interval(secs => ?)", b.lease.Seconds())).
+		Where(sq.Expr("t.task_id IN (?)", b.sub.Suffix("FOR UPDATE OF t SKIP LOCKED"))).
+		Suffix("RETURNING " + strings.Join(tasksColumns, ", ")).
+		ToSql()
+}
+
+// TasksRestartBuilder возвращает в очередь все задачи по фильтру: сбрасывает попытки и причину
+// dead-letter и возвращает типы перезапущенных задач.
+type TasksRestartBuilder struct {
+	b sq.UpdateBuilder
+}
+
+func NewTasksRestartBuilder() *TasksRestartBuilder {
+	return &TasksRestartBuilder{
+		b: newQueryBuilder().Update("bodyfuel.tasks t").
+			Set("task_state", entities.TaskStateRunning).
+			Set("attempts", 0).
+			Set("failure_reason", "").
+			Set("retry_at", sq.Expr("NOW()")).
+			Set("updated_at", sq.Expr("NOW()")).
+			Suffix("RETURNING t.task_type_nm"),
+	}
+}
+
+func (b *TasksRestartBuilder) WithFilterSpecification(s *TasksFilterSpecification) *TasksRestartBuilder {
+	b.b = ApplyFilter(b.b, s)
+	return b
+}
+
+func (b *TasksRestartBuilder) ToSql() (string, []interface{}, error) {
+	return b.b.ToSql()
+}
+
+// TasksForUsersInsertBuilder создаёт одним INSERT … SELECT по задаче каждому пользователю из заданных
+// часовых поясов: в нагрузку из спецификации добавляется user_id. Возвращает число созданных задач
+// не через RETURNING, а через RowsAffected.
+type TasksForUsersInsertBuilder struct {
+	spec dto.UserTasksSpec
+}
+
+func NewTasksForUsersInsertBuilder(spec dto.UserTasksSpec) *TasksForUsersInsertBuilder {
+	return &TasksForUsersInsertBuilder{spec: spec}
+}
+
+func (b *TasksForUsersInsertBuilder) ToSql() (string, []interface{}, error) {
+	attribute := string(b.spec.Attribute)
+	if attribute == "" || attribute == "null" {
+		attribute = "{}"
+	}
+
+	// подзапрос собирается с плейсхолдерами "?": нумерацию $N проставит внешний INSERT.
+	// Типы указаны явно: параметр в списке SELECT без приведения Postgres считает текстом
+	users := sq.Select().
+		Column("gen_random_uuid()").
+		Column("?::text", b.spec.TypeNm.String()).
+		Column("?::text", entities.TaskStateRunning.String()).
+		Column("?::int", b.spec.MaxAttempts).
+		Column("0").
+		Column("NOW()").
+		Column("NOW()").
+		Column("NOW()").
+		Column("?::jsonb || jsonb_build_object('user_id', u.id)", attribute).
+		From("bodyfuel.user_info u").
+		Where(sq.Eq{"u.timezone": b.spec.Timezones}).
+		Where("u.deletion_scheduled_at IS NULL")
+
+	return newQueryBuilder().Insert("bodyfuel.tasks").
+		Columns("task_id", "task_type_nm", "task_state", "max_attempts", "attempts",
+			"retry_at", "created_at", "updated_at", "attribute").
+		Select(users).
+		ToSql()
+}
+
 type TasksDeleteBuilder struct {
 	b sq.DeleteBuilder
 }
 
 func NewTasksDeleteBuilder() *TasksDeleteBuilder {
 	return &TasksDeleteBuilder{
-		b: newDeleteQueryBuilder().Delete("bodyfuel.tasks"),
+		b: newDeleteQueryBuilder().Delete("bodyfuel.tasks t"),
 	}
 }
 
 func (b *TasksDeleteBuilder) WithID(ids []uuid.UUID) *TasksDeleteBuilder {
-	b.b = b.b.Where(sq.Eq{"task_id": ids})
+	b.b = b.b.Where(sq.Eq{"t.task_id": ids})
+	return b
+}
+
+// WithOwner удаляет задачи, только пока их держит owner.
+func (b *TasksDeleteBuilder) WithOwner(owner string) *TasksDeleteBuilder {
+	b.b = b.b.Where(sq.Eq{"t.locked_by": owner})
+	return b
+}
+
+// WithoutLease пропускает задачи, которые сейчас в аренде у исполнителя.
+func (b *TasksDeleteBuilder) WithoutLease() *TasksDeleteBuilder {
+	b.b = b.b.Where("(t.locked_until IS NULL OR t.locked_until <= NOW())")
+	return b
+}
+
+func (b *TasksDeleteBuilder) WithFilterSpecification(s *TasksFilterSpecification) *TasksDeleteBuilder {
+	b.b = ApplyFilter(b.b, s)
 	return b
 }
 
@@ -19,7 +19,12 @@ type UserInfoFilterSpecification struct {
 	Password  *string
 	Email     *string
 	Phone     *string
+	Role      *string
+	AppleSub  *string
 	CreatedAt *time.Time
+
+	EmailVerified *bool
+	PhoneVerified *bool
 }
 
 func NewUserInfoFilterSpecification(f dto.UserInfoFilter) *UserInfoFilterSpecification {
@@ -31,7 +36,12 @@ func NewUserInfoFilterSpecification(f dto.UserInfoFilter) *UserInfoFilterSpecifi
 		Password:  f.Password,
 		Email:     f.Email,
 		Phone:     f.Phone,
+		Role:      f.Role,
+		AppleSub:  f.AppleSub,
 		CreatedAt: f.CreatedAt,
+
+		EmailVerified: f.EmailVerified,
+		PhoneVerified: f.PhoneVerified,
 	}
 
 	return s
@@ -68,13 +78,36 @@ func (spec *UserInfoFilterSpecification) Predicates() []sq.Sqlizer {
 		predicates = append(predicates, sq.Eq{"user_info.phone": v})
 	}
 
+	if v := spec.Role; v != nil {
+		predicates = append(predicates, sq.Eq{"user_info.role": v})
+	}
+
+	if v := spec.AppleSub; v != nil {
+		predicates = append(predicates, sq.Eq{"user_info.apple_sub": v})
+	}
+
 	if v := spec.CreatedAt; v != nil {
 		predicates = append(predicates, sq.Eq{"user_info.created_at": v})
 	}
 
+	if v := spec.EmailVerified; v != nil {
+		predicates = append(predicates, verifiedPredicate("user_info.email_verified_at", *v))
+	}
+
+	if v := spec.PhoneVerified; v != nil {
+		predicates = append(predicates, verifiedPredicate("user_info.phone_verified_at", *v))
+	}
+
 	return predicates
 }
 
+func verifiedPredicate(column string, verified bool) sq.Sqlizer {
+	if verified {
+		return sq.NotEq{column: nil}
+	}
+	return sq.Eq{column: nil}
+}
+
 type UserInfoSelectBuilder struct {
 	b sq.SelectBuilder
 }
@@ -88,9 +121,17 @@ func NewUserInfoSelectBuilder() *UserInfoSelectBuilder {
 		"user_info.password",
 		"user_info.email",
 		"user_info.phone",
+		"user_info.role",
 		"user_info.created_at",
 		"user_info.email_verified_at",
 		"user_info.phone_verified_at",
+		"user_info.totp_secret",
+		"user_info.totp_enabled_at",
+		"user_info.totp_last_step",
+		"user_info.apple_sub",
+		"user_info.timezone",
+		"user_info.locale",
+		"user_info.deletion_scheduled_at",
 	).From(userInfoTable)
 
 	return &UserInfoSelectBuilder{b: selectBuilder}
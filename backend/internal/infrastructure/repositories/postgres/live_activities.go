@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	queryUpsertLiveActivity = `INSERT INTO bodyfuel.user_live_activities (id, user_id, workout_id, push_token, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (workout_id, push_token) DO UPDATE SET
+			updated_at = EXCLUDED.updated_at`
+)
+
+type LiveActivitiesRepo struct {
+	getter dbClientGetter
+}
+
+func NewLiveActivitiesRepository(db *sqlx.DB) *LiveActivitiesRepo {
+	return &LiveActivitiesRepo{getter: dbClientGetter{db: db}}
+}
+
+func (r *LiveActivitiesRepo) Upsert(ctx context.Context, a *entities.LiveActivity) error {
+	row := models.NewLiveActivityRow(a)
+
+	_, err := r.getter.Get(ctx).ExecContext(ctx, queryUpsertLiveActivity,
+		row.ID,
+		row.UserID,
+		row.WorkoutID,
+		row.PushToken,
+		row.CreatedAt,
+		row.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LiveActivitiesRepo) List(ctx context.Context, f dto.LiveActivityFilter) ([]*entities.LiveActivity, error) {
+	q := psq.Select(
+		"id", "user_id", "workout_id", "push_token", "created_at", "updated_at",
+	).From("bodyfuel.user_live_activities").Where(liveActivityFilter(f)).OrderBy("created_at")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.LiveActivityRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("select context: %w", err)
+	}
+
+	result := make([]*entities.LiveActivity, len(rows))
+	for i := range rows {
+		result[i] = rows[i].ToEntity()
+	}
+
+	return result, nil
+}
+
+// Delete удаляет активности по фильтру. Пустой фильтр не удаляет ничего.
+func (r *LiveActivitiesRepo) Delete(ctx context.Context, f dto.LiveActivityFilter) error {
+	where := liveActivityFilter(f)
+	if len(where) == 0 {
+		return nil
+	}
+
+	query, args, err := psq.Delete("bodyfuel.user_live_activities").Where(where).ToSql()
+	if err != nil {
+		return fmt.Errorf("build sql: %w", err)
+	}
+
+	if _, err = r.getter.Get(ctx).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByToken удаляет активность, токен которой APNs больше не принимает.
+func (r *LiveActivitiesRepo) DeleteByToken(ctx context.Context, pushToken string) error {
+	return r.Delete(ctx, dto.LiveActivityFilter{PushToken: &pushToken})
+}
+
+func liveActivityFilter(f dto.LiveActivityFilter) sq.Eq {
+	where := sq.Eq{}
+	if f.UserID != nil {
+		where["user_id"] = *f.UserID
+	}
+	if f.WorkoutID != nil {
+		where["workout_id"] = *f.WorkoutID
+	}
+	if f.PushToken != nil {
+		where["push_token"] = *f.PushToken
+	}
+	return where
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditEventRow struct {
+	ID        uuid.UUID               `db:"id"`
+	UserID    *uuid.UUID              `db:"user_id"`
+	ActorID   *uuid.UUID              `db:"actor_id"`
+	EventType entities.AuditEventType `db:"event_type"`
+	IP        string                  `db:"ip"`
+	UserAgent string                  `db:"user_agent"`
+	Changes   []byte                  `db:"changes"`
+	Details   []byte                  `db:"details"`
+	CreatedAt time.Time               `db:"created_at"`
+}
+
+func NewAuditEventRow(e *entities.AuditEvent) (*AuditEventRow, error) {
+	changes := e.Changes()
+	if changes == nil {
+		changes = map[string]entities.AuditChange{}
+	}
+	rawChanges, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal changes: %w", err)
+	}
+
+	details := e.Details()
+	if details == nil {
+		details = map[string]string{}
+	}
+	rawDetails, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("marshal details: %w", err)
+	}
+
+	return &AuditEventRow{
+		ID:        e.ID(),
+		UserID:    e.UserID(),
+		ActorID:   e.ActorID(),
+		EventType: e.Type(),
+		IP:        e.IP(),
+		UserAgent: e.UserAgent(),
+		Changes:   rawChanges,
+		Details:   rawDetails,
+		CreatedAt: e.CreatedAt(),
+	}, nil
+}
+
+func (r *AuditEventRow) ToEntity() (*entities.AuditEvent, error) {
+	var changes map[string]entities.AuditChange
+	if len(r.Changes) > 0 {
+		if err := json.Unmarshal(r.Changes, &changes); err != nil {
+			return nil, fmt.Errorf("unmarshal changes: %w", err)
+		}
+	}
+
+	var details map[string]string
+	if len(r.Details) > 0 {
+		if err := json.Unmarshal(r.Details, &details); err != nil {
+			return nil, fmt.Errorf("unmarshal details: %w", err)
+		}
+	}
+
+	return entities.NewAuditEvent(entities.WithAuditEventRestoreSpec(entities.AuditEventRestoreSpec{
+		ID:        r.ID,
+		UserID:    r.UserID,
+		ActorID:   r.ActorID,
+		Type:      r.EventType,
+		IP:        r.IP,
+		UserAgent: r.UserAgent,
+		Changes:   changes,
+		Details:   details,
+		CreatedAt: r.CreatedAt,
+	})), nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LiveActivityRow struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	WorkoutID uuid.UUID `db:"workout_id"`
+	PushToken string    `db:"push_token"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func NewLiveActivityRow(a *entities.LiveActivity) *LiveActivityRow {
+	return &LiveActivityRow{
+		ID:        a.ID(),
+		UserID:    a.UserID(),
+		WorkoutID: a.WorkoutID(),
+		PushToken: a.PushToken(),
+		CreatedAt: a.CreatedAt(),
+		UpdatedAt: a.UpdatedAt(),
+	}
+}
+
+func (r *LiveActivityRow) ToEntity() *entities.LiveActivity {
+	return entities.RestoreLiveActivity(entities.LiveActivityRestoreSpec{
+		ID:        r.ID,
+		UserID:    r.UserID,
+		WorkoutID: r.WorkoutID,
+		PushToken: r.PushToken,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	})
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationPreferencesRow — настройки вместе с часовым поясом пользователя. Поля настроек
+// пустые, если пользователь их не менял.
+type NotificationPreferencesRow struct {
+	UserID         uuid.UUID  `db:"user_id"`
+	Timezone       string     `db:"timezone"`
+	Channels       []byte     `db:"channels"`
+	QuietHoursFrom *int       `db:"quiet_hours_from"`
+	QuietHoursTo   *int       `db:"quiet_hours_to"`
+	UpdatedAt      *time.Time `db:"updated_at"`
+}
+
+func NewNotificationPreferencesRow(p *entities.NotificationPreferences) (*NotificationPreferencesRow, error) {
+	channels, err := json.Marshal(p.Channels())
+	if err != nil {
+		return nil, fmt.Errorf("marshal channels: %w", err)
+	}
+
+	updatedAt := p.UpdatedAt()
+	row := &NotificationPreferencesRow{
+		UserID:    p.UserID(),
+		Timezone:  p.Timezone(),
+		Channels:  channels,
+		UpdatedAt: &updatedAt,
+	}
+	if q := p.QuietHours(); q != nil {
+		row.QuietHoursFrom, row.QuietHoursTo = &q.From, &q.To
+	}
+
+	return row, nil
+}
+
+func (r *NotificationPreferencesRow) ToEntity() (*entities.NotificationPreferences, error) {
+	spec := entities.NotificationPreferencesRestoreSpec{
+		UserID:   r.UserID,
+		Timezone: r.Timezone,
+	}
+	if len(r.Channels) != 0 {
+		if err := json.Unmarshal(r.Channels, &spec.Channels); err != nil {
+			return nil, fmt.Errorf("unmarshal channels: %w", err)
+		}
+	}
+	if r.QuietHoursFrom != nil && r.QuietHoursTo != nil {
+		spec.QuietHours = &entities.QuietHours{From: *r.QuietHoursFrom, To: *r.QuietHoursTo}
+	}
+	if r.UpdatedAt != nil {
+		spec.UpdatedAt = *r.UpdatedAt
+	}
+
+	return entities.NewNotificationPreferences(spec), nil
+}
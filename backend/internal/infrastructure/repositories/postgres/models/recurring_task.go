@@ -0,0 +1,65 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RecurringTaskRow struct {
+	ID           uuid.UUID                   `db:"id"`
+	Name         string                      `db:"name"`
+	Cron         string                      `db:"cron"`
+	TypeNm       entities.TaskType           `db:"task_type_nm"`
+	Attribute    []byte                      `db:"attribute"`
+	Scope        entities.RecurringTaskScope `db:"scope"`
+	Timezone     string                      `db:"timezone"`
+	MaxAttempts  int                         `db:"max_attempts"`
+	Enabled      bool                        `db:"enabled"`
+	CheckedUntil time.Time                   `db:"checked_until"`
+	LastRunAt    *time.Time                  `db:"last_run_at"`
+	CreatedAt    time.Time                   `db:"created_at"`
+	UpdatedAt    time.Time                   `db:"updated_at"`
+}
+
+func NewRecurringTaskRow(r *entities.RecurringTask) *RecurringTaskRow {
+	attribute := []byte(r.Attribute())
+	if len(attribute) == 0 {
+		attribute = []byte("{}")
+	}
+
+	return &RecurringTaskRow{
+		ID:           r.ID(),
+		Name:         r.Name(),
+		Cron:         r.Cron(),
+		TypeNm:       r.TypeNm(),
+		Attribute:    attribute,
+		Scope:        r.Scope(),
+		Timezone:     r.Timezone(),
+		MaxAttempts:  r.MaxAttempts(),
+		Enabled:      r.IsEnabled(),
+		CheckedUntil: r.CheckedUntil(),
+		LastRunAt:    r.LastRunAt(),
+		CreatedAt:    r.CreatedAt(),
+		UpdatedAt:    r.UpdatedAt(),
+	}
+}
+
+func (r *RecurringTaskRow) ToEntity() *entities.RecurringTask {
+	return entities.NewRecurringTask(entities.WithRecurringTaskRestoreSpec(entities.RecurringTaskRestoreSpec{
+		ID:           r.ID,
+		Name:         r.Name,
+		Cron:         r.Cron,
+		TypeNm:       r.TypeNm,
+		Attribute:    r.Attribute,
+		Scope:        r.Scope,
+		Timezone:     r.Timezone,
+		MaxAttempts:  r.MaxAttempts,
+		Enabled:      r.Enabled,
+		CheckedUntil: r.CheckedUntil,
+		LastRunAt:    r.LastRunAt,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}))
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TaskAttemptRow struct {
+	ID         uuid.UUID                   `db:"id"`
+	TaskID     uuid.UUID                   `db:"task_id"`
+	TaskTypeNm entities.TaskType           `db:"task_type_nm"`
+	Attempt    int                         `db:"attempt"`
+	Owner      string                      `db:"owner"`
+	Outcome    entities.TaskAttemptOutcome `db:"outcome"`
+	Error      string                      `db:"error"`
+	StartedAt  time.Time                   `db:"started_at"`
+	DurationMs int64                       `db:"duration_ms"`
+}
+
+func NewTaskAttemptRow(a *entities.TaskAttempt) *TaskAttemptRow {
+	return &TaskAttemptRow{
+		ID:         a.ID(),
+		TaskID:     a.TaskID(),
+		TaskTypeNm: a.TaskType(),
+		Attempt:    a.Attempt(),
+		Owner:      a.Owner(),
+		Outcome:    a.Outcome(),
+		Error:      a.Error(),
+		StartedAt:  a.StartedAt(),
+		DurationMs: a.Duration().Milliseconds(),
+	}
+}
+
+func (r *TaskAttemptRow) ToEntity() *entities.TaskAttempt {
+	return entities.NewTaskAttempt(entities.WithTaskAttemptRestoreSpec(entities.TaskAttemptRestoreSpec{
+		ID:        r.ID,
+		TaskID:    r.TaskID,
+		TaskType:  r.TaskTypeNm,
+		Attempt:   r.Attempt,
+		Owner:     r.Owner,
+		Outcome:   r.Outcome,
+		Error:     r.Error,
+		StartedAt: r.StartedAt,
+		Duration:  time.Duration(r.DurationMs) * time.Millisecond,
+	}))
+}
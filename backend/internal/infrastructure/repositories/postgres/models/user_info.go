@@ -2,21 +2,31 @@ package models
 
 import (
 	"backend/internal/domain/entities"
+	"database/sql"
 	"github.com/google/uuid"
 	"time"
 )
 
 type UserInfoRow struct {
-	ID              uuid.UUID  `db:"id"`
-	Username        string     `db:"username"`
-	Name            string     `db:"name"`
-	Surname         string     `db:"surname"`
-	Password        string     `db:"password"`
-	Email           string     `db:"email"`
-	Phone           string     `db:"phone"`
-	CreatedAt       time.Time  `db:"created_at"`
-	EmailVerifiedAt *time.Time `db:"email_verified_at"`
-	PhoneVerifiedAt *time.Time `db:"phone_verified_at"`
+	ID              uuid.UUID      `db:"id"`
+	Username        string         `db:"username"`
+	Name            string         `db:"name"`
+	Surname         string         `db:"surname"`
+	Password        string         `db:"password"`
+	Email           string         `db:"email"`
+	Phone           string         `db:"phone"`
+	Role            string         `db:"role"`
+	CreatedAt       time.Time      `db:"created_at"`
+	EmailVerifiedAt *time.Time     `db:"email_verified_at"`
+	PhoneVerifiedAt *time.Time     `db:"phone_verified_at"`
+	TOTPSecret      string         `db:"totp_secret"`
+	TOTPEnabledAt   *time.Time     `db:"totp_enabled_at"`
+	TOTPLastStep    int64          `db:"totp_last_step"`
+	AppleSub        sql.NullString `db:"apple_sub"`
+	Timezone        string         `db:"timezone"`
+	Locale          string         `db:"locale"`
+
+	DeletionScheduledAt *time.Time `db:"deletion_scheduled_at"`
 }
 
 func NewUserInfoRow(userInfo *entities.UserInfo) *UserInfoRow {
@@ -28,9 +38,18 @@ func NewUserInfoRow(userInfo *entities.UserInfo) *UserInfoRow {
 		Password:        userInfo.Password(),
 		Email:           userInfo.Email(),
 		Phone:           userInfo.Phone(),
+		Role:            userInfo.Role().String(),
 		CreatedAt:       userInfo.CreatedAt(),
 		EmailVerifiedAt: userInfo.EmailVerifiedAt(),
 		PhoneVerifiedAt: userInfo.PhoneVerifiedAt(),
+		TOTPSecret:      userInfo.TOTPSecret(),
+		TOTPEnabledAt:   userInfo.TOTPEnabledAt(),
+		TOTPLastStep:    userInfo.TOTPLastStep(),
+		AppleSub:        sql.NullString{String: userInfo.AppleSub(), Valid: userInfo.AppleSub() != ""},
+		Timezone:        userInfo.Timezone(),
+		Locale:          userInfo.Locale(),
+
+		DeletionScheduledAt: userInfo.DeletionScheduledAt(),
 	}
 }
 
@@ -44,9 +63,18 @@ func (u *UserInfoRow) ToEntity() *entities.UserInfo {
 			Password:        u.Password,
 			Email:           u.Email,
 			Phone:           u.Phone,
+			Role:            entities.UserRole(u.Role),
 			CreatedAt:       u.CreatedAt,
 			EmailVerifiedAt: u.EmailVerifiedAt,
 			PhoneVerifiedAt: u.PhoneVerifiedAt,
+			TOTPSecret:      u.TOTPSecret,
+			TOTPEnabledAt:   u.TOTPEnabledAt,
+			TOTPLastStep:    u.TOTPLastStep,
+			AppleSub:        u.AppleSub.String,
+			Timezone:        u.Timezone,
+			Locale:          u.Locale,
+
+			DeletionScheduledAt: u.DeletionScheduledAt,
 		}),
 	)
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserPersonalTokenRow struct {
+	ID         uuid.UUID  `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	Name       string     `db:"name"`
+	TokenHash  string     `db:"token_hash"`
+	Scopes     string     `db:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	LastUsedIP string     `db:"last_used_ip"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+func NewUserPersonalTokenRow(t *entities.UserPersonalToken) *UserPersonalTokenRow {
+	scopes := make([]string, len(t.Scopes()))
+	for i, s := range t.Scopes() {
+		scopes[i] = s.String()
+	}
+
+	return &UserPersonalTokenRow{
+		ID:         t.ID(),
+		UserID:     t.UserID(),
+		Name:       t.Name(),
+		TokenHash:  t.TokenHash(),
+		Scopes:     strings.Join(scopes, " "),
+		ExpiresAt:  t.ExpiresAt(),
+		LastUsedAt: t.LastUsedAt(),
+		LastUsedIP: t.LastUsedIP(),
+		CreatedAt:  t.CreatedAt(),
+	}
+}
+
+func (r *UserPersonalTokenRow) ToEntity() *entities.UserPersonalToken {
+	fields := strings.Fields(r.Scopes)
+	scopes := make([]entities.PersonalTokenScope, len(fields))
+	for i, s := range fields {
+		scopes[i] = entities.PersonalTokenScope(s)
+	}
+
+	return entities.NewUserPersonalToken(entities.WithUserPersonalTokenRestoreSpec(entities.UserPersonalTokenRestoreSpec{
+		ID:         r.ID,
+		UserID:     r.UserID,
+		Name:       r.Name,
+		TokenHash:  r.TokenHash,
+		Scopes:     scopes,
+		ExpiresAt:  r.ExpiresAt,
+		LastUsedAt: r.LastUsedAt,
+		LastUsedIP: r.LastUsedIP,
+		CreatedAt:  r.CreatedAt,
+	}))
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"backend/internal/domain/entities"
+	"github.com/google/uuid"
+	"time"
+)
+
+type UserRecoveryCodeRow struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	CodeHash  string     `db:"code_hash"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+func NewUserRecoveryCodeRow(c *entities.UserRecoveryCode) *UserRecoveryCodeRow {
+	return &UserRecoveryCodeRow{
+		ID:        c.ID(),
+		UserID:    c.UserID(),
+		CodeHash:  c.CodeHash(),
+		UsedAt:    c.UsedAt(),
+		CreatedAt: c.CreatedAt(),
+	}
+}
@@ -7,29 +7,44 @@ import (
 )
 
 type UserRefreshTokenRow struct {
-	ID        uuid.UUID `db:"id"`
-	UserID    uuid.UUID `db:"user_id"`
-	TokenHash string    `db:"token_hash"`
-	ExpiresAt time.Time `db:"expires_at"`
-	CreatedAt time.Time `db:"created_at"`
+	ID         uuid.UUID `db:"id"`
+	UserID     uuid.UUID `db:"user_id"`
+	TokenHash  string    `db:"token_hash"`
+	DeviceName string    `db:"device_name"`
+	Platform   string    `db:"platform"`
+	IP         string    `db:"ip"`
+	UserAgent  string    `db:"user_agent"`
+	ExpiresAt  time.Time `db:"expires_at"`
+	CreatedAt  time.Time `db:"created_at"`
+	LastUsedAt time.Time `db:"last_used_at"`
 }
 
 func NewUserRefreshTokenRow(t *entities.UserRefreshToken) *UserRefreshTokenRow {
 	return &UserRefreshTokenRow{
-		ID:        t.ID(),
-		UserID:    t.UserID(),
-		TokenHash: t.TokenHash(),
-		ExpiresAt: t.ExpiresAt(),
-		CreatedAt: t.CreatedAt(),
+		ID:         t.ID(),
+		UserID:     t.UserID(),
+		TokenHash:  t.TokenHash(),
+		DeviceName: t.DeviceName(),
+		Platform:   t.Platform(),
+		IP:         t.IP(),
+		UserAgent:  t.UserAgent(),
+		ExpiresAt:  t.ExpiresAt(),
+		CreatedAt:  t.CreatedAt(),
+		LastUsedAt: t.LastUsedAt(),
 	}
 }
 
 func (r *UserRefreshTokenRow) ToEntity() *entities.UserRefreshToken {
 	return entities.NewUserRefreshToken(entities.WithUserRefreshTokenRestoreSpec(entities.UserRefreshTokenRestoreSpec{
-		ID:        r.ID,
-		UserID:    r.UserID,
-		TokenHash: r.TokenHash,
-		ExpiresAt: r.ExpiresAt,
-		CreatedAt: r.CreatedAt,
+		ID:         r.ID,
+		UserID:     r.UserID,
+		TokenHash:  r.TokenHash,
+		DeviceName: r.DeviceName,
+		Platform:   r.Platform,
+		IP:         r.IP,
+		UserAgent:  r.UserAgent,
+		ExpiresAt:  r.ExpiresAt,
+		CreatedAt:  r.CreatedAt,
+		LastUsedAt: r.LastUsedAt,
 	}))
 }
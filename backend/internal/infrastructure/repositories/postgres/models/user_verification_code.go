@@ -12,6 +12,7 @@ type UserVerificationCodeRow struct {
 	UserID    uuid.UUID            `db:"user_id"`
 	CodeHash  string               `db:"code_hash"`
 	CodeType  string               `db:"code_type"`
+	Target    sql.NullString       `db:"target"`
 	ExpiresAt time.Time            `db:"expires_at"`
 	UsedAt    sql.NullTime         `db:"used_at"`
 	CreatedAt time.Time            `db:"created_at"`
@@ -26,6 +27,9 @@ func NewUserVerificationCodeRow(c *entities.UserVerificationCode) *UserVerificat
 		ExpiresAt: c.ExpiresAt(),
 		CreatedAt: c.CreatedAt(),
 	}
+	if c.Target() != "" {
+		row.Target = sql.NullString{String: c.Target(), Valid: true}
+	}
 	if c.UsedAt() != nil {
 		row.UsedAt = sql.NullTime{Time: *c.UsedAt(), Valid: true}
 	}
@@ -38,6 +42,7 @@ func (r *UserVerificationCodeRow) ToEntity() *entities.UserVerificationCode {
 		UserID:    r.UserID,
 		CodeHash:  r.CodeHash,
 		CodeType:  entities.VerificationCodeType(r.CodeType),
+		Target:    r.Target.String,
 		ExpiresAt: r.ExpiresAt,
 		CreatedAt: r.CreatedAt,
 	}
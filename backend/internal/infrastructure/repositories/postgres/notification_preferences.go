@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// queryGetNotificationPreferences отдаёт настройки вместе с часовым поясом; пользователь без
+	// сохранённых настроек тоже находится — с пустыми полями настроек.
+	queryGetNotificationPreferences = `SELECT u.id AS user_id, u.timezone, p.channels,
+		p.quiet_hours_from, p.quiet_hours_to, p.updated_at
+		FROM bodyfuel.user_info u
+		LEFT JOIN bodyfuel.user_notification_preferences p ON p.user_id = u.id
+		WHERE u.id = $1`
+
+	queryUpsertNotificationPreferences = `INSERT INTO bodyfuel.user_notification_preferences (
+			user_id, channels, quiet_hours_from, quiet_hours_to, updated_at
+		) VALUES (:user_id, :channels, :quiet_hours_from, :quiet_hours_to, :updated_at)
+		ON CONFLICT (user_id) DO UPDATE SET
+			channels         = EXCLUDED.channels,
+			quiet_hours_from = EXCLUDED.quiet_hours_from,
+			quiet_hours_to   = EXCLUDED.quiet_hours_to,
+			updated_at       = EXCLUDED.updated_at`
+)
+
+type NotificationPreferencesRepo struct {
+	getter dbClientGetter
+}
+
+func NewNotificationPreferencesRepository(db *sqlx.DB) *NotificationPreferencesRepo {
+	return &NotificationPreferencesRepo{getter: dbClientGetter{db: db}}
+}
+
+// Get возвращает настройки пользователя; если он их не менял — настройки по умолчанию.
+func (r *NotificationPreferencesRepo) Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error) {
+	var row models.NotificationPreferencesRow
+	if err := r.getter.Get(ctx).GetContext(ctx, &row, queryGetNotificationPreferences, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrUserInfoNotFound
+		}
+		return nil, fmt.Errorf("get context: %w", err)
+	}
+
+	return row.ToEntity()
+}
+
+func (r *NotificationPreferencesRepo) Save(ctx context.Context, p *entities.NotificationPreferences) error {
+	row, err := models.NewNotificationPreferencesRow(p)
+	if err != nil {
+		return fmt.Errorf("new notification preferences row: %w", err)
+	}
+
+	if _, err = r.getter.Get(ctx).NamedExecContext(ctx, queryUpsertNotificationPreferences, row); err != nil {
+		return fmt.Errorf("named exec context: %w", err)
+	}
+
+	return nil
+}
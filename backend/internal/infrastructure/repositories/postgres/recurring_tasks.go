@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const queryUpdateRecurringTask = `UPDATE bodyfuel.recurring_tasks SET
+	cron          = :cron,
+	attribute     = :attribute,
+	timezone      = :timezone,
+	max_attempts  = :max_attempts,
+	enabled       = :enabled,
+	checked_until = :checked_until,
+	last_run_at   = :last_run_at,
+	updated_at    = :updated_at
+	WHERE id = :id`
+
+var recurringTaskColumns = []string{
+	"id", "name", "cron", "task_type_nm", "attribute", "scope", "timezone", "max_attempts",
+	"enabled", "checked_until", "last_run_at", "created_at", "updated_at",
+}
+
+// RecurringTasksRepo хранит расписания задач. Сами расписания создаются миграциями:
+// обработчик нового типа задачи всё равно появляется только с выкладкой кода.
+type RecurringTasksRepo struct {
+	getter dbClientGetter
+}
+
+func NewRecurringTasksRepository(db *sqlx.DB) *RecurringTasksRepo {
+	return &RecurringTasksRepo{getter: dbClientGetter{db: db}}
+}
+
+func (r *RecurringTasksRepo) List(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error) {
+	q := psq.Select(recurringTaskColumns...).From("bodyfuel.recurring_tasks").OrderBy("name")
+
+	if f.Name != nil {
+		q = q.Where(sq.Eq{"name": *f.Name})
+	}
+	if f.Enabled != nil {
+		q = q.Where(sq.Eq{"enabled": *f.Enabled})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.RecurringTaskRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("list recurring tasks: %w", err)
+	}
+
+	result := make([]*entities.RecurringTask, len(rows))
+	for i := range rows {
+		result[i] = rows[i].ToEntity()
+	}
+	return result, nil
+}
+
+// Get возвращает расписание по имени; withBlock блокирует строку до конца транзакции.
+func (r *RecurringTasksRepo) Get(ctx context.Context, name string, withBlock bool) (*entities.RecurringTask, error) {
+	q := psq.Select(recurringTaskColumns...).From("bodyfuel.recurring_tasks").Where(sq.Eq{"name": name})
+	if withBlock {
+		q = q.Suffix("FOR UPDATE")
+	}
+
+	return r.get(ctx, q)
+}
+
+// TryLock блокирует расписание до конца транзакции, не дожидаясь чужой блокировки. Если расписание
+// уже обрабатывает другая реплика, возвращает errs.ErrRecurringTaskNotFound.
+func (r *RecurringTasksRepo) TryLock(ctx context.Context, id uuid.UUID) (*entities.RecurringTask, error) {
+	q := psq.Select(recurringTaskColumns...).From("bodyfuel.recurring_tasks").
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	return r.get(ctx, q)
+}
+
+func (r *RecurringTasksRepo) get(ctx context.Context, q sq.SelectBuilder) (*entities.RecurringTask, error) {
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var row models.RecurringTaskRow
+	if err = r.getter.Get(ctx).GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrRecurringTaskNotFound
+		}
+		return nil, fmt.Errorf("get recurring task: %w", err)
+	}
+
+	return row.ToEntity(), nil
+}
+
+func (r *RecurringTasksRepo) Update(ctx context.Context, t *entities.RecurringTask) error {
+	res, err := r.getter.Get(ctx).NamedExecContext(ctx, queryUpdateRecurringTask, models.NewRecurringTaskRow(t))
+	if err != nil {
+		return fmt.Errorf("update recurring task: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		return errs.ErrRecurringTaskNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+const queryCreateTaskAttempt = `INSERT INTO bodyfuel.task_attempts
+	(id, task_id, task_type_nm, attempt, owner, outcome, error, started_at, duration_ms)
+	VALUES (:id, :task_id, :task_type_nm, :attempt, :owner, :outcome, :error, :started_at, :duration_ms)`
+
+var taskAttemptColumns = []string{
+	"id", "task_id", "task_type_nm", "attempt", "owner", "outcome", "error", "started_at", "duration_ms",
+}
+
+// TaskAttemptsRepo пишет и читает историю выполнения задач. Записи не меняются, а удаляются
+// только целиком по сроку хранения.
+type TaskAttemptsRepo struct {
+	getter dbClientGetter
+}
+
+func NewTaskAttemptsRepository(db *sqlx.DB) *TaskAttemptsRepo {
+	return &TaskAttemptsRepo{getter: dbClientGetter{db: db}}
+}
+
+func (r *TaskAttemptsRepo) Create(ctx context.Context, a *entities.TaskAttempt) error {
+	if _, err := r.getter.Get(ctx).NamedExecContext(ctx, queryCreateTaskAttempt, models.NewTaskAttemptRow(a)); err != nil {
+		return fmt.Errorf("create task attempt: %w", err)
+	}
+	return nil
+}
+
+// List возвращает попытки по фильтру, новые — первыми.
+func (r *TaskAttemptsRepo) List(ctx context.Context, f dto.TaskAttemptsFilter) ([]*entities.TaskAttempt, error) {
+	q := psq.Select(taskAttemptColumns...).From("bodyfuel.task_attempts").OrderBy("started_at DESC", "id")
+
+	if f.TaskID != nil {
+		q = q.Where(sq.Eq{"task_id": *f.TaskID})
+	}
+	if len(f.Types) > 0 {
+		q = q.Where(sq.Eq{"task_type_nm": f.Types})
+	}
+	if len(f.Outcomes) > 0 {
+		q = q.Where(sq.Eq{"outcome": f.Outcomes})
+	}
+	if f.StartedFrom != nil {
+		q = q.Where(sq.GtOrEq{"started_at": *f.StartedFrom})
+	}
+	if f.StartedTo != nil {
+		q = q.Where(sq.LtOrEq{"started_at": *f.StartedTo})
+	}
+	if f.Limit != nil {
+		q = q.Limit(uint64(*f.Limit))
+	}
+	if f.Offset != nil {
+		q = q.Offset(uint64(*f.Offset))
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.TaskAttemptRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("list task attempts: %w", err)
+	}
+
+	result := make([]*entities.TaskAttempt, len(rows))
+	for i := range rows {
+		result[i] = rows[i].ToEntity()
+	}
+	return result, nil
+}
+
+// DeleteBefore удаляет попытки, начатые раньше before, и возвращает их число.
+func (r *TaskAttemptsRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	query, args, err := psq.Delete("bodyfuel.task_attempts").Where(sq.Lt{"started_at": before}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sql: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete task attempts: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(ar), nil
+}
@@ -3,12 +3,15 @@ package postgres
 import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"backend/internal/infrastructure/repositories/postgres/builders"
 	"backend/internal/infrastructure/repositories/postgres/models"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -20,17 +23,71 @@ const (
 		retry_at, created_at, updated_at, attribute
 	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
+	// queryTaskCreateDedup занимает ключ дедупликации и вставляет задачу одним запросом. Ключ, который
+	// ещё действует, не перезаписывается, и тогда задача не вставляется; параллельная вставка того же
+	// ключа дождётся коммита первой. Параметры списка SELECT приводятся явно: типы из него не выводятся.
+	queryTaskCreateDedup = `WITH dedup AS (
+		INSERT INTO bodyfuel.task_dedup_keys (task_type_nm, dedup_key, task_id, expires_at)
+		VALUES ($2, $10, $1, $11)
+		ON CONFLICT (task_type_nm, dedup_key) DO UPDATE
+			SET task_id = EXCLUDED.task_id, expires_at = EXCLUDED.expires_at
+			WHERE bodyfuel.task_dedup_keys.expires_at <= NOW()
+		RETURNING task_id
+	)
+	INSERT INTO bodyfuel.tasks (
+		task_id, task_type_nm, task_state, max_attempts, attempts,
+		retry_at, created_at, updated_at, attribute, dedup_key
+	)
+	SELECT task_id, $2, $3::text, $4::int, $5::int, $6::timestamptz, $7::timestamptz, $8::timestamptz, $9::jsonb, $10
+	FROM dedup`
+
+	queryTaskDeleteExpiredDedupKeys = `DELETE FROM bodyfuel.task_dedup_keys WHERE expires_at <= NOW()`
+
+	// queryTaskNotify будит исполнителей, слушающих TasksNotifyChannel. Внутри транзакции
+	// Postgres доставит уведомление только после коммита, когда задача уже видна.
+	queryTaskNotify = `SELECT pg_notify($1, $2)`
+
+	// queryTaskUpdate меняет задачу вне исполнителя (перезапуск из API). Задача в аренде не
+	// трогается: её результат запишет исполнитель. Истёкшая аренда снимается, чтобы прежний
+	// владелец уже не смог записать результат поверх.
 	queryTaskUpdate = `UPDATE bodyfuel.tasks SET
-		task_type_nm = :task_type_nm,
-		task_state   = :task_state,
-		max_attempts = :max_attempts,
-		attempts     = :attempts,
-		retry_at     = :retry_at,
-		updated_at   = :updated_at,
-		attribute    = :attribute
-		WHERE task_id = :task_id`
+		task_type_nm   = :task_type_nm,
+		task_state     = :task_state,
+		max_attempts   = :max_attempts,
+		attempts       = :attempts,
+		retry_at       = :retry_at,
+		updated_at     = :updated_at,
+		attribute      = :attribute,
+		failure_reason = :failure_reason,
+		locked_by      = '',
+		locked_until   = NULL
+		WHERE task_id = :task_id
+		AND (locked_until IS NULL OR locked_until <= NOW())`
+
+	queryTaskExists = `SELECT EXISTS (SELECT 1 FROM bodyfuel.tasks WHERE task_id = $1)`
+
+	// queryTaskRelease записывает результат попытки и снимает аренду, только если задачу всё ещё
+	// держит этот исполнитель.
+	queryTaskRelease = `UPDATE bodyfuel.tasks SET
+		task_type_nm   = :task_type_nm,
+		task_state     = :task_state,
+		max_attempts   = :max_attempts,
+		attempts       = :attempts,
+		retry_at       = :retry_at,
+		updated_at     = :updated_at,
+		attribute      = :attribute,
+		failure_reason = :failure_reason,
+		locked_by      = '',
+		locked_until   = NULL
+		WHERE task_id = :task_id AND locked_by = :locked_by`
 )
 
+// leasedTaskRow — строка задачи вместе с владельцем аренды для queryTaskRelease.
+type leasedTaskRow struct {
+	*models.TaskRow
+	LockedBy string `db:"locked_by"`
+}
+
 type TasksRepo struct {
 	getter dbClientGetter
 }
@@ -39,13 +96,15 @@ func NewTasksRepository(db *sqlx.DB) *TasksRepo {
 	return &TasksRepo{getter: dbClientGetter{db: db}}
 }
 
+// Create ставит задачу в очередь. Задача с ключом дедупликации, который ещё занят задачей того же
+// типа, не создаётся, и это не ошибка.
 func (r *TasksRepo) Create(ctx context.Context, task *entities.Task) error {
 	row, err := models.NewTaskRow(task)
 	if err != nil {
 		return fmt.Errorf("new task row: %w", err)
 	}
 
-	_, err = r.getter.Get(ctx).ExecContext(ctx, queryTaskCreate,
+	args := []any{
 		row.UUID,
 		row.TypeNm,
 		row.State,
@@ -55,14 +114,85 @@ func (r *TasksRepo) Create(ctx context.Context, task *entities.Task) error {
 		row.CreatedAt,
 		row.UpdatedAt,
 		row.Attribute,
-	)
+	}
+	query := queryTaskCreate
+	if row.DedupKey != "" {
+		query = queryTaskCreateDedup
+		args = append(args, row.DedupKey, task.DedupUntil())
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("exec context: %w", err)
 	}
 
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		// такую задачу уже поставили в пределах окна дедупликации
+		return nil
+	}
+
+	// отложенные задачи исполнитель найдёт сам, когда подойдёт их время
+	if task.RetryAt().After(time.Now()) {
+		return nil
+	}
+
+	if _, err = r.getter.Get(ctx).ExecContext(ctx, queryTaskNotify, TasksNotifyChannel, row.TypeNm.String()); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
 	return nil
 }
 
+// CreateForUsers создаёт задачу каждому пользователю из часовых поясов spec.Timezones и возвращает их число.
+func (r *TasksRepo) CreateForUsers(ctx context.Context, spec dto.UserTasksSpec) (int, error) {
+	if len(spec.Timezones) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := builders.NewTasksForUsersInsertBuilder(spec).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sql: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("exec context: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		return 0, nil
+	}
+
+	if _, err = r.getter.Get(ctx).ExecContext(ctx, queryTaskNotify, TasksNotifyChannel, spec.TypeNm.String()); err != nil {
+		return 0, fmt.Errorf("notify: %w", err)
+	}
+
+	return int(ar), nil
+}
+
+// DeleteExpiredDedupKeys удаляет истёкшие ключи дедупликации и возвращает их число.
+func (r *TasksRepo) DeleteExpiredDedupKeys(ctx context.Context) (int, error) {
+	res, err := r.getter.Get(ctx).ExecContext(ctx, queryTaskDeleteExpiredDedupKeys)
+	if err != nil {
+		return 0, fmt.Errorf("exec context: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(ar), nil
+}
+
 func (r *TasksRepo) Get(ctx context.Context, f dto.TasksFilter, withBlock bool) (*entities.Task, error) {
 	b := builders.NewTasksSelectBuilder().
 		WithFilterSpecification(builders.NewTasksFilterSpecification(f)).
@@ -90,7 +220,15 @@ func (r *TasksRepo) Get(ctx context.Context, f dto.TasksFilter, withBlock bool)
 
 func (r *TasksRepo) List(ctx context.Context, f dto.TasksFilter, withBlock bool) ([]*entities.Task, error) {
 	b := builders.NewTasksSelectBuilder().
-		WithFilterSpecification(builders.NewTasksFilterSpecification(f))
+		WithFilterSpecification(builders.NewTasksFilterSpecification(f)).
+		OrderByTyped(builders.OrderBy{Field: "t.created_at", Direction: builders.DescDirection})
+
+	if f.Limit != nil {
+		b = b.Limit(*f.Limit)
+	}
+	if f.Offset != nil {
+		b = b.Offset(*f.Offset)
+	}
 
 	if withBlock {
 		b = b.WithBlock()
@@ -118,6 +256,41 @@ func (r *TasksRepo) List(ctx context.Context, f dto.TasksFilter, withBlock bool)
 	return result, nil
 }
 
+// Claim берёт в аренду до f.Limit задач по фильтру, которые сейчас никто не держит: владельцем
+// записывается owner, аренда истекает через lease. Экземпляры, вызывающие Claim одновременно,
+// получают разные задачи. Release и Complete снимают аренду.
+func (r *TasksRepo) Claim(ctx context.Context, f dto.TasksFilter, owner string, lease time.Duration) ([]*entities.Task, error) {
+	b := builders.NewTasksClaimBuilder(owner, lease).
+		WithFilterSpecification(builders.NewTasksFilterSpecification(f))
+
+	if f.Limit != nil {
+		b = b.Limit(*f.Limit)
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.TaskRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("select context: %w", err)
+	}
+
+	result := make([]*entities.Task, len(rows))
+	for i := range rows {
+		e, err := rows[i].ToEntity()
+		if err != nil {
+			return nil, fmt.Errorf("to entity: %w", err)
+		}
+		result[i] = e
+	}
+
+	return result, nil
+}
+
+// Update сохраняет задачу, которую меняют вне исполнителя. Задача в аренде не меняется —
+// errs.ErrTaskLocked.
 func (r *TasksRepo) Update(ctx context.Context, task *entities.Task) error {
 	row, err := models.NewTaskRow(task)
 	if err != nil {
@@ -134,21 +307,139 @@ func (r *TasksRepo) Update(ctx context.Context, task *entities.Task) error {
 		return fmt.Errorf("rows affected: %w", err)
 	}
 	if ar == 0 {
-		return fmt.Errorf("task not found")
+		return errs.ErrTaskLocked
 	}
 
 	return nil
 }
 
+// Release записывает результат попытки и снимает аренду owner. Если аренду уже забрал другой
+// экземпляр, задача не меняется — errs.ErrTaskLeaseLost.
+func (r *TasksRepo) Release(ctx context.Context, task *entities.Task, owner string) error {
+	row, err := models.NewTaskRow(task)
+	if err != nil {
+		return fmt.Errorf("new task row: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).NamedExecContext(ctx, queryTaskRelease, leasedTaskRow{TaskRow: row, LockedBy: owner})
+	if err != nil {
+		return fmt.Errorf("named exec context: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		return errs.ErrTaskLeaseLost
+	}
+
+	return nil
+}
+
+// Complete удаляет выполненную задачу, если её всё ещё держит owner. Задачу, которую уже забрал
+// другой экземпляр, не трогает — errs.ErrTaskLeaseLost. Задачи, удалённой во время выполнения
+// (например, вместе с аккаунтом), уже нет, и это не ошибка.
+func (r *TasksRepo) Complete(ctx context.Context, id uuid.UUID, owner string) error {
+	query, args, err := builders.NewTasksDeleteBuilder().
+		WithID([]uuid.UUID{id}).
+		WithOwner(owner).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build sql: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		var exists bool
+		if err = r.getter.Get(ctx).GetContext(ctx, &exists, queryTaskExists, id); err != nil {
+			return fmt.Errorf("get context: %w", err)
+		}
+		if exists {
+			return errs.ErrTaskLeaseLost
+		}
+	}
+
+	return nil
+}
+
+// Delete удаляет задачи вне исполнителя. Задачи в аренде остаются; если удалены не все,
+// возвращается errs.ErrTaskLocked.
 func (r *TasksRepo) Delete(ctx context.Context, ids []uuid.UUID) error {
-	query, args, err := builders.NewTasksDeleteBuilder().WithID(ids).ToSql()
+	query, args, err := builders.NewTasksDeleteBuilder().WithID(ids).WithoutLease().ToSql()
 	if err != nil {
 		return fmt.Errorf("build sql: %w", err)
 	}
 
-	if _, err = r.getter.Get(ctx).ExecContext(ctx, query, args...); err != nil {
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
 		return fmt.Errorf("exec context: %w", err)
 	}
 
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if int(ar) < len(ids) {
+		return errs.ErrTaskLocked
+	}
+
 	return nil
 }
+
+// DeleteByFilter удаляет все задачи по фильтру и возвращает их число.
+func (r *TasksRepo) DeleteByFilter(ctx context.Context, f dto.TasksFilter) (int, error) {
+	query, args, err := builders.NewTasksDeleteBuilder().
+		WithFilterSpecification(builders.NewTasksFilterSpecification(f)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sql: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("exec context: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(ar), nil
+}
+
+// RestartByFilter возвращает в очередь все задачи по фильтру и будит исполнителей их типов.
+// Возвращает число перезапущенных задач.
+func (r *TasksRepo) RestartByFilter(ctx context.Context, f dto.TasksFilter) (int, error) {
+	query, args, err := builders.NewTasksRestartBuilder().
+		WithFilterSpecification(builders.NewTasksFilterSpecification(f)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sql: %w", err)
+	}
+
+	var types []entities.TaskType
+	if err = r.getter.Get(ctx).SelectContext(ctx, &types, query, args...); err != nil {
+		return 0, fmt.Errorf("select context: %w", err)
+	}
+
+	restarted := len(types)
+
+	slices.Sort(types)
+	for _, typ := range slices.Compact(types) {
+		if _, err = r.getter.Get(ctx).ExecContext(ctx, queryTaskNotify, TasksNotifyChannel, typ.String()); err != nil {
+			return 0, fmt.Errorf("notify: %w", err)
+		}
+	}
+
+	return restarted, nil
+}
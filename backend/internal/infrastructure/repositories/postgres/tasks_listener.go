@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// TasksNotifyChannel — канал NOTIFY, в который TasksRepo.Create пишет тип новой задачи.
+const TasksNotifyChannel = "bodyfuel_tasks"
+
+// TasksListener ждёт уведомлений о новых задачах на выделенном соединении из пула.
+type TasksListener struct {
+	db *sqlx.DB
+}
+
+func NewTasksListener(db *sqlx.DB) *TasksListener {
+	return &TasksListener{db: db}
+}
+
+// Listen подписывается на TasksNotifyChannel и вызывает fn с типом каждой новой задачи, пока не отменён
+// контекст или не оборвалось соединение. Уведомления, пришедшие без подписки, теряются, поэтому после
+// ошибки вызывающий должен переподписаться и сам проверить очередь.
+func (l *TasksListener) Listen(ctx context.Context, fn func(entities.TaskType)) error {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("unexpected driver connection %T", driverConn)
+			return nil
+		}
+
+		listenErr = listen(ctx, c, fn)
+
+		// соединение остаётся подписанным или прерванным посреди ожидания — в пул его не возвращаем
+		return driver.ErrBadConn
+	})
+
+	return listenErr
+}
+
+func listen(ctx context.Context, c *stdlib.Conn, fn func(entities.TaskType)) error {
+	pgConn := c.Conn()
+
+	if _, err := pgConn.Exec(ctx, "LISTEN "+TasksNotifyChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	for {
+		n, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		fn(entities.TaskType(n.Payload))
+	}
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDSNEnv — строка подключения к тестовой базе. Без неё тесты репозиториев пропускаются:
+// миграции накатываются на эту базу, поэтому рабочую указывать нельзя.
+const testDSNEnv = "BODYFUEL_TEST_POSTGRES_DSN"
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	db, err := sqlx.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	require.NoError(t, goose.SetDialect("postgres"))
+	require.NoError(t, goose.Up(db.DB, "../../../../migrations"))
+
+	return db
+}
+
+// claimTestTask ставит задачу в очередь и берёт её в аренду за owner.
+func claimTestTask(t *testing.T, repo *TasksRepo, owner string) *entities.Task {
+	t.Helper()
+	ctx := context.Background()
+
+	task := entities.TaskKindSendCodeOnEmail.NewTask(entities.EmailTaskPayload{Email: "lease@example.com", Body: "1"},
+		entities.TaskSchedule{MaxAttempts: 3})
+	require.NoError(t, repo.Create(ctx, task))
+	t.Cleanup(func() {
+		_, _ = repo.getter.db.ExecContext(ctx, `DELETE FROM bodyfuel.tasks WHERE task_id = $1`, task.UUID())
+	})
+
+	limit := 1
+	claimed, err := repo.Claim(ctx, dto.TasksFilter{IDs: []uuid.UUID{task.UUID()}, Limit: &limit}, owner, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	return claimed[0]
+}
+
+func TestTasksRepo_OtherOwnerCannotFinishTask(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTasksRepository(newTestDB(t))
+	task := claimTestTask(t, repo, "executor-a")
+
+	task.Failed("stale result")
+	assert.ErrorIs(t, repo.Release(ctx, task, "executor-b"), errs.ErrTaskLeaseLost)
+	assert.ErrorIs(t, repo.Complete(ctx, task.UUID(), "executor-b"), errs.ErrTaskLeaseLost)
+
+	stored, err := repo.Get(ctx, dto.TasksFilter{IDs: []uuid.UUID{task.UUID()}}, false)
+	require.NoError(t, err)
+	assert.False(t, stored.IsFailed())
+
+	var lockedBy string
+	require.NoError(t, repo.getter.db.GetContext(ctx, &lockedBy,
+		`SELECT locked_by FROM bodyfuel.tasks WHERE task_id = $1`, task.UUID()))
+	assert.Equal(t, "executor-a", lockedBy)
+
+	assert.NoError(t, repo.Complete(ctx, task.UUID(), "executor-a"))
+}
+
+func TestTasksRepo_LeasedTaskIsNotChangedOutsideExecutor(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTasksRepository(newTestDB(t))
+	task := claimTestTask(t, repo, "executor-a")
+
+	task.Restart()
+	assert.ErrorIs(t, repo.Update(ctx, task), errs.ErrTaskLocked)
+	assert.ErrorIs(t, repo.Delete(ctx, []uuid.UUID{task.UUID()}), errs.ErrTaskLocked)
+
+	// после того как исполнитель отпустил задачу, её можно менять и удалять
+	require.NoError(t, repo.Release(ctx, task, "executor-a"))
+	assert.NoError(t, repo.Update(ctx, task))
+	assert.NoError(t, repo.Delete(ctx, []uuid.UUID{task.UUID()}))
+}
+
+func TestTasksRepo_CompleteDeletedTask(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTasksRepository(newTestDB(t))
+	task := claimTestTask(t, repo, "executor-a")
+
+	// задачу удалили во время выполнения, например вместе с аккаунтом
+	_, err := repo.getter.db.ExecContext(ctx, `DELETE FROM bodyfuel.tasks WHERE task_id = $1`, task.UUID())
+	require.NoError(t, err)
+
+	assert.NoError(t, repo.Complete(ctx, task.UUID(), "executor-a"))
+}
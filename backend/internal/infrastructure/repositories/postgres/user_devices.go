@@ -22,6 +22,8 @@ const (
 			updated_at = EXCLUDED.updated_at`
 
 	queryDeleteUserDevice = `DELETE FROM bodyfuel.user_devices WHERE id = $1 AND user_id = $2`
+
+	queryDeleteUserDeviceByToken = `DELETE FROM bodyfuel.user_devices WHERE device_token = $1`
 )
 
 type UserDevicesRepo struct {
@@ -91,3 +93,13 @@ func (r *UserDevicesRepo) Delete(ctx context.Context, id, userID uuid.UUID) erro
 
 	return nil
 }
+
+// DeleteByToken удаляет устройство у всех пользователей: недействительный токен не принадлежит никому.
+func (r *UserDevicesRepo) DeleteByToken(ctx context.Context, deviceToken string) error {
+	_, err := r.getter.Get(ctx).ExecContext(ctx, queryDeleteUserDeviceByToken, deviceToken)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
@@ -24,17 +24,32 @@ const (
                                     "password",
                                     "email",
                                     "phone",
-                                    "created_at") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	queryUpdateUserInfo = `UPDATE bodyfuel.user_info SET
+                                    "role",
+                                    "created_at",
+                                    "email_verified_at",
+                                    "apple_sub",
+                                    "timezone",
+                                    "locale") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	// queryListUserTimezones — часовые пояса пользователей, которым приходят задачи по расписанию
+	queryListUserTimezones = `SELECT DISTINCT timezone FROM bodyfuel.user_info WHERE deletion_scheduled_at IS NULL`
+	queryUpdateUserInfo    = `UPDATE bodyfuel.user_info SET
 									username=:username,
 									name=:name,
 									surname=:surname,
 									password=:password,
 									email=:email,
 									phone=:phone,
+									role=:role,
 									created_at=:created_at,
 									email_verified_at=:email_verified_at,
-									phone_verified_at=:phone_verified_at
+									phone_verified_at=:phone_verified_at,
+									totp_secret=:totp_secret,
+									totp_enabled_at=:totp_enabled_at,
+									totp_last_step=:totp_last_step,
+									apple_sub=:apple_sub,
+									timezone=:timezone,
+									locale=:locale,
+									deletion_scheduled_at=:deletion_scheduled_at
 									WHERE id=:id`
 )
 
@@ -83,7 +98,12 @@ func (r *UserInfoRepo) Create(ctx context.Context, userInfo *entities.UserInfo)
 		row.Password,
 		row.Email,
 		row.Phone,
+		row.Role,
 		row.CreatedAt,
+		row.EmailVerifiedAt,
+		row.AppleSub,
+		row.Timezone,
+		row.Locale,
 	)
 	if err != nil {
 		return fmt.Errorf("exec context: %w", err)
@@ -101,11 +121,11 @@ func (r *UserInfoRepo) Update(ctx context.Context, userInfo *entities.UserInfo)
 
 	rowAffected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("rows affected: %w", errs.ErrUserInfoNotFound)
+		return fmt.Errorf("rows affected: %w", err)
 	}
 
 	if rowAffected == 0 {
-		return fmt.Errorf("rows affected: %w", err)
+		return fmt.Errorf("rows affected: %w", errs.ErrUserInfoNotFound)
 	}
 
 	return nil
@@ -134,3 +154,13 @@ func (r *UserInfoRepo) Delete(ctx context.Context, f dto.UserInfoFilter) error {
 
 	return nil
 }
+
+// ListTimezones возвращает часовые пояса пользователей, аккаунт которых не ждёт удаления.
+func (r *UserInfoRepo) ListTimezones(ctx context.Context) ([]string, error) {
+	var timezones []string
+	if err := r.getter.Get(ctx).SelectContext(ctx, &timezones, queryListUserTimezones); err != nil {
+		return nil, fmt.Errorf("list user timezones: %w", err)
+	}
+
+	return timezones, nil
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	queryCreatePersonalToken = `INSERT INTO bodyfuel.user_personal_tokens
+		(id, user_id, name, token_hash, scopes, expires_at, last_used_at, last_used_ip, created_at)
+		VALUES (:id, :user_id, :name, :token_hash, :scopes, :expires_at, :last_used_at, :last_used_ip, :created_at)`
+
+	queryCountPersonalTokensByUser = `SELECT COUNT(*) FROM bodyfuel.user_personal_tokens WHERE user_id = $1`
+
+	queryDeletePersonalToken = `DELETE FROM bodyfuel.user_personal_tokens WHERE id = $1 AND user_id = $2`
+
+	// Отметка использования пишется не чаще раза в минуту, чтобы скрипт с частыми запросами
+	// не обновлял строку на каждом из них.
+	queryTouchPersonalToken = `UPDATE bodyfuel.user_personal_tokens SET last_used_at = NOW(), last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute' OR last_used_ip <> $2)`
+)
+
+var personalTokenColumns = []string{
+	"id", "user_id", "name", "token_hash", "scopes", "expires_at", "last_used_at", "last_used_ip", "created_at",
+}
+
+type UserPersonalTokensRepo struct {
+	getter dbClientGetter
+}
+
+func NewUserPersonalTokensRepository(db *sqlx.DB) *UserPersonalTokensRepo {
+	return &UserPersonalTokensRepo{getter: dbClientGetter{db: db}}
+}
+
+func (r *UserPersonalTokensRepo) Create(ctx context.Context, t *entities.UserPersonalToken) error {
+	if _, err := r.getter.Get(ctx).NamedExecContext(ctx, queryCreatePersonalToken, models.NewUserPersonalTokenRow(t)); err != nil {
+		return fmt.Errorf("create personal token: %w", err)
+	}
+	return nil
+}
+
+func (r *UserPersonalTokensRepo) GetByHash(ctx context.Context, tokenHash string) (*entities.UserPersonalToken, error) {
+	query, args, err := psq.Select(personalTokenColumns...).
+		From("bodyfuel.user_personal_tokens").
+		Where(sq.Eq{"token_hash": tokenHash}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var row models.UserPersonalTokenRow
+	if err = r.getter.Get(ctx).GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrPersonalTokenNotFound
+		}
+		return nil, fmt.Errorf("get personal token: %w", err)
+	}
+	return row.ToEntity(), nil
+}
+
+func (r *UserPersonalTokensRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*entities.UserPersonalToken, error) {
+	query, args, err := psq.Select(personalTokenColumns...).
+		From("bodyfuel.user_personal_tokens").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.UserPersonalTokenRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("list personal tokens: %w", err)
+	}
+
+	result := make([]*entities.UserPersonalToken, len(rows))
+	for i := range rows {
+		result[i] = rows[i].ToEntity()
+	}
+	return result, nil
+}
+
+func (r *UserPersonalTokensRepo) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	var n int
+	if err := r.getter.Get(ctx).GetContext(ctx, &n, queryCountPersonalTokensByUser, userID); err != nil {
+		return 0, fmt.Errorf("count personal tokens: %w", err)
+	}
+	return n, nil
+}
+
+// Delete удаляет токен пользователя. Чужой или несуществующий токен — ErrPersonalTokenNotFound.
+func (r *UserPersonalTokensRepo) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	res, err := r.getter.Get(ctx).ExecContext(ctx, queryDeletePersonalToken, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete personal token: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		return errs.ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+// Touch отмечает использование токена с адреса ip.
+func (r *UserPersonalTokensRepo) Touch(ctx context.Context, id uuid.UUID, ip string) error {
+	if _, err := r.getter.Get(ctx).ExecContext(ctx, queryTouchPersonalToken, id, ip); err != nil {
+		return fmt.Errorf("touch personal token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/infrastructure/repositories/postgres/models"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	queryCreateRecoveryCode = `INSERT INTO bodyfuel.user_recovery_codes (id, user_id, code_hash, used_at, created_at)
+		VALUES (:id, :user_id, :code_hash, :used_at, :created_at)`
+
+	queryUseRecoveryCode = `UPDATE bodyfuel.user_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	queryCountUnusedRecoveryCodes = `SELECT COUNT(*) FROM bodyfuel.user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	queryDeleteRecoveryCodesByUser = `DELETE FROM bodyfuel.user_recovery_codes WHERE user_id = $1`
+)
+
+type UserRecoveryCodesRepo struct {
+	getter dbClientGetter
+}
+
+func NewUserRecoveryCodesRepository(db *sqlx.DB) *UserRecoveryCodesRepo {
+	return &UserRecoveryCodesRepo{getter: dbClientGetter{db: db}}
+}
+
+// Replace заменяет все коды пользователя новым набором. Вызывать в транзакции.
+func (r *UserRecoveryCodesRepo) Replace(ctx context.Context, userID uuid.UUID, codes []*entities.UserRecoveryCode) error {
+	if err := r.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, c := range codes {
+		if _, err := r.getter.Get(ctx).NamedExecContext(ctx, queryCreateRecoveryCode, models.NewUserRecoveryCodeRow(c)); err != nil {
+			return fmt.Errorf("create recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// Use помечает неиспользованный код использованным. false — такого кода нет или он уже использован.
+func (r *UserRecoveryCodesRepo) Use(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	res, err := r.getter.Get(ctx).ExecContext(ctx, queryUseRecoveryCode, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return ar > 0, nil
+}
+
+func (r *UserRecoveryCodesRepo) CountUnused(ctx context.Context, userID uuid.UUID) (int, error) {
+	var n int
+	if err := r.getter.Get(ctx).GetContext(ctx, &n, queryCountUnusedRecoveryCodes, userID); err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return n, nil
+}
+
+func (r *UserRecoveryCodesRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.getter.Get(ctx).ExecContext(ctx, queryDeleteRecoveryCodesByUser, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	return nil
+}
@@ -3,9 +3,14 @@ package postgres
 import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"backend/internal/infrastructure/repositories/postgres/builders"
 	"backend/internal/infrastructure/repositories/postgres/models"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -14,12 +19,30 @@ import (
 
 const (
 	queryCreateRefreshToken = `INSERT INTO bodyfuel.user_refresh_tokens
-		(id, user_id, token_hash, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5)`
+		(id, user_id, token_hash, device_name, platform, ip, user_agent, expires_at, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	queryUpdateRefreshToken = `UPDATE bodyfuel.user_refresh_tokens SET
+		token_hash   = :token_hash,
+		device_name  = :device_name,
+		platform     = :platform,
+		ip           = :ip,
+		user_agent   = :user_agent,
+		expires_at   = :expires_at,
+		last_used_at = :last_used_at
+		WHERE id = :id`
 
 	queryDeleteRefreshTokensByUser = `DELETE FROM bodyfuel.user_refresh_tokens WHERE user_id = $1`
+
+	queryCreateRotatedRefreshToken = `INSERT INTO bodyfuel.user_refresh_token_history (token_hash, family_id, rotated_at)
+		VALUES ($1, $2, NOW())`
 )
 
+var refreshTokenColumns = []string{
+	"id", "user_id", "token_hash", "device_name", "platform", "ip", "user_agent",
+	"expires_at", "created_at", "last_used_at",
+}
+
 type UserRefreshTokensRepo struct {
 	getter dbClientGetter
 }
@@ -31,7 +54,8 @@ func NewUserRefreshTokensRepository(db *sqlx.DB) *UserRefreshTokensRepo {
 func (r *UserRefreshTokensRepo) Create(ctx context.Context, t *entities.UserRefreshToken) error {
 	row := models.NewUserRefreshTokenRow(t)
 	_, err := r.getter.Get(ctx).ExecContext(ctx, queryCreateRefreshToken,
-		row.ID, row.UserID, row.TokenHash, row.ExpiresAt, row.CreatedAt,
+		row.ID, row.UserID, row.TokenHash, row.DeviceName, row.Platform, row.IP, row.UserAgent,
+		row.ExpiresAt, row.CreatedAt, row.LastUsedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("create refresh token: %w", err)
@@ -40,18 +64,7 @@ func (r *UserRefreshTokensRepo) Create(ctx context.Context, t *entities.UserRefr
 }
 
 func (r *UserRefreshTokensRepo) Get(ctx context.Context, f dto.UserRefreshTokenFilter) (*entities.UserRefreshToken, error) {
-	q := psq.Select("id", "user_id", "token_hash", "expires_at", "created_at").
-		From("bodyfuel.user_refresh_tokens")
-
-	if f.ID != nil {
-		q = q.Where(sq.Eq{"id": *f.ID})
-	}
-	if f.UserID != nil {
-		q = q.Where(sq.Eq{"user_id": *f.UserID})
-	}
-	if f.TokenHash != nil {
-		q = q.Where(sq.Eq{"token_hash": *f.TokenHash})
-	}
+	q := applyRefreshTokenFilter(psq.Select(refreshTokenColumns...).From("bodyfuel.user_refresh_tokens"), f)
 
 	query, args, err := q.ToSql()
 	if err != nil {
@@ -60,11 +73,60 @@ func (r *UserRefreshTokensRepo) Get(ctx context.Context, f dto.UserRefreshTokenF
 
 	var row models.UserRefreshTokenRow
 	if err = r.getter.Get(ctx).GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrSessionNotFound
+		}
 		return nil, fmt.Errorf("get refresh token: %w", err)
 	}
 	return row.ToEntity(), nil
 }
 
+func (r *UserRefreshTokensRepo) List(ctx context.Context, f dto.UserRefreshTokenFilter) ([]*entities.UserRefreshToken, error) {
+	q := applyRefreshTokenFilter(psq.Select(refreshTokenColumns...).From("bodyfuel.user_refresh_tokens"), f).
+		OrderBy("last_used_at DESC")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build sql: %w", err)
+	}
+
+	var rows []models.UserRefreshTokenRow
+	if err = r.getter.Get(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("list refresh tokens: %w", err)
+	}
+
+	result := make([]*entities.UserRefreshToken, len(rows))
+	for i := range rows {
+		result[i] = rows[i].ToEntity()
+	}
+	return result, nil
+}
+
+func (r *UserRefreshTokensRepo) Update(ctx context.Context, t *entities.UserRefreshToken) error {
+	res, err := r.getter.Get(ctx).NamedExecContext(ctx, queryUpdateRefreshToken, models.NewUserRefreshTokenRow(t))
+	if err != nil {
+		return fmt.Errorf("update refresh token: %w", err)
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if ar == 0 {
+		return fmt.Errorf("update refresh token: %w", errs.ErrSessionNotFound)
+	}
+	return nil
+}
+
+// CreateRotated запоминает хэш ротированного токена в истории семейства familyID.
+func (r *UserRefreshTokensRepo) CreateRotated(ctx context.Context, familyID uuid.UUID, tokenHash string) error {
+	_, err := r.getter.Get(ctx).ExecContext(ctx, queryCreateRotatedRefreshToken, tokenHash, familyID)
+	if err != nil {
+		return fmt.Errorf("create rotated refresh token: %w", err)
+	}
+	return nil
+}
+
 func (r *UserRefreshTokensRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
 	_, err := r.getter.Get(ctx).ExecContext(ctx, queryDeleteRefreshTokensByUser, userID)
 	if err != nil {
@@ -74,23 +136,53 @@ func (r *UserRefreshTokensRepo) DeleteByUser(ctx context.Context, userID uuid.UU
 }
 
 func (r *UserRefreshTokensRepo) Delete(ctx context.Context, f dto.UserRefreshTokenFilter) error {
-	q := psq.Delete("bodyfuel.user_refresh_tokens")
+	q := applyRefreshTokenFilter(psq.Delete("bodyfuel.user_refresh_tokens"), f)
+	query, args, err := q.ToSql()
+	if err != nil {
+		return fmt.Errorf("build sql: %w", err)
+	}
+	_, err = r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("delete refresh token: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired удаляет сессии, истёкшие раньше before, вместе с историей их ротаций, и возвращает их число.
+func (r *UserRefreshTokensRepo) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	query, args, err := psq.Delete("bodyfuel.user_refresh_tokens").Where(sq.Lt{"expires_at": before}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sql: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired refresh tokens: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(n), nil
+}
+
+func applyRefreshTokenFilter[B builders.WhereBuilder[B]](q B, f dto.UserRefreshTokenFilter) B {
 	if f.ID != nil {
 		q = q.Where(sq.Eq{"id": *f.ID})
 	}
+	if f.ExcludeID != nil {
+		q = q.Where(sq.NotEq{"id": *f.ExcludeID})
+	}
 	if f.UserID != nil {
 		q = q.Where(sq.Eq{"user_id": *f.UserID})
 	}
 	if f.TokenHash != nil {
 		q = q.Where(sq.Eq{"token_hash": *f.TokenHash})
 	}
-	query, args, err := q.ToSql()
-	if err != nil {
-		return fmt.Errorf("build sql: %w", err)
-	}
-	_, err = r.getter.Get(ctx).ExecContext(ctx, query, args...)
-	if err != nil {
-		return fmt.Errorf("delete refresh token: %w", err)
+	if f.RotatedTokenHash != nil {
+		q = q.Where(sq.Expr("id IN (SELECT family_id FROM bodyfuel.user_refresh_token_history WHERE token_hash = ?)", *f.RotatedTokenHash))
 	}
-	return nil
+	return q
 }
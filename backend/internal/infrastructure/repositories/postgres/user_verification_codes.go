@@ -6,6 +6,7 @@ import (
 	"backend/internal/infrastructure/repositories/postgres/models"
 	"context"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -13,8 +14,8 @@ import (
 
 const (
 	queryCreateVerificationCode = `INSERT INTO bodyfuel.user_verification_codes
-		(id, user_id, code_hash, code_type, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		(id, user_id, code_hash, code_type, target, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
 	queryMarkVerificationCodeUsed = `UPDATE bodyfuel.user_verification_codes
 		SET used_at = NOW() WHERE id = $1`
@@ -31,7 +32,7 @@ func NewUserVerificationCodesRepository(db *sqlx.DB) *UserVerificationCodesRepo
 func (r *UserVerificationCodesRepo) Create(ctx context.Context, c *entities.UserVerificationCode) error {
 	row := models.NewUserVerificationCodeRow(c)
 	_, err := r.getter.Get(ctx).ExecContext(ctx, queryCreateVerificationCode,
-		row.ID, row.UserID, row.CodeHash, row.CodeType, row.ExpiresAt, row.CreatedAt,
+		row.ID, row.UserID, row.CodeHash, row.CodeType, row.Target, row.ExpiresAt, row.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("create verification code: %w", err)
@@ -40,7 +41,7 @@ func (r *UserVerificationCodesRepo) Create(ctx context.Context, c *entities.User
 }
 
 func (r *UserVerificationCodesRepo) GetLatest(ctx context.Context, f dto.UserVerificationCodeFilter) (*entities.UserVerificationCode, error) {
-	q := psq.Select("id", "user_id", "code_hash", "code_type", "expires_at", "used_at", "created_at").
+	q := psq.Select("id", "user_id", "code_hash", "code_type", "target", "expires_at", "used_at", "created_at").
 		From("bodyfuel.user_verification_codes").
 		OrderBy("created_at DESC").
 		Limit(1)
@@ -71,3 +72,23 @@ func (r *UserVerificationCodesRepo) MarkUsed(ctx context.Context, id interface{}
 	}
 	return nil
 }
+
+// DeleteExpired удаляет коды, истёкшие раньше before, и возвращает их число.
+func (r *UserVerificationCodesRepo) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	query, args, err := psq.Delete("bodyfuel.user_verification_codes").Where(sq.Lt{"expires_at": before}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sql: %w", err)
+	}
+
+	res, err := r.getter.Get(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired verification codes: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(n), nil
+}
@@ -62,7 +62,7 @@ func (r *WorkoutRepository) Get(ctx context.Context, f dto.WorkoutsFilter, withB
 	var row models.WorkoutRow
 	if err := r.getter.Get(ctx).GetContext(ctx, &row, query, args...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errs.ErrUserWeightNotFound
+			return nil, errs.ErrWorkoutNotFound
 		}
 		return nil, fmt.Errorf("get context: %w", err)
 	}
@@ -0,0 +1,160 @@
+package account
+
+import (
+	"archive/zip"
+	"backend/internal/dto"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// buildArchive собирает ZIP: по разделу выгрузки <раздел>.json и <раздел>.csv,
+// фото еды в photos/food/ и аватар в photos/avatar.
+func (s *Service) buildArchive(ctx context.Context, userID uuid.UUID) (*bytes.Reader, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, section := range dto.AccountExportSections {
+		rows, err := s.accountRepo.ExportSection(ctx, section, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeSection(zw, section.String(), rows); err != nil {
+			return nil, fmt.Errorf("write %s: %w", section, err)
+		}
+	}
+
+	photos, err := s.storage.ListObjects(ctx, foodPhotosPrefix(userID))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range photos {
+		if err := s.copyObject(ctx, zw, key, "photos/food/"+path.Base(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	avatar, err := s.avatarKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if avatar != "" {
+		if err := s.copyObject(ctx, zw, avatar, "photos/avatar"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close zip: %w", err)
+	}
+
+	return bytes.NewReader(buf.Bytes()), nil
+}
+
+func (s *Service) copyObject(ctx context.Context, zw *zip.Writer, key, name string) error {
+	obj, err := s.storage.GetObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := io.Copy(w, obj); err != nil {
+		return fmt.Errorf("copy %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func writeSection(zw *zip.Writer, name string, rows []json.RawMessage) error {
+	records := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		dec := json.NewDecoder(bytes.NewReader(row))
+		dec.UseNumber()
+
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			return fmt.Errorf("decode row: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	w, err := zw.Create(name + ".json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(records); err != nil {
+		return err
+	}
+
+	w, err = zw.Create(name + ".csv")
+	if err != nil {
+		return err
+	}
+
+	return writeCSV(w, records)
+}
+
+// writeCSV пишет записи таблицей: колонки — объединение ключей всех записей в алфавитном порядке,
+// вложенные объекты и массивы — JSON в ячейке.
+func writeCSV(w io.Writer, records []map[string]any) error {
+	columnSet := make(map[string]struct{})
+	for _, r := range records {
+		for k := range r {
+			columnSet[k] = struct{}{}
+		}
+	}
+	columns := make([]string, 0, len(columnSet))
+	for k := range columnSet {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		line := make([]string, len(columns))
+		for i, c := range columns {
+			line[i] = csvValue(r[c])
+		}
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func csvValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return fmt.Sprint(val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,344 @@
+// Package account отвечает за жизненный цикл аккаунта целиком: отложенное удаление всех данных
+// пользователя и выгрузку этих данных в архив.
+package account
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultDeletionGracePeriod — сколько аккаунт ждёт удаления, пока его можно восстановить.
+	DefaultDeletionGracePeriod = 7 * 24 * time.Hour
+	// DefaultExportLinkTTL — срок действия ссылки на архив. Подписанная ссылка S3 живёт не больше 7 дней.
+	DefaultExportLinkTTL = 72 * time.Hour
+
+	deleteTaskMaxAttempts   = 10
+	exportTaskMaxAttempts   = 3
+	accountEmailMaxAttempts = 5
+
+	// emailTimeLayout — формат дат в письмах, время в поясе пользователя.
+	emailTimeLayout = "02.01.2006 15:04 MST"
+)
+
+type (
+	TransactionManager interface {
+		Do(ctx context.Context, fn func(ctx context.Context) error) error
+	}
+
+	UserInfoRepository interface {
+		Get(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error)
+		Update(ctx context.Context, userInfo *entities.UserInfo) error
+	}
+
+	AccountRepository interface {
+		ExportSection(ctx context.Context, section dto.AccountExportSection, userID uuid.UUID) ([]json.RawMessage, error)
+		Purge(ctx context.Context, userID uuid.UUID) error
+	}
+
+	TasksRepository interface {
+		Create(ctx context.Context, task *entities.Task) error
+		List(ctx context.Context, f dto.TasksFilter, withBlock bool) ([]*entities.Task, error)
+	}
+
+	// ObjectStorage — бакет MinIO с аватарами, фото еды и архивами выгрузки.
+	ObjectStorage interface {
+		ListObjects(ctx context.Context, prefix string) ([]string, error)
+		GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+		PutObject(ctx context.Context, key, contentType string, body io.Reader) error
+		DeleteObjects(ctx context.Context, keys []string) error
+		PresignGetObject(ctx context.Context, key string, ttl time.Duration) (string, error)
+	}
+)
+
+type Config struct {
+	TransactionManager  TransactionManager
+	UserInfoRepository  UserInfoRepository
+	AccountRepository   AccountRepository
+	TasksRepository     TasksRepository
+	ObjectStorage       ObjectStorage
+	DeletionGracePeriod time.Duration // 0 — DefaultDeletionGracePeriod
+	ExportLinkTTL       time.Duration // 0 — DefaultExportLinkTTL
+}
+
+type Service struct {
+	txm                 TransactionManager
+	userInfoRepo        UserInfoRepository
+	accountRepo         AccountRepository
+	tasksRepo           TasksRepository
+	storage             ObjectStorage
+	deletionGracePeriod time.Duration
+	exportLinkTTL       time.Duration
+}
+
+func NewService(c *Config) *Service {
+	s := &Service{
+		txm:                 c.TransactionManager,
+		userInfoRepo:        c.UserInfoRepository,
+		accountRepo:         c.AccountRepository,
+		tasksRepo:           c.TasksRepository,
+		storage:             c.ObjectStorage,
+		deletionGracePeriod: c.DeletionGracePeriod,
+		exportLinkTTL:       c.ExportLinkTTL,
+	}
+	if s.deletionGracePeriod <= 0 {
+		s.deletionGracePeriod = DefaultDeletionGracePeriod
+	}
+	if s.exportLinkTTL <= 0 {
+		s.exportLinkTTL = DefaultExportLinkTTL
+	}
+
+	return s
+}
+
+// ScheduleDeletion планирует удаление аккаунта через grace-период и возвращает его дату.
+// Повторный вызов не сдвигает уже назначенную дату.
+func (s *Service) ScheduleDeletion(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	var at time.Time
+	err := s.txm.Do(ctx, func(ctx context.Context) error {
+		user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, true)
+		if err != nil {
+			return err
+		}
+		if user.IsDeletionScheduled() {
+			at = *user.DeletionScheduledAt()
+			return nil
+		}
+
+		at = time.Now().Add(s.deletionGracePeriod)
+		user.ScheduleDeletion(at)
+		if err := s.userInfoRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// задача стартует в назначенное время; если удаление отменят, она завершится ничего не сделав
+		if err := s.tasksRepo.Create(ctx, entities.TaskKindDeleteAccount.NewTask(
+			entities.AccountTaskPayload{UserID: userID},
+			entities.TaskSchedule{RetryAt: at},
+		)); err != nil {
+			return fmt.Errorf("create delete task: %w", err)
+		}
+
+		if user.IsEmailVerified() {
+			return s.createEmailTask(ctx, user, entities.NotificationTemplateAccountDeletion, map[string]string{
+				"deletion_at": at.In(user.Location()).Format(emailTimeLayout),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("schedule deletion: %w", err)
+	}
+
+	return at, nil
+}
+
+// CancelDeletion отменяет запланированное удаление. Задача удаления остаётся в очереди
+// и при запуске увидит, что удалять нечего.
+func (s *Service) CancelDeletion(ctx context.Context, userID uuid.UUID) error {
+	err := s.txm.Do(ctx, func(ctx context.Context) error {
+		user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, true)
+		if err != nil {
+			return err
+		}
+		if !user.IsDeletionScheduled() {
+			return errs.ErrDeletionNotScheduled
+		}
+
+		user.CancelDeletion()
+		return s.userInfoRepo.Update(ctx, user)
+	})
+	if err != nil {
+		return fmt.Errorf("cancel deletion: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeAccount безвозвратно удаляет объекты пользователя в MinIO и все его строки в базе.
+// Вызывается исполнителем задач. Если удаление отменили или перенесли, ничего не делает.
+func (s *Service) PurgeAccount(ctx context.Context, userID uuid.UUID) error {
+	// строка пользователя заблокирована до конца удаления: отмена, пришедшая в это время, дождётся его
+	return s.txm.Do(ctx, func(ctx context.Context) error {
+		user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, true)
+		if errors.Is(err, errs.ErrUserInfoNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("purge account: %w", err)
+		}
+		if !user.IsDeletionDue(time.Now()) {
+			return nil
+		}
+
+		// сначала объекты: если упадёт база, задача повторится и удалит оставшееся
+		keys, err := s.userObjectKeys(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("purge account: %w", err)
+		}
+		if err := s.storage.DeleteObjects(ctx, keys); err != nil {
+			return fmt.Errorf("purge account: %w", err)
+		}
+
+		if err := s.accountRepo.Purge(ctx, userID); err != nil {
+			return fmt.Errorf("purge account: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RequestExport ставит в очередь сборку архива с данными пользователя. Ссылка на архив придёт
+// на подтверждённый email, поэтому без него выгрузка недоступна. Пока предыдущий запрос
+// не обработан, новый не создаётся.
+func (s *Service) RequestExport(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return fmt.Errorf("request export: %w", err)
+	}
+	if !user.IsEmailVerified() {
+		return fmt.Errorf("request export: %w", errs.ErrExportEmailRequired)
+	}
+
+	pending, err := s.tasksRepo.List(ctx, dto.TasksFilter{
+		UserID: &userID,
+		Types:  []entities.TaskType{entities.TaskTypeExportUserData},
+		States: []entities.TaskState{entities.TaskStateRunning},
+	}, false)
+	if err != nil {
+		return fmt.Errorf("request export: %w", err)
+	}
+	if len(pending) > 0 {
+		return nil
+	}
+
+	if err := s.tasksRepo.Create(ctx, entities.TaskKindExportUserData.NewTask(
+		entities.AccountTaskPayload{UserID: userID},
+		entities.TaskSchedule{},
+	)); err != nil {
+		return fmt.Errorf("request export: %w", err)
+	}
+
+	return nil
+}
+
+// ExportUserData собирает архив, кладёт его в MinIO вместо предыдущего и ставит письмо
+// со ссылкой на скачивание. Вызывается исполнителем задач.
+func (s *Service) ExportUserData(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if errors.Is(err, errs.ErrUserInfoNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	archive, err := s.buildArchive(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	prefix := exportPrefix(userID)
+	previous, err := s.storage.ListObjects(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+	if err := s.storage.DeleteObjects(ctx, previous); err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	key := prefix + "bodyfuel-export-" + time.Now().UTC().Format("20060102-150405") + ".zip"
+	if err := s.storage.PutObject(ctx, key, "application/zip", archive); err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	link, err := s.storage.PresignGetObject(ctx, key, s.exportLinkTTL)
+	if err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.exportLinkTTL)
+	if err := s.createEmailTask(ctx, user, entities.NotificationTemplateDataExportReady, map[string]string{
+		"link":       link,
+		"expires_at": expiresAt.In(user.Location()).Format(emailTimeLayout),
+	}); err != nil {
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) createEmailTask(
+	ctx context.Context,
+	user *entities.UserInfo,
+	template entities.NotificationTemplate,
+	vars map[string]string,
+) error {
+	err := s.tasksRepo.Create(ctx, entities.TaskKindSendNotificationEmail.NewTask(entities.EmailTaskPayload{
+		UserID:   user.ID(),
+		Email:    user.Email(),
+		Template: template,
+		Vars:     vars,
+		Category: entities.NotificationCategorySecurity,
+	}, entities.TaskSchedule{MaxAttempts: accountEmailMaxAttempts}))
+	if err != nil {
+		return fmt.Errorf("create email task: %w", err)
+	}
+
+	return nil
+}
+
+// userObjectKeys собирает все объекты пользователя: аватар, фото еды и архивы выгрузки.
+func (s *Service) userObjectKeys(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	var keys []string
+	for _, prefix := range []string{foodPhotosPrefix(userID), exportPrefix(userID)} {
+		found, err := s.storage.ListObjects(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, found...)
+	}
+
+	avatar, err := s.avatarKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if avatar != "" {
+		keys = append(keys, avatar)
+	}
+
+	return keys, nil
+}
+
+// avatarKey возвращает ключ аватара или пустую строку, если аватар не загружен.
+// Аватар лежит в корне бакета под ключом <userID> (см. avatar.Service.PresignPutAvatar).
+func (s *Service) avatarKey(ctx context.Context, userID uuid.UUID) (string, error) {
+	found, err := s.storage.ListObjects(ctx, userID.String())
+	if err != nil {
+		return "", err
+	}
+	for _, key := range found {
+		if key == userID.String() {
+			return key, nil
+		}
+	}
+
+	return "", nil
+}
+
+func foodPhotosPrefix(userID uuid.UUID) string {
+	return "food-photos/" + userID.String() + "/"
+}
+
+func exportPrefix(userID uuid.UUID) string {
+	return "exports/" + userID.String() + "/"
+}
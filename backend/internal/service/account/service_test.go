@@ -0,0 +1,381 @@
+package account
+
+import (
+	"archive/zip"
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ── mocks ──────────────────────────────────────────────────────────────────
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) Do(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+type mockUserInfoRepo struct{ mock.Mock }
+
+func (m *mockUserInfoRepo) Get(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error) {
+	args := m.Called(ctx, f, withBlock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.UserInfo), args.Error(1)
+}
+
+func (m *mockUserInfoRepo) Update(ctx context.Context, u *entities.UserInfo) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+type mockAccountRepo struct{ mock.Mock }
+
+func (m *mockAccountRepo) ExportSection(ctx context.Context, section dto.AccountExportSection, userID uuid.UUID) ([]json.RawMessage, error) {
+	args := m.Called(ctx, section, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]json.RawMessage), args.Error(1)
+}
+
+func (m *mockAccountRepo) Purge(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+type mockTasksRepo struct{ mock.Mock }
+
+func (m *mockTasksRepo) Create(ctx context.Context, t *entities.Task) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *mockTasksRepo) List(ctx context.Context, f dto.TasksFilter, withBlock bool) ([]*entities.Task, error) {
+	args := m.Called(ctx, f, withBlock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Task), args.Error(1)
+}
+
+// memStorage — бакет в памяти: проверять содержимое архива и набор удалённых ключей проще, чем через моки.
+type memStorage struct {
+	objects map[string][]byte
+	deleted []string
+}
+
+func newMemStorage(keys ...string) *memStorage {
+	s := &memStorage{objects: make(map[string][]byte)}
+	for _, k := range keys {
+		s.objects[k] = []byte("content of " + k)
+	}
+	return s
+}
+
+func (s *memStorage) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *memStorage) GetObject(_ context.Context, key string) (io.ReadCloser, error) {
+	b, ok := s.objects[key]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memStorage) PutObject(_ context.Context, key, _ string, body io.Reader) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = b
+	return nil
+}
+
+func (s *memStorage) DeleteObjects(_ context.Context, keys []string) error {
+	for _, k := range keys {
+		delete(s.objects, k)
+	}
+	s.deleted = append(s.deleted, keys...)
+	return nil
+}
+
+func (s *memStorage) PresignGetObject(_ context.Context, key string, _ time.Duration) (string, error) {
+	return "https://minio.example.com/" + key + "?signature", nil
+}
+
+// ── helpers ────────────────────────────────────────────────────────────────
+
+type testDeps struct {
+	userRepo    *mockUserInfoRepo
+	accountRepo *mockAccountRepo
+	tasksRepo   *mockTasksRepo
+	storage     *memStorage
+}
+
+func newTestService(storage *memStorage) (*Service, testDeps) {
+	d := testDeps{
+		userRepo:    &mockUserInfoRepo{},
+		accountRepo: &mockAccountRepo{},
+		tasksRepo:   &mockTasksRepo{},
+		storage:     storage,
+	}
+	svc := NewService(&Config{
+		TransactionManager: &mockTxManager{},
+		UserInfoRepository: d.userRepo,
+		AccountRepository:  d.accountRepo,
+		TasksRepository:    d.tasksRepo,
+		ObjectStorage:      storage,
+	})
+	return svc, d
+}
+
+func newUser(id uuid.UUID, emailVerified bool, deletionAt *time.Time) *entities.UserInfo {
+	spec := entities.UserInfoRestoreSpec{
+		ID:                  id,
+		Username:            "user",
+		Email:               "user@example.com",
+		DeletionScheduledAt: deletionAt,
+	}
+	if emailVerified {
+		now := time.Now()
+		spec.EmailVerifiedAt = &now
+	}
+	return entities.NewUserInfo(entities.WithUserInfoRestoreSpec(spec))
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func isTask(typ entities.TaskType) interface{} {
+	return mock.MatchedBy(func(t *entities.Task) bool { return t.TypeNm() == typ })
+}
+
+// ── ScheduleDeletion / CancelDeletion ──────────────────────────────────────
+
+func TestService_ScheduleDeletion(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("schedules purge task after grace period and notifies verified email", func(t *testing.T) {
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, true).Return(newUser(userID, true, nil), nil)
+		d.userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+			return u.IsDeletionScheduled()
+		})).Return(nil)
+
+		var deleteTask *entities.Task
+		d.tasksRepo.On("Create", mock.Anything, isTask(entities.TaskTypeDeleteAccount)).
+			Run(func(args mock.Arguments) { deleteTask = args.Get(1).(*entities.Task) }).Return(nil)
+		d.tasksRepo.On("Create", mock.Anything, isTask(entities.TaskTypeSendNotificationEmail)).Return(nil)
+
+		at, err := svc.ScheduleDeletion(context.Background(), userID)
+
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(DefaultDeletionGracePeriod), at, time.Minute)
+		require.NotNil(t, deleteTask)
+		assert.Equal(t, at, deleteTask.RetryAt())
+		assert.False(t, deleteTask.IsAvailableForExecution())
+		payload, err := entities.TaskKindDeleteAccount.Payload(deleteTask)
+		require.NoError(t, err)
+		assert.Equal(t, userID, payload.UserID)
+		assert.Zero(t, deleteTask.MaxAttempts(), "limit comes from the handler policy")
+		d.userRepo.AssertExpectations(t)
+		d.tasksRepo.AssertExpectations(t)
+	})
+
+	t.Run("repeated request keeps the original date", func(t *testing.T) {
+		scheduled := time.Now().Add(48 * time.Hour)
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, true).Return(newUser(userID, true, &scheduled), nil)
+
+		at, err := svc.ScheduleDeletion(context.Background(), userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, scheduled, at)
+		d.userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		d.tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_CancelDeletion(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("clears scheduled deletion", func(t *testing.T) {
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, true).
+			Return(newUser(userID, true, timePtr(time.Now().Add(time.Hour))), nil)
+		d.userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+			return !u.IsDeletionScheduled()
+		})).Return(nil)
+
+		require.NoError(t, svc.CancelDeletion(context.Background(), userID))
+		d.userRepo.AssertExpectations(t)
+	})
+
+	t.Run("nothing to cancel", func(t *testing.T) {
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, true).Return(newUser(userID, true, nil), nil)
+
+		err := svc.CancelDeletion(context.Background(), userID)
+		assert.ErrorIs(t, err, errs.ErrDeletionNotScheduled)
+	})
+}
+
+// ── PurgeAccount ───────────────────────────────────────────────────────────
+
+func TestService_PurgeAccount(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	t.Run("removes objects and rows when due", func(t *testing.T) {
+		storage := newMemStorage(
+			userID.String(),
+			"food-photos/"+userID.String()+"/a.jpg",
+			"exports/"+userID.String()+"/old.zip",
+			otherID.String(),
+			"food-photos/"+otherID.String()+"/b.jpg",
+		)
+		svc, d := newTestService(storage)
+		d.userRepo.On("Get", mock.Anything, mock.Anything, true).
+			Return(newUser(userID, false, timePtr(time.Now().Add(-time.Minute))), nil)
+		d.accountRepo.On("Purge", mock.Anything, userID).Return(nil)
+
+		require.NoError(t, svc.PurgeAccount(context.Background(), userID))
+
+		assert.ElementsMatch(t, []string{
+			userID.String(),
+			"food-photos/" + userID.String() + "/a.jpg",
+			"exports/" + userID.String() + "/old.zip",
+		}, storage.deleted)
+		assert.Len(t, storage.objects, 2)
+		d.accountRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips cancelled or not yet due deletion", func(t *testing.T) {
+		for _, at := range []*time.Time{nil, timePtr(time.Now().Add(time.Hour))} {
+			storage := newMemStorage(userID.String())
+			svc, d := newTestService(storage)
+			d.userRepo.On("Get", mock.Anything, mock.Anything, true).Return(newUser(userID, false, at), nil)
+
+			require.NoError(t, svc.PurgeAccount(context.Background(), userID))
+			assert.Empty(t, storage.deleted)
+			d.accountRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("already deleted user", func(t *testing.T) {
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, true).Return(nil, errs.ErrUserInfoNotFound)
+
+		assert.NoError(t, svc.PurgeAccount(context.Background(), userID))
+	})
+}
+
+// ── RequestExport / ExportUserData ─────────────────────────────────────────
+
+func TestService_RequestExport(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("requires verified email", func(t *testing.T) {
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, false).Return(newUser(userID, false, nil), nil)
+
+		err := svc.RequestExport(context.Background(), userID)
+		assert.ErrorIs(t, err, errs.ErrExportEmailRequired)
+	})
+
+	t.Run("enqueues export task once", func(t *testing.T) {
+		svc, d := newTestService(newMemStorage())
+		d.userRepo.On("Get", mock.Anything, mock.Anything, false).Return(newUser(userID, true, nil), nil)
+		d.tasksRepo.On("List", mock.Anything, mock.Anything, false).Return([]*entities.Task{}, nil).Once()
+		d.tasksRepo.On("Create", mock.Anything, isTask(entities.TaskTypeExportUserData)).Return(nil).Once()
+
+		require.NoError(t, svc.RequestExport(context.Background(), userID))
+
+		pending := entities.TaskKindExportUserData.NewTask(entities.AccountTaskPayload{UserID: userID}, entities.TaskSchedule{})
+		d.tasksRepo.On("List", mock.Anything, mock.Anything, false).Return([]*entities.Task{pending}, nil).Once()
+
+		require.NoError(t, svc.RequestExport(context.Background(), userID))
+		d.tasksRepo.AssertNumberOfCalls(t, "Create", 1)
+	})
+}
+
+func TestService_ExportUserData(t *testing.T) {
+	userID := uuid.New()
+	storage := newMemStorage(
+		userID.String(),
+		"food-photos/"+userID.String()+"/lunch.jpg",
+		"exports/"+userID.String()+"/previous.zip",
+	)
+	svc, d := newTestService(storage)
+	d.userRepo.On("Get", mock.Anything, mock.Anything, false).Return(newUser(userID, true, nil), nil)
+	d.accountRepo.On("ExportSection", mock.Anything, dto.AccountExportProfile, userID).
+		Return([]json.RawMessage{json.RawMessage(`{"username":"user","email":"user@example.com","age":null}`)}, nil)
+	d.accountRepo.On("ExportSection", mock.Anything, dto.AccountExportWeight, userID).
+		Return([]json.RawMessage{
+			json.RawMessage(`{"weight":80.5,"date":"2026-01-01"}`),
+			json.RawMessage(`{"weight":79,"date":"2026-01-08","note":"после отпуска"}`),
+		}, nil)
+	d.accountRepo.On("ExportSection", mock.Anything, mock.Anything, userID).Return([]json.RawMessage{}, nil)
+
+	var email entities.EmailTaskPayload
+	d.tasksRepo.On("Create", mock.Anything, isTask(entities.TaskTypeSendNotificationEmail)).
+		Run(func(args mock.Arguments) {
+			email, _ = entities.TaskKindSendNotificationEmail.Payload(args.Get(1).(*entities.Task))
+		}).Return(nil)
+
+	require.NoError(t, svc.ExportUserData(context.Background(), userID))
+
+	assert.Equal(t, []string{"exports/" + userID.String() + "/previous.zip"}, storage.deleted)
+	exports, _ := storage.ListObjects(context.Background(), "exports/"+userID.String()+"/")
+	require.Len(t, exports, 1)
+
+	archive := storage.objects[exports[0]]
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	for _, section := range dto.AccountExportSections {
+		assert.Contains(t, files, section.String()+".json")
+		assert.Contains(t, files, section.String()+".csv")
+	}
+	assert.Equal(t, "date,note,weight\n2026-01-01,,80.5\n2026-01-08,после отпуска,79\n", files["weight.csv"])
+	assert.Equal(t, "age,email,username\n,user@example.com,user\n", files["profile.csv"])
+	assert.JSONEq(t, `[{"username":"user","email":"user@example.com","age":null}]`, files["profile.json"])
+	assert.Equal(t, "content of food-photos/"+userID.String()+"/lunch.jpg", files["photos/food/lunch.jpg"])
+	assert.Equal(t, "content of "+userID.String(), files["photos/avatar"])
+
+	assert.Equal(t, "user@example.com", email.Email)
+	assert.Equal(t, entities.NotificationTemplateDataExportReady, email.Template)
+	assert.Contains(t, email.Vars["link"], "https://minio.example.com/"+exports[0])
+}
@@ -0,0 +1,28 @@
+package account
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/service/executor"
+	"context"
+	"time"
+)
+
+// exportTaskTimeout — дольше таймаута исполнителя по умолчанию: архив собирается вместе с фотографиями
+const exportTaskTimeout = 2 * time.Minute
+
+// RegisterTaskHandlers регистрирует в исполнителе удаление и выгрузку аккаунта.
+func (s *Service) RegisterTaskHandlers(r *executor.Registry) {
+	executor.Register(r, entities.TaskKindDeleteAccount, executor.Handler[entities.AccountTaskPayload]{
+		Handle: func(ctx context.Context, _ *entities.Task, p entities.AccountTaskPayload) error {
+			return s.PurgeAccount(ctx, p.UserID)
+		},
+		MaxAttempts: deleteTaskMaxAttempts,
+	})
+	executor.Register(r, entities.TaskKindExportUserData, executor.Handler[entities.AccountTaskPayload]{
+		Handle: func(ctx context.Context, _ *entities.Task, p entities.AccountTaskPayload) error {
+			return s.ExportUserData(ctx, p.UserID)
+		},
+		Timeout:     exportTaskTimeout,
+		MaxAttempts: exportTaskMaxAttempts,
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"backend/pkg/logging"
 	"context"
 	"fmt"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -74,7 +75,7 @@ func (u *Service) Register(ctx context.Context, info entities.UserInfoInitSpec)
 }
 
 func (u *Service) Login(ctx context.Context, ua entities.UserAuthInitSpec) (string, error) {
-	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Username: &ua.Username}, false)
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Username: &ua.Login}, false)
 	if err != nil {
 		u.log.Errorf("%s: %v", "login", err)
 		return "", fmt.Errorf("login: %w", err)
@@ -96,7 +97,7 @@ func (u *Service) checkPasswordAndTakeToken(user *entities.UserInfo, pass string
 		return "", errors.ErrInvalidCredentials
 	}
 
-	token, err := JWT.GenerateJWT(user)
+	token, err := JWT.GenerateJWT(user, uuid.Nil)
 	if err != nil {
 		u.log.Errorf("%s: %v", errors.ErrTokenGeneration, err)
 		return "", fmt.Errorf("%w: %v", errors.ErrTokenGeneration, err)
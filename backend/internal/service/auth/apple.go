@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/errors"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// appleUsernamePrefix — новым пользователям Apple выдаётся ник apple_<хэш sub>, его можно сменить в профиле.
+const appleUsernamePrefix = "apple_"
+
+// AppleSignInParams — тело POST /auth/apple. Имя Apple отдаёт только приложению и только при первой
+// авторизации, поэтому оно приходит от клиента, а не из токена.
+type AppleSignInParams struct {
+	IdentityToken string
+	Nonce         string
+	Name          string
+	Surname       string
+}
+
+// SignInWithApple входит по identity token'у Apple. Пользователь ищется по Apple sub; если не найден —
+// привязывается существующий аккаунт с тем же подтверждённым email или создаётся новый.
+func (u *Service) SignInWithApple(ctx context.Context, p AppleSignInParams, meta dto.SessionMetadata) (LoginResult, error) {
+	if u.appleVerifier == nil {
+		return LoginResult{}, fmt.Errorf("sign in with apple: %w", errors.ErrAppleSignInDisabled)
+	}
+
+	claims, err := u.appleVerifier.Verify(ctx, p.IdentityToken, p.Nonce)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("sign in with apple: %w: %v", errors.ErrInvalidAppleToken, err)
+	}
+
+	var user *entities.UserInfo
+	// событие журнала для нового или привязанного аккаунта, пишется после транзакции
+	var accountEvent entities.AuditEventType
+	err = u.txm.Do(ctx, func(ctx context.Context) error {
+		if existing, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{AppleSub: &claims.Subject}, false); err == nil {
+			user = existing
+			return nil
+		}
+
+		if claims.Email == "" {
+			return errors.ErrAppleEmailRequired
+		}
+
+		if existing, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Email: &claims.Email}, true); err == nil {
+			// Привязываем, только если оба email подтверждены: иначе тот, кто зарегистрировался на чужой адрес,
+			// получил бы доступ к аккаунту настоящего владельца, когда тот войдёт через Apple.
+			if !claims.EmailVerified || !existing.IsEmailVerified() || existing.AppleSub() != "" {
+				return errors.ErrAppleAccountConflict
+			}
+			existing.LinkApple(claims.Subject)
+			if err := u.userInfoRepo.Update(ctx, existing); err != nil {
+				return fmt.Errorf("link apple: %w", err)
+			}
+			user = existing
+			accountEvent = entities.AuditEventAppleLinked
+			return nil
+		}
+
+		spec := entities.UserInfoInitSpec{
+			ID:        uuid.New(),
+			Username:  appleUsernamePrefix + hashToken(claims.Subject)[:12],
+			Name:      p.Name,
+			Surname:   p.Surname,
+			Email:     claims.Email,
+			CreatedAt: time.Now(),
+			AppleSub:  claims.Subject,
+		}
+		// адрес private relay тоже считается подтверждённым: Apple пересылает письма на настоящий ящик
+		if claims.EmailVerified || claims.IsPrivateEmail {
+			spec.EmailVerifiedAt = &spec.CreatedAt
+		}
+
+		user = entities.NewUserInfo(entities.WithUserInfoInitSpec(spec))
+		if err := u.userInfoRepo.Create(ctx, user); err != nil {
+			return fmt.Errorf("create user: %w", err)
+		}
+		accountEvent = entities.AuditEventRegister
+		return nil
+	})
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("sign in with apple: %w", err)
+	}
+
+	if accountEvent != "" {
+		u.auditSession(ctx, accountEvent, user.ID(), meta, map[string]string{"method": loginMethodApple})
+	}
+
+	res, err := u.completeLogin(ctx, user, meta, loginMethodApple)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("sign in with apple: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/pkg/logging"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Значения details.method у событий входа.
+const (
+	loginMethodPassword = "password"
+	loginMethodCode     = "code"
+	loginMethodApple    = "apple"
+	loginMethodMFA      = "mfa"
+)
+
+// audit пишет событие в журнал безопасности. IP, User-Agent и автора, если они не заданы в spec,
+// берёт из метаданных запроса. Ошибка записи не прерывает операцию: событие уже произошло,
+// поэтому она только логируется. Вызывать вне транзакции — иначе ошибка записи откатит её.
+func (u *Service) audit(ctx context.Context, spec entities.AuditEventInitSpec) {
+	if u.auditRepo == nil {
+		return
+	}
+
+	meta := dto.RequestMetadataFromContext(ctx)
+	if spec.IP == "" {
+		spec.IP = meta.IP
+	}
+	if spec.UserAgent == "" {
+		spec.UserAgent = meta.UserAgent
+	}
+	if spec.ActorID == nil {
+		spec.ActorID = meta.ActorID
+	}
+
+	if err := u.auditRepo.Create(ctx, entities.NewAuditEvent(entities.WithAuditEventInitSpec(spec))); err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: audit %s: %v", spec.Type, err)
+	}
+}
+
+// auditUser — событие, которое пользователь userID выполнил сам над своим аккаунтом.
+func (u *Service) auditUser(ctx context.Context, eventType entities.AuditEventType, userID uuid.UUID, details map[string]string) {
+	u.audit(ctx, entities.AuditEventInitSpec{
+		UserID:  &userID,
+		ActorID: &userID,
+		Type:    eventType,
+		Details: details,
+	})
+}
+
+// auditSession — событие входа или обновления токенов: клиент берётся из метаданных сессии.
+func (u *Service) auditSession(ctx context.Context, eventType entities.AuditEventType, userID uuid.UUID, meta dto.SessionMetadata, details map[string]string) {
+	u.audit(ctx, entities.AuditEventInitSpec{
+		UserID:    &userID,
+		ActorID:   &userID,
+		Type:      eventType,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		Details:   details,
+	})
+}
+
+// auditFailure — неудачная попытка входа или подтверждения. Автора нет: запрос не аутентифицирован.
+// userID = nil, если пользователь не найден; тогда введённый логин сохраняется в details.login.
+func (u *Service) auditFailure(ctx context.Context, eventType entities.AuditEventType, userID *uuid.UUID, ip, userAgent string, details map[string]string) {
+	u.audit(ctx, entities.AuditEventInitSpec{
+		UserID:    userID,
+		Type:      eventType,
+		IP:        ip,
+		UserAgent: userAgent,
+		Details:   details,
+	})
+}
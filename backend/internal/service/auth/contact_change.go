@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestContactChange начинает смену email (codeType EmailChange) или телефона (PhoneChange).
+// Код уходит на новый адрес, а сам адрес ждёт в коде, пока пользователь не введёт его в ConfirmContactChange.
+// На старый подтверждённый адрес отправляется уведомление о запросе.
+func (u *Service) RequestContactChange(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType, value string) error {
+	throttleID := userID.String() + ":" + string(codeType)
+	if err := u.throttle(ctx, sendCooldownLimit, throttleID); err != nil {
+		return fmt.Errorf("request contact change: %w", err)
+	}
+	if err := u.throttle(ctx, sendHourlyLimit, throttleID); err != nil {
+		return fmt.Errorf("request contact change: %w", err)
+	}
+
+	value = strings.TrimSpace(value)
+	if codeType == entities.VerificationCodeEmailChange {
+		value = strings.ToLower(value)
+	}
+
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return fmt.Errorf("request contact change: %w", err)
+	}
+
+	var current string
+	var verified bool
+	switch codeType {
+	case entities.VerificationCodeEmailChange:
+		current, verified = user.Email(), user.IsEmailVerified()
+	case entities.VerificationCodePhoneChange:
+		current, verified = user.Phone(), user.IsPhoneVerified()
+	default:
+		return fmt.Errorf("request contact change: unknown code type %s", codeType)
+	}
+	if strings.EqualFold(value, current) {
+		return fmt.Errorf("request contact change: %w", errs.ErrContactUnchanged)
+	}
+	if err := u.checkContactFree(ctx, userID, codeType, value); err != nil {
+		return fmt.Errorf("request contact change: %w", err)
+	}
+
+	code, codeHash, err := generateVerificationCode()
+	if err != nil {
+		return fmt.Errorf("request contact change: %w", err)
+	}
+
+	vcEntity := entities.NewUserVerificationCode(entities.WithUserVerificationCodeInitSpec(entities.UserVerificationCodeInitSpec{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  codeHash,
+		CodeType:  codeType,
+		Target:    value,
+		ExpiresAt: time.Now().Add(verificationCodeTTL),
+	}))
+
+	tasks := []*entities.Task{contactChangeCodeTask(userID, codeType, value, code)}
+	if verified {
+		tasks = append(tasks, contactChangeNoticeTask(userID, codeType, current, value))
+	}
+
+	err = u.txm.Do(ctx, func(ctx context.Context) error {
+		if err := u.verificationCodesRepo.Create(ctx, vcEntity); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if err := u.tasksRepo.Create(ctx, task); err != nil {
+				return fmt.Errorf("create task: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("request contact change: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventContactChangeRequested, userID, map[string]string{
+		"code_type": string(codeType),
+		"target":    value,
+	})
+	return nil
+}
+
+// ConfirmContactChange проверяет код с нового адреса и одной записью меняет адрес и время его подтверждения.
+func (u *Service) ConfirmContactChange(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType, code string) error {
+	account := userID.String() + ":" + string(codeType)
+	if err := u.checkLocked(ctx, verifyAccountPolicy, account); err != nil {
+		return fmt.Errorf("confirm contact change: %w", err)
+	}
+
+	record, err := u.verificationCodesRepo.GetLatest(ctx, dto.UserVerificationCodeFilter{
+		UserID:   &userID,
+		CodeType: &codeType,
+	})
+	if err != nil {
+		return fmt.Errorf("confirm contact change: %w", errs.ErrInvalidVerificationCode)
+	}
+	if record.IsExpired() {
+		return fmt.Errorf("confirm contact change: %w", errs.ErrVerificationCodeExpired)
+	}
+	if record.IsUsed() {
+		return fmt.Errorf("confirm contact change: %w", errs.ErrVerificationCodeAlreadyUsed)
+	}
+
+	if hashToken(code) != record.CodeHash() {
+		u.registerFailure(ctx, verifyAccountPolicy, account)
+		return fmt.Errorf("confirm contact change: %w", u.rejectCode(ctx, record.ID()))
+	}
+	u.resetFailures(ctx, verifyAccountPolicy, account)
+
+	target := record.Target()
+	var field, previous string
+	err = u.txm.Do(ctx, func(ctx context.Context) error {
+		user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, true)
+		if err != nil {
+			return fmt.Errorf("get user: %w", err)
+		}
+		// адрес мог занять кто-то другой, пока код шёл к пользователю
+		if err := u.checkContactFree(ctx, userID, codeType, target); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		params := entities.UserInfoUpdateParams{}
+		switch codeType {
+		case entities.VerificationCodeEmailChange:
+			params.Email, params.EmailVerifiedAt = &target, &now
+			field, previous = "email", user.Email()
+		case entities.VerificationCodePhoneChange:
+			params.Phone, params.PhoneVerifiedAt = &target, &now
+			field, previous = "phone", user.Phone()
+		default:
+			return fmt.Errorf("unknown code type %s", codeType)
+		}
+		user.Update(params)
+
+		if err := u.userInfoRepo.Update(ctx, user); err != nil {
+			return fmt.Errorf("update user: %w", err)
+		}
+		return u.verificationCodesRepo.MarkUsed(ctx, record.ID())
+	})
+	if err != nil {
+		return fmt.Errorf("confirm contact change: %w", err)
+	}
+
+	u.audit(ctx, entities.AuditEventInitSpec{
+		UserID:  &userID,
+		ActorID: &userID,
+		Type:    entities.AuditEventContactChanged,
+		Changes: map[string]entities.AuditChange{field: {Old: previous, New: target}},
+	})
+	return nil
+}
+
+// checkContactFree проверяет, что email не занят другим пользователем (он уникален), а телефон — не подтверждён
+// другим пользователем: неподтверждённые телефоны могут совпадать.
+func (u *Service) checkContactFree(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType, value string) error {
+	f := dto.UserInfoFilter{}
+	if codeType == entities.VerificationCodeEmailChange {
+		f.Email = &value
+	} else {
+		verified := true
+		f.Phone, f.PhoneVerified = &value, &verified
+	}
+
+	other, err := u.userInfoRepo.Get(ctx, f, false)
+	if errors.Is(err, errs.ErrUserInfoNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check contact: %w", err)
+	}
+	if other.ID() != userID {
+		return errs.ErrContactTaken
+	}
+	return nil
+}
+
+func contactChangeCodeTask(userID uuid.UUID, codeType entities.VerificationCodeType, value, code string) *entities.Task {
+	if codeType == entities.VerificationCodeEmailChange {
+		return entities.TaskKindSendCodeOnEmail.NewTask(entities.EmailTaskPayload{
+			UserID:   userID,
+			Email:    value,
+			Template: entities.NotificationTemplateEmailChangeCode,
+			Code:     code,
+		}, entities.TaskSchedule{})
+	}
+	return entities.TaskKindSendCodeOnPhone.NewTask(entities.SMSTaskPayload{
+		UserID:   userID,
+		Phone:    value,
+		Template: entities.NotificationTemplatePhoneChangeCode,
+		Code:     code,
+	}, entities.TaskSchedule{})
+}
+
+// contactChangeNoticeTask предупреждает старый адрес о запросе смены. Задача отправки кода, а не уведомления:
+// уведомления уходят только на текущий подтверждённый адрес, а старый к моменту отправки может уже смениться.
+func contactChangeNoticeTask(userID uuid.UUID, codeType entities.VerificationCodeType, current, value string) *entities.Task {
+	if codeType == entities.VerificationCodeEmailChange {
+		return entities.TaskKindSendCodeOnEmail.NewTask(entities.EmailTaskPayload{
+			UserID:   userID,
+			Email:    current,
+			Template: entities.NotificationTemplateEmailChangeRequested,
+			Vars:     map[string]string{"new_email": value},
+		}, entities.TaskSchedule{})
+	}
+	return entities.TaskKindSendCodeOnPhone.NewTask(entities.SMSTaskPayload{
+		UserID:   userID,
+		Phone:    current,
+		Template: entities.NotificationTemplatePhoneChangeRequested,
+	}, entities.TaskSchedule{})
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"backend/internal/errors"
+	"backend/pkg/logging"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCodeAttempts — после стольких неверных вводов код подтверждения сжигается, нужно запросить новый.
+const maxCodeAttempts = 5
+
+// attemptPolicy — лимит неудачных попыток на один идентификатор (аккаунт, IP).
+// После maxFails неудач в окне идентификатор блокируется на baseLock, каждая следующая неудача
+// удваивает блокировку до maxLock.
+type attemptPolicy struct {
+	name     string
+	maxFails int64
+	window   time.Duration
+	baseLock time.Duration
+	maxLock  time.Duration
+}
+
+// sendLimit — не больше limit запросов в окне, без блокировок.
+type sendLimit struct {
+	name   string
+	limit  int64
+	window time.Duration
+}
+
+var (
+	loginAccountPolicy  = attemptPolicy{name: "login:account", maxFails: 5, window: time.Hour, baseLock: time.Minute, maxLock: time.Hour}
+	loginIPPolicy       = attemptPolicy{name: "login:ip", maxFails: 20, window: time.Hour, baseLock: time.Minute, maxLock: time.Hour}
+	verifyAccountPolicy = attemptPolicy{name: "verify:account", maxFails: 10, window: time.Hour, baseLock: 5 * time.Minute, maxLock: 6 * time.Hour}
+	resetAccountPolicy  = attemptPolicy{name: "reset:account", maxFails: 10, window: time.Hour, baseLock: 5 * time.Minute, maxLock: 6 * time.Hour}
+	resetIPPolicy       = attemptPolicy{name: "reset:ip", maxFails: 30, window: time.Hour, baseLock: time.Minute, maxLock: time.Hour}
+	mfaAccountPolicy    = attemptPolicy{name: "mfa:account", maxFails: 5, window: time.Hour, baseLock: time.Minute, maxLock: time.Hour}
+
+	sendCooldownLimit = sendLimit{name: "send:cooldown", limit: 1, window: time.Minute}
+	sendHourlyLimit   = sendLimit{name: "send:hourly", limit: 5, window: time.Hour}
+	recoverIPLimit    = sendLimit{name: "recover:ip", limit: 20, window: time.Hour}
+	loginCodeIPLimit  = sendLimit{name: "login-code:ip", limit: 20, window: time.Hour}
+)
+
+func (p attemptPolicy) failsKey(id string) string { return "auth:" + p.name + ":fails:" + id }
+func (p attemptPolicy) lockKey(id string) string  { return "auth:" + p.name + ":lock:" + id }
+
+// lockFor — длительность блокировки после n-й неудачи (n >= maxFails).
+func (p attemptPolicy) lockFor(n int64) time.Duration {
+	shift := n - p.maxFails
+	if shift >= 30 {
+		return p.maxLock
+	}
+	lock := p.baseLock << shift
+	if lock > p.maxLock {
+		return p.maxLock
+	}
+	return lock
+}
+
+func codeAttemptsKey(codeID uuid.UUID) string { return "auth:code:fails:" + codeID.String() }
+
+func accountKey(login string) string { return strings.ToLower(strings.TrimSpace(login)) }
+
+// Ошибки хранилища счётчиков не блокируют вход: при недоступном Redis/Postgres лимиты временно не работают.
+
+// checkLocked возвращает TooManyAttemptsError, если идентификатор заблокирован политикой.
+func (u *Service) checkLocked(ctx context.Context, p attemptPolicy, id string) error {
+	if u.attempts == nil || id == "" {
+		return nil
+	}
+
+	ttl, err := u.attempts.TTL(ctx, p.lockKey(id))
+	if err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: check %s lock: %v", p.name, err)
+		return nil
+	}
+	if ttl > 0 {
+		return &errors.TooManyAttemptsError{RetryAfter: ttl}
+	}
+	return nil
+}
+
+// registerFailure засчитывает неудачную попытку и при превышении лимита ставит блокировку.
+func (u *Service) registerFailure(ctx context.Context, p attemptPolicy, id string) {
+	if u.attempts == nil || id == "" {
+		return
+	}
+
+	n, err := u.attempts.Incr(ctx, p.failsKey(id), p.window)
+	if err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: register %s failure: %v", p.name, err)
+		return
+	}
+	if n < p.maxFails {
+		return
+	}
+
+	lock := p.lockFor(n)
+	if _, err := u.attempts.Incr(ctx, p.lockKey(id), lock); err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: lock %s: %v", p.name, err)
+		return
+	}
+	logging.GetLoggerFromContext(ctx).WithFields(logging.Fields{
+		"policy": p.name,
+		"id":     id,
+		"fails":  n,
+	}).Warnf("auth: locked for %s after %d failed attempts", lock, n)
+}
+
+// resetFailures сбрасывает счётчик после успешной попытки.
+func (u *Service) resetFailures(ctx context.Context, p attemptPolicy, id string) {
+	if u.attempts == nil || id == "" {
+		return
+	}
+
+	if err := u.attempts.Del(ctx, p.failsKey(id), p.lockKey(id)); err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: reset %s failures: %v", p.name, err)
+	}
+}
+
+// throttle пропускает не больше l.limit запросов за окно.
+func (u *Service) throttle(ctx context.Context, l sendLimit, id string) error {
+	if u.attempts == nil || id == "" {
+		return nil
+	}
+
+	key := "auth:" + l.name + ":" + id
+	n, err := u.attempts.Incr(ctx, key, l.window)
+	if err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: throttle %s: %v", l.name, err)
+		return nil
+	}
+	if n <= l.limit {
+		return nil
+	}
+
+	ttl, err := u.attempts.TTL(ctx, key)
+	if err != nil || ttl <= 0 {
+		ttl = l.window
+	}
+	return &errors.TooManyAttemptsError{RetryAfter: ttl}
+}
+
+// codeAttemptsExhausted засчитывает неверный ввод кода и сообщает, пора ли его сжечь.
+func (u *Service) codeAttemptsExhausted(ctx context.Context, codeID uuid.UUID) bool {
+	if u.attempts == nil {
+		return false
+	}
+
+	n, err := u.attempts.Incr(ctx, codeAttemptsKey(codeID), verificationCodeTTL)
+	if err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: register code failure: %v", err)
+		return false
+	}
+	return n >= maxCodeAttempts
+}
+
+// rejectCode обрабатывает неверный код: после maxCodeAttempts неудач код помечается использованным.
+func (u *Service) rejectCode(ctx context.Context, codeID uuid.UUID) error {
+	if !u.codeAttemptsExhausted(ctx, codeID) {
+		return errors.ErrInvalidVerificationCode
+	}
+
+	if err := u.verificationCodesRepo.MarkUsed(ctx, codeID); err != nil {
+		return fmt.Errorf("invalidate code: %w", err)
+	}
+	return errors.ErrVerificationCodeAttemptsLimit
+}
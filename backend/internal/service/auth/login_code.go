@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/errors"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SendLoginCode отправляет одноразовый код входа без пароля: на email, если вход по email, в SMS, если по
+// телефону, а для ника — на подтверждённый email, иначе на подтверждённый телефон. Как и SendRecoveryCode,
+// не сообщает, существует ли пользователь.
+func (u *Service) SendLoginCode(ctx context.Context, login, ip string) error {
+	account := "login:" + accountKey(login)
+	if err := u.throttle(ctx, loginCodeIPLimit, ip); err != nil {
+		return fmt.Errorf("send login code: %w", err)
+	}
+	if err := u.throttle(ctx, sendCooldownLimit, account); err != nil {
+		return fmt.Errorf("send login code: %w", err)
+	}
+	if err := u.throttle(ctx, sendHourlyLimit, account); err != nil {
+		return fmt.Errorf("send login code: %w", err)
+	}
+
+	user, err := u.findByLogin(ctx, login)
+	if err != nil {
+		// don't reveal if user exists
+		return nil
+	}
+
+	taskType, ok := loginCodeChannel(user, login)
+	if !ok {
+		return nil
+	}
+
+	code, codeHash, err := generateVerificationCode()
+	if err != nil {
+		return fmt.Errorf("send login code: %w", err)
+	}
+
+	vcEntity := entities.NewUserVerificationCode(entities.WithUserVerificationCodeInitSpec(entities.UserVerificationCodeInitSpec{
+		ID:        uuid.New(),
+		UserID:    user.ID(),
+		CodeHash:  codeHash,
+		CodeType:  entities.VerificationCodeLogin,
+		ExpiresAt: time.Now().Add(verificationCodeTTL),
+	}))
+
+	if err := u.verificationCodesRepo.Create(ctx, vcEntity); err != nil {
+		return fmt.Errorf("send login code: %w", err)
+	}
+
+	var task *entities.Task
+	switch taskType {
+	case entities.TaskTypeSendCodeOnEmail:
+		task = entities.TaskKindSendCodeOnEmail.NewTask(entities.EmailTaskPayload{
+			UserID:   user.ID(),
+			Email:    user.Email(),
+			Template: entities.NotificationTemplateLoginCode,
+			Code:     code,
+		}, entities.TaskSchedule{})
+	case entities.TaskTypeSendCodeOnPhone:
+		task = entities.TaskKindSendCodeOnPhone.NewTask(entities.SMSTaskPayload{
+			UserID:   user.ID(),
+			Phone:    user.Phone(),
+			Template: entities.NotificationTemplateLoginCode,
+			Code:     code,
+		}, entities.TaskSchedule{})
+	}
+
+	if err := u.tasksRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("send login code: create task: %w", err)
+	}
+
+	userID := user.ID()
+	u.auditFailure(ctx, entities.AuditEventLoginCodeRequested, &userID, ip, "", nil)
+	return nil
+}
+
+// LoginWithCode — вход по коду из SendLoginCode. Неудачи считаются теми же политиками, что и вход по паролю.
+func (u *Service) LoginWithCode(ctx context.Context, login, code string, meta dto.SessionMetadata) (LoginResult, error) {
+	account := accountKey(login)
+	if err := u.checkLocked(ctx, loginAccountPolicy, account); err != nil {
+		return LoginResult{}, fmt.Errorf("login with code: %w", err)
+	}
+	if err := u.checkLocked(ctx, loginIPPolicy, meta.IP); err != nil {
+		return LoginResult{}, fmt.Errorf("login with code: %w", err)
+	}
+
+	user, err := u.findByLogin(ctx, login)
+	if err != nil {
+		u.registerFailure(ctx, loginAccountPolicy, account)
+		u.registerFailure(ctx, loginIPPolicy, meta.IP)
+		u.auditFailure(ctx, entities.AuditEventLoginFailed, nil, meta.IP, meta.UserAgent, map[string]string{
+			"method": loginMethodCode, "login": login, "reason": "unknown_user",
+		})
+		return LoginResult{}, fmt.Errorf("login with code: %w", errors.ErrInvalidVerificationCode)
+	}
+
+	account = accountKey(user.Username())
+	if err := u.checkLocked(ctx, loginAccountPolicy, account); err != nil {
+		return LoginResult{}, fmt.Errorf("login with code: %w", err)
+	}
+
+	userID := user.ID()
+	codeType := entities.VerificationCodeLogin
+	record, err := u.verificationCodesRepo.GetLatest(ctx, dto.UserVerificationCodeFilter{
+		UserID:   &userID,
+		CodeType: &codeType,
+	})
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("login with code: %w", errors.ErrInvalidVerificationCode)
+	}
+	if record.IsExpired() {
+		return LoginResult{}, fmt.Errorf("login with code: %w", errors.ErrVerificationCodeExpired)
+	}
+	if record.IsUsed() {
+		return LoginResult{}, fmt.Errorf("login with code: %w", errors.ErrVerificationCodeAlreadyUsed)
+	}
+
+	if hashToken(code) != record.CodeHash() {
+		u.registerFailure(ctx, loginAccountPolicy, account)
+		u.registerFailure(ctx, loginIPPolicy, meta.IP)
+		u.auditFailure(ctx, entities.AuditEventLoginFailed, &userID, meta.IP, meta.UserAgent, map[string]string{
+			"method": loginMethodCode, "reason": "invalid_code",
+		})
+		return LoginResult{}, fmt.Errorf("login with code: %w", u.rejectCode(ctx, record.ID()))
+	}
+
+	if err := u.verificationCodesRepo.MarkUsed(ctx, record.ID()); err != nil {
+		return LoginResult{}, fmt.Errorf("login with code: mark used: %w", err)
+	}
+	u.resetFailures(ctx, loginAccountPolicy, account)
+
+	res, err := u.completeLogin(ctx, user, meta, loginMethodCode)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("login with code: %w", err)
+	}
+
+	return res, nil
+}
+
+// loginCodeChannel выбирает, куда отправить код входа. Код уходит только на подтверждённые контакты.
+func loginCodeChannel(user *entities.UserInfo, login string) (entities.TaskType, bool) {
+	login = strings.TrimSpace(login)
+	switch {
+	case login == user.Username():
+	case strings.Contains(login, "@"):
+		return entities.TaskTypeSendCodeOnEmail, user.IsEmailVerified()
+	case phonePattern.MatchString(login):
+		return entities.TaskTypeSendCodeOnPhone, user.IsPhoneVerified()
+	}
+
+	if user.IsEmailVerified() {
+		return entities.TaskTypeSendCodeOnEmail, true
+	}
+	if user.IsPhoneVerified() {
+		return entities.TaskTypeSendCodeOnPhone, true
+	}
+	return "", false
+}
@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/errors"
+	"backend/pkg/JWT"
+	"backend/pkg/totp"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	totpIssuer = "BodyFuel"
+
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet без похожих символов (0/o, 1/l/i), код печатается как xxxx-xxxx.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeLen      = 8
+)
+
+// TOTPEnrollment — данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type MFAStatus struct {
+	TOTPEnabled       bool
+	EnabledAt         *time.Time
+	RecoveryCodesLeft int
+}
+
+// GetMFAStatus возвращает состояние 2FA пользователя.
+func (u *Service) GetMFAStatus(ctx context.Context, userID uuid.UUID) (MFAStatus, error) {
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return MFAStatus{}, fmt.Errorf("mfa status: %w", err)
+	}
+	if !user.IsTOTPEnabled() {
+		return MFAStatus{}, nil
+	}
+
+	left, err := u.recoveryCodesRepo.CountUnused(ctx, userID)
+	if err != nil {
+		return MFAStatus{}, fmt.Errorf("mfa status: %w", err)
+	}
+
+	return MFAStatus{TOTPEnabled: true, EnabledAt: user.TOTPEnabledAt(), RecoveryCodesLeft: left}, nil
+}
+
+// EnrollTOTP генерирует новый секрет. 2FA не включается, пока пользователь не подтвердит его кодом
+// через ConfirmTOTP; повторный вызов до подтверждения заменяет секрет.
+func (u *Service) EnrollTOTP(ctx context.Context, userID uuid.UUID) (TOTPEnrollment, error) {
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("enroll totp: %w", err)
+	}
+	if user.IsTOTPEnabled() {
+		return TOTPEnrollment{}, fmt.Errorf("enroll totp: %w", errors.ErrMFAAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("enroll totp: %w", err)
+	}
+
+	user.StartTOTPEnrollment(secret)
+	if err := u.userInfoRepo.Update(ctx, user); err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("enroll totp: %w", err)
+	}
+
+	account := user.Email()
+	if account == "" {
+		account = user.Username()
+	}
+
+	return TOTPEnrollment{Secret: secret, URI: totp.URI(totpIssuer, account, secret)}, nil
+}
+
+// ConfirmTOTP включает 2FA после проверки первого кода и возвращает коды восстановления.
+// Коды показываются один раз, в базе хранятся только их хэши.
+func (u *Service) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	account := userID.String()
+	if err := u.checkLocked(ctx, mfaAccountPolicy, account); err != nil {
+		return nil, fmt.Errorf("confirm totp: %w", err)
+	}
+
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return nil, fmt.Errorf("confirm totp: %w", err)
+	}
+	if user.IsTOTPEnabled() {
+		return nil, fmt.Errorf("confirm totp: %w", errors.ErrMFAAlreadyEnabled)
+	}
+	if user.TOTPSecret() == "" {
+		return nil, fmt.Errorf("confirm totp: %w", errors.ErrMFAEnrollmentNotStarted)
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret(), code, time.Now(), 0)
+	if !ok {
+		u.registerFailure(ctx, mfaAccountPolicy, account)
+		u.auditUser(ctx, entities.AuditEventMFAFailed, userID, map[string]string{"reason": "invalid_code"})
+		return nil, fmt.Errorf("confirm totp: %w", errors.ErrInvalidMFACode)
+	}
+	u.resetFailures(ctx, mfaAccountPolicy, account)
+
+	codes, records, err := generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, fmt.Errorf("confirm totp: %w", err)
+	}
+
+	user.EnableTOTP(step)
+	err = u.txm.Do(ctx, func(ctx context.Context) error {
+		if err := u.userInfoRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return u.recoveryCodesRepo.Replace(ctx, userID, records)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("confirm totp: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventMFAEnabled, userID, map[string]string{"factor": "totp"})
+	return codes, nil
+}
+
+// DisableTOTP выключает 2FA. Требует действующий TOTP-код или код восстановления.
+func (u *Service) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := u.checkSecondFactor(ctx, userID, code)
+	if err != nil {
+		return fmt.Errorf("disable totp: %w", err)
+	}
+
+	user.DisableTOTP()
+	err = u.txm.Do(ctx, func(ctx context.Context) error {
+		if err := u.userInfoRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return u.recoveryCodesRepo.DeleteByUser(ctx, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("disable totp: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventMFADisabled, userID, map[string]string{"factor": "totp"})
+	return nil
+}
+
+// RegenerateRecoveryCodes выпускает новый набор кодов восстановления, старые перестают действовать.
+func (u *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if _, err := u.checkSecondFactor(ctx, userID, code); err != nil {
+		return nil, fmt.Errorf("regenerate recovery codes: %w", err)
+	}
+
+	codes, records, err := generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, fmt.Errorf("regenerate recovery codes: %w", err)
+	}
+
+	if err := u.txm.Do(ctx, func(ctx context.Context) error {
+		return u.recoveryCodesRepo.Replace(ctx, userID, records)
+	}); err != nil {
+		return nil, fmt.Errorf("regenerate recovery codes: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventRecoveryCodesRegenerated, userID, nil)
+	return codes, nil
+}
+
+// VerifyMFA — второй шаг входа: обменивает MFA-токен из Login и TOTP-код (или код восстановления)
+// на пару токенов. Устройство и платформа берутся из первого шага, если клиент их не повторил.
+func (u *Service) VerifyMFA(ctx context.Context, mfaToken, code string, meta dto.SessionMetadata) (TokenPair, error) {
+	challenge, err := JWT.ParseMFAChallenge(mfaToken)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("verify mfa: %w", errors.ErrInvalidMFAChallenge)
+	}
+
+	user, err := u.checkSecondFactor(ctx, challenge.UserID, code)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("verify mfa: %w", err)
+	}
+
+	if meta.DeviceName == "" {
+		meta.DeviceName = challenge.DeviceName
+	}
+	if meta.Platform == "" {
+		meta.Platform = challenge.Platform
+	}
+
+	pair, err := u.startSession(ctx, user, meta, loginMethodMFA)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("verify mfa: %w", err)
+	}
+
+	return pair, nil
+}
+
+// checkSecondFactor проверяет TOTP-код или код восстановления пользователя с включённой 2FA.
+// Принятый TOTP-шаг и использованный код восстановления сразу сохраняются, повторно их не принять.
+func (u *Service) checkSecondFactor(ctx context.Context, userID uuid.UUID, code string) (*entities.UserInfo, error) {
+	account := userID.String()
+	if err := u.checkLocked(ctx, mfaAccountPolicy, account); err != nil {
+		return nil, err
+	}
+
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTOTPEnabled() {
+		return nil, errors.ErrMFANotEnabled
+	}
+
+	if step, ok := totp.Validate(user.TOTPSecret(), code, time.Now(), user.TOTPLastStep()); ok {
+		user.UseTOTPStep(step)
+		if err := u.userInfoRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+		u.resetFailures(ctx, mfaAccountPolicy, account)
+		return user, nil
+	}
+
+	used, err := u.recoveryCodesRepo.Use(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		u.registerFailure(ctx, mfaAccountPolicy, account)
+		u.auditFailure(ctx, entities.AuditEventMFAFailed, &userID, "", "", map[string]string{"reason": "invalid_code"})
+		return nil, errors.ErrInvalidMFACode
+	}
+
+	u.resetFailures(ctx, mfaAccountPolicy, account)
+	return user, nil
+}
+
+// generateRecoveryCodes возвращает коды для показа пользователю и сущности с их хэшами для сохранения.
+func generateRecoveryCodes(userID uuid.UUID) ([]string, []*entities.UserRecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]*entities.UserRecoveryCode, 0, recoveryCodeCount)
+
+	alphabetLen := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeLen)
+		for j := range raw {
+			n, err := rand.Int(rand.Reader, alphabetLen)
+			if err != nil {
+				return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+			}
+			raw[j] = recoveryCodeAlphabet[n.Int64()]
+		}
+
+		code := string(raw[:recoveryCodeLen/2]) + "-" + string(raw[recoveryCodeLen/2:])
+		codes = append(codes, code)
+		records = append(records, entities.NewUserRecoveryCode(entities.WithUserRecoveryCodeInitSpec(entities.UserRecoveryCodeInitSpec{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})))
+	}
+
+	return codes, records, nil
+}
+
+// normalizeRecoveryCode прощает регистр, пробелы и дефис при вводе кода восстановления.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
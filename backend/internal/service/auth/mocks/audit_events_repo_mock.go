@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"backend/internal/domain/entities"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditEventsRepository struct {
+	mock.Mock
+}
+
+func (_m *AuditEventsRepository) Create(ctx context.Context, e *entities.AuditEvent) error {
+	ret := _m.Called(ctx, e)
+	return ret.Error(0)
+}
+
+func NewAuditEventsRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditEventsRepository {
+	m := &AuditEventsRepository{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"backend/internal/domain/entities"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type UserPersonalTokensRepository struct {
+	mock.Mock
+}
+
+func (_m *UserPersonalTokensRepository) Create(ctx context.Context, t *entities.UserPersonalToken) error {
+	ret := _m.Called(ctx, t)
+	return ret.Error(0)
+}
+
+func (_m *UserPersonalTokensRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.UserPersonalToken, error) {
+	ret := _m.Called(ctx, tokenHash)
+	var r0 *entities.UserPersonalToken
+	if v := ret.Get(0); v != nil {
+		r0 = v.(*entities.UserPersonalToken)
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *UserPersonalTokensRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*entities.UserPersonalToken, error) {
+	ret := _m.Called(ctx, userID)
+	var r0 []*entities.UserPersonalToken
+	if v := ret.Get(0); v != nil {
+		r0 = v.([]*entities.UserPersonalToken)
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *UserPersonalTokensRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	ret := _m.Called(ctx, userID)
+	return ret.Int(0), ret.Error(1)
+}
+
+func (_m *UserPersonalTokensRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	ret := _m.Called(ctx, id, userID)
+	return ret.Error(0)
+}
+
+func (_m *UserPersonalTokensRepository) Touch(ctx context.Context, id uuid.UUID, ip string) error {
+	ret := _m.Called(ctx, id, ip)
+	return ret.Error(0)
+}
+
+func NewUserPersonalTokensRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserPersonalTokensRepository {
+	m := &UserPersonalTokensRepository{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
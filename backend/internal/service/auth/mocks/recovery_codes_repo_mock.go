@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"backend/internal/domain/entities"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type UserRecoveryCodesRepository struct {
+	mock.Mock
+}
+
+func (_m *UserRecoveryCodesRepository) Replace(ctx context.Context, userID uuid.UUID, codes []*entities.UserRecoveryCode) error {
+	ret := _m.Called(ctx, userID, codes)
+	return ret.Error(0)
+}
+
+func (_m *UserRecoveryCodesRepository) Use(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	ret := _m.Called(ctx, userID, codeHash)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (_m *UserRecoveryCodesRepository) CountUnused(ctx context.Context, userID uuid.UUID) (int, error) {
+	ret := _m.Called(ctx, userID)
+	return ret.Int(0), ret.Error(1)
+}
+
+func (_m *UserRecoveryCodesRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+	return ret.Error(0)
+}
+
+func NewUserRecoveryCodesRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserRecoveryCodesRepository {
+	m := &UserRecoveryCodesRepository{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
@@ -4,6 +4,7 @@ import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -26,6 +27,24 @@ func (_m *UserRefreshTokensRepository) Get(ctx context.Context, f dto.UserRefres
 	return ret.Get(0).(*entities.UserRefreshToken), ret.Error(1)
 }
 
+func (_m *UserRefreshTokensRepository) List(ctx context.Context, f dto.UserRefreshTokenFilter) ([]*entities.UserRefreshToken, error) {
+	ret := _m.Called(ctx, f)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]*entities.UserRefreshToken), ret.Error(1)
+}
+
+func (_m *UserRefreshTokensRepository) Update(ctx context.Context, t *entities.UserRefreshToken) error {
+	ret := _m.Called(ctx, t)
+	return ret.Error(0)
+}
+
+func (_m *UserRefreshTokensRepository) CreateRotated(ctx context.Context, familyID uuid.UUID, tokenHash string) error {
+	ret := _m.Called(ctx, familyID, tokenHash)
+	return ret.Error(0)
+}
+
 func (_m *UserRefreshTokensRepository) Delete(ctx context.Context, f dto.UserRefreshTokenFilter) error {
 	ret := _m.Called(ctx, f)
 	return ret.Error(0)
@@ -36,6 +55,11 @@ func (_m *UserRefreshTokensRepository) DeleteByUser(ctx context.Context, userID
 	return ret.Error(0)
 }
 
+func (_m *UserRefreshTokensRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+	return ret.Int(0), ret.Error(1)
+}
+
 func NewUserRefreshTokensRepository(t interface {
 	mock.TestingT
 	Cleanup(func())
@@ -4,6 +4,7 @@ import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -30,6 +31,11 @@ func (_m *UserVerificationCodesRepository) MarkUsed(ctx context.Context, id inte
 	return ret.Error(0)
 }
 
+func (_m *UserVerificationCodesRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+	return ret.Int(0), ret.Error(1)
+}
+
 func NewUserVerificationCodesRepository(t interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
+	"backend/pkg/logging"
+	"backend/pkg/password"
+	"context"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordViolations — ошибки pkg/password, которые означают слабый пароль, а не сбой проверки.
+var passwordViolations = []error{
+	password.ErrTooShort,
+	password.ErrTooLong,
+	password.ErrTooFewClasses,
+	password.ErrMatchesIdentity,
+	password.ErrBreached,
+}
+
+// validatePassword проверяет новый пароль политикой. Нарушение возвращается как *errs.PasswordPolicyError.
+func (u *Service) validatePassword(plain string, identity ...string) error {
+	if u.passwordPolicy == nil {
+		return nil
+	}
+
+	err := u.passwordPolicy.Validate(plain, identity...)
+	if err == nil {
+		return nil
+	}
+	for _, v := range passwordViolations {
+		if errors.Is(err, v) {
+			return &errs.PasswordPolicyError{Reason: err.Error()}
+		}
+	}
+	return err
+}
+
+func (u *Service) bcryptCost() int {
+	if u.passwordPolicy == nil {
+		return bcrypt.DefaultCost
+	}
+	return u.passwordPolicy.BcryptCost()
+}
+
+// rehashPassword пересчитывает хэш пароля, если он посчитан с другой стоимостью bcrypt, чем задана сейчас.
+// Вызывается после успешной проверки пароля, пока открытый пароль ещё известен. Ошибка не мешает входу.
+func (u *Service) rehashPassword(ctx context.Context, user *entities.UserInfo, plain string) {
+	cost, err := bcrypt.Cost([]byte(user.Password()))
+	if err != nil || cost == u.bcryptCost() {
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(plain), u.bcryptCost())
+	if err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: rehash password: %v", err)
+		return
+	}
+
+	newPass := string(hashed)
+	user.Update(entities.UserInfoUpdateParams{Password: &newPass})
+	if err := u.userInfoRepo.Update(ctx, user); err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: rehash password: update user: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/errors"
+	"backend/pkg/JWT"
+	"backend/pkg/logging"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	personalTokenLength  = 32
+	maxPersonalTokens    = 20
+	maxPersonalTokenName = 100
+)
+
+// CreatedPersonalToken — новый токен. Raw показывается пользователю один раз, в базе остаётся только хэш.
+type CreatedPersonalToken struct {
+	Token *entities.UserPersonalToken
+	Raw   string
+}
+
+// CreatePersonalToken выпускает персональный токен с правами scopes. expiresAt = nil — бессрочный токен.
+func (u *Service) CreatePersonalToken(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (CreatedPersonalToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > maxPersonalTokenName {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", errors.ErrPersonalTokenNameInvalid)
+	}
+
+	tokenScopes, err := parsePersonalTokenScopes(scopes)
+	if err != nil {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", err)
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", errors.ErrPersonalTokenExpiryInPast)
+	}
+
+	n, err := u.personalTokensRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", err)
+	}
+	if n >= maxPersonalTokens {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", errors.ErrPersonalTokensLimit)
+	}
+
+	random, err := generateRandomHex(personalTokenLength)
+	if err != nil {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", err)
+	}
+	raw := JWT.PersonalTokenPrefix + random
+
+	token := entities.NewUserPersonalToken(entities.WithUserPersonalTokenInitSpec(entities.UserPersonalTokenInitSpec{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(raw),
+		Scopes:    tokenScopes,
+		ExpiresAt: expiresAt,
+	}))
+
+	if err := u.personalTokensRepo.Create(ctx, token); err != nil {
+		return CreatedPersonalToken{}, fmt.Errorf("create personal token: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventPersonalTokenCreated, userID, map[string]string{
+		"token_id": token.ID().String(),
+		"name":     name,
+		"scopes":   strings.Join(scopes, " "),
+	})
+
+	return CreatedPersonalToken{Token: token, Raw: raw}, nil
+}
+
+func (u *Service) ListPersonalTokens(ctx context.Context, userID uuid.UUID) ([]*entities.UserPersonalToken, error) {
+	tokens, err := u.personalTokensRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list personal tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokePersonalToken удаляет токен пользователя; запросы с ним сразу перестают проходить.
+func (u *Service) RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	if err := u.personalTokensRepo.Delete(ctx, tokenID, userID); err != nil {
+		return fmt.Errorf("revoke personal token: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventPersonalTokenRevoked, userID, map[string]string{"token_id": tokenID.String()})
+	return nil
+}
+
+// VerifyPersonalToken проверяет токен из заголовка Authorization и отмечает его использование.
+// Вызывается из JWT.JWTAuthMiddleware на каждый запрос с персональным токеном.
+func (u *Service) VerifyPersonalToken(ctx context.Context, rawToken, ip string) (JWT.PersonalTokenIdentity, error) {
+	token, err := u.personalTokensRepo.GetByHash(ctx, hashToken(rawToken))
+	if err != nil {
+		return JWT.PersonalTokenIdentity{}, fmt.Errorf("verify personal token: %v: %w", err, errors.ErrInvalidPersonalToken)
+	}
+	if token.IsExpired(time.Now()) {
+		return JWT.PersonalTokenIdentity{}, fmt.Errorf("verify personal token: expired: %w", errors.ErrInvalidPersonalToken)
+	}
+
+	userID := token.UserID()
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return JWT.PersonalTokenIdentity{}, fmt.Errorf("verify personal token: %w", err)
+	}
+
+	// Отметка использования не должна ронять запрос: токен уже проверен.
+	if err := u.personalTokensRepo.Touch(ctx, token.ID(), ip); err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("auth: touch personal token %s: %v", token.ID(), err)
+	}
+
+	scopes := make([]string, len(token.Scopes()))
+	for i, s := range token.Scopes() {
+		scopes[i] = s.String()
+	}
+
+	return JWT.PersonalTokenIdentity{
+		TokenID: token.ID(),
+		UserID:  user.ID(),
+		Role:    user.Role().String(),
+		Scopes:  scopes,
+	}, nil
+}
+
+// parsePersonalTokenScopes проверяет права и убирает повторы, сохраняя порядок.
+func parsePersonalTokenScopes(scopes []string) ([]entities.PersonalTokenScope, error) {
+	result := make([]entities.PersonalTokenScope, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !entities.IsKnownPersonalTokenScope(s) {
+			return nil, fmt.Errorf("%w: %q", errors.ErrUnknownPersonalTokenScope, s)
+		}
+		if !slices.Contains(result, entities.PersonalTokenScope(s)) {
+			result = append(result, entities.PersonalTokenScope(s))
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.ErrPersonalTokenScopesRequired
+	}
+	return result, nil
+}
@@ -5,18 +5,25 @@ import (
 	"backend/internal/dto"
 	"backend/internal/errors"
 	"backend/pkg/JWT"
+	"backend/pkg/apple"
+	"backend/pkg/logging"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// phonePattern совпадает с проверкой телефона при регистрации.
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
 const (
 	refreshTokenLength   = 64
 	verificationCodeLen  = 6
@@ -29,6 +36,14 @@ type TokenPair struct {
 	RefreshToken string
 }
 
+// LoginResult — итог проверки пароля. Если у пользователя включена 2FA, токены не выдаются:
+// вместо них MFAToken, который обменивается на пару токенов через VerifyMFA.
+type LoginResult struct {
+	Tokens      TokenPair
+	MFARequired bool
+	MFAToken    string
+}
+
 type (
 	UserInfoRepository interface {
 		Get(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error)
@@ -39,23 +54,66 @@ type (
 	UserRefreshTokensRepository interface {
 		Create(ctx context.Context, t *entities.UserRefreshToken) error
 		Get(ctx context.Context, f dto.UserRefreshTokenFilter) (*entities.UserRefreshToken, error)
+		List(ctx context.Context, f dto.UserRefreshTokenFilter) ([]*entities.UserRefreshToken, error)
+		Update(ctx context.Context, t *entities.UserRefreshToken) error
+		CreateRotated(ctx context.Context, familyID uuid.UUID, tokenHash string) error
 		Delete(ctx context.Context, f dto.UserRefreshTokenFilter) error
 		DeleteByUser(ctx context.Context, userID uuid.UUID) error
+		DeleteExpired(ctx context.Context, before time.Time) (int, error)
 	}
 
 	UserVerificationCodesRepository interface {
 		Create(ctx context.Context, c *entities.UserVerificationCode) error
 		GetLatest(ctx context.Context, f dto.UserVerificationCodeFilter) (*entities.UserVerificationCode, error)
 		MarkUsed(ctx context.Context, id interface{}) error
+		DeleteExpired(ctx context.Context, before time.Time) (int, error)
+	}
+
+	UserRecoveryCodesRepository interface {
+		Replace(ctx context.Context, userID uuid.UUID, codes []*entities.UserRecoveryCode) error
+		Use(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+		CountUnused(ctx context.Context, userID uuid.UUID) (int, error)
+		DeleteByUser(ctx context.Context, userID uuid.UUID) error
+	}
+
+	UserPersonalTokensRepository interface {
+		Create(ctx context.Context, t *entities.UserPersonalToken) error
+		GetByHash(ctx context.Context, tokenHash string) (*entities.UserPersonalToken, error)
+		ListByUser(ctx context.Context, userID uuid.UUID) ([]*entities.UserPersonalToken, error)
+		CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+		Delete(ctx context.Context, id, userID uuid.UUID) error
+		Touch(ctx context.Context, id uuid.UUID, ip string) error
 	}
 
 	TasksRepository interface {
 		Create(ctx context.Context, t *entities.Task) error
 	}
 
+	AuditEventsRepository interface {
+		Create(ctx context.Context, e *entities.AuditEvent) error
+	}
+
 	TransactionManager interface {
 		Do(ctx context.Context, fn func(ctx context.Context) error) (err error)
 	}
+
+	// AppleVerifier проверяет identity token Sign in with Apple (pkg/apple).
+	AppleVerifier interface {
+		Verify(ctx context.Context, idToken, nonce string) (apple.Claims, error)
+	}
+
+	// PasswordPolicy проверяет новые пароли и задаёт стоимость bcrypt (pkg/password).
+	PasswordPolicy interface {
+		Validate(password string, identity ...string) error
+		BcryptCost() int
+	}
+
+	// AttemptsStore хранит счётчики попыток с TTL: Redis (pkg/cache) или Postgres, если Redis не настроен.
+	AttemptsStore interface {
+		Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+		TTL(ctx context.Context, key string) (time.Duration, error)
+		Del(ctx context.Context, keys ...string) error
+	}
 )
 
 type Service struct {
@@ -63,7 +121,13 @@ type Service struct {
 	userInfoRepo          UserInfoRepository
 	refreshTokensRepo     UserRefreshTokensRepository
 	verificationCodesRepo UserVerificationCodesRepository
+	recoveryCodesRepo     UserRecoveryCodesRepository
+	personalTokensRepo    UserPersonalTokensRepository
+	auditRepo             AuditEventsRepository
 	tasksRepo             TasksRepository
+	attempts              AttemptsStore
+	appleVerifier         AppleVerifier
+	passwordPolicy        PasswordPolicy
 }
 
 type Config struct {
@@ -71,7 +135,14 @@ type Config struct {
 	UserInfoRepository          UserInfoRepository
 	UserRefreshTokensRepository UserRefreshTokensRepository
 	VerificationCodesRepository UserVerificationCodesRepository
+	RecoveryCodesRepository     UserRecoveryCodesRepository
+	PersonalTokensRepository    UserPersonalTokensRepository
+	AuditEventsRepository       AuditEventsRepository
 	TasksRepository             TasksRepository
+	AttemptsStore               AttemptsStore
+	AppleVerifier               AppleVerifier
+	// PasswordPolicy — nil: новые пароли не проверяются, хэши считаются с bcrypt.DefaultCost.
+	PasswordPolicy PasswordPolicy
 }
 
 func NewService(c *Config) *Service {
@@ -80,12 +151,18 @@ func NewService(c *Config) *Service {
 		userInfoRepo:          c.UserInfoRepository,
 		refreshTokensRepo:     c.UserRefreshTokensRepository,
 		verificationCodesRepo: c.VerificationCodesRepository,
+		recoveryCodesRepo:     c.RecoveryCodesRepository,
+		personalTokensRepo:    c.PersonalTokensRepository,
+		auditRepo:             c.AuditEventsRepository,
 		tasksRepo:             c.TasksRepository,
+		attempts:              c.AttemptsStore,
+		appleVerifier:         c.AppleVerifier,
+		passwordPolicy:        c.PasswordPolicy,
 	}
 }
 
 func (u *Service) hashesPassword(user *entities.UserInfoInitSpec) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), u.bcryptCost())
 	if err != nil {
 		return fmt.Errorf("%s: %v", errors.ErrHashedPassword, err)
 	}
@@ -94,7 +171,11 @@ func (u *Service) hashesPassword(user *entities.UserInfoInitSpec) error {
 }
 
 func (u *Service) Register(ctx context.Context, info entities.UserInfoInitSpec) error {
-	return u.txm.Do(ctx, func(ctx context.Context) error {
+	if err := u.validatePassword(info.Password, info.Username, info.Email); err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+
+	err := u.txm.Do(ctx, func(ctx context.Context) error {
 		existingUser, _ := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Username: &info.Username}, false)
 		if existingUser != nil {
 			return fmt.Errorf("register: %w", errors.ErrUserAlreadyExists)
@@ -110,35 +191,109 @@ func (u *Service) Register(ctx context.Context, info entities.UserInfoInitSpec)
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	u.auditUser(ctx, entities.AuditEventRegister, info.ID, map[string]string{"method": loginMethodPassword})
+	return nil
 }
 
-func (u *Service) Login(ctx context.Context, ua entities.UserAuthInitSpec) (TokenPair, error) {
-	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Username: &ua.Username}, false)
+func (u *Service) Login(ctx context.Context, ua entities.UserAuthInitSpec, meta dto.SessionMetadata) (LoginResult, error) {
+	account := accountKey(ua.Login)
+	if err := u.checkLocked(ctx, loginAccountPolicy, account); err != nil {
+		return LoginResult{}, fmt.Errorf("login: %w", err)
+	}
+	if err := u.checkLocked(ctx, loginIPPolicy, meta.IP); err != nil {
+		return LoginResult{}, fmt.Errorf("login: %w", err)
+	}
+
+	user, err := u.findByLogin(ctx, ua.Login)
 	if err != nil {
-		return TokenPair{}, fmt.Errorf("login: %w", err)
+		u.registerFailure(ctx, loginAccountPolicy, account)
+		u.registerFailure(ctx, loginIPPolicy, meta.IP)
+		u.auditFailure(ctx, entities.AuditEventLoginFailed, nil, meta.IP, meta.UserAgent, map[string]string{
+			"method": loginMethodPassword, "login": ua.Login, "reason": "unknown_user",
+		})
+		return LoginResult{}, fmt.Errorf("login: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password()), []byte(ua.Password)); err != nil {
-		return TokenPair{}, fmt.Errorf("login: %w", errors.ErrInvalidCredentials)
+	// неудачи считаются на аккаунт, каким бы идентификатором ни входили
+	account = accountKey(user.Username())
+	if err := u.checkLocked(ctx, loginAccountPolicy, account); err != nil {
+		return LoginResult{}, fmt.Errorf("login: %w", err)
 	}
 
-	accessToken, err := JWT.GenerateJWT(user)
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password()), []byte(ua.Password)); err != nil {
+		u.registerFailure(ctx, loginAccountPolicy, account)
+		u.registerFailure(ctx, loginIPPolicy, meta.IP)
+		userID := user.ID()
+		u.auditFailure(ctx, entities.AuditEventLoginFailed, &userID, meta.IP, meta.UserAgent, map[string]string{
+			"method": loginMethodPassword, "reason": "invalid_password",
+		})
+		return LoginResult{}, fmt.Errorf("login: %w", errors.ErrInvalidCredentials)
+	}
+	u.resetFailures(ctx, loginAccountPolicy, account)
+	u.rehashPassword(ctx, user, ua.Password)
+
+	res, err := u.completeLogin(ctx, user, meta, loginMethodPassword)
 	if err != nil {
-		return TokenPair{}, fmt.Errorf("login: %w: %v", errors.ErrTokenGeneration, err)
+		return LoginResult{}, fmt.Errorf("login: %w", err)
+	}
+
+	return res, nil
+}
+
+// findByLogin ищет пользователя по нику, а если такого ника нет — по подтверждённому email или
+// подтверждённому телефону. Неподтверждённые email и телефон для входа не годятся.
+func (u *Service) findByLogin(ctx context.Context, login string) (*entities.UserInfo, error) {
+	login = strings.TrimSpace(login)
+	if user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Username: &login}, false); err == nil {
+		return user, nil
 	}
 
-	rawRefresh, err := u.issueRefreshToken(ctx, user.ID())
+	verified := true
+	switch {
+	case strings.Contains(login, "@"):
+		return u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Email: &login, EmailVerified: &verified}, false)
+	case phonePattern.MatchString(login):
+		return u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Phone: &login, PhoneVerified: &verified}, false)
+	default:
+		return nil, errors.ErrUserInfoNotFound
+	}
+}
+
+// completeLogin завершает вход проверенного пользователя: открывает сессию или, если включена 2FA,
+// выдаёт MFA-токен для второго шага. method — чем подтверждён первый фактор, для журнала безопасности.
+func (u *Service) completeLogin(ctx context.Context, user *entities.UserInfo, meta dto.SessionMetadata, method string) (LoginResult, error) {
+	if user.IsTOTPEnabled() {
+		mfaToken, err := JWT.GenerateMFAChallenge(JWT.MFAChallenge{
+			UserID:     user.ID(),
+			DeviceName: meta.DeviceName,
+			Platform:   meta.Platform,
+		})
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("%w: %v", errors.ErrTokenGeneration, err)
+		}
+		u.auditSession(ctx, entities.AuditEventMFAChallenge, user.ID(), meta, map[string]string{"method": method})
+		return LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	pair, err := u.startSession(ctx, user, meta, method)
 	if err != nil {
-		return TokenPair{}, fmt.Errorf("login: %w", err)
+		return LoginResult{}, err
 	}
 
-	return TokenPair{AccessToken: accessToken, RefreshToken: rawRefresh}, nil
+	return LoginResult{Tokens: pair}, nil
 }
 
-func (u *Service) Refresh(ctx context.Context, rawToken string) (TokenPair, error) {
+func (u *Service) Refresh(ctx context.Context, rawToken string, meta dto.SessionMetadata) (TokenPair, error) {
 	hash := hashToken(rawToken)
 	record, err := u.refreshTokensRepo.Get(ctx, dto.UserRefreshTokenFilter{TokenHash: &hash})
 	if err != nil {
+		if family, ferr := u.refreshTokensRepo.Get(ctx, dto.UserRefreshTokenFilter{RotatedTokenHash: &hash}); ferr == nil {
+			return TokenPair{}, fmt.Errorf("refresh: %w", u.revokeReusedFamily(ctx, family, meta))
+		}
 		return TokenPair{}, fmt.Errorf("refresh: token not found: %w", errors.ErrInvalidCredentials)
 	}
 	if record.IsExpired() {
@@ -152,23 +307,142 @@ func (u *Service) Refresh(ctx context.Context, rawToken string) (TokenPair, erro
 		return TokenPair{}, fmt.Errorf("refresh: %w", err)
 	}
 
-	// rotate: delete old token, issue new pair
-	_ = u.refreshTokensRepo.Delete(ctx, dto.UserRefreshTokenFilter{TokenHash: &hash})
-
-	accessToken, err := JWT.GenerateJWT(user)
+	// rotate in place: the session keeps its ID (it is the token family ID), only the token hash changes.
+	// The old hash goes to the family history so a replay of it can be detected.
+	rawRefresh, err := generateRandomHex(refreshTokenLength)
 	if err != nil {
-		return TokenPair{}, fmt.Errorf("refresh: %w: %v", errors.ErrTokenGeneration, err)
+		return TokenPair{}, fmt.Errorf("refresh: %w", err)
 	}
 
-	rawRefresh, err := u.issueRefreshToken(ctx, user.ID())
+	record.Rotate(hashToken(rawRefresh), time.Now().Add(refreshTokenTTL), meta.IP, meta.UserAgent)
+	err = u.txm.Do(ctx, func(ctx context.Context) error {
+		// history insert goes first: its primary key rejects a concurrent rotation of the same token
+		if err := u.refreshTokensRepo.CreateRotated(ctx, record.ID(), hash); err != nil {
+			return err
+		}
+		return u.refreshTokensRepo.Update(ctx, record)
+	})
 	if err != nil {
 		return TokenPair{}, fmt.Errorf("refresh: %w", err)
 	}
 
+	accessToken, err := JWT.GenerateJWT(user, record.ID())
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("refresh: %w: %v", errors.ErrTokenGeneration, err)
+	}
+
+	u.auditSession(ctx, entities.AuditEventTokenRefresh, userID, meta, map[string]string{"session_id": record.ID().String()})
 	return TokenPair{AccessToken: accessToken, RefreshToken: rawRefresh}, nil
 }
 
+// revokeReusedFamily вызывается, когда предъявлен уже ротированный refresh-токен: либо его украли,
+// либо украли текущий. Отличить нельзя, поэтому сессия (семейство токенов) отзывается целиком.
+func (u *Service) revokeReusedFamily(ctx context.Context, family *entities.UserRefreshToken, meta dto.SessionMetadata) error {
+	logging.GetLoggerFromContext(ctx).WithFields(logging.Fields{
+		"user_id":    family.UserID().String(),
+		"family_id":  family.ID().String(),
+		"ip":         meta.IP,
+		"user_agent": meta.UserAgent,
+	}).Alertf("refresh_token_reuse", "Rotated refresh token presented again, revoking token family")
+
+	userID := family.UserID()
+	u.auditFailure(ctx, entities.AuditEventRefreshTokenReuse, &userID, meta.IP, meta.UserAgent, map[string]string{
+		"session_id": family.ID().String(),
+	})
+
+	familyID := family.ID()
+	if err := u.refreshTokensRepo.Delete(ctx, dto.UserRefreshTokenFilter{ID: &familyID}); err != nil {
+		return fmt.Errorf("revoke token family: %w", err)
+	}
+
+	return errors.ErrRefreshTokenReused
+}
+
+// ListSessions возвращает активные (не истёкшие) сессии пользователя, последние использованные — первыми.
+func (u *Service) ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.UserRefreshToken, error) {
+	records, err := u.refreshTokensRepo.List(ctx, dto.UserRefreshTokenFilter{UserID: &userID})
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	sessions := make([]*entities.UserRefreshToken, 0, len(records))
+	for _, r := range records {
+		if !r.IsExpired() {
+			sessions = append(sessions, r)
+		}
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession завершает одну сессию пользователя. Чужая сессия для него не существует.
+func (u *Service) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := u.revokeSession(ctx, userID, sessionID, entities.AuditEventSessionRevoked); err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	return nil
+}
+
+// Logout завершает текущую сессию. Отличается от RevokeSession только событием в журнале.
+func (u *Service) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := u.revokeSession(ctx, userID, sessionID, entities.AuditEventLogout); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+	return nil
+}
+
+// VerifySession проверяет, что сессия access-токена ещё действует, и возвращает текущую роль
+// пользователя. Вызывается из JWT.JWTAuthMiddleware; результат кэшируется там же.
+func (u *Service) VerifySession(ctx context.Context, userID, sessionID uuid.UUID) (string, error) {
+	session, err := u.refreshTokensRepo.Get(ctx, dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID})
+	if err != nil {
+		return "", fmt.Errorf("verify session: %v: %w", err, errors.ErrSessionNotFound)
+	}
+	if session.IsExpired() {
+		return "", fmt.Errorf("verify session: expired: %w", errors.ErrSessionNotFound)
+	}
+
+	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return "", fmt.Errorf("verify session: %w", err)
+	}
+
+	return user.Role().String(), nil
+}
+
+func (u *Service) revokeSession(ctx context.Context, userID, sessionID uuid.UUID, eventType entities.AuditEventType) error {
+	if _, err := u.refreshTokensRepo.Get(ctx, dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID}); err != nil {
+		return errors.ErrSessionNotFound
+	}
+
+	if err := u.refreshTokensRepo.Delete(ctx, dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID}); err != nil {
+		return err
+	}
+	JWT.ForgetSession(sessionID)
+
+	u.auditUser(ctx, eventType, userID, map[string]string{"session_id": sessionID.String()})
+	return nil
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме текущей.
+func (u *Service) RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID) error {
+	if err := u.refreshTokensRepo.Delete(ctx, dto.UserRefreshTokenFilter{UserID: &userID, ExcludeID: &currentSessionID}); err != nil {
+		return fmt.Errorf("revoke other sessions: %w", err)
+	}
+
+	u.auditUser(ctx, entities.AuditEventOtherSessionsRevoked, userID, map[string]string{"session_id": currentSessionID.String()})
+	return nil
+}
+
 func (u *Service) SendVerificationCode(ctx context.Context, userID uuid.UUID, codeType entities.VerificationCodeType) error {
+	throttleID := userID.String() + ":" + string(codeType)
+	if err := u.throttle(ctx, sendCooldownLimit, throttleID); err != nil {
+		return fmt.Errorf("send verification code: %w", err)
+	}
+	if err := u.throttle(ctx, sendHourlyLimit, throttleID); err != nil {
+		return fmt.Errorf("send verification code: %w", err)
+	}
+
 	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
 	if err != nil {
 		return fmt.Errorf("send verification code: %w", err)
@@ -184,6 +458,7 @@ func (u *Service) SendVerificationCode(ctx context.Context, userID uuid.UUID, co
 		UserID:    userID,
 		CodeHash:  codeHash,
 		CodeType:  codeType,
+		Target:    verificationTarget(user, codeType),
 		ExpiresAt: time.Now().Add(verificationCodeTTL),
 	}))
 
@@ -191,37 +466,27 @@ func (u *Service) SendVerificationCode(ctx context.Context, userID uuid.UUID, co
 		return fmt.Errorf("send verification code: %w", err)
 	}
 
-	var taskType entities.TaskType
-	var taskAttr entities.TaskAttribute
+	var task *entities.Task
 
 	switch codeType {
 	case entities.VerificationCodeEmail:
-		taskType = entities.TaskTypeSendCodeOnEmail
-		taskAttr = entities.TaskAttribute{
-			UserID:  userID,
-			Email:   user.Email(),
-			Subject: "BodyFuel — подтверждение email",
-			Body:    fmt.Sprintf("Ваш код подтверждения: %s", code),
-			Code:    code,
-		}
+		task = entities.TaskKindSendCodeOnEmail.NewTask(entities.EmailTaskPayload{
+			UserID:   userID,
+			Email:    user.Email(),
+			Template: entities.NotificationTemplateVerificationCode,
+			Code:     code,
+		}, entities.TaskSchedule{})
 	case entities.VerificationCodePhone:
-		taskType = entities.TaskTypeSendCodeOnPhone
-		taskAttr = entities.TaskAttribute{
-			UserID: userID,
-			Phone:  user.Phone(),
-			Body:   fmt.Sprintf("BodyFuel: ваш код подтверждения %s", code),
-			Code:   code,
-		}
+		task = entities.TaskKindSendCodeOnPhone.NewTask(entities.SMSTaskPayload{
+			UserID:   userID,
+			Phone:    user.Phone(),
+			Template: entities.NotificationTemplateVerificationCode,
+			Code:     code,
+		}, entities.TaskSchedule{})
 	default:
 		return fmt.Errorf("send verification code: unknown code type %s", codeType)
 	}
 
-	task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-		TypeNm:      taskType,
-		MaxAttempts: 5,
-		Attribute:   taskAttr,
-	}))
-
 	if err := u.tasksRepo.Create(ctx, task); err != nil {
 		return fmt.Errorf("send verification code: create task: %w", err)
 	}
@@ -230,6 +495,11 @@ func (u *Service) SendVerificationCode(ctx context.Context, userID uuid.UUID, co
 }
 
 func (u *Service) VerifyCode(ctx context.Context, userID uuid.UUID, code string, codeType entities.VerificationCodeType) error {
+	account := userID.String() + ":" + string(codeType)
+	if err := u.checkLocked(ctx, verifyAccountPolicy, account); err != nil {
+		return fmt.Errorf("verify code: %w", err)
+	}
+
 	record, err := u.verificationCodesRepo.GetLatest(ctx, dto.UserVerificationCodeFilter{
 		UserID:   &userID,
 		CodeType: &codeType,
@@ -246,8 +516,10 @@ func (u *Service) VerifyCode(ctx context.Context, userID uuid.UUID, code string,
 
 	inputHash := hashToken(code)
 	if inputHash != record.CodeHash() {
-		return fmt.Errorf("verify code: %w", errors.ErrInvalidVerificationCode)
+		u.registerFailure(ctx, verifyAccountPolicy, account)
+		return fmt.Errorf("verify code: %w", u.rejectCode(ctx, record.ID()))
 	}
+	u.resetFailures(ctx, verifyAccountPolicy, account)
 
 	if err := u.verificationCodesRepo.MarkUsed(ctx, record.ID()); err != nil {
 		return fmt.Errorf("verify code: mark used: %w", err)
@@ -257,6 +529,10 @@ func (u *Service) VerifyCode(ctx context.Context, userID uuid.UUID, code string,
 	if err != nil {
 		return fmt.Errorf("verify code: get user: %w", err)
 	}
+	// код подтверждает только тот адрес, на который был отправлен
+	if record.Target() != "" && record.Target() != verificationTarget(user, codeType) {
+		return fmt.Errorf("verify code: %w", errors.ErrInvalidVerificationCode)
+	}
 
 	now := time.Now()
 	params := entities.UserInfoUpdateParams{}
@@ -272,10 +548,40 @@ func (u *Service) VerifyCode(ctx context.Context, userID uuid.UUID, code string,
 		return fmt.Errorf("verify code: update user: %w", err)
 	}
 
+	eventType := entities.AuditEventEmailVerified
+	if codeType == entities.VerificationCodePhone {
+		eventType = entities.AuditEventPhoneVerified
+	}
+	u.auditUser(ctx, eventType, userID, map[string]string{"target": verificationTarget(user, codeType)})
 	return nil
 }
 
-func (u *Service) SendRecoveryCode(ctx context.Context, email string) error {
+// verificationTarget — текущий адрес пользователя, который подтверждает код данного типа.
+func verificationTarget(user *entities.UserInfo, codeType entities.VerificationCodeType) string {
+	switch codeType {
+	case entities.VerificationCodeEmail:
+		return user.Email()
+	case entities.VerificationCodePhone:
+		return user.Phone()
+	default:
+		return ""
+	}
+}
+
+// SendRecoveryCode отправляет код сброса пароля. Лимиты считаются по email до поиска пользователя,
+// поэтому 429 не выдаёт, зарегистрирован ли адрес.
+func (u *Service) SendRecoveryCode(ctx context.Context, email, ip string) error {
+	account := accountKey(email)
+	if err := u.throttle(ctx, recoverIPLimit, ip); err != nil {
+		return fmt.Errorf("send recovery code: %w", err)
+	}
+	if err := u.throttle(ctx, sendCooldownLimit, "recover:"+account); err != nil {
+		return fmt.Errorf("send recovery code: %w", err)
+	}
+	if err := u.throttle(ctx, sendHourlyLimit, "recover:"+account); err != nil {
+		return fmt.Errorf("send recovery code: %w", err)
+	}
+
 	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Email: &email}, false)
 	if err != nil {
 		// don't reveal if user exists
@@ -300,28 +606,35 @@ func (u *Service) SendRecoveryCode(ctx context.Context, email string) error {
 		return fmt.Errorf("send recovery code: %w", err)
 	}
 
-	task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-		TypeNm:      entities.TaskTypeSendCodeOnEmail,
-		MaxAttempts: 5,
-		Attribute: entities.TaskAttribute{
-			UserID:  user.ID(),
-			Email:   email,
-			Subject: "BodyFuel — восстановление пароля",
-			Body:    fmt.Sprintf("Ваш код для сброса пароля: %s", code),
-			Code:    code,
-		},
-	}))
+	task := entities.TaskKindSendCodeOnEmail.NewTask(entities.EmailTaskPayload{
+		UserID:   user.ID(),
+		Email:    email,
+		Template: entities.NotificationTemplatePasswordResetCode,
+		Code:     code,
+	}, entities.TaskSchedule{})
 
 	if err := u.tasksRepo.Create(ctx, task); err != nil {
 		return fmt.Errorf("send recovery code: create task: %w", err)
 	}
 
+	resetUserID := user.ID()
+	u.auditFailure(ctx, entities.AuditEventPasswordResetRequested, &resetUserID, ip, "", nil)
 	return nil
 }
 
-func (u *Service) ResetPassword(ctx context.Context, email, code, newPassword string) error {
+func (u *Service) ResetPassword(ctx context.Context, email, code, newPassword, ip string) error {
+	account := accountKey(email)
+	if err := u.checkLocked(ctx, resetAccountPolicy, account); err != nil {
+		return fmt.Errorf("reset password: %w", err)
+	}
+	if err := u.checkLocked(ctx, resetIPPolicy, ip); err != nil {
+		return fmt.Errorf("reset password: %w", err)
+	}
+
 	user, err := u.userInfoRepo.Get(ctx, dto.UserInfoFilter{Email: &email}, false)
 	if err != nil {
+		u.registerFailure(ctx, resetAccountPolicy, account)
+		u.registerFailure(ctx, resetIPPolicy, ip)
 		return fmt.Errorf("reset password: %w", errors.ErrInvalidCredentials)
 	}
 
@@ -343,10 +656,17 @@ func (u *Service) ResetPassword(ctx context.Context, email, code, newPassword st
 
 	inputHash := hashToken(code)
 	if inputHash != record.CodeHash() {
-		return fmt.Errorf("reset password: %w", errors.ErrInvalidVerificationCode)
+		u.registerFailure(ctx, resetAccountPolicy, account)
+		u.registerFailure(ctx, resetIPPolicy, ip)
+		u.auditFailure(ctx, entities.AuditEventPasswordResetFailed, &resetUserID, ip, "", map[string]string{"reason": "invalid_code"})
+		return fmt.Errorf("reset password: %w", u.rejectCode(ctx, record.ID()))
+	}
+
+	if err := u.validatePassword(newPassword, user.Username(), user.Email()); err != nil {
+		return fmt.Errorf("reset password: %w", err)
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), u.bcryptCost())
 	if err != nil {
 		return fmt.Errorf("reset password: hash: %w", err)
 	}
@@ -363,30 +683,66 @@ func (u *Service) ResetPassword(ctx context.Context, email, code, newPassword st
 
 	// invalidate all refresh tokens after password reset
 	_ = u.refreshTokensRepo.DeleteByUser(ctx, user.ID())
-
+	u.resetFailures(ctx, resetAccountPolicy, account)
+	u.resetFailures(ctx, loginAccountPolicy, accountKey(user.Username()))
+
+	u.audit(ctx, entities.AuditEventInitSpec{
+		UserID:  &resetUserID,
+		ActorID: &resetUserID,
+		Type:    entities.AuditEventPasswordReset,
+		IP:      ip,
+		Changes: map[string]entities.AuditChange{"password": {Old: entities.AuditRedacted, New: entities.AuditRedacted}},
+	})
 	return nil
 }
 
-// issueRefreshToken generates a random token, stores its hash, returns raw token.
-func (u *Service) issueRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+// startSession открывает новую сессию и выдаёт пару токенов для неё.
+func (u *Service) startSession(ctx context.Context, user *entities.UserInfo, meta dto.SessionMetadata, method string) (TokenPair, error) {
+	rawRefresh, sessionID, err := u.issueRefreshToken(ctx, user.ID(), meta)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	accessToken, err := JWT.GenerateJWT(user, sessionID)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("%w: %v", errors.ErrTokenGeneration, err)
+	}
+
+	u.auditSession(ctx, entities.AuditEventLogin, user.ID(), meta, map[string]string{
+		"method":      method,
+		"session_id":  sessionID.String(),
+		"device_name": meta.DeviceName,
+		"platform":    meta.Platform,
+	})
+
+	return TokenPair{AccessToken: accessToken, RefreshToken: rawRefresh}, nil
+}
+
+// issueRefreshToken starts a new session: generates a random token, stores its hash
+// with the client metadata, returns raw token and session ID.
+func (u *Service) issueRefreshToken(ctx context.Context, userID uuid.UUID, meta dto.SessionMetadata) (string, uuid.UUID, error) {
 	raw, err := generateRandomHex(refreshTokenLength)
 	if err != nil {
-		return "", fmt.Errorf("issue refresh token: %w", err)
+		return "", uuid.Nil, fmt.Errorf("issue refresh token: %w", err)
 	}
 
 	hash := hashToken(raw)
 	record := entities.NewUserRefreshToken(entities.WithUserRefreshTokenInitSpec(entities.UserRefreshTokenInitSpec{
-		ID:        uuid.New(),
-		UserID:    userID,
-		TokenHash: hash,
-		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		ID:         uuid.New(),
+		UserID:     userID,
+		TokenHash:  hash,
+		DeviceName: meta.DeviceName,
+		Platform:   meta.Platform,
+		IP:         meta.IP,
+		UserAgent:  meta.UserAgent,
+		ExpiresAt:  time.Now().Add(refreshTokenTTL),
 	}))
 
 	if err := u.refreshTokensRepo.Create(ctx, record); err != nil {
-		return "", fmt.Errorf("issue refresh token: %w", err)
+		return "", uuid.Nil, fmt.Errorf("issue refresh token: %w", err)
 	}
 
-	return raw, nil
+	return raw, record.ID(), nil
 }
 
 func hashToken(raw string) string {
@@ -5,11 +5,33 @@ import (
 	"backend/internal/dto"
 	autherrors "backend/internal/errors"
 	"backend/internal/service/auth/mocks"
+	"backend/pkg/JWT"
+	"backend/pkg/apple"
+	"backend/pkg/password"
+	"backend/pkg/totp"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +40,35 @@ import (
 
 // helpers
 
+const testSigningKeyID = "test-key"
+
+// TestMain настраивает pkg/JWT одноразовым Ed25519-ключом: без ключей GenerateJWT не выпускает токены.
+func TestMain(m *testing.M) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		panic(err)
+	}
+
+	err = JWT.Configure(JWT.Config{
+		Issuer:   "bodyfuel-test",
+		Audience: "bodyfuel-api",
+		Keys: []JWT.KeyConfig{{
+			ID:         testSigningKeyID,
+			Algorithm:  JWT.AlgEdDSA,
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		}},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
 func strPtr(s string) *string { return &s }
 
 func newHashedUser(username, password string) *entities.UserInfo {
@@ -171,7 +222,7 @@ func TestService_Login(t *testing.T) {
 				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Username: strPtr("user")}, false).Return(user, nil)
 				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
 			},
-			auth:    entities.UserAuthInitSpec{Username: "user", Password: "password"},
+			auth:    entities.UserAuthInitSpec{Login: "user", Password: "password"},
 			wantErr: nil,
 		},
 		{
@@ -179,7 +230,7 @@ func TestService_Login(t *testing.T) {
 			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
 				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Username: strPtr("user")}, false).Return(user, nil)
 			},
-			auth:    entities.UserAuthInitSpec{Username: "user", Password: "wrong"},
+			auth:    entities.UserAuthInitSpec{Login: "user", Password: "wrong"},
 			wantErr: autherrors.ErrInvalidCredentials,
 		},
 		{
@@ -187,7 +238,7 @@ func TestService_Login(t *testing.T) {
 			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
 				userRepo.On("Get", mock.Anything, mock.Anything, false).Return(nil, autherrors.ErrUserInfoNotFound)
 			},
-			auth:    entities.UserAuthInitSpec{Username: "nobody", Password: "pass"},
+			auth:    entities.UserAuthInitSpec{Login: "nobody", Password: "pass"},
 			wantErr: autherrors.ErrUserInfoNotFound,
 		},
 		{
@@ -196,7 +247,7 @@ func TestService_Login(t *testing.T) {
 				userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
 				refreshRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db error"))
 			},
-			auth:    entities.UserAuthInitSpec{Username: "user", Password: "password"},
+			auth:    entities.UserAuthInitSpec{Login: "user", Password: "password"},
 			wantErr: errors.New("db error"),
 		},
 	}
@@ -212,7 +263,8 @@ func TestService_Login(t *testing.T) {
 				UserRefreshTokensRepository: refreshRepo,
 			})
 
-			pair, err := s.Login(ctx, tt.auth)
+			res, err := s.Login(ctx, tt.auth, dto.SessionMetadata{DeviceName: "iPhone", Platform: "ios"})
+			pair := res.Tokens
 
 			if tt.wantErr != nil {
 				assert.Error(t, err)
@@ -239,41 +291,59 @@ func TestService_Refresh(t *testing.T) {
 	tests := []struct {
 		name         string
 		rawToken     string
-		prepareMocks func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository)
-		wantErr      bool
+		prepareMocks func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, txm *mocks.TransactionManager)
+		wantErr      error
 	}{
 		{
 			name:     "success — token rotated",
 			rawToken: rawToken,
-			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, txm *mocks.TransactionManager) {
 				token := newActiveRefreshToken(user.ID(), rawToken)
 				hash := hashToken(rawToken)
 				tokenUserID := token.UserID()
+				sessionID := token.ID()
 				refreshRepo.On("Get", mock.Anything, dto.UserRefreshTokenFilter{TokenHash: &hash}).Return(token, nil)
-				refreshRepo.On("Delete", mock.Anything, dto.UserRefreshTokenFilter{TokenHash: &hash}).Return(nil)
 				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &tokenUserID}, false).Return(user, nil)
-				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+				txm.On("Do", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) })
+				refreshRepo.On("CreateRotated", mock.Anything, sessionID, hash).Return(nil)
+				refreshRepo.On("Update", mock.Anything, mock.MatchedBy(func(t *entities.UserRefreshToken) bool {
+					return t.ID() == sessionID && t.TokenHash() != hash && t.IP() == "10.0.0.1"
+				})).Return(nil)
 			},
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:     "token not found",
 			rawToken: "unknowntoken",
-			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
-				refreshRepo.On("Get", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, txm *mocks.TransactionManager) {
+				refreshRepo.On("Get", mock.Anything, mock.Anything).Return(nil, autherrors.ErrSessionNotFound)
 			},
-			wantErr: true,
+			wantErr: autherrors.ErrInvalidCredentials,
+		},
+		{
+			name:     "rotated token replayed — family revoked",
+			rawToken: rawToken,
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, txm *mocks.TransactionManager) {
+				family := newActiveRefreshToken(user.ID(), "currentrawtoken")
+				hash := hashToken(rawToken)
+				familyID := family.ID()
+				refreshRepo.On("Get", mock.Anything, dto.UserRefreshTokenFilter{TokenHash: &hash}).Return(nil, autherrors.ErrSessionNotFound)
+				refreshRepo.On("Get", mock.Anything, dto.UserRefreshTokenFilter{RotatedTokenHash: &hash}).Return(family, nil)
+				refreshRepo.On("Delete", mock.Anything, dto.UserRefreshTokenFilter{ID: &familyID}).Return(nil)
+			},
+			wantErr: autherrors.ErrRefreshTokenReused,
 		},
 		{
 			name:     "token expired",
 			rawToken: rawToken,
-			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, txm *mocks.TransactionManager) {
 				token := newExpiredRefreshToken(user.ID(), rawToken)
 				hash := hashToken(rawToken)
 				refreshRepo.On("Get", mock.Anything, dto.UserRefreshTokenFilter{TokenHash: &hash}).Return(token, nil)
 				refreshRepo.On("Delete", mock.Anything, dto.UserRefreshTokenFilter{TokenHash: &hash}).Return(nil)
 			},
-			wantErr: true,
+			wantErr: autherrors.ErrTokenExpired,
 		},
 	}
 
@@ -281,16 +351,18 @@ func TestService_Refresh(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			userRepo := mocks.NewUserInfoRepository(t)
 			refreshRepo := mocks.NewUserRefreshTokensRepository(t)
-			tt.prepareMocks(userRepo, refreshRepo)
+			txm := mocks.NewTransactionManager(t)
+			tt.prepareMocks(userRepo, refreshRepo, txm)
 
 			s := NewService(&Config{
+				TransactionManager:          txm,
 				UserInfoRepository:          userRepo,
 				UserRefreshTokensRepository: refreshRepo,
 			})
 
-			pair, err := s.Refresh(ctx, tt.rawToken)
-			if tt.wantErr {
-				assert.Error(t, err)
+			pair, err := s.Refresh(ctx, tt.rawToken, dto.SessionMetadata{IP: "10.0.0.1"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 				assert.Empty(t, pair.AccessToken)
 			} else {
 				assert.NoError(t, err)
@@ -301,6 +373,236 @@ func TestService_Refresh(t *testing.T) {
 	}
 }
 
+// ──────────────────────────────────────────────────────────────
+// Sessions
+// ──────────────────────────────────────────────────────────────
+
+func TestService_Login_StoresSessionMetadata(t *testing.T) {
+	ctx := context.Background()
+	user := newHashedUser("user", "password")
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+	refreshRepo.On("Create", mock.Anything, mock.MatchedBy(func(t *entities.UserRefreshToken) bool {
+		return t.UserID() == user.ID() &&
+			t.DeviceName() == "iPhone 15" &&
+			t.Platform() == "ios" &&
+			t.IP() == "10.0.0.1" &&
+			t.UserAgent() == "BodyFuel/1.0"
+	})).Return(nil)
+
+	s := NewService(&Config{
+		UserInfoRepository:          userRepo,
+		UserRefreshTokensRepository: refreshRepo,
+	})
+
+	_, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "password"}, dto.SessionMetadata{
+		DeviceName: "iPhone 15",
+		Platform:   "ios",
+		IP:         "10.0.0.1",
+		UserAgent:  "BodyFuel/1.0",
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestService_Login_AccessTokenClaims(t *testing.T) {
+	ctx := context.Background()
+	user := newHashedUser("user", "password")
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+	refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+
+	s := NewService(&Config{
+		UserInfoRepository:          userRepo,
+		UserRefreshTokensRepository: refreshRepo,
+	})
+
+	res, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "password"}, dto.SessionMetadata{})
+	assert.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(res.Tokens.AccessToken, claims)
+	assert.NoError(t, err)
+	assert.Equal(t, testSigningKeyID, token.Header["kid"])
+	assert.Equal(t, JWT.AlgEdDSA, token.Method.Alg())
+	assert.Equal(t, "bodyfuel-test", claims["iss"])
+	assert.Equal(t, "bodyfuel-api", claims["aud"])
+	assert.Equal(t, user.ID().String(), claims["sub"])
+	assert.NotEmpty(t, claims["sid"])
+}
+
+func TestService_ListSessions_SkipsExpired(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	active := newActiveRefreshToken(userID, "active")
+	expired := newExpiredRefreshToken(userID, "expired")
+
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	refreshRepo.On("List", mock.Anything, dto.UserRefreshTokenFilter{UserID: &userID}).
+		Return([]*entities.UserRefreshToken{active, expired}, nil)
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo})
+
+	sessions, err := s.ListSessions(ctx, userID)
+
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, active.ID(), sessions[0].ID())
+}
+
+func TestService_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	sessionID := uuid.New()
+	filter := dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID}
+
+	tests := []struct {
+		name         string
+		prepareMocks func(refreshRepo *mocks.UserRefreshTokensRepository)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			prepareMocks: func(refreshRepo *mocks.UserRefreshTokensRepository) {
+				refreshRepo.On("Get", mock.Anything, filter).Return(newActiveRefreshToken(userID, "raw"), nil)
+				refreshRepo.On("Delete", mock.Anything, filter).Return(nil)
+			},
+		},
+		{
+			name: "session of another user",
+			prepareMocks: func(refreshRepo *mocks.UserRefreshTokensRepository) {
+				refreshRepo.On("Get", mock.Anything, filter).Return(nil, autherrors.ErrSessionNotFound)
+			},
+			wantErr: autherrors.ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+			tt.prepareMocks(refreshRepo)
+
+			s := NewService(&Config{UserRefreshTokensRepository: refreshRepo})
+
+			err := s.RevokeSession(ctx, userID, sessionID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_VerifySession(t *testing.T) {
+	ctx := context.Background()
+	user := newHashedUser("user", "password")
+	userID := user.ID()
+	sessionID := uuid.New()
+	filter := dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID}
+
+	t.Run("active session returns current role", func(t *testing.T) {
+		refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+		userRepo := mocks.NewUserInfoRepository(t)
+		refreshRepo.On("Get", mock.Anything, filter).Return(newActiveRefreshToken(userID, "raw"), nil)
+		userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil)
+
+		s := NewService(&Config{UserRefreshTokensRepository: refreshRepo, UserInfoRepository: userRepo})
+
+		role, err := s.VerifySession(ctx, userID, sessionID)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.UserRoleUser.String(), role)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+		refreshRepo.On("Get", mock.Anything, filter).Return(nil, errors.New("not found"))
+
+		s := NewService(&Config{UserRefreshTokensRepository: refreshRepo})
+
+		_, err := s.VerifySession(ctx, userID, sessionID)
+		assert.ErrorIs(t, err, autherrors.ErrSessionNotFound)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+		refreshRepo.On("Get", mock.Anything, filter).Return(newExpiredRefreshToken(userID, "raw"), nil)
+
+		s := NewService(&Config{UserRefreshTokensRepository: refreshRepo})
+
+		_, err := s.VerifySession(ctx, userID, sessionID)
+		assert.ErrorIs(t, err, autherrors.ErrSessionNotFound)
+	})
+}
+
+// TestJWTAuthMiddleware_ChecksSession — access-токен перестаёт действовать сразу после отзыва
+// сессии, а роль берётся из базы, а не из claim'а.
+func TestJWTAuthMiddleware_ChecksSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID, sessionID := uuid.New(), uuid.New()
+	filter := dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID}
+	admin := entities.NewUserInfo(entities.WithUserInfoRestoreSpec(entities.UserInfoRestoreSpec{
+		ID: userID, Username: "admin", Role: entities.UserRoleAdmin,
+	}))
+	demoted := entities.NewUserInfo(entities.WithUserInfoRestoreSpec(entities.UserInfoRestoreSpec{
+		ID: userID, Username: "admin", Role: entities.UserRoleUser,
+	}))
+
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	userRepo := mocks.NewUserInfoRepository(t)
+	refreshRepo.On("Get", mock.Anything, filter).Return(newActiveRefreshToken(userID, "raw"), nil).Twice()
+	refreshRepo.On("Delete", mock.Anything, filter).Return(nil).Once()
+	refreshRepo.On("Get", mock.Anything, filter).Return(nil, errors.New("not found")).Once()
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(demoted, nil).Once()
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo, UserInfoRepository: userRepo})
+	JWT.ConfigureSessions(s, time.Minute)
+	t.Cleanup(func() { JWT.ConfigureSessions(nil, 0) })
+
+	router := gin.New()
+	router.GET("/me", JWT.JWTAuthMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("role"))
+	})
+	token, err := JWT.GenerateJWT(admin, sessionID)
+	assert.NoError(t, err)
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, entities.UserRoleUser.String(), w.Body.String())
+
+	// второй запрос обслуживается из кэша
+	assert.Equal(t, http.StatusOK, get().Code)
+
+	assert.NoError(t, s.Logout(context.Background(), userID, sessionID))
+	assert.Equal(t, http.StatusUnauthorized, get().Code)
+}
+
+func TestService_RevokeOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	currentID := uuid.New()
+
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	refreshRepo.On("Delete", mock.Anything, dto.UserRefreshTokenFilter{UserID: &userID, ExcludeID: &currentID}).Return(nil)
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo})
+
+	assert.NoError(t, s.RevokeOtherSessions(ctx, userID, currentID))
+}
+
 // ──────────────────────────────────────────────────────────────
 // SendVerificationCode
 // ──────────────────────────────────────────────────────────────
@@ -516,7 +818,7 @@ func TestService_SendRecoveryCode(t *testing.T) {
 				TasksRepository:             taskRepo,
 			})
 
-			err := s.SendRecoveryCode(ctx, email)
+			err := s.SendRecoveryCode(ctx, email, "10.0.0.1")
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -604,7 +906,7 @@ func TestService_ResetPassword(t *testing.T) {
 				UserRefreshTokensRepository: refreshRepo,
 			})
 
-			err := s.ResetPassword(ctx, email, tt.code, "newpassword123")
+			err := s.ResetPassword(ctx, email, tt.code, "newpassword123", "10.0.0.1")
 			if tt.wantErr != nil {
 				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
@@ -640,3 +942,1370 @@ func TestService_hashesPassword(t *testing.T) {
 		})
 	}
 }
+
+// ──────────────────────────────────────────────────────────────
+// Attempt limits
+// ──────────────────────────────────────────────────────────────
+
+// memoryAttemptsStore — AttemptsStore в памяти с честными TTL.
+type memoryAttemptsStore struct {
+	counters map[string]int64
+	expires  map[string]time.Time
+}
+
+func newMemoryAttemptsStore() *memoryAttemptsStore {
+	return &memoryAttemptsStore{counters: map[string]int64{}, expires: map[string]time.Time{}}
+}
+
+func (m *memoryAttemptsStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	if exp, ok := m.expires[key]; !ok || time.Now().After(exp) {
+		m.counters[key] = 0
+		m.expires[key] = time.Now().Add(ttl)
+	}
+	m.counters[key]++
+	return m.counters[key], nil
+}
+
+func (m *memoryAttemptsStore) TTL(_ context.Context, key string) (time.Duration, error) {
+	exp, ok := m.expires[key]
+	if !ok || time.Now().After(exp) {
+		return 0, nil
+	}
+	return time.Until(exp), nil
+}
+
+func (m *memoryAttemptsStore) Del(_ context.Context, keys ...string) error {
+	for _, k := range keys {
+		delete(m.counters, k)
+		delete(m.expires, k)
+	}
+	return nil
+}
+
+func TestAttemptPolicy_lockFor(t *testing.T) {
+	p := attemptPolicy{maxFails: 5, baseLock: time.Minute, maxLock: time.Hour}
+
+	assert.Equal(t, time.Minute, p.lockFor(5))
+	assert.Equal(t, 2*time.Minute, p.lockFor(6))
+	assert.Equal(t, 32*time.Minute, p.lockFor(10))
+	assert.Equal(t, time.Hour, p.lockFor(11))
+	assert.Equal(t, time.Hour, p.lockFor(1000))
+}
+
+func TestService_Login_LocksAccountAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	user := newHashedUser("user", "password")
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil).Times(int(loginAccountPolicy.maxFails))
+
+	s := NewService(&Config{UserInfoRepository: userRepo, AttemptsStore: newMemoryAttemptsStore()})
+
+	for i := int64(0); i < loginAccountPolicy.maxFails; i++ {
+		_, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "wrong"}, dto.SessionMetadata{IP: "10.0.0.1"})
+		assert.ErrorIs(t, err, autherrors.ErrInvalidCredentials)
+	}
+
+	// заблокирован даже с верным паролем и без обращения к БД; регистр логина не помогает
+	_, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "USER", Password: "password"}, dto.SessionMetadata{IP: "10.0.0.2"})
+	assert.ErrorIs(t, err, autherrors.ErrTooManyAttempts)
+
+	var tooMany *autherrors.TooManyAttemptsError
+	if assert.ErrorAs(t, err, &tooMany) {
+		assert.InDelta(t, time.Minute.Seconds(), tooMany.RetryAfter.Seconds(), 1)
+	}
+}
+
+func TestService_VerifyCode_InvalidatesCodeAfterTooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	codeType := entities.VerificationCodeEmail
+	vc := newVerificationCode(userID, "123456", codeType, false, false)
+
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+	vcRepo.On("MarkUsed", mock.Anything, vc.ID()).Return(nil).Once()
+
+	s := NewService(&Config{VerificationCodesRepository: vcRepo, AttemptsStore: newMemoryAttemptsStore()})
+
+	for i := 1; i < maxCodeAttempts; i++ {
+		err := s.VerifyCode(ctx, userID, "000000", codeType)
+		assert.ErrorIs(t, err, autherrors.ErrInvalidVerificationCode)
+	}
+
+	err := s.VerifyCode(ctx, userID, "000000", codeType)
+	assert.ErrorIs(t, err, autherrors.ErrVerificationCodeAttemptsLimit)
+}
+
+func TestService_SendVerificationCode_Throttled(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	user := entities.NewUserInfo(entities.WithUserInfoInitSpec(entities.UserInfoInitSpec{
+		ID: userID, Username: "user", Email: "user@example.com",
+	}))
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	taskRepo := mocks.NewTasksRepository(t)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil).Once()
+	vcRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserVerificationCode")).Return(nil).Once()
+	taskRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.Task")).Return(nil).Once()
+
+	s := NewService(&Config{
+		UserInfoRepository:          userRepo,
+		VerificationCodesRepository: vcRepo,
+		TasksRepository:             taskRepo,
+		AttemptsStore:               newMemoryAttemptsStore(),
+	})
+
+	assert.NoError(t, s.SendVerificationCode(ctx, userID, entities.VerificationCodeEmail))
+	assert.ErrorIs(t, s.SendVerificationCode(ctx, userID, entities.VerificationCodeEmail), autherrors.ErrTooManyAttempts)
+}
+
+func TestService_SendRecoveryCode_ThrottledForUnknownEmail(t *testing.T) {
+	ctx := context.Background()
+	email := "nobody@example.com"
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: &email}, false).Return(nil, autherrors.ErrUserInfoNotFound).Once()
+
+	s := NewService(&Config{UserInfoRepository: userRepo, AttemptsStore: newMemoryAttemptsStore()})
+
+	assert.NoError(t, s.SendRecoveryCode(ctx, email, "10.0.0.1"))
+	assert.ErrorIs(t, s.SendRecoveryCode(ctx, email, "10.0.0.1"), autherrors.ErrTooManyAttempts)
+}
+
+// ──────────────────────────────────────────────────────────────
+// Two-factor authentication
+// ──────────────────────────────────────────────────────────────
+
+func newTOTPUser(username, password string) (*entities.UserInfo, string) {
+	user := newHashedUser(username, password)
+	secret, _ := totp.GenerateSecret()
+	user.StartTOTPEnrollment(secret)
+	user.EnableTOTP(0)
+	return user, secret
+}
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	return code
+}
+
+func TestService_Login_RequiresSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	user, _ := newTOTPUser("user", "password")
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+
+	s := NewService(&Config{UserInfoRepository: userRepo, UserRefreshTokensRepository: refreshRepo})
+
+	res, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "password"}, dto.SessionMetadata{DeviceName: "iPhone", Platform: "ios"})
+	assert.NoError(t, err)
+	assert.True(t, res.MFARequired)
+	assert.Empty(t, res.Tokens.AccessToken)
+	assert.Empty(t, res.Tokens.RefreshToken)
+
+	// MFA-токен не годится как access-токен: другая аудитория и нет user_id
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(res.MFAToken, claims)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "bodyfuel-api", claims["aud"])
+	assert.NotContains(t, claims, "user_id")
+
+	challenge, err := JWT.ParseMFAChallenge(res.MFAToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID(), challenge.UserID)
+	assert.Equal(t, "iPhone", challenge.DeviceName)
+}
+
+func TestService_VerifyMFA(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		code         func(t *testing.T, secret string) string
+		prepareMocks func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, codesRepo *mocks.UserRecoveryCodesRepository)
+		wantErr      error
+	}{
+		{
+			name: "valid totp code — session opened with device from login",
+			code: currentTOTPCode,
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, codesRepo *mocks.UserRecoveryCodesRepository) {
+				userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+				userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+					return u.TOTPLastStep() > 0
+				})).Return(nil)
+				refreshRepo.On("Create", mock.Anything, mock.MatchedBy(func(t *entities.UserRefreshToken) bool {
+					return t.DeviceName() == "iPhone" && t.Platform() == "ios"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "valid recovery code",
+			code: func(*testing.T, string) string { return "ABCD-EFGH" },
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, codesRepo *mocks.UserRecoveryCodesRepository) {
+				userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+				codesRepo.On("Use", mock.Anything, user.ID(), hashToken("abcdefgh")).Return(true, nil)
+				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+			},
+		},
+		{
+			name: "wrong code",
+			code: func(*testing.T, string) string { return "000000" },
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository, codesRepo *mocks.UserRecoveryCodesRepository) {
+				userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+				codesRepo.On("Use", mock.Anything, user.ID(), mock.Anything).Return(false, nil)
+			},
+			wantErr: autherrors.ErrInvalidMFACode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, secret := newTOTPUser("user", "password")
+			userRepo := mocks.NewUserInfoRepository(t)
+			refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+			codesRepo := mocks.NewUserRecoveryCodesRepository(t)
+			tt.prepareMocks(user, userRepo, refreshRepo, codesRepo)
+
+			s := NewService(&Config{
+				UserInfoRepository:          userRepo,
+				UserRefreshTokensRepository: refreshRepo,
+				RecoveryCodesRepository:     codesRepo,
+			})
+
+			mfaToken, err := JWT.GenerateMFAChallenge(JWT.MFAChallenge{UserID: user.ID(), DeviceName: "iPhone", Platform: "ios"})
+			assert.NoError(t, err)
+
+			pair, err := s.VerifyMFA(ctx, mfaToken, tt.code(t, secret), dto.SessionMetadata{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, pair.AccessToken)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, pair.AccessToken)
+				assert.NotEmpty(t, pair.RefreshToken)
+			}
+		})
+	}
+}
+
+func TestService_VerifyMFA_RejectsReplayedTOTPCode(t *testing.T) {
+	ctx := context.Background()
+	user, secret := newTOTPUser("user", "password")
+	user.UseTOTPStep(totp.Step(time.Now()) + totp.Skew)
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	codesRepo := mocks.NewUserRecoveryCodesRepository(t)
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+	codesRepo.On("Use", mock.Anything, user.ID(), mock.Anything).Return(false, nil)
+
+	s := NewService(&Config{UserInfoRepository: userRepo, RecoveryCodesRepository: codesRepo})
+
+	mfaToken, err := JWT.GenerateMFAChallenge(JWT.MFAChallenge{UserID: user.ID()})
+	assert.NoError(t, err)
+
+	_, err = s.VerifyMFA(ctx, mfaToken, currentTOTPCode(t, secret), dto.SessionMetadata{})
+	assert.ErrorIs(t, err, autherrors.ErrInvalidMFACode)
+}
+
+func TestService_VerifyMFA_InvalidChallenge(t *testing.T) {
+	s := NewService(&Config{})
+
+	_, err := s.VerifyMFA(context.Background(), "not-a-token", "123456", dto.SessionMetadata{})
+	assert.ErrorIs(t, err, autherrors.ErrInvalidMFAChallenge)
+}
+
+func TestService_EnrollAndConfirmTOTP(t *testing.T) {
+	ctx := context.Background()
+	user := newHashedUser("user", "password")
+	userID := user.ID()
+
+	txm := mocks.NewTransactionManager(t)
+	userRepo := mocks.NewUserInfoRepository(t)
+	codesRepo := mocks.NewUserRecoveryCodesRepository(t)
+	txm.On("Do", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) })
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil)
+	userRepo.On("Update", mock.Anything, user).Return(nil).Twice()
+	codesRepo.On("Replace", mock.Anything, userID, mock.MatchedBy(func(codes []*entities.UserRecoveryCode) bool {
+		return len(codes) == recoveryCodeCount
+	})).Return(nil)
+
+	s := NewService(&Config{TransactionManager: txm, UserInfoRepository: userRepo, RecoveryCodesRepository: codesRepo})
+
+	enrollment, err := s.EnrollTOTP(ctx, userID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/BodyFuel:user@example.com")
+	assert.False(t, user.IsTOTPEnabled())
+
+	_, err = s.ConfirmTOTP(ctx, userID, "000000")
+	assert.ErrorIs(t, err, autherrors.ErrInvalidMFACode)
+
+	codes, err := s.ConfirmTOTP(ctx, userID, currentTOTPCode(t, enrollment.Secret))
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-9]{4}-[a-z2-9]{4}$`, codes[0])
+	assert.True(t, user.IsTOTPEnabled())
+
+	_, err = s.EnrollTOTP(ctx, userID)
+	assert.ErrorIs(t, err, autherrors.ErrMFAAlreadyEnabled)
+}
+
+func TestService_ConfirmTOTP_NotStarted(t *testing.T) {
+	user := newHashedUser("user", "password")
+	userID := user.ID()
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil)
+
+	s := NewService(&Config{UserInfoRepository: userRepo})
+
+	_, err := s.ConfirmTOTP(context.Background(), userID, "123456")
+	assert.ErrorIs(t, err, autherrors.ErrMFAEnrollmentNotStarted)
+}
+
+func TestService_DisableTOTP(t *testing.T) {
+	ctx := context.Background()
+	user, secret := newTOTPUser("user", "password")
+	userID := user.ID()
+
+	txm := mocks.NewTransactionManager(t)
+	userRepo := mocks.NewUserInfoRepository(t)
+	codesRepo := mocks.NewUserRecoveryCodesRepository(t)
+	txm.On("Do", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) })
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil)
+	userRepo.On("Update", mock.Anything, user).Return(nil)
+	codesRepo.On("DeleteByUser", mock.Anything, userID).Return(nil)
+
+	s := NewService(&Config{TransactionManager: txm, UserInfoRepository: userRepo, RecoveryCodesRepository: codesRepo})
+
+	assert.NoError(t, s.DisableTOTP(ctx, userID, currentTOTPCode(t, secret)))
+	assert.False(t, user.IsTOTPEnabled())
+	assert.Empty(t, user.TOTPSecret())
+}
+
+// ──────────────────────────────────────────────────────────────
+// Sign in with Apple
+// ──────────────────────────────────────────────────────────────
+
+const testAppleClientID = "com.bodyfuel.app"
+
+// appleStub — локальная замена appleid.apple.com: отдаёт JWKS и подписывает identity token'ы.
+type appleStub struct {
+	key    *rsa.PrivateKey
+	server *httptest.Server
+}
+
+func newAppleStub(t *testing.T) *appleStub {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &appleStub{key: key}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "apple-test",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func (s *appleStub) verifier(t *testing.T) *apple.Verifier {
+	v, err := apple.NewVerifier(apple.Config{ClientIDs: []string{testAppleClientID}, JWKSURL: s.server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (s *appleStub) token(t *testing.T, overrides jwt.MapClaims) string {
+	claims := jwt.MapClaims{
+		"iss":            apple.DefaultIssuer,
+		"aud":            testAppleClientID,
+		"sub":            "001234.abcdef.0042",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(10 * time.Minute).Unix(),
+		"email":          "x7k2q9@privaterelay.appleid.com",
+		"email_verified": "true",
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "apple-test"
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestService_SignInWithApple(t *testing.T) {
+	ctx := context.Background()
+	stub := newAppleStub(t)
+	appleSub := "001234.abcdef.0042"
+
+	verifiedUser := func(email string) *entities.UserInfo {
+		user := newHashedUser("john", "password")
+		now := time.Now()
+		user.Update(entities.UserInfoUpdateParams{Email: &email, EmailVerifiedAt: &now})
+		return user
+	}
+
+	tests := []struct {
+		name         string
+		token        func(t *testing.T) string
+		prepareMocks func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository)
+		wantErr      error
+	}{
+		{
+			name:  "known apple id — signs in",
+			token: func(t *testing.T) string { return stub.token(t, nil) },
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				user := newHashedUser("john", "password")
+				user.LinkApple(appleSub)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{AppleSub: &appleSub}, false).Return(user, nil)
+				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+			},
+		},
+		{
+			name:  "new user with private relay email — created with verified email",
+			token: func(t *testing.T) string { return stub.token(t, jwt.MapClaims{"email_verified": false}) },
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{AppleSub: &appleSub}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: strPtr("x7k2q9@privaterelay.appleid.com")}, true).Return(nil, autherrors.ErrUserInfoNotFound)
+				userRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+					return u.AppleSub() == appleSub &&
+						u.Email() == "x7k2q9@privaterelay.appleid.com" &&
+						u.IsEmailVerified() &&
+						u.Name() == "John" &&
+						strings.HasPrefix(u.Username(), "apple_")
+				})).Return(nil)
+				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+			},
+		},
+		{
+			name:  "existing account with verified email — linked",
+			token: func(t *testing.T) string { return stub.token(t, jwt.MapClaims{"email": "john@example.com"}) },
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{AppleSub: &appleSub}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: strPtr("john@example.com")}, true).Return(verifiedUser("john@example.com"), nil)
+				userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+					return u.AppleSub() == appleSub
+				})).Return(nil)
+				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+			},
+		},
+		{
+			name:  "existing account with unverified email — conflict",
+			token: func(t *testing.T) string { return stub.token(t, jwt.MapClaims{"email": "john@example.com"}) },
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{AppleSub: &appleSub}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: strPtr("john@example.com")}, true).Return(newHashedUser("john", "password"), nil)
+			},
+			wantErr: autherrors.ErrAppleAccountConflict,
+		},
+		{
+			name:         "token for another app — rejected",
+			token:        func(t *testing.T) string { return stub.token(t, jwt.MapClaims{"aud": "com.other.app"}) },
+			prepareMocks: func(*mocks.UserInfoRepository, *mocks.UserRefreshTokensRepository) {},
+			wantErr:      autherrors.ErrInvalidAppleToken,
+		},
+		{
+			name: "expired token — rejected",
+			token: func(t *testing.T) string {
+				return stub.token(t, jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+			},
+			prepareMocks: func(*mocks.UserInfoRepository, *mocks.UserRefreshTokensRepository) {},
+			wantErr:      autherrors.ErrInvalidAppleToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txm := mocks.NewTransactionManager(t)
+			userRepo := mocks.NewUserInfoRepository(t)
+			refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+			txm.On("Do", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }).Maybe()
+			tt.prepareMocks(userRepo, refreshRepo)
+
+			s := NewService(&Config{
+				TransactionManager:          txm,
+				UserInfoRepository:          userRepo,
+				UserRefreshTokensRepository: refreshRepo,
+				AppleVerifier:               stub.verifier(t),
+			})
+
+			res, err := s.SignInWithApple(ctx, AppleSignInParams{IdentityToken: tt.token(t), Name: "John"}, dto.SessionMetadata{Platform: "ios"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, res.Tokens.AccessToken)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, res.Tokens.AccessToken)
+				assert.NotEmpty(t, res.Tokens.RefreshToken)
+			}
+		})
+	}
+}
+
+func TestService_SignInWithApple_Nonce(t *testing.T) {
+	stub := newAppleStub(t)
+	sum := sha256.Sum256([]byte("raw-nonce"))
+
+	s := NewService(&Config{AppleVerifier: stub.verifier(t)})
+
+	_, err := s.SignInWithApple(context.Background(), AppleSignInParams{
+		IdentityToken: stub.token(t, jwt.MapClaims{"nonce": hex.EncodeToString(sum[:])}),
+		Nonce:         "another-nonce",
+	}, dto.SessionMetadata{})
+	assert.ErrorIs(t, err, autherrors.ErrInvalidAppleToken)
+}
+
+func TestService_SignInWithApple_Disabled(t *testing.T) {
+	s := NewService(&Config{})
+
+	_, err := s.SignInWithApple(context.Background(), AppleSignInParams{IdentityToken: "token"}, dto.SessionMetadata{})
+	assert.ErrorIs(t, err, autherrors.ErrAppleSignInDisabled)
+}
+
+// ──────────────────────────────────────────────────────────────
+// Login by email / phone and passwordless codes
+// ──────────────────────────────────────────────────────────────
+
+func newVerifiedContactsUser(password string) *entities.UserInfo {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	now := time.Now()
+	return entities.NewUserInfo(entities.WithUserInfoRestoreSpec(entities.UserInfoRestoreSpec{
+		ID:              uuid.New(),
+		Username:        "user",
+		Password:        string(hashed),
+		Email:           "user@example.com",
+		Phone:           "+79001234567",
+		EmailVerifiedAt: &now,
+		PhoneVerifiedAt: &now,
+	}))
+}
+
+func TestService_Login_ByVerifiedContact(t *testing.T) {
+	ctx := context.Background()
+	user := newVerifiedContactsUser("password")
+	verified := true
+
+	tests := []struct {
+		name   string
+		login  string
+		filter dto.UserInfoFilter
+	}{
+		{
+			name:   "verified email",
+			login:  "user@example.com",
+			filter: dto.UserInfoFilter{Email: strPtr("user@example.com"), EmailVerified: &verified},
+		},
+		{
+			name:   "verified phone",
+			login:  "+79001234567",
+			filter: dto.UserInfoFilter{Phone: strPtr("+79001234567"), PhoneVerified: &verified},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewUserInfoRepository(t)
+			refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+			userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Username: &tt.login}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+			userRepo.On("Get", mock.Anything, tt.filter, false).Return(user, nil)
+			refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+
+			s := NewService(&Config{UserInfoRepository: userRepo, UserRefreshTokensRepository: refreshRepo})
+
+			res, err := s.Login(ctx, entities.UserAuthInitSpec{Login: tt.login, Password: "password"}, dto.SessionMetadata{})
+			assert.NoError(t, err)
+			assert.NotEmpty(t, res.Tokens.AccessToken)
+		})
+	}
+}
+
+func TestService_Login_UnverifiedEmailRejected(t *testing.T) {
+	userRepo := mocks.NewUserInfoRepository(t)
+	// репозиторий ищет только среди подтверждённых email, неподтверждённый адрес не находится
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(nil, autherrors.ErrUserInfoNotFound)
+
+	s := NewService(&Config{UserInfoRepository: userRepo})
+
+	_, err := s.Login(context.Background(), entities.UserAuthInitSpec{Login: "user@example.com", Password: "password"}, dto.SessionMetadata{})
+	assert.Error(t, err)
+	userRepo.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestService_SendLoginCode(t *testing.T) {
+	ctx := context.Background()
+	user := newVerifiedContactsUser("password")
+	unverified := newHashedUser("plain", "password")
+
+	tests := []struct {
+		name         string
+		login        string
+		prepareMocks func(userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository)
+	}{
+		{
+			name:  "email login — code goes to email",
+			login: "user@example.com",
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository) {
+				userRepo.On("Get", mock.Anything, mock.MatchedBy(func(f dto.UserInfoFilter) bool { return f.Username != nil }), false).
+					Return(nil, autherrors.ErrUserInfoNotFound)
+				userRepo.On("Get", mock.Anything, mock.MatchedBy(func(f dto.UserInfoFilter) bool { return f.Email != nil }), false).
+					Return(user, nil)
+				vcRepo.On("Create", mock.Anything, mock.MatchedBy(func(vc *entities.UserVerificationCode) bool {
+					return vc.CodeType() == entities.VerificationCodeLogin && vc.UserID() == user.ID()
+				})).Return(nil)
+				taskRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *entities.Task) bool {
+					p, err := entities.TaskKindSendCodeOnEmail.Payload(task)
+					return err == nil && p.Template == entities.NotificationTemplateLoginCode && p.Code != ""
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "phone login — code goes to sms",
+			login: "+79001234567",
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository) {
+				userRepo.On("Get", mock.Anything, mock.MatchedBy(func(f dto.UserInfoFilter) bool { return f.Username != nil }), false).
+					Return(nil, autherrors.ErrUserInfoNotFound)
+				userRepo.On("Get", mock.Anything, mock.MatchedBy(func(f dto.UserInfoFilter) bool { return f.Phone != nil }), false).
+					Return(user, nil)
+				vcRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserVerificationCode")).Return(nil)
+				taskRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *entities.Task) bool {
+					return task.TypeNm() == entities.TaskTypeSendCodeOnPhone
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "username without verified contacts — nothing sent",
+			login: "plain",
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Username: strPtr("plain")}, false).Return(unverified, nil)
+			},
+		},
+		{
+			name:  "unknown user — silently returns nil (anti-enumeration)",
+			login: "nobody",
+			prepareMocks: func(userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Username: strPtr("nobody")}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewUserInfoRepository(t)
+			vcRepo := mocks.NewUserVerificationCodesRepository(t)
+			taskRepo := mocks.NewTasksRepository(t)
+			tt.prepareMocks(userRepo, vcRepo, taskRepo)
+
+			s := NewService(&Config{
+				UserInfoRepository:          userRepo,
+				VerificationCodesRepository: vcRepo,
+				TasksRepository:             taskRepo,
+			})
+
+			assert.NoError(t, s.SendLoginCode(ctx, tt.login, "10.0.0.1"))
+		})
+	}
+}
+
+func TestService_LoginWithCode(t *testing.T) {
+	ctx := context.Background()
+	user := newVerifiedContactsUser("password")
+
+	tests := []struct {
+		name         string
+		code         string
+		prepareMocks func(vcRepo *mocks.UserVerificationCodesRepository, refreshRepo *mocks.UserRefreshTokensRepository)
+		wantErr      error
+	}{
+		{
+			name: "valid code — returns token pair",
+			code: "123456",
+			prepareMocks: func(vcRepo *mocks.UserVerificationCodesRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				vc := newVerificationCode(user.ID(), "123456", entities.VerificationCodeLogin, false, false)
+				vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+				vcRepo.On("MarkUsed", mock.Anything, vc.ID()).Return(nil)
+				refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "000000",
+			prepareMocks: func(vcRepo *mocks.UserVerificationCodesRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				vc := newVerificationCode(user.ID(), "123456", entities.VerificationCodeLogin, false, false)
+				vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+			},
+			wantErr: autherrors.ErrInvalidVerificationCode,
+		},
+		{
+			name: "used code",
+			code: "123456",
+			prepareMocks: func(vcRepo *mocks.UserVerificationCodesRepository, refreshRepo *mocks.UserRefreshTokensRepository) {
+				vc := newVerificationCode(user.ID(), "123456", entities.VerificationCodeLogin, false, true)
+				vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+			},
+			wantErr: autherrors.ErrVerificationCodeAlreadyUsed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewUserInfoRepository(t)
+			vcRepo := mocks.NewUserVerificationCodesRepository(t)
+			refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+			userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Username: strPtr("user")}, false).Return(user, nil)
+			tt.prepareMocks(vcRepo, refreshRepo)
+
+			s := NewService(&Config{
+				UserInfoRepository:          userRepo,
+				VerificationCodesRepository: vcRepo,
+				UserRefreshTokensRepository: refreshRepo,
+			})
+
+			res, err := s.LoginWithCode(ctx, "user", tt.code, dto.SessionMetadata{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, res.Tokens.AccessToken)
+		})
+	}
+}
+
+// ──────────────────────────────────────────────────────────────
+// Email / phone change
+// ──────────────────────────────────────────────────────────────
+
+var errContactLookup = errors.New("db error")
+
+func TestService_RequestContactChange(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		codeType     entities.VerificationCodeType
+		value        string
+		prepareMocks func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository, txm *mocks.TransactionManager)
+		wantErr      error
+	}{
+		{
+			name:     "email — code to new address, notice to old",
+			codeType: entities.VerificationCodeEmailChange,
+			value:    "New@Example.com",
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository, txm *mocks.TransactionManager) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: uuidPtr(user.ID())}, false).Return(user, nil)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: strPtr("new@example.com")}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+				txm.On("Do", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) })
+				vcRepo.On("Create", mock.Anything, mock.MatchedBy(func(vc *entities.UserVerificationCode) bool {
+					return vc.CodeType() == entities.VerificationCodeEmailChange && vc.Target() == "new@example.com"
+				})).Return(nil)
+				taskRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *entities.Task) bool {
+					p, _ := entities.TaskKindSendCodeOnEmail.Payload(task)
+					return p.Email == "new@example.com" && p.Code != ""
+				})).Return(nil).Once()
+				taskRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *entities.Task) bool {
+					p, _ := entities.TaskKindSendCodeOnEmail.Payload(task)
+					return p.Email == "user@example.com" && p.Code == ""
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name:     "same email — rejected",
+			codeType: entities.VerificationCodeEmailChange,
+			value:    "user@example.com",
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository, txm *mocks.TransactionManager) {
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: uuidPtr(user.ID())}, false).Return(user, nil)
+			},
+			wantErr: autherrors.ErrContactUnchanged,
+		},
+		{
+			name:     "phone verified by another user — rejected",
+			codeType: entities.VerificationCodePhoneChange,
+			value:    "+79990000000",
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository, txm *mocks.TransactionManager) {
+				verified := true
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: uuidPtr(user.ID())}, false).Return(user, nil)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Phone: strPtr("+79990000000"), PhoneVerified: &verified}, false).
+					Return(newHashedUser("other", "password"), nil)
+			},
+			wantErr: autherrors.ErrContactTaken,
+		},
+		{
+			name:     "lookup error — not treated as free",
+			codeType: entities.VerificationCodePhoneChange,
+			value:    "+79990000000",
+			prepareMocks: func(user *entities.UserInfo, userRepo *mocks.UserInfoRepository, vcRepo *mocks.UserVerificationCodesRepository, taskRepo *mocks.TasksRepository, txm *mocks.TransactionManager) {
+				verified := true
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: uuidPtr(user.ID())}, false).Return(user, nil)
+				userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Phone: strPtr("+79990000000"), PhoneVerified: &verified}, false).
+					Return(nil, errContactLookup)
+			},
+			wantErr: errContactLookup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newVerifiedContactsUser("password")
+			userRepo := mocks.NewUserInfoRepository(t)
+			vcRepo := mocks.NewUserVerificationCodesRepository(t)
+			taskRepo := mocks.NewTasksRepository(t)
+			txm := mocks.NewTransactionManager(t)
+			tt.prepareMocks(user, userRepo, vcRepo, taskRepo, txm)
+
+			s := NewService(&Config{
+				TransactionManager:          txm,
+				UserInfoRepository:          userRepo,
+				VerificationCodesRepository: vcRepo,
+				TasksRepository:             taskRepo,
+			})
+
+			err := s.RequestContactChange(ctx, user.ID(), tt.codeType, tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_ConfirmContactChange(t *testing.T) {
+	ctx := context.Background()
+	user := newVerifiedContactsUser("password")
+	userID := user.ID()
+
+	vc := entities.NewUserVerificationCode(entities.WithUserVerificationCodeInitSpec(entities.UserVerificationCodeInitSpec{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  hashToken("123456"),
+		CodeType:  entities.VerificationCodeEmailChange,
+		Target:    "new@example.com",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	txm := mocks.NewTransactionManager(t)
+	txm.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) })
+	vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+	vcRepo.On("MarkUsed", mock.Anything, vc.ID()).Return(nil)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, true).Return(user, nil)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: strPtr("new@example.com")}, false).Return(nil, autherrors.ErrUserInfoNotFound)
+	userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+		return u.Email() == "new@example.com" && u.IsEmailVerified() && u.EmailVerifiedAt().After(time.Now().Add(-time.Minute))
+	})).Return(nil)
+
+	s := NewService(&Config{
+		TransactionManager:          txm,
+		UserInfoRepository:          userRepo,
+		VerificationCodesRepository: vcRepo,
+	})
+
+	assert.NoError(t, s.ConfirmContactChange(ctx, userID, entities.VerificationCodeEmailChange, "123456"))
+}
+
+func TestService_ConfirmContactChange_LookupError(t *testing.T) {
+	ctx := context.Background()
+	user := newVerifiedContactsUser("password")
+	userID := user.ID()
+	verified := true
+
+	vc := entities.NewUserVerificationCode(entities.WithUserVerificationCodeInitSpec(entities.UserVerificationCodeInitSpec{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  hashToken("123456"),
+		CodeType:  entities.VerificationCodePhoneChange,
+		Target:    "+79990000000",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	txm := mocks.NewTransactionManager(t)
+	txm.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) })
+	vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, true).Return(user, nil)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Phone: strPtr("+79990000000"), PhoneVerified: &verified}, false).
+		Return(nil, errContactLookup)
+
+	s := NewService(&Config{
+		TransactionManager:          txm,
+		UserInfoRepository:          userRepo,
+		VerificationCodesRepository: vcRepo,
+	})
+
+	// телефон не уникален в базе: при сбое проверки его нельзя подтверждать, иначе он окажется у двух аккаунтов
+	err := s.ConfirmContactChange(ctx, userID, entities.VerificationCodePhoneChange, "123456")
+	assert.ErrorIs(t, err, errContactLookup)
+	userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	vcRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+}
+
+func TestService_VerifyCode_RejectsCodeForPreviousAddress(t *testing.T) {
+	user := newHashedUser("user", "password")
+	userID := user.ID()
+
+	vc := entities.NewUserVerificationCode(entities.WithUserVerificationCodeInitSpec(entities.UserVerificationCodeInitSpec{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  hashToken("123456"),
+		CodeType:  entities.VerificationCodeEmail,
+		Target:    "old@example.com",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	vcRepo.On("GetLatest", mock.Anything, mock.Anything).Return(vc, nil)
+	vcRepo.On("MarkUsed", mock.Anything, vc.ID()).Return(nil)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil)
+
+	s := NewService(&Config{UserInfoRepository: userRepo, VerificationCodesRepository: vcRepo})
+
+	err := s.VerifyCode(context.Background(), userID, "123456", entities.VerificationCodeEmail)
+	assert.ErrorIs(t, err, autherrors.ErrInvalidVerificationCode)
+}
+
+// ──────────────────────────────────────────────────────────────
+// Personal access tokens
+// ──────────────────────────────────────────────────────────────
+
+func TestService_CreatePersonalToken(t *testing.T) {
+	userID := uuid.New()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		tokenName    string
+		scopes       []string
+		expiresAt    *time.Time
+		prepareMocks func(repo *mocks.UserPersonalTokensRepository)
+		wantErr      error
+		wantScopes   []entities.PersonalTokenScope
+	}{
+		{
+			name:      "success dedups scopes",
+			tokenName: "  export script ",
+			scopes:    []string{"nutrition:read", "workouts:write", "nutrition:read"},
+			expiresAt: &future,
+			prepareMocks: func(repo *mocks.UserPersonalTokensRepository) {
+				repo.On("CountByUser", mock.Anything, userID).Return(0, nil)
+				repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantScopes: []entities.PersonalTokenScope{
+				entities.PersonalTokenScopeNutritionRead,
+				entities.PersonalTokenScopeWorkoutsWrite,
+			},
+		},
+		{
+			name:         "unknown scope",
+			tokenName:    "script",
+			scopes:       []string{"nutrition:read", "admin:write"},
+			prepareMocks: func(repo *mocks.UserPersonalTokensRepository) {},
+			wantErr:      autherrors.ErrUnknownPersonalTokenScope,
+		},
+		{
+			name:         "no scopes",
+			tokenName:    "script",
+			prepareMocks: func(repo *mocks.UserPersonalTokensRepository) {},
+			wantErr:      autherrors.ErrPersonalTokenScopesRequired,
+		},
+		{
+			name:         "empty name",
+			tokenName:    "   ",
+			scopes:       []string{"weight:read"},
+			prepareMocks: func(repo *mocks.UserPersonalTokensRepository) {},
+			wantErr:      autherrors.ErrPersonalTokenNameInvalid,
+		},
+		{
+			name:         "expiry in the past",
+			tokenName:    "script",
+			scopes:       []string{"weight:read"},
+			expiresAt:    &past,
+			prepareMocks: func(repo *mocks.UserPersonalTokensRepository) {},
+			wantErr:      autherrors.ErrPersonalTokenExpiryInPast,
+		},
+		{
+			name:      "limit reached",
+			tokenName: "script",
+			scopes:    []string{"weight:read"},
+			prepareMocks: func(repo *mocks.UserPersonalTokensRepository) {
+				repo.On("CountByUser", mock.Anything, userID).Return(maxPersonalTokens, nil)
+			},
+			wantErr: autherrors.ErrPersonalTokensLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewUserPersonalTokensRepository(t)
+			tt.prepareMocks(repo)
+
+			s := NewService(&Config{PersonalTokensRepository: repo})
+
+			created, err := s.CreatePersonalToken(context.Background(), userID, tt.tokenName, tt.scopes, tt.expiresAt)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(created.Raw, JWT.PersonalTokenPrefix))
+			assert.Equal(t, hashToken(created.Raw), created.Token.TokenHash())
+			assert.Equal(t, "export script", created.Token.Name())
+			assert.Equal(t, tt.wantScopes, created.Token.Scopes())
+			assert.Equal(t, tt.expiresAt, created.Token.ExpiresAt())
+		})
+	}
+}
+
+func TestService_VerifyPersonalToken(t *testing.T) {
+	user := newHashedUser("user", "password")
+	userID := user.ID()
+	raw := JWT.PersonalTokenPrefix + "secret"
+
+	newToken := func(expiresAt *time.Time) *entities.UserPersonalToken {
+		return entities.NewUserPersonalToken(entities.WithUserPersonalTokenInitSpec(entities.UserPersonalTokenInitSpec{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Name:      "script",
+			TokenHash: hashToken(raw),
+			Scopes:    []entities.PersonalTokenScope{entities.PersonalTokenScopeWeightRead},
+			ExpiresAt: expiresAt,
+		}))
+	}
+
+	t.Run("success", func(t *testing.T) {
+		token := newToken(nil)
+		repo := mocks.NewUserPersonalTokensRepository(t)
+		userRepo := mocks.NewUserInfoRepository(t)
+		repo.On("GetByHash", mock.Anything, hashToken(raw)).Return(token, nil)
+		repo.On("Touch", mock.Anything, token.ID(), "10.0.0.1").Return(nil)
+		userRepo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &userID}, false).Return(user, nil)
+
+		s := NewService(&Config{PersonalTokensRepository: repo, UserInfoRepository: userRepo})
+
+		identity, err := s.VerifyPersonalToken(context.Background(), raw, "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Equal(t, JWT.PersonalTokenIdentity{
+			TokenID: token.ID(),
+			UserID:  userID,
+			Role:    entities.UserRoleUser.String(),
+			Scopes:  []string{"weight:read"},
+		}, identity)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := time.Now().Add(-time.Minute)
+		repo := mocks.NewUserPersonalTokensRepository(t)
+		repo.On("GetByHash", mock.Anything, hashToken(raw)).Return(newToken(&expired), nil)
+
+		s := NewService(&Config{PersonalTokensRepository: repo})
+
+		_, err := s.VerifyPersonalToken(context.Background(), raw, "10.0.0.1")
+		assert.ErrorIs(t, err, autherrors.ErrInvalidPersonalToken)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		repo := mocks.NewUserPersonalTokensRepository(t)
+		repo.On("GetByHash", mock.Anything, hashToken(raw)).Return(nil, autherrors.ErrPersonalTokenNotFound)
+
+		s := NewService(&Config{PersonalTokensRepository: repo})
+
+		_, err := s.VerifyPersonalToken(context.Background(), raw, "10.0.0.1")
+		assert.ErrorIs(t, err, autherrors.ErrInvalidPersonalToken)
+	})
+}
+
+func TestService_RevokePersonalToken_NotOwned(t *testing.T) {
+	userID, tokenID := uuid.New(), uuid.New()
+
+	repo := mocks.NewUserPersonalTokensRepository(t)
+	repo.On("Delete", mock.Anything, tokenID, userID).Return(autherrors.ErrPersonalTokenNotFound)
+
+	s := NewService(&Config{PersonalTokensRepository: repo})
+
+	err := s.RevokePersonalToken(context.Background(), userID, tokenID)
+	assert.ErrorIs(t, err, autherrors.ErrPersonalTokenNotFound)
+}
+
+func uuidPtr(id uuid.UUID) *uuid.UUID { return &id }
+
+// ──────────────────────────────────────────────────────────────
+// Audit
+// ──────────────────────────────────────────────────────────────
+
+func TestService_Login_AuditsResult(t *testing.T) {
+	ctx := context.Background()
+	user := newHashedUser("user", "password")
+	userID := user.ID()
+	meta := dto.SessionMetadata{IP: "10.0.0.1", UserAgent: "BodyFuel/1.0"}
+
+	t.Run("success", func(t *testing.T) {
+		userRepo := mocks.NewUserInfoRepository(t)
+		refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+		auditRepo := mocks.NewAuditEventsRepository(t)
+		userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+		refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+		auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+			return e.Type() == entities.AuditEventLogin &&
+				*e.UserID() == userID && *e.ActorID() == userID &&
+				e.IP() == "10.0.0.1" && e.UserAgent() == "BodyFuel/1.0" &&
+				e.Details()["method"] == "password" && e.Details()["session_id"] != ""
+		})).Return(nil)
+
+		s := NewService(&Config{UserInfoRepository: userRepo, UserRefreshTokensRepository: refreshRepo, AuditEventsRepository: auditRepo})
+
+		_, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "password"}, meta)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		userRepo := mocks.NewUserInfoRepository(t)
+		auditRepo := mocks.NewAuditEventsRepository(t)
+		userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+		auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+			return e.Type() == entities.AuditEventLoginFailed &&
+				*e.UserID() == userID && e.ActorID() == nil &&
+				e.Details()["reason"] == "invalid_password"
+		})).Return(nil)
+
+		s := NewService(&Config{UserInfoRepository: userRepo, AuditEventsRepository: auditRepo})
+
+		_, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "wrong"}, meta)
+		assert.ErrorIs(t, err, autherrors.ErrInvalidCredentials)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		userRepo := mocks.NewUserInfoRepository(t)
+		auditRepo := mocks.NewAuditEventsRepository(t)
+		userRepo.On("Get", mock.Anything, mock.Anything, false).Return(nil, autherrors.ErrUserInfoNotFound)
+		auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+			return e.Type() == entities.AuditEventLoginFailed && e.UserID() == nil &&
+				e.Details()["login"] == "ghost" && e.Details()["reason"] == "unknown_user"
+		})).Return(nil)
+
+		s := NewService(&Config{UserInfoRepository: userRepo, AuditEventsRepository: auditRepo})
+
+		_, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "ghost", Password: "password"}, meta)
+		assert.Error(t, err)
+	})
+
+	t.Run("audit failure does not break login", func(t *testing.T) {
+		userRepo := mocks.NewUserInfoRepository(t)
+		refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+		auditRepo := mocks.NewAuditEventsRepository(t)
+		userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+		refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+		auditRepo.On("Create", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		s := NewService(&Config{UserInfoRepository: userRepo, UserRefreshTokensRepository: refreshRepo, AuditEventsRepository: auditRepo})
+
+		res, err := s.Login(ctx, entities.UserAuthInitSpec{Login: "user", Password: "password"}, meta)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, res.Tokens.AccessToken)
+	})
+}
+
+func TestService_Refresh_AuditsTokenReuse(t *testing.T) {
+	rawToken := "replayedrawtoken1"
+	family := newActiveRefreshToken(uuid.New(), "currentrawtoken")
+	familyID, userID := family.ID(), family.UserID()
+	hash := hashToken(rawToken)
+
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	auditRepo := mocks.NewAuditEventsRepository(t)
+	refreshRepo.On("Get", mock.Anything, dto.UserRefreshTokenFilter{TokenHash: &hash}).Return(nil, autherrors.ErrSessionNotFound)
+	refreshRepo.On("Get", mock.Anything, dto.UserRefreshTokenFilter{RotatedTokenHash: &hash}).Return(family, nil)
+	refreshRepo.On("Delete", mock.Anything, dto.UserRefreshTokenFilter{ID: &familyID}).Return(nil)
+	auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+		return e.Type() == entities.AuditEventRefreshTokenReuse &&
+			*e.UserID() == userID && e.IP() == "10.0.0.2" &&
+			e.Details()["session_id"] == familyID.String()
+	})).Return(nil)
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo, AuditEventsRepository: auditRepo})
+
+	_, err := s.Refresh(context.Background(), rawToken, dto.SessionMetadata{IP: "10.0.0.2"})
+	assert.ErrorIs(t, err, autherrors.ErrRefreshTokenReused)
+}
+
+func TestService_Logout_AuditsEvent(t *testing.T) {
+	userID, sessionID := uuid.New(), uuid.New()
+	ctx := context.WithValue(context.Background(), dto.RequestMetadataKey, dto.RequestMetadata{IP: "10.0.0.3", UserAgent: "curl"})
+	filter := dto.UserRefreshTokenFilter{ID: &sessionID, UserID: &userID}
+
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	auditRepo := mocks.NewAuditEventsRepository(t)
+	refreshRepo.On("Get", mock.Anything, filter).Return(newActiveRefreshToken(userID, "raw"), nil)
+	refreshRepo.On("Delete", mock.Anything, filter).Return(nil)
+	auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+		return e.Type() == entities.AuditEventLogout && e.IP() == "10.0.0.3" && e.UserAgent() == "curl" &&
+			e.Details()["session_id"] == sessionID.String()
+	})).Return(nil)
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo, AuditEventsRepository: auditRepo})
+
+	assert.NoError(t, s.Logout(ctx, userID, sessionID))
+}
+
+// ──────────────────────────────────────────────────────────────
+// Password policy
+// ──────────────────────────────────────────────────────────────
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func newPasswordPolicy(t *testing.T, cfg password.Config) *password.Policy {
+	t.Helper()
+	p, err := password.NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("password policy: %v", err)
+	}
+	return p
+}
+
+func TestService_Register_PasswordPolicy(t *testing.T) {
+	breachedFile := filepath.Join(t.TempDir(), "breached.txt")
+	assert.NoError(t, os.WriteFile(breachedFile, []byte(sha1Hex("Summer2024!")+":1234\n"), 0o600))
+
+	rangeDir := t.TempDir()
+	hash := sha1Hex("Winter2024!")
+	assert.NoError(t, os.WriteFile(filepath.Join(rangeDir, hash[:5]+".txt"), []byte("0000000000000000000000000000000000A:1\r\n"+hash[5:]+":42\r\n"), 0o600))
+
+	tests := []struct {
+		name       string
+		list       string
+		password   string
+		wantReason error
+	}{
+		{name: "too short", password: "Ab1!", wantReason: password.ErrTooShort},
+		{name: "too few character classes", password: "onlylowercase", wantReason: password.ErrTooFewClasses},
+		{name: "matches username", password: "John_Doe99", wantReason: password.ErrMatchesIdentity},
+		{name: "matches email local part", password: "Jdoe.Mail1", wantReason: password.ErrMatchesIdentity},
+		{name: "breached — hash file", list: breachedFile, password: "Summer2024!", wantReason: password.ErrBreached},
+		{name: "breached — range directory", list: rangeDir, password: "Winter2024!", wantReason: password.ErrBreached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newPasswordPolicy(t, password.Config{MinLength: 8, MinCharClasses: 3, BreachedList: tt.list})
+			s := NewService(&Config{PasswordPolicy: policy})
+
+			err := s.Register(context.Background(), entities.UserInfoInitSpec{
+				Username: "john_doe99",
+				Email:    "jdoe.mail1@example.com",
+				Password: tt.password,
+			})
+
+			assert.ErrorIs(t, err, autherrors.ErrWeakPassword)
+			var policyErr *autherrors.PasswordPolicyError
+			if assert.ErrorAs(t, err, &policyErr) {
+				assert.Contains(t, policyErr.Reason, tt.wantReason.Error())
+			}
+		})
+	}
+}
+
+func TestService_ResetPassword_RejectsWeakPassword(t *testing.T) {
+	email := "user@example.com"
+	user := entities.NewUserInfo(entities.WithUserInfoInitSpec(entities.UserInfoInitSpec{ID: uuid.New(), Username: "user", Email: email}))
+	userID := user.ID()
+	codeType := entities.VerificationCodeRecover
+	vc := newVerificationCode(userID, "654321", codeType, false, false)
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	userRepo.On("Get", mock.Anything, dto.UserInfoFilter{Email: &email}, false).Return(user, nil)
+	vcRepo.On("GetLatest", mock.Anything, dto.UserVerificationCodeFilter{UserID: &userID, CodeType: &codeType}).Return(vc, nil)
+
+	s := NewService(&Config{
+		UserInfoRepository:          userRepo,
+		VerificationCodesRepository: vcRepo,
+		PasswordPolicy:              newPasswordPolicy(t, password.Config{}),
+	})
+
+	// код не помечается использованным: после отказа можно повторить с другим паролем
+	err := s.ResetPassword(context.Background(), email, "654321", "short", "10.0.0.1")
+	assert.ErrorIs(t, err, autherrors.ErrWeakPassword)
+}
+
+func TestService_Login_RehashesPasswordOnCostChange(t *testing.T) {
+	user := newHashedUser("user", "password")
+	policy := newPasswordPolicy(t, password.Config{BcryptCost: bcrypt.MinCost})
+
+	userRepo := mocks.NewUserInfoRepository(t)
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	userRepo.On("Get", mock.Anything, mock.Anything, false).Return(user, nil)
+	userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+		cost, err := bcrypt.Cost([]byte(u.Password()))
+		return err == nil && cost == bcrypt.MinCost &&
+			bcrypt.CompareHashAndPassword([]byte(u.Password()), []byte("password")) == nil
+	})).Return(nil).Once()
+	refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.UserRefreshToken")).Return(nil)
+
+	s := NewService(&Config{UserInfoRepository: userRepo, UserRefreshTokensRepository: refreshRepo, PasswordPolicy: policy})
+
+	_, err := s.Login(context.Background(), entities.UserAuthInitSpec{Login: "user", Password: "password"}, dto.SessionMetadata{})
+	assert.NoError(t, err)
+
+	// хэш уже с нужной стоимостью — повторный вход ничего не пересчитывает
+	_, err = s.Login(context.Background(), entities.UserAuthInitSpec{Login: "user", Password: "password"}, dto.SessionMetadata{})
+	assert.NoError(t, err)
+}
+
+func TestService_CleanupExpired_DeletesOlderThanKeep(t *testing.T) {
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+
+	keepBoundary := mock.MatchedBy(func(before time.Time) bool {
+		d := time.Since(before) - 7*24*time.Hour
+		return d >= 0 && d < time.Minute
+	})
+	refreshRepo.On("DeleteExpired", mock.Anything, keepBoundary).Return(3, nil).Once()
+	vcRepo.On("DeleteExpired", mock.Anything, keepBoundary).Return(5, nil).Once()
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo, VerificationCodesRepository: vcRepo})
+
+	err := s.CleanupExpired(context.Background(), 7*24*time.Hour)
+	assert.NoError(t, err)
+}
+
+func TestService_CleanupExpired_SessionsErrorStops(t *testing.T) {
+	refreshRepo := mocks.NewUserRefreshTokensRepository(t)
+	vcRepo := mocks.NewUserVerificationCodesRepository(t)
+	refreshRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(0, errors.New("db down")).Once()
+
+	s := NewService(&Config{UserRefreshTokensRepository: refreshRepo, VerificationCodesRepository: vcRepo})
+
+	err := s.CleanupExpired(context.Background(), 0)
+	assert.Error(t, err)
+	vcRepo.AssertNotCalled(t, "DeleteExpired", mock.Anything, mock.Anything)
+}
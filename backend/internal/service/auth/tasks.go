@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/service/executor"
+	"backend/pkg/logging"
+	"context"
+	"fmt"
+	"time"
+)
+
+// cleanupTaskTimeout — по одному DELETE на таблицу, но таблицы сессий бывают большими
+const cleanupTaskTimeout = time.Minute
+
+// RegisterTaskHandlers регистрирует в исполнителе очистку истёкших сессий и кодов подтверждения.
+// Задачу создаёт расписание cleanup_expired_auth.
+func (u *Service) RegisterTaskHandlers(r *executor.Registry) {
+	executor.Register(r, entities.TaskKindCleanupExpiredAuth, executor.Handler[entities.CleanupTaskPayload]{
+		Handle: func(ctx context.Context, _ *entities.Task, p entities.CleanupTaskPayload) error {
+			return u.CleanupExpired(ctx, time.Duration(p.KeepDays)*24*time.Hour)
+		},
+		Timeout: cleanupTaskTimeout,
+	})
+}
+
+// CleanupExpired удаляет сессии и коды подтверждения, срок которых истёк больше keep назад.
+// Истёкшие записи уже ни на что не годятся, а хранятся только для разбора инцидентов.
+func (u *Service) CleanupExpired(ctx context.Context, keep time.Duration) error {
+	before := time.Now().Add(-keep)
+
+	sessions, err := u.refreshTokensRepo.DeleteExpired(ctx, before)
+	if err != nil {
+		return fmt.Errorf("cleanup expired sessions: %w", err)
+	}
+
+	codes, err := u.verificationCodesRepo.DeleteExpired(ctx, before)
+	if err != nil {
+		return fmt.Errorf("cleanup expired verification codes: %w", err)
+	}
+
+	logging.GetLoggerFromContext(ctx).Infof("Deleted %d sessions and %d verification codes expired before %s",
+		sessions, codes, before.Format(time.RFC3339))
+
+	return nil
+}
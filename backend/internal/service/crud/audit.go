@@ -0,0 +1,42 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/pkg/logging"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// audit пишет изменение данных пользователя userID в журнал безопасности. Автор, IP и User-Agent
+// берутся из метаданных запроса: при правке профиля администратором автором будет он.
+// Ошибка записи только логируется; вызывать после транзакции, чтобы не откатить её.
+func (s *Service) audit(ctx context.Context, eventType entities.AuditEventType, userID uuid.UUID, changes map[string]entities.AuditChange, details map[string]string) {
+	if s.auditEventsRepository == nil {
+		return
+	}
+
+	meta := dto.RequestMetadataFromContext(ctx)
+	event := entities.NewAuditEvent(entities.WithAuditEventInitSpec(entities.AuditEventInitSpec{
+		UserID:    &userID,
+		ActorID:   meta.ActorID,
+		Type:      eventType,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		Changes:   changes,
+		Details:   details,
+	}))
+	if err := s.auditEventsRepository.Create(ctx, event); err != nil {
+		logging.GetLoggerFromContext(ctx).Warnf("crud: audit %s: %v", eventType, err)
+	}
+}
+
+func (s *Service) ListAuditEvents(ctx context.Context, f dto.AuditEventFilter) ([]*entities.AuditEvent, error) {
+	events, err := s.auditEventsRepository.List(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("list audit events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,103 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/service/crud/mocks"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_UpdateInfoUser_AuditsRoleChangeByAdmin(t *testing.T) {
+	id, adminID := uuid.New(), uuid.New()
+	ctx := context.WithValue(context.Background(), dto.RequestMetadataKey, dto.RequestMetadata{IP: "10.0.0.1", ActorID: &adminID})
+	user := entities.NewUserInfo(entities.WithUserInfoInitSpec(entities.UserInfoInitSpec{ID: id, Name: "John"}))
+
+	repo := mocks.NewUserInfoRepository(t)
+	auditRepo := mocks.NewAuditEventsRepository(t)
+	repo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &id}, false).Return(user, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+		return e.Type() == entities.AuditEventRoleChanged &&
+			*e.UserID() == id && *e.ActorID() == adminID && e.IP() == "10.0.0.1" &&
+			assert.ObjectsAreEqual(map[string]entities.AuditChange{
+				"role": {Old: "user", New: "coach"},
+				"name": {Old: "John", New: "Jack"},
+			}, e.Changes())
+	})).Return(nil)
+
+	s := NewService(&Config{
+		UserInfoRepository:    repo,
+		TransactionManager:    &passThroughTxManager{},
+		AuditEventsRepository: auditRepo,
+	})
+
+	role := entities.UserRoleCoach
+	err := s.UpdateInfoUser(ctx, dto.UserInfoFilter{ID: &id}, entities.UserInfoUpdateParams{Role: &role, Name: strPtr("Jack")})
+	assert.NoError(t, err)
+}
+
+func TestService_UpdateInfoUser_RedactsPassword(t *testing.T) {
+	id := uuid.New()
+	user := entities.NewUserInfo(entities.WithUserInfoInitSpec(entities.UserInfoInitSpec{ID: id}))
+
+	repo := mocks.NewUserInfoRepository(t)
+	auditRepo := mocks.NewAuditEventsRepository(t)
+	repo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &id}, false).Return(user, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(e *entities.AuditEvent) bool {
+		return e.Type() == entities.AuditEventProfileUpdated &&
+			assert.ObjectsAreEqual(map[string]entities.AuditChange{
+				"password": {Old: entities.AuditRedacted, New: entities.AuditRedacted},
+			}, e.Changes())
+	})).Return(nil)
+
+	s := NewService(&Config{
+		UserInfoRepository:    repo,
+		TransactionManager:    &passThroughTxManager{},
+		AuditEventsRepository: auditRepo,
+	})
+
+	err := s.UpdateInfoUser(context.Background(), dto.UserInfoFilter{ID: &id}, entities.UserInfoUpdateParams{Password: strPtr("$2a$10$hash")})
+	assert.NoError(t, err)
+}
+
+func TestService_UpdateParamsUser_SkipsAuditWithoutChanges(t *testing.T) {
+	userID := uuid.New()
+	f := dto.UserParamsFilter{UserID: &userID}
+	params := newTestUserParams(userID)
+	height := params.Height()
+
+	repo := mocks.NewUserParamsRepository(t)
+	auditRepo := mocks.NewAuditEventsRepository(t)
+	repo.On("Get", mock.Anything, f, false).Return(params, nil)
+	repo.On("Update", mock.Anything, params).Return(nil)
+
+	s := NewService(&Config{
+		UserParamsRepository:  repo,
+		TransactionManager:    &passThroughTxManager{},
+		AuditEventsRepository: auditRepo,
+	})
+
+	err := s.UpdateParamsUser(context.Background(), f, entities.UserParamsUpdateParams{Height: &height})
+	assert.NoError(t, err)
+}
+
+func TestService_ListAuditEvents(t *testing.T) {
+	userID := uuid.New()
+	f := dto.AuditEventFilter{UserID: &userID}
+	event := entities.NewAuditEvent(entities.WithAuditEventInitSpec(entities.AuditEventInitSpec{UserID: &userID, Type: entities.AuditEventLogin}))
+
+	auditRepo := mocks.NewAuditEventsRepository(t)
+	auditRepo.On("List", mock.Anything, f).Return([]*entities.AuditEvent{event}, nil)
+
+	s := NewService(&Config{AuditEventsRepository: auditRepo})
+
+	events, err := s.ListAuditEvents(context.Background(), f)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entities.AuditEvent{event}, events)
+}
@@ -0,0 +1,88 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegisterLiveActivity сохраняет push-токен Live Activity тренировки. Тренировка должна принадлежать пользователю.
+func (s *Service) RegisterLiveActivity(ctx context.Context, spec entities.LiveActivityInitSpec) error {
+	if s.liveActivitiesRepository == nil {
+		return fmt.Errorf("register live activity: live activities are not configured")
+	}
+
+	f := dto.WorkoutsFilter{ID: &spec.WorkoutID, UserID: &spec.UserID}
+	if _, err := s.workoutsRepository.Get(ctx, f, false); err != nil {
+		return fmt.Errorf("register live activity: get workout: %w", err)
+	}
+
+	if err := s.liveActivitiesRepository.Upsert(ctx, entities.NewLiveActivity(spec)); err != nil {
+		return fmt.Errorf("register live activity: %w", err)
+	}
+
+	return nil
+}
+
+// pushLiveActivity ставит обновление Live Activity на каждое устройство, где запущена активность тренировки.
+// Вызывается в транзакции изменения: обновление уходит, только если изменение сохранено. workout nil —
+// тренировка загружается, только если активности есть. Завершённая тренировка закрывает активности,
+// и их токены удаляются.
+func (s *Service) pushLiveActivity(ctx context.Context, workoutID uuid.UUID, workout *entities.Workout) error {
+	if s.liveActivitiesRepository == nil {
+		return nil
+	}
+
+	activities, err := s.liveActivitiesRepository.List(ctx, dto.LiveActivityFilter{WorkoutID: &workoutID})
+	if err != nil {
+		return fmt.Errorf("list live activities: %w", err)
+	}
+	if len(activities) == 0 {
+		return nil
+	}
+
+	if workout == nil {
+		if workout, err = s.workoutsRepository.Get(ctx, dto.WorkoutsFilter{ID: &workoutID}, false); err != nil {
+			return fmt.Errorf("get workout: %w", err)
+		}
+	}
+
+	exercises, err := s.workoutsExerciseRepository.List(ctx, dto.WorkoutsExerciseFilter{WorkoutID: &workoutID}, false)
+	if err != nil {
+		return fmt.Errorf("list workout exercises: %w", err)
+	}
+
+	state := entities.NewLiveActivityState(workout, exercises)
+	if state.CurrentExerciseID != nil && s.exercisesRepository != nil {
+		// Без названия активность всё равно показывает прогресс, поэтому ошибка не прерывает обновление.
+		if exercise, err := s.exercisesRepository.Get(ctx, dto.ExerciseFilter{ID: state.CurrentExerciseID}, false); err == nil {
+			state.CurrentExerciseName = exercise.Name()
+		}
+	}
+
+	now := time.Now()
+	for _, a := range activities {
+		task := entities.TaskKindSendLiveActivity.NewTask(entities.LiveActivityTaskPayload{
+			UserID:    a.UserID(),
+			WorkoutID: workoutID,
+			PushToken: a.PushToken(),
+			State:     state,
+			Timestamp: now,
+		}, entities.TaskSchedule{})
+		if err := s.tasksRepository.Create(ctx, task); err != nil {
+			return fmt.Errorf("create live activity task: %w", err)
+		}
+	}
+
+	if state.Ended() {
+		if err := s.liveActivitiesRepository.Delete(ctx, dto.LiveActivityFilter{WorkoutID: &workoutID}); err != nil {
+			return fmt.Errorf("delete ended live activities: %w", err)
+		}
+	}
+
+	return nil
+}
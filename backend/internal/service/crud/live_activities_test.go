@@ -0,0 +1,314 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/service/crud/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ── inline mocks for LiveActivitiesRepository and WorkoutsExerciseRepository ──
+
+type mockLiveActivitiesRepository struct{ mock.Mock }
+
+func (m *mockLiveActivitiesRepository) Upsert(ctx context.Context, a *entities.LiveActivity) error {
+	return m.Called(ctx, a).Error(0)
+}
+
+func (m *mockLiveActivitiesRepository) List(ctx context.Context, f dto.LiveActivityFilter) ([]*entities.LiveActivity, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.LiveActivity), args.Error(1)
+}
+
+func (m *mockLiveActivitiesRepository) Delete(ctx context.Context, f dto.LiveActivityFilter) error {
+	return m.Called(ctx, f).Error(0)
+}
+
+type mockWorkoutsExerciseRepository struct{ mock.Mock }
+
+func (m *mockWorkoutsExerciseRepository) Get(ctx context.Context, f dto.WorkoutsExerciseFilter, withBlock bool) (*entities.WorkoutsExercise, error) {
+	args := m.Called(ctx, f, withBlock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.WorkoutsExercise), args.Error(1)
+}
+
+func (m *mockWorkoutsExerciseRepository) Create(ctx context.Context, we *entities.WorkoutsExercise) error {
+	return m.Called(ctx, we).Error(0)
+}
+
+func (m *mockWorkoutsExerciseRepository) Update(ctx context.Context, we *entities.WorkoutsExercise) error {
+	return m.Called(ctx, we).Error(0)
+}
+
+func (m *mockWorkoutsExerciseRepository) Delete(ctx context.Context, f dto.WorkoutsExerciseFilter) error {
+	return m.Called(ctx, f).Error(0)
+}
+
+func (m *mockWorkoutsExerciseRepository) List(ctx context.Context, f dto.WorkoutsExerciseFilter, withBlock bool) ([]*entities.WorkoutsExercise, error) {
+	args := m.Called(ctx, f, withBlock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.WorkoutsExercise), args.Error(1)
+}
+
+// ── helpers ────────────────────────────────────────────────────────────────
+
+type liveActivityDeps struct {
+	activities *mockLiveActivitiesRepository
+	workouts   *mocks.WorkoutsRepository
+	exercises  *mockWorkoutsExerciseRepository
+	catalog    *mocks.ExercisesRepository
+	tasks      *mockTasksRepository
+}
+
+func newLiveActivityService() (*Service, liveActivityDeps) {
+	d := liveActivityDeps{
+		activities: &mockLiveActivitiesRepository{},
+		workouts:   &mocks.WorkoutsRepository{},
+		exercises:  &mockWorkoutsExerciseRepository{},
+		catalog:    &mocks.ExercisesRepository{},
+		tasks:      &mockTasksRepository{},
+	}
+	s := NewService(&Config{
+		TransactionManager:         &passThroughTxManager{},
+		WorkoutsRepository:         d.workouts,
+		WorkoutsExerciseRepository: d.exercises,
+		ExercisesRepository:        d.catalog,
+		TasksRepository:            d.tasks,
+		LiveActivitiesRepository:   d.activities,
+	})
+	return s, d
+}
+
+func newLiveActivityWorkout(id, userID uuid.UUID, status entities.WorkoutsStatus) *entities.Workout {
+	now := time.Now()
+	return entities.NewWorkout(entities.WithWorkoutRestoreSpec(entities.WorkoutRestoreSpec{
+		ID:        id,
+		UserID:    userID,
+		Status:    status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+}
+
+func newLiveActivityExercise(workoutID, exerciseID uuid.UUID, status entities.ExerciseStatus, order int) *entities.WorkoutsExercise {
+	return entities.NewWorkoutsExercise(entities.WithWorkoutsExerciseRestoreSpec(entities.WorkoutsExerciseRestoreSpec{
+		WorkoutID:  workoutID,
+		ExerciseID: exerciseID,
+		ModifyReps: 12,
+		Status:     status,
+		OrderIndex: order,
+	}))
+}
+
+func liveActivityPayloads(t *testing.T, tasks *mockTasksRepository) []entities.LiveActivityTaskPayload {
+	t.Helper()
+	var payloads []entities.LiveActivityTaskPayload
+	for _, call := range tasks.Calls {
+		if call.Method != "Create" {
+			continue
+		}
+		task := call.Arguments.Get(1).(*entities.Task)
+		require.Equal(t, entities.TaskTypeSendLiveActivity, task.TypeNm())
+		p, err := entities.TaskKindSendLiveActivity.Payload(task)
+		require.NoError(t, err)
+		payloads = append(payloads, p)
+	}
+	return payloads
+}
+
+// ── RegisterLiveActivity ───────────────────────────────────────────────────
+
+func TestRegisterLiveActivity_Success(t *testing.T) {
+	userID, workoutID := uuid.New(), uuid.New()
+	s, d := newLiveActivityService()
+
+	d.workouts.On("Get", mock.Anything, dto.WorkoutsFilter{ID: &workoutID, UserID: &userID}, false).
+		Return(newLiveActivityWorkout(workoutID, userID, entities.WorkoutStatusInActive), nil)
+	d.activities.On("Upsert", mock.Anything, mock.MatchedBy(func(a *entities.LiveActivity) bool {
+		return a.WorkoutID() == workoutID && a.UserID() == userID && a.PushToken() == "activity-token"
+	})).Return(nil)
+
+	err := s.RegisterLiveActivity(context.Background(), entities.LiveActivityInitSpec{
+		UserID:    userID,
+		WorkoutID: workoutID,
+		PushToken: "activity-token",
+	})
+
+	assert.NoError(t, err)
+	d.activities.AssertExpectations(t)
+}
+
+func TestRegisterLiveActivity_ForeignWorkout(t *testing.T) {
+	s, d := newLiveActivityService()
+
+	d.workouts.On("Get", mock.Anything, mock.Anything, false).Return(nil, errors.New("not found"))
+
+	err := s.RegisterLiveActivity(context.Background(), entities.LiveActivityInitSpec{
+		UserID:    uuid.New(),
+		WorkoutID: uuid.New(),
+		PushToken: "activity-token",
+	})
+
+	assert.Error(t, err)
+	d.activities.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+// ── pushLiveActivity ───────────────────────────────────────────────────────
+
+func TestUpdateWorkoutByFilter_PushesLiveActivity(t *testing.T) {
+	userID, workoutID := uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+	s, d := newLiveActivityService()
+
+	d.workouts.On("Get", mock.Anything, mock.Anything, true).
+		Return(newLiveActivityWorkout(workoutID, userID, entities.WorkoutStatusCreated), nil)
+	d.workouts.On("Update", mock.Anything, mock.Anything).Return(nil)
+	d.activities.On("List", mock.Anything, dto.LiveActivityFilter{WorkoutID: &workoutID}).
+		Return([]*entities.LiveActivity{
+			entities.NewLiveActivity(entities.LiveActivityInitSpec{UserID: userID, WorkoutID: workoutID, PushToken: "phone"}),
+			entities.NewLiveActivity(entities.LiveActivityInitSpec{UserID: userID, WorkoutID: workoutID, PushToken: "watch"}),
+		}, nil)
+	d.exercises.On("List", mock.Anything, dto.WorkoutsExerciseFilter{WorkoutID: &workoutID}, false).
+		Return([]*entities.WorkoutsExercise{
+			newLiveActivityExercise(workoutID, second, entities.ExerciseStatusPending, 2),
+			newLiveActivityExercise(workoutID, first, entities.ExerciseStatusCompleted, 1),
+		}, nil)
+	d.catalog.On("Get", mock.Anything, dto.ExerciseFilter{ID: &second}, false).
+		Return(entities.NewExercise(entities.WithExerciseRestoreSpec(entities.ExerciseRestoreSpec{ID: second, Name: "Приседания"})), nil)
+	d.tasks.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	status := entities.WorkoutStatusInActive
+	err := s.UpdateWorkoutByFilter(context.Background(), dto.WorkoutsFilter{ID: &workoutID}, entities.WorkoutUpdateParams{Status: &status})
+	require.NoError(t, err)
+
+	payloads := liveActivityPayloads(t, d.tasks)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, "phone", payloads[0].PushToken)
+	assert.Equal(t, "watch", payloads[1].PushToken)
+
+	state := payloads[0].State
+	assert.Equal(t, entities.WorkoutStatusInActive, state.Status)
+	require.NotNil(t, state.CurrentExerciseID)
+	assert.Equal(t, second, *state.CurrentExerciseID)
+	assert.Equal(t, "Приседания", state.CurrentExerciseName)
+	assert.Equal(t, 12, state.CurrentExerciseReps)
+	assert.Equal(t, 2, state.ExerciseIndex)
+	assert.Equal(t, 2, state.ExercisesTotal)
+	assert.Equal(t, 1, state.CompletedExercises)
+	assert.False(t, payloads[0].Timestamp.IsZero())
+
+	d.activities.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestUpdateWorkoutByFilter_EndsLiveActivity(t *testing.T) {
+	userID, workoutID := uuid.New(), uuid.New()
+	s, d := newLiveActivityService()
+
+	d.workouts.On("Get", mock.Anything, mock.Anything, true).
+		Return(newLiveActivityWorkout(workoutID, userID, entities.WorkoutStatusInActive), nil)
+	d.workouts.On("Update", mock.Anything, mock.Anything).Return(nil)
+	d.activities.On("List", mock.Anything, mock.Anything).
+		Return([]*entities.LiveActivity{
+			entities.NewLiveActivity(entities.LiveActivityInitSpec{UserID: userID, WorkoutID: workoutID, PushToken: "phone"}),
+		}, nil)
+	d.exercises.On("List", mock.Anything, mock.Anything, false).
+		Return([]*entities.WorkoutsExercise{
+			newLiveActivityExercise(workoutID, uuid.New(), entities.ExerciseStatusCompleted, 1),
+		}, nil)
+	d.tasks.On("Create", mock.Anything, mock.Anything).Return(nil)
+	d.activities.On("Delete", mock.Anything, dto.LiveActivityFilter{WorkoutID: &workoutID}).Return(nil)
+
+	status := entities.WorkoutStatusDone
+	err := s.UpdateWorkoutByFilter(context.Background(), dto.WorkoutsFilter{ID: &workoutID}, entities.WorkoutUpdateParams{Status: &status})
+	require.NoError(t, err)
+
+	payloads := liveActivityPayloads(t, d.tasks)
+	require.Len(t, payloads, 1)
+	assert.True(t, payloads[0].State.Ended())
+	assert.Nil(t, payloads[0].State.CurrentExerciseID)
+
+	d.activities.AssertExpectations(t)
+	d.catalog.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateWorkoutByFilter_SameStatusSkipsLiveActivity(t *testing.T) {
+	userID, workoutID := uuid.New(), uuid.New()
+	s, d := newLiveActivityService()
+
+	d.workouts.On("Get", mock.Anything, mock.Anything, true).
+		Return(newLiveActivityWorkout(workoutID, userID, entities.WorkoutStatusInActive), nil)
+	d.workouts.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	status := entities.WorkoutStatusInActive
+	err := s.UpdateWorkoutByFilter(context.Background(), dto.WorkoutsFilter{ID: &workoutID}, entities.WorkoutUpdateParams{Status: &status})
+	require.NoError(t, err)
+
+	d.activities.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	d.tasks.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateWorkoutExerciseByFilter_PushesLiveActivity(t *testing.T) {
+	userID, workoutID, exerciseID := uuid.New(), uuid.New(), uuid.New()
+	s, d := newLiveActivityService()
+
+	we := newLiveActivityExercise(workoutID, exerciseID, entities.ExerciseStatusPending, 1)
+	d.exercises.On("Get", mock.Anything, mock.Anything, true).Return(we, nil)
+	d.exercises.On("Update", mock.Anything, we).Return(nil)
+	d.activities.On("List", mock.Anything, dto.LiveActivityFilter{WorkoutID: &workoutID}).
+		Return([]*entities.LiveActivity{
+			entities.NewLiveActivity(entities.LiveActivityInitSpec{UserID: userID, WorkoutID: workoutID, PushToken: "phone"}),
+		}, nil)
+	d.workouts.On("Get", mock.Anything, dto.WorkoutsFilter{ID: &workoutID}, false).
+		Return(newLiveActivityWorkout(workoutID, userID, entities.WorkoutStatusInActive), nil)
+	d.exercises.On("List", mock.Anything, mock.Anything, false).Return([]*entities.WorkoutsExercise{we}, nil)
+	d.catalog.On("Get", mock.Anything, mock.Anything, false).Return(nil, errors.New("not found"))
+	d.tasks.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	status := entities.ExerciseStatusInProgress
+	err := s.UpdateWorkoutExerciseByFilter(context.Background(),
+		dto.WorkoutsExerciseFilter{WorkoutID: &workoutID, ExerciseID: &exerciseID},
+		entities.WorkoutsExerciseUpdateParams{Status: &status})
+	require.NoError(t, err)
+
+	// Без названия упражнения обновление всё равно уходит
+	payloads := liveActivityPayloads(t, d.tasks)
+	require.Len(t, payloads, 1)
+	require.NotNil(t, payloads[0].State.CurrentExerciseID)
+	assert.Equal(t, exerciseID, *payloads[0].State.CurrentExerciseID)
+	assert.Empty(t, payloads[0].State.CurrentExerciseName)
+	assert.Equal(t, 1, payloads[0].State.ExerciseIndex)
+}
+
+func TestUpdateWorkoutExerciseByFilter_NoLiveActivities(t *testing.T) {
+	workoutID, exerciseID := uuid.New(), uuid.New()
+	s, d := newLiveActivityService()
+
+	we := newLiveActivityExercise(workoutID, exerciseID, entities.ExerciseStatusPending, 1)
+	d.exercises.On("Get", mock.Anything, mock.Anything, true).Return(we, nil)
+	d.exercises.On("Update", mock.Anything, we).Return(nil)
+	d.activities.On("List", mock.Anything, mock.Anything).Return([]*entities.LiveActivity{}, nil)
+
+	status := entities.ExerciseStatusCompleted
+	err := s.UpdateWorkoutExerciseByFilter(context.Background(),
+		dto.WorkoutsExerciseFilter{WorkoutID: &workoutID, ExerciseID: &exerciseID},
+		entities.WorkoutsExerciseUpdateParams{Status: &status})
+	require.NoError(t, err)
+
+	d.workouts.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	d.tasks.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
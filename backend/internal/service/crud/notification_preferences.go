@@ -0,0 +1,45 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/errors"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetNotificationPreferences возвращает настройки уведомлений; если пользователь их не менял — настройки по умолчанию.
+func (s *Service) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error) {
+	prefs, err := s.notificationPreferencesRepository.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// UpdateNotificationPreferences заменяет настройки уведомлений целиком. Уже поставленные уведомления
+// подчиняются новым настройкам: исполнитель сверяется с ними перед отправкой.
+func (s *Service) UpdateNotificationPreferences(
+	ctx context.Context,
+	userID uuid.UUID,
+	p entities.NotificationPreferencesUpdateParams,
+) (*entities.NotificationPreferences, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidNotificationPreferences, err)
+	}
+
+	prefs, err := s.notificationPreferencesRepository.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get notification preferences: %w", err)
+	}
+
+	prefs.Update(p)
+
+	if err = s.notificationPreferencesRepository.Save(ctx, prefs); err != nil {
+		return nil, fmt.Errorf("save notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
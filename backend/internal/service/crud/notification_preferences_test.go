@@ -0,0 +1,123 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	errs "backend/internal/errors"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ── inline mocks ───────────────────────────────────────────────────────────
+
+type mockNotificationPreferencesRepository struct{ mock.Mock }
+
+func (m *mockNotificationPreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.NotificationPreferences), args.Error(1)
+}
+
+func (m *mockNotificationPreferencesRepository) Save(ctx context.Context, p *entities.NotificationPreferences) error {
+	return m.Called(ctx, p).Error(0)
+}
+
+// ── UpdateNotificationPreferences ──────────────────────────────────────────
+
+func TestUpdateNotificationPreferences_ReplacesMatrixAndQuietHours(t *testing.T) {
+	userID := uuid.New()
+	prefs := entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID:     userID,
+		Timezone:   "Europe/Moscow",
+		QuietHours: &entities.QuietHours{From: 23 * 60, To: 8 * 60},
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategoryWorkouts: {entities.NotificationChannelSMS: false},
+		},
+	})
+
+	repo := &mockNotificationPreferencesRepository{}
+	repo.On("Get", mock.Anything, userID).Return(prefs, nil).Once()
+	repo.On("Save", mock.Anything, prefs).Return(nil).Once()
+
+	svc := &Service{notificationPreferencesRepository: repo}
+	got, err := svc.UpdateNotificationPreferences(context.Background(), userID, entities.NotificationPreferencesUpdateParams{
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategoryRecommendations: {entities.NotificationChannelPush: false},
+		},
+	})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	// не переданные пары снова включены, тихие часы выключены
+	assert.True(t, got.Allows(entities.NotificationCategoryWorkouts, entities.NotificationChannelSMS))
+	assert.False(t, got.Allows(entities.NotificationCategoryRecommendations, entities.NotificationChannelPush))
+	assert.Nil(t, got.QuietHours())
+	assert.Equal(t, "Europe/Moscow", got.Timezone())
+}
+
+func TestUpdateNotificationPreferences_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		params entities.NotificationPreferencesUpdateParams
+	}{
+		{
+			name: "security email",
+			params: entities.NotificationPreferencesUpdateParams{Channels: entities.NotificationMatrix{
+				entities.NotificationCategorySecurity: {entities.NotificationChannelEmail: false},
+			}},
+		},
+		{
+			name: "unknown category",
+			params: entities.NotificationPreferencesUpdateParams{Channels: entities.NotificationMatrix{
+				"marketing": {entities.NotificationChannelEmail: false},
+			}},
+		},
+		{
+			name: "unknown channel",
+			params: entities.NotificationPreferencesUpdateParams{Channels: entities.NotificationMatrix{
+				entities.NotificationCategoryWorkouts: {"telegram": true},
+			}},
+		},
+		{
+			name:   "empty quiet hours",
+			params: entities.NotificationPreferencesUpdateParams{QuietHours: &entities.QuietHours{From: 600, To: 600}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockNotificationPreferencesRepository{}
+			svc := &Service{notificationPreferencesRepository: repo}
+
+			_, err := svc.UpdateNotificationPreferences(context.Background(), uuid.New(), tt.params)
+
+			assert.ErrorIs(t, err, errs.ErrInvalidNotificationPreferences)
+			repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateNotificationPreferences_SecurityPushCanBeDisabled(t *testing.T) {
+	userID := uuid.New()
+	prefs := entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{UserID: userID})
+
+	repo := &mockNotificationPreferencesRepository{}
+	repo.On("Get", mock.Anything, userID).Return(prefs, nil).Once()
+	repo.On("Save", mock.Anything, prefs).Return(nil).Once()
+
+	svc := &Service{notificationPreferencesRepository: repo}
+	got, err := svc.UpdateNotificationPreferences(context.Background(), userID, entities.NotificationPreferencesUpdateParams{
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategorySecurity: {entities.NotificationChannelPush: false, entities.NotificationChannelEmail: true},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, got.Allows(entities.NotificationCategorySecurity, entities.NotificationChannelPush))
+	assert.True(t, got.Allows(entities.NotificationCategorySecurity, entities.NotificationChannelEmail))
+}
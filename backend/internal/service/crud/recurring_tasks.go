@@ -0,0 +1,78 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	"backend/internal/errors"
+	"backend/pkg/cron"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ListRecurringTasks возвращает расписания задач.
+func (s *Service) ListRecurringTasks(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error) {
+	list, err := s.recurringTasksRepository.List(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring tasks: %w", err)
+	}
+
+	return list, nil
+}
+
+// UpdateRecurringTask меняет расписание по имени. Новые cron, часовой пояс и нагрузка проверяются
+// заранее: исполнитель пропускает срабатывания неверного расписания.
+func (s *Service) UpdateRecurringTask(ctx context.Context, name string, p entities.RecurringTaskUpdateParams) (*entities.RecurringTask, error) {
+	var rt *entities.RecurringTask
+	err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		rt, err = s.recurringTasksRepository.Get(ctx, name, true)
+		if err != nil {
+			return fmt.Errorf("get recurring task: %w", err)
+		}
+
+		if err = validateRecurringTaskUpdate(rt, p); err != nil {
+			return err
+		}
+
+		rt.Update(p)
+
+		if err = s.recurringTasksRepository.Update(ctx, rt); err != nil {
+			return fmt.Errorf("update recurring task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+func validateRecurringTaskUpdate(rt *entities.RecurringTask, p entities.RecurringTaskUpdateParams) error {
+	if p.Cron != nil {
+		if _, err := cron.Parse(*p.Cron); err != nil {
+			return fmt.Errorf("%w: cron: %v", errors.ErrInvalidRecurringTask, err)
+		}
+	}
+	if p.Timezone != nil {
+		if _, err := time.LoadLocation(*p.Timezone); err != nil || *p.Timezone == "" || *p.Timezone == "Local" {
+			return fmt.Errorf("%w: unknown timezone %q", errors.ErrInvalidRecurringTask, *p.Timezone)
+		}
+	}
+	if p.MaxAttempts != nil && *p.MaxAttempts < 0 {
+		return fmt.Errorf("%w: max_attempts must not be negative", errors.ErrInvalidRecurringTask)
+	}
+	if p.Attribute != nil {
+		if !json.Valid(p.Attribute) || !bytes.HasPrefix(bytes.TrimSpace(p.Attribute), []byte("{")) {
+			return fmt.Errorf("%w: attribute must be a JSON object", errors.ErrInvalidRecurringTask)
+		}
+		if _, err := entities.DecodeTaskPayload(rt.TypeNm(), p.Attribute); err != nil {
+			return fmt.Errorf("%w: attribute does not match %s: %v", errors.ErrInvalidRecurringTask, rt.TypeNm(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package crud
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ── inline mocks ───────────────────────────────────────────────────────────
+
+type mockRecurringTasksRepository struct{ mock.Mock }
+
+func (m *mockRecurringTasksRepository) List(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.RecurringTask), args.Error(1)
+}
+
+func (m *mockRecurringTasksRepository) Get(ctx context.Context, name string, withBlock bool) (*entities.RecurringTask, error) {
+	args := m.Called(ctx, name, withBlock)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RecurringTask), args.Error(1)
+}
+
+func (m *mockRecurringTasksRepository) Update(ctx context.Context, t *entities.RecurringTask) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+// ── helpers ────────────────────────────────────────────────────────────────
+
+func newRecurringTaskService(repo *mockRecurringTasksRepository) *Service {
+	return &Service{
+		transactionManager:       &passThroughTxManager{},
+		recurringTasksRepository: repo,
+	}
+}
+
+func newTestRecurringTask(enabled bool, checkedUntil time.Time) *entities.RecurringTask {
+	return entities.NewRecurringTask(entities.WithRecurringTaskRestoreSpec(entities.RecurringTaskRestoreSpec{
+		ID:           uuid.New(),
+		Name:         "meal_reminder",
+		Cron:         "0 13 * * *",
+		TypeNm:       entities.TaskTypeSendReminder,
+		Attribute:    json.RawMessage(`{"title": "Обед"}`),
+		Scope:        entities.RecurringTaskScopePerUser,
+		Timezone:     "UTC",
+		Enabled:      enabled,
+		CheckedUntil: checkedUntil,
+	}))
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// ── UpdateRecurringTask ────────────────────────────────────────────────────
+
+func TestUpdateRecurringTask_Enable_ResetsCheckedUntil(t *testing.T) {
+	repo := &mockRecurringTasksRepository{}
+	// выключенное месяц назад расписание не должно догонять срабатывания за этот месяц
+	rt := newTestRecurringTask(false, time.Now().AddDate(0, -1, 0))
+	repo.On("Get", mock.Anything, "meal_reminder", true).Return(rt, nil).Once()
+	repo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	got, err := newRecurringTaskService(repo).UpdateRecurringTask(context.Background(), "meal_reminder", entities.RecurringTaskUpdateParams{
+		Cron:    ptr("30 12 * * 1-5"),
+		Enabled: ptr(true),
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, got.IsEnabled())
+	assert.Equal(t, "30 12 * * 1-5", got.Cron())
+	assert.WithinDuration(t, time.Now(), got.CheckedUntil(), time.Minute)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateRecurringTask_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		p    entities.RecurringTaskUpdateParams
+	}{
+		{"bad cron", entities.RecurringTaskUpdateParams{Cron: ptr("0 25 * * *")}},
+		{"unknown timezone", entities.RecurringTaskUpdateParams{Timezone: ptr("Mars/Olympus")}},
+		{"local timezone", entities.RecurringTaskUpdateParams{Timezone: ptr("Local")}},
+		{"negative max attempts", entities.RecurringTaskUpdateParams{MaxAttempts: ptr(-1)}},
+		{"attribute not object", entities.RecurringTaskUpdateParams{Attribute: json.RawMessage(`[1, 2]`)}},
+		{"attribute does not match type", entities.RecurringTaskUpdateParams{Attribute: json.RawMessage(`{"title": 5}`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRecurringTasksRepository{}
+			repo.On("Get", mock.Anything, "meal_reminder", true).Return(newTestRecurringTask(true, time.Now()), nil).Once()
+
+			_, err := newRecurringTaskService(repo).UpdateRecurringTask(context.Background(), "meal_reminder", tt.p)
+
+			assert.ErrorIs(t, err, errs.ErrInvalidRecurringTask)
+			repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateRecurringTask_NotFound(t *testing.T) {
+	repo := &mockRecurringTasksRepository{}
+	repo.On("Get", mock.Anything, "missing", true).Return(nil, errs.ErrRecurringTaskNotFound).Once()
+
+	_, err := newRecurringTaskService(repo).UpdateRecurringTask(context.Background(), "missing", entities.RecurringTaskUpdateParams{Enabled: ptr(false)})
+
+	assert.ErrorIs(t, err, errs.ErrRecurringTaskNotFound)
+}
@@ -35,8 +35,21 @@ type (
 	TasksRepository interface {
 		List(ctx context.Context, f dto.TasksFilter, withBlock bool) ([]*entities.Task, error)
 		Get(ctx context.Context, f dto.TasksFilter, withBlock bool) (*entities.Task, error)
+		Create(ctx context.Context, t *entities.Task) error
 		Update(ctx context.Context, t *entities.Task) error
 		Delete(ctx context.Context, ids []uuid.UUID) error
+		RestartByFilter(ctx context.Context, f dto.TasksFilter) (int, error)
+		DeleteByFilter(ctx context.Context, f dto.TasksFilter) (int, error)
+	}
+
+	TaskAttemptsRepository interface {
+		List(ctx context.Context, f dto.TaskAttemptsFilter) ([]*entities.TaskAttempt, error)
+	}
+
+	RecurringTasksRepository interface {
+		List(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error)
+		Get(ctx context.Context, name string, withBlock bool) (*entities.RecurringTask, error)
+		Update(ctx context.Context, t *entities.RecurringTask) error
 	}
 
 	UserDevicesRepository interface {
@@ -85,6 +98,22 @@ type (
 		Get(ctx context.Context, f dto.ExerciseFilter, withBlock bool) (*entities.Exercise, error)
 	}
 
+	AuditEventsRepository interface {
+		Create(ctx context.Context, e *entities.AuditEvent) error
+		List(ctx context.Context, f dto.AuditEventFilter) ([]*entities.AuditEvent, error)
+	}
+
+	NotificationPreferencesRepository interface {
+		Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error)
+		Save(ctx context.Context, p *entities.NotificationPreferences) error
+	}
+
+	LiveActivitiesRepository interface {
+		Upsert(ctx context.Context, a *entities.LiveActivity) error
+		List(ctx context.Context, f dto.LiveActivityFilter) ([]*entities.LiveActivity, error)
+		Delete(ctx context.Context, f dto.LiveActivityFilter) error
+	}
+
 	TransactionManager interface {
 		Do(ctx context.Context, fn func(ctx context.Context) error) (err error)
 	}
@@ -96,12 +125,19 @@ type Config struct {
 	UserParamsRepository       UserParamsRepository
 	UserWeightRepository       UserWeightRepository
 	TasksRepository            TasksRepository
+	TaskAttemptsRepository     TaskAttemptsRepository
+	RecurringTasksRepository   RecurringTasksRepository
 	ExercisesRepository        ExercisesRepository
 	WorkoutsRepository         WorkoutsRepository
 	WorkoutsExerciseRepository WorkoutsExerciseRepository
 	UserDevicesRepository      UserDevicesRepository
 	UserCaloriesRepository     UserCaloriesRepository
+	AuditEventsRepository      AuditEventsRepository
 	Log                        logging.Entry
+
+	NotificationPreferencesRepository NotificationPreferencesRepository
+	// LiveActivitiesRepository — токены Live Activity тренировок. Без него обновления не отправляются.
+	LiveActivitiesRepository LiveActivitiesRepository
 }
 
 type Service struct {
@@ -110,12 +146,18 @@ type Service struct {
 	userParamsRepository       UserParamsRepository
 	userWeightRepository       UserWeightRepository
 	tasksRepository            TasksRepository
+	taskAttemptsRepository     TaskAttemptsRepository
+	recurringTasksRepository   RecurringTasksRepository
 	exercisesRepository        ExercisesRepository
 	workoutsRepository         WorkoutsRepository
 	workoutsExerciseRepository WorkoutsExerciseRepository
 	userDevicesRepository      UserDevicesRepository
 	userCaloriesRepository     UserCaloriesRepository
+	auditEventsRepository      AuditEventsRepository
 	log                        logging.Entry
+
+	notificationPreferencesRepository NotificationPreferencesRepository
+	liveActivitiesRepository          LiveActivitiesRepository
 }
 
 func NewService(c *Config) *Service {
@@ -125,11 +167,17 @@ func NewService(c *Config) *Service {
 		userParamsRepository:       c.UserParamsRepository,
 		userWeightRepository:       c.UserWeightRepository,
 		tasksRepository:            c.TasksRepository,
+		taskAttemptsRepository:     c.TaskAttemptsRepository,
+		recurringTasksRepository:   c.RecurringTasksRepository,
 		exercisesRepository:        c.ExercisesRepository,
 		workoutsRepository:         c.WorkoutsRepository,
 		workoutsExerciseRepository: c.WorkoutsExerciseRepository,
 		userDevicesRepository:      c.UserDevicesRepository,
 		userCaloriesRepository:     c.UserCaloriesRepository,
+		auditEventsRepository:      c.AuditEventsRepository,
 		log:                        c.Log,
+
+		notificationPreferencesRepository: c.NotificationPreferencesRepository,
+		liveActivitiesRepository:          c.LiveActivitiesRepository,
 	}
 }
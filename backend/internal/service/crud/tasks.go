@@ -3,14 +3,17 @@ package crud
 import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"context"
 	"fmt"
 
 	"github.com/google/uuid"
 )
 
-func (s *Service) GetTask(ctx context.Context, id uuid.UUID) (*entities.Task, error) {
-	t, err := s.tasksRepository.Get(ctx, dto.TasksFilter{IDs: []uuid.UUID{id}}, false)
+// GetTask возвращает задачу по фильтру. Для пользовательских ручек фильтр всегда содержит UserID,
+// поэтому чужая задача выглядит как несуществующая.
+func (s *Service) GetTask(ctx context.Context, f dto.TasksFilter) (*entities.Task, error) {
+	t, err := s.tasksRepository.Get(ctx, f, false)
 	if err != nil {
 		return nil, fmt.Errorf("get task: %w", err)
 	}
@@ -26,11 +29,11 @@ func (s *Service) ListTasks(ctx context.Context, filter dto.TasksFilter) ([]*ent
 	return ts, nil
 }
 
-func (s *Service) RestartTask(ctx context.Context, id uuid.UUID) error {
+// RestartTask перезапускает задачу по фильтру в любом состоянии. Только для администратора:
+// ограничения RestartUserTask здесь не действуют.
+func (s *Service) RestartTask(ctx context.Context, f dto.TasksFilter) error {
 	if err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
-		t, err := s.tasksRepository.Get(ctx, dto.TasksFilter{
-			IDs: []uuid.UUID{id},
-		}, true)
+		t, err := s.tasksRepository.Get(ctx, f, true)
 		if err != nil {
 			return fmt.Errorf("get task: %w", err)
 		}
@@ -49,10 +52,86 @@ func (s *Service) RestartTask(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (s *Service) DeleteTask(ctx context.Context, id uuid.UUID) error {
-	if err := s.tasksRepository.Delete(ctx, []uuid.UUID{id}); err != nil {
-		return fmt.Errorf("delete task: %w", err)
+// RestartUserTask перезапускает упавшую задачу пользователя. Перезапустить можно только задачу
+// из dead-letter и только тип из entities.TaskType.UserRetryable, иначе — errs.ErrTaskNotRestartable.
+func (s *Service) RestartUserTask(ctx context.Context, userID, taskID uuid.UUID) error {
+	if err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
+		t, err := s.tasksRepository.Get(ctx, dto.TasksFilter{IDs: []uuid.UUID{taskID}, UserID: &userID}, true)
+		if err != nil {
+			return fmt.Errorf("get task: %w", err)
+		}
+
+		if !t.IsFailed() || !t.TypeNm().UserRetryable() {
+			return errs.ErrTaskNotRestartable
+		}
+
+		t.Restart()
+
+		if err = s.tasksRepository.Update(ctx, t); err != nil {
+			return fmt.Errorf("update task: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTask удаляет задачу по фильтру. Только для администратора: пользователь не может убрать
+// из очереди, например, уведомление безопасности или удаление аккаунта.
+func (s *Service) DeleteTask(ctx context.Context, f dto.TasksFilter) error {
+	if err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
+		t, err := s.tasksRepository.Get(ctx, f, true)
+		if err != nil {
+			return fmt.Errorf("get task: %w", err)
+		}
+
+		if err = s.tasksRepository.Delete(ctx, []uuid.UUID{t.UUID()}); err != nil {
+			return fmt.Errorf("delete task: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("transaction: %w", err)
 	}
 
 	return nil
 }
+
+// RetryFailedTasks возвращает в очередь задачи из dead-letter по фильтру и возвращает их число.
+// Фильтр по состоянию игнорируется: перезапускаются только failed.
+func (s *Service) RetryFailedTasks(ctx context.Context, f dto.TasksFilter) (int, error) {
+	f.States = []entities.TaskState{entities.TaskStateFailed}
+
+	n, err := s.tasksRepository.RestartByFilter(ctx, f)
+	if err != nil {
+		return 0, fmt.Errorf("restart tasks: %w", err)
+	}
+
+	return n, nil
+}
+
+// PurgeFailedTasks удаляет задачи из dead-letter по фильтру и возвращает их число.
+// Фильтр по состоянию игнорируется: задачи в очереди не трогаются. История попыток остаётся.
+func (s *Service) PurgeFailedTasks(ctx context.Context, f dto.TasksFilter) (int, error) {
+	f.States = []entities.TaskState{entities.TaskStateFailed}
+
+	n, err := s.tasksRepository.DeleteByFilter(ctx, f)
+	if err != nil {
+		return 0, fmt.Errorf("delete tasks: %w", err)
+	}
+
+	return n, nil
+}
+
+// ListTaskAttempts возвращает историю выполнения задач, в том числе уже удалённых.
+func (s *Service) ListTaskAttempts(ctx context.Context, f dto.TaskAttemptsFilter) ([]*entities.TaskAttempt, error) {
+	attempts, err := s.taskAttemptsRepository.List(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("list task attempts: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -3,6 +3,7 @@ package crud
 import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"context"
 	"errors"
 	"testing"
@@ -33,6 +34,10 @@ func (m *mockTasksRepository) Get(ctx context.Context, f dto.TasksFilter, withBl
 	return args.Get(0).(*entities.Task), args.Error(1)
 }
 
+func (m *mockTasksRepository) Create(ctx context.Context, t *entities.Task) error {
+	return m.Called(ctx, t).Error(0)
+}
+
 func (m *mockTasksRepository) Update(ctx context.Context, t *entities.Task) error {
 	return m.Called(ctx, t).Error(0)
 }
@@ -41,6 +46,16 @@ func (m *mockTasksRepository) Delete(ctx context.Context, ids []uuid.UUID) error
 	return m.Called(ctx, ids).Error(0)
 }
 
+func (m *mockTasksRepository) RestartByFilter(ctx context.Context, f dto.TasksFilter) (int, error) {
+	args := m.Called(ctx, f)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTasksRepository) DeleteByFilter(ctx context.Context, f dto.TasksFilter) (int, error) {
+	args := m.Called(ctx, f)
+	return args.Int(0), args.Error(1)
+}
+
 // passThroughTxManager is shared by all crud test files in this package.
 type passThroughTxManager struct{}
 
@@ -81,13 +96,29 @@ func TestGetTask_Success(t *testing.T) {
 	repo.On("Get", mock.Anything, dto.TasksFilter{IDs: []uuid.UUID{id}}, false).Return(task, nil)
 
 	svc := newTaskService(repo)
-	got, err := svc.GetTask(ctx, id)
+	got, err := svc.GetTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{id}})
 
 	assert.NoError(t, err)
 	assert.Equal(t, task, got)
 	repo.AssertExpectations(t)
 }
 
+func TestGetTask_ScopedToUser(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	userID := uuid.New()
+	f := dto.TasksFilter{IDs: []uuid.UUID{id}, UserID: &userID}
+
+	repo := &mockTasksRepository{}
+	repo.On("Get", mock.Anything, f, false).Return(nil, errors.New("not found"))
+
+	svc := newTaskService(repo)
+	_, err := svc.GetTask(ctx, f)
+
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
+
 func TestGetTask_NotFound(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
@@ -96,7 +127,7 @@ func TestGetTask_NotFound(t *testing.T) {
 	repo.On("Get", mock.Anything, mock.Anything, false).Return(nil, errors.New("not found"))
 
 	svc := newTaskService(repo)
-	_, err := svc.GetTask(ctx, id)
+	_, err := svc.GetTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{id}})
 	assert.Error(t, err)
 }
 
@@ -136,7 +167,7 @@ func TestRestartTask_Success(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
 	task := newTestTask(id, entities.TaskTypeSendNotificationEmail)
-	task.Failed()
+	task.Failed("max attempts exceeded")
 
 	repo := &mockTasksRepository{}
 	repo.On("Get", mock.Anything, dto.TasksFilter{IDs: []uuid.UUID{id}}, true).Return(task, nil)
@@ -145,10 +176,11 @@ func TestRestartTask_Success(t *testing.T) {
 	})).Return(nil)
 
 	svc := newTaskService(repo)
-	err := svc.RestartTask(ctx, id)
+	err := svc.RestartTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{id}})
 
 	assert.NoError(t, err)
 	assert.Equal(t, entities.TaskStateRunning, task.State())
+	assert.Empty(t, task.FailureReason())
 	repo.AssertExpectations(t)
 }
 
@@ -159,33 +191,163 @@ func TestRestartTask_GetError(t *testing.T) {
 	repo.On("Get", mock.Anything, mock.Anything, true).Return(nil, errors.New("not found"))
 
 	svc := newTaskService(repo)
-	err := svc.RestartTask(ctx, uuid.New())
+	err := svc.RestartTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{uuid.New()}})
 	assert.Error(t, err)
 }
 
+// ── RestartUserTask ────────────────────────────────────────────────────────
+
+func TestRestartUserTask_FailedPush_Restarted(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	userID := uuid.New()
+	task := newTestTask(id, entities.TaskTypeSendPushNotification)
+	task.Failed("max attempts exceeded")
+
+	repo := &mockTasksRepository{}
+	repo.On("Get", mock.Anything, dto.TasksFilter{IDs: []uuid.UUID{id}, UserID: &userID}, true).Return(task, nil)
+	repo.On("Update", mock.Anything, task).Return(nil)
+
+	svc := newTaskService(repo)
+	err := svc.RestartUserTask(ctx, userID, id)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.TaskStateRunning, task.State())
+	repo.AssertExpectations(t)
+}
+
+func TestRestartUserTask_NotFailed_Rejected(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+
+	repo := &mockTasksRepository{}
+	repo.On("Get", mock.Anything, mock.Anything, true).Return(newTestTask(id, entities.TaskTypeSendPushNotification), nil)
+
+	svc := newTaskService(repo)
+	err := svc.RestartUserTask(ctx, uuid.New(), id)
+
+	assert.ErrorIs(t, err, errs.ErrTaskNotRestartable)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRestartUserTask_RestrictedTypes_Rejected(t *testing.T) {
+	for _, typ := range []entities.TaskType{
+		entities.TaskTypeSendCodeOnEmail,
+		entities.TaskTypeSendCodeOnPhone,
+		entities.TaskTypeSendNotificationEmail,
+		entities.TaskTypeSendNotificationPhone,
+		entities.TaskTypeDeleteAccount,
+	} {
+		t.Run(typ.String(), func(t *testing.T) {
+			id := uuid.New()
+			task := newTestTask(id, typ)
+			task.Failed("max attempts exceeded")
+
+			repo := &mockTasksRepository{}
+			repo.On("Get", mock.Anything, mock.Anything, true).Return(task, nil)
+
+			svc := newTaskService(repo)
+			err := svc.RestartUserTask(context.Background(), uuid.New(), id)
+
+			assert.ErrorIs(t, err, errs.ErrTaskNotRestartable)
+			assert.True(t, task.IsFailed())
+			repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // ── DeleteTask ─────────────────────────────────────────────────────────────
 
 func TestDeleteTask_Success(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
+	userID := uuid.New()
+	f := dto.TasksFilter{IDs: []uuid.UUID{id}, UserID: &userID}
 
 	repo := &mockTasksRepository{}
+	repo.On("Get", mock.Anything, f, true).Return(newTestTask(id, entities.TaskTypeSendCodeOnEmail), nil)
 	repo.On("Delete", mock.Anything, []uuid.UUID{id}).Return(nil)
 
 	svc := newTaskService(repo)
-	err := svc.DeleteTask(ctx, id)
+	err := svc.DeleteTask(ctx, f)
 
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
 
+func TestDeleteTask_ForeignTaskNotDeleted(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	repo := &mockTasksRepository{}
+	repo.On("Get", mock.Anything, mock.Anything, true).Return(nil, errors.New("not found"))
+
+	svc := newTaskService(repo)
+	err := svc.DeleteTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{uuid.New()}, UserID: &userID})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
 func TestDeleteTask_RepoError(t *testing.T) {
 	ctx := context.Background()
+	id := uuid.New()
 
 	repo := &mockTasksRepository{}
+	repo.On("Get", mock.Anything, mock.Anything, true).Return(newTestTask(id, entities.TaskTypeSendCodeOnEmail), nil)
 	repo.On("Delete", mock.Anything, mock.Anything).Return(errors.New("db error"))
 
 	svc := newTaskService(repo)
-	err := svc.DeleteTask(ctx, uuid.New())
+	err := svc.DeleteTask(ctx, dto.TasksFilter{IDs: []uuid.UUID{id}})
+	assert.Error(t, err)
+}
+
+// ── RetryFailedTasks / PurgeFailedTasks ────────────────────────────────────
+
+func TestRetryFailedTasks_OnlyFailed(t *testing.T) {
+	ctx := context.Background()
+	from := time.Now().Add(-time.Hour)
+	f := dto.TasksFilter{
+		Types:       []entities.TaskType{entities.TaskTypeSendPushNotification},
+		States:      []entities.TaskState{entities.TaskStateRunning},
+		CreatedFrom: &from,
+	}
+
+	repo := &mockTasksRepository{}
+	repo.On("RestartByFilter", mock.Anything, dto.TasksFilter{
+		Types:       []entities.TaskType{entities.TaskTypeSendPushNotification},
+		States:      []entities.TaskState{entities.TaskStateFailed},
+		CreatedFrom: &from,
+	}).Return(7, nil)
+
+	svc := newTaskService(repo)
+	n, err := svc.RetryFailedTasks(ctx, f)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, n)
+	repo.AssertExpectations(t)
+}
+
+func TestRetryFailedTasks_RepoError(t *testing.T) {
+	repo := &mockTasksRepository{}
+	repo.On("RestartByFilter", mock.Anything, mock.Anything).Return(0, errors.New("db error"))
+
+	_, err := newTaskService(repo).RetryFailedTasks(context.Background(), dto.TasksFilter{})
 	assert.Error(t, err)
 }
+
+func TestPurgeFailedTasks_OnlyFailed(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mockTasksRepository{}
+	repo.On("DeleteByFilter", mock.Anything, dto.TasksFilter{
+		States: []entities.TaskState{entities.TaskStateFailed},
+	}).Return(3, nil)
+
+	svc := newTaskService(repo)
+	n, err := svc.PurgeFailedTasks(ctx, dto.TasksFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	repo.AssertExpectations(t)
+}
@@ -14,6 +14,8 @@ func (s *Service) RegisterUserDevice(ctx context.Context, spec entities.UserDevi
 	if err := s.userDevicesRepository.Upsert(ctx, device); err != nil {
 		return fmt.Errorf("register user device: %w", err)
 	}
+
+	s.audit(ctx, entities.AuditEventDeviceRegistered, spec.UserID, nil, map[string]string{"platform": spec.Platform})
 	return nil
 }
 
@@ -29,5 +31,7 @@ func (s *Service) DeleteUserDevice(ctx context.Context, id, userID uuid.UUID) er
 	if err := s.userDevicesRepository.Delete(ctx, id, userID); err != nil {
 		return fmt.Errorf("delete user device: %w", err)
 	}
+
+	s.audit(ctx, entities.AuditEventDeviceDeleted, userID, nil, map[string]string{"device_id": id.String()})
 	return nil
 }
@@ -30,19 +30,47 @@ func (s *Service) CreateInfoUser(ctx context.Context, info entities.UserInfoInit
 }
 
 func (s *Service) UpdateInfoUser(ctx context.Context, f dto.UserInfoFilter, info entities.UserInfoUpdateParams) error {
-	return s.transactionManager.Do(ctx, func(ctx context.Context) error {
-		ui, err := s.userInfoRepository.Get(ctx, f, false)
+	var ui *entities.UserInfo
+	var changes map[string]entities.AuditChange
+	err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		ui, err = s.userInfoRepository.Get(ctx, f, false)
 		if err != nil {
 			return fmt.Errorf("update user info: get user info: %w", err)
 		}
 
+		// email и телефон меняются только через подтверждение нового адреса (auth.Service.RequestContactChange)
+		if (info.Email != nil && *info.Email != ui.Email()) || (info.Phone != nil && *info.Phone != ui.Phone()) {
+			return fmt.Errorf("update user info: %w", errors.ErrContactChangeRequired)
+		}
+
+		before := ui.AuditFields()
 		ui.Update(info)
+		changes = entities.DiffAuditFields(before, ui.AuditFields())
 
 		if err := s.userInfoRepository.Update(ctx, ui); err != nil {
 			return fmt.Errorf("update user info: %w", err)
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// хэш пароля в снимок не входит: фиксируем только сам факт смены
+	if info.Password != nil {
+		changes["password"] = entities.AuditChange{Old: entities.AuditRedacted, New: entities.AuditRedacted}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	eventType := entities.AuditEventProfileUpdated
+	if _, ok := changes["role"]; ok {
+		eventType = entities.AuditEventRoleChanged
+	}
+	s.audit(ctx, eventType, ui.ID(), changes, nil)
+	return nil
 }
 
 func (s *Service) DeleteInfoUser(ctx context.Context, f dto.UserInfoFilter) error {
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -137,3 +138,95 @@ func TestService_CreateInfoUser(t *testing.T) {
 		})
 	}
 }
+
+func TestService_UpdateInfoUser_Role(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+
+	user := entities.NewUserInfo(entities.WithUserInfoInitSpec(entities.UserInfoInitSpec{ID: id}))
+	assert.Equal(t, entities.UserRoleUser, user.Role(), "новый пользователь по умолчанию получает роль user")
+
+	repo := mocks.NewUserInfoRepository(t)
+	tx := mocks.NewTransactionManager(t)
+	tx.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		})
+	repo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &id}, false).Return(user, nil)
+	repo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+		return u.Role() == entities.UserRoleCoach
+	})).Return(nil)
+
+	s := NewService(&Config{
+		UserInfoRepository: repo,
+		TransactionManager: tx,
+	})
+
+	role := entities.UserRoleCoach
+	err := s.UpdateInfoUser(ctx, dto.UserInfoFilter{ID: &id}, entities.UserInfoUpdateParams{Role: &role})
+
+	assert.NoError(t, err)
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestService_UpdateInfoUser_ContactChangeRequiresConfirmation(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		params  entities.UserInfoUpdateParams
+		wantErr error
+	}{
+		{
+			name:    "new email — rejected",
+			params:  entities.UserInfoUpdateParams{Email: strPtr("new@example.com")},
+			wantErr: errs.ErrContactChangeRequired,
+		},
+		{
+			name:    "new phone — rejected",
+			params:  entities.UserInfoUpdateParams{Phone: strPtr("+79990000000")},
+			wantErr: errs.ErrContactChangeRequired,
+		},
+		{
+			name:   "same email and phone — verification kept",
+			params: entities.UserInfoUpdateParams{Name: strPtr("John"), Email: strPtr("user@example.com"), Phone: strPtr("+79001234567")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := entities.NewUserInfo(entities.WithUserInfoRestoreSpec(entities.UserInfoRestoreSpec{
+				ID:              id,
+				Email:           "user@example.com",
+				Phone:           "+79001234567",
+				EmailVerifiedAt: &now,
+				PhoneVerifiedAt: &now,
+			}))
+
+			repo := mocks.NewUserInfoRepository(t)
+			tx := mocks.NewTransactionManager(t)
+			tx.On("Do", mock.Anything, mock.Anything).
+				Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				})
+			repo.On("Get", mock.Anything, dto.UserInfoFilter{ID: &id}, false).Return(user, nil)
+			if tt.wantErr == nil {
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(u *entities.UserInfo) bool {
+					return u.IsEmailVerified() && u.IsPhoneVerified()
+				})).Return(nil)
+			}
+
+			s := NewService(&Config{UserInfoRepository: repo, TransactionManager: tx})
+
+			err := s.UpdateInfoUser(ctx, dto.UserInfoFilter{ID: &id}, tt.params)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,38 +17,66 @@ func (s *Service) GetParamsUser(ctx context.Context, f dto.UserParamsFilter, wit
 }
 
 func (s *Service) CreateParamsUser(ctx context.Context, params entities.UserParamsInitSpec) error {
-	return s.transactionManager.Do(ctx, func(ctx context.Context) error {
+	up := entities.NewUserParams(entities.WithUserParamsInitSpec(params))
+	err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
 		if _, err := s.userParamsRepository.Get(ctx, dto.UserParamsFilter{UserID: &params.UserID}, false); err == nil {
 			return fmt.Errorf("create user params: %w", errors.ErrUserParamsAlreadyExists)
 		}
 
-		if err := s.userParamsRepository.Create(ctx, entities.NewUserParams(entities.WithUserParamsInitSpec(params))); err != nil {
+		if err := s.userParamsRepository.Create(ctx, up); err != nil {
 			return fmt.Errorf("create user params: %w", err)
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, entities.AuditEventParamsCreated, params.UserID, entities.DiffAuditFields(nil, up.AuditFields()), nil)
+	return nil
 }
 
 func (s *Service) UpdateParamsUser(ctx context.Context, f dto.UserParamsFilter, userParams entities.UserParamsUpdateParams) error {
-	return s.transactionManager.Do(ctx, func(ctx context.Context) error {
-		up, err := s.userParamsRepository.Get(ctx, f, false)
+	var up *entities.UserParams
+	var changes map[string]entities.AuditChange
+	err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		up, err = s.userParamsRepository.Get(ctx, f, false)
 		if err != nil {
 			return fmt.Errorf("update user params: get user params: %w", err)
 		}
+		before := up.AuditFields()
 		up.Update(userParams)
+		changes = entities.DiffAuditFields(before, up.AuditFields())
 
 		if err := s.userParamsRepository.Update(ctx, up); err != nil {
 			return fmt.Errorf("update user params: update: %w", err)
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		s.audit(ctx, entities.AuditEventParamsUpdated, up.UserID(), changes, nil)
+	}
+	return nil
 }
 
 func (s *Service) DeleteParamsUser(ctx context.Context, f dto.UserParamsFilter) error {
-	return s.transactionManager.Do(ctx, func(ctx context.Context) error {
+	err := s.transactionManager.Do(ctx, func(ctx context.Context) error {
 		if err := s.userParamsRepository.Delete(ctx, f); err != nil {
 			return fmt.Errorf("delete user params: delete:%w", err)
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if f.UserID != nil {
+		s.audit(ctx, entities.AuditEventParamsDeleted, *f.UserID, nil, nil)
+	}
+	return nil
 }
@@ -67,12 +67,19 @@ func (s *Service) UpdateWorkoutByFilter(ctx context.Context, f dto.WorkoutsFilte
 			return fmt.Errorf("update workout: get workout: %w", err)
 		}
 
+		status := workout.Status()
 		workout.Update(params)
 
 		if err := s.workoutsRepository.Update(ctx, workout); err != nil {
 			return fmt.Errorf("update workout: save: %w", err)
 		}
 
+		if workout.Status() != status {
+			if err := s.pushLiveActivity(ctx, workout.ID(), workout); err != nil {
+				return fmt.Errorf("update workout: %w", err)
+			}
+		}
+
 		return nil
 	})
 }
@@ -161,6 +168,7 @@ func (s *Service) UpdateWorkoutExerciseByFilter(ctx context.Context, f dto.Worko
 		}
 
 		// Обновляем поля
+		status := workoutExercise.Status()
 		workoutExercise.Update(params)
 
 		// Сохраняем изменения
@@ -168,6 +176,13 @@ func (s *Service) UpdateWorkoutExerciseByFilter(ctx context.Context, f dto.Worko
 			return fmt.Errorf("update workout exercise: save: %w", err)
 		}
 
+		// Статус упражнения меняет текущее упражнение в Live Activity
+		if workoutExercise.Status() != status {
+			if err := s.pushLiveActivity(ctx, workoutExercise.WorkoutID(), nil); err != nil {
+				return fmt.Errorf("update workout exercise: %w", err)
+			}
+		}
+
 		return nil
 	})
 }
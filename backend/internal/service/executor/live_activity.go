@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"backend/internal/domain/entities"
+	"backend/pkg/logging"
+	"backend/pkg/notifications/apns"
+	"backend/pkg/notifications/push"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// liveActivityDismissAfter — сколько завершённая тренировка остаётся на экране блокировки.
+const liveActivityDismissAfter = 15 * time.Minute
+
+type (
+	LiveActivityClient interface {
+		SendLiveActivity(pushToken string, p apns.LiveActivityPayload) error
+	}
+
+	LiveActivitiesRepository interface {
+		DeleteByToken(ctx context.Context, pushToken string) error
+	}
+)
+
+// LiveActivityConfig — отправка обновлений Live Activity. Repository нужен, чтобы удалять токены,
+// которые APNs больше не принимает; без него такие задачи уходят в dead-letter.
+type LiveActivityConfig struct {
+	Repository LiveActivitiesRepository
+	Client     LiveActivityClient
+}
+
+type liveActivities struct {
+	repo   LiveActivitiesRepository
+	client LiveActivityClient
+
+	log logging.Entry
+}
+
+// RegisterLiveActivityHandlers регистрирует отправку обновлений Live Activity тренировок.
+func RegisterLiveActivityHandlers(r *Registry, cfg LiveActivityConfig) {
+	l := &liveActivities{
+		repo:   cfg.Repository,
+		client: cfg.Client,
+		log: logging.GetLoggerFromContext(context.Background()).WithFields(logging.Fields{
+			moduleFieldName: executorModuleName,
+		}),
+	}
+
+	Register(r, entities.TaskKindSendLiveActivity, Handler[entities.LiveActivityTaskPayload]{
+		Handle:  l.handleLiveActivityTask,
+		Timeout: notificationTimeout,
+	})
+}
+
+func (l *liveActivities) handleLiveActivityTask(ctx context.Context, _ *entities.Task, p entities.LiveActivityTaskPayload) error {
+	if p.PushToken == "" {
+		return Permanent(fmt.Errorf("push token is empty"))
+	}
+
+	if l.client == nil {
+		return fmt.Errorf("live activity client is not configured")
+	}
+
+	payload := apns.LiveActivityPayload{
+		Event:        apns.LiveActivityEventUpdate,
+		ContentState: p.State,
+		Timestamp:    p.Timestamp,
+	}
+	if p.State.Ended() {
+		dismissAt := p.Timestamp.Add(liveActivityDismissAfter)
+		payload.Event = apns.LiveActivityEventEnd
+		payload.DismissalDate = &dismissAt
+	}
+
+	err := l.client.SendLiveActivity(p.PushToken, payload)
+	if !errors.Is(err, push.ErrInvalidDeviceToken) {
+		return err
+	}
+
+	// Активность закрыта на устройстве или приложение удалено: повтор получит тот же ответ.
+	if l.repo == nil {
+		return Permanent(err)
+	}
+	if err := l.repo.DeleteByToken(ctx, p.PushToken); err != nil {
+		return fmt.Errorf("delete live activity with invalid token: %w", err)
+	}
+
+	l.log.Warnf("Deleted live activity of workout %s after push failure: %s", p.WorkoutID, err)
+	return nil
+}
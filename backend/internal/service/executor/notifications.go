@@ -0,0 +1,389 @@
+package executor
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"backend/pkg/logging"
+	"backend/pkg/notifications/push"
+	"backend/pkg/templates"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// codeMaxAttempts — у одноразовых кодов короткий срок жизни, дальше повторять бессмысленно
+	codeMaxAttempts = 5
+	// notificationTimeout — один запрос к SendGrid, Twilio, APNs или FCM
+	notificationTimeout = 30 * time.Second
+)
+
+type (
+	UserInfoRepository interface {
+		Get(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error)
+	}
+
+	EmailClient interface {
+		SendEmail(to, subject, body string) error
+	}
+
+	SMSClient interface {
+		SendSMS(to, body string) error
+	}
+
+	// PushClient — провайдер push одной платформы: APNs или FCM.
+	PushClient interface {
+		Send(deviceToken string, p push.Payload) error
+	}
+
+	UserDevicesRepository interface {
+		List(ctx context.Context, f dto.UserDeviceFilter) ([]*entities.UserDevice, error)
+		DeleteByToken(ctx context.Context, deviceToken string) error
+	}
+
+	TasksCreator interface {
+		Create(ctx context.Context, t *entities.Task) error
+	}
+
+	NotificationPreferencesRepository interface {
+		Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error)
+	}
+
+	TemplateRenderer interface {
+		Render(id, locale string, vars map[string]string) (templates.Message, error)
+	}
+)
+
+// NotificationsConfig — каналы доставки кодов и уведомлений. Напоминания по расписанию
+// раскладываются в push-задачи по устройствам, поэтому нужны UserDevicesRepository и TasksRepository;
+// без них обработчик напоминаний не регистрируется. PreferencesRepository необязателен: без него
+// уведомления отправляются без учёта настроек пользователя. Templates рендерит задачи с шаблоном
+// на языке получателя из UserInfoRepository. PushClients — провайдеры push по платформе устройства
+// (entities.DevicePlatformIOS, entities.DevicePlatformAndroid); push на платформу без провайдера не отправляется.
+type NotificationsConfig struct {
+	UserInfoRepository    UserInfoRepository
+	UserDevicesRepository UserDevicesRepository
+	TasksRepository       TasksCreator
+	PreferencesRepository NotificationPreferencesRepository
+	Templates             TemplateRenderer
+	EmailClient           EmailClient
+	SMSClient             SMSClient
+	PushClients           map[string]PushClient
+}
+
+type notifications struct {
+	userInfoRepo UserInfoRepository
+	devicesRepo  UserDevicesRepository
+	tasksRepo    TasksCreator
+	prefsRepo    NotificationPreferencesRepository
+	templates    TemplateRenderer
+	emailClient  EmailClient
+	smsClient    SMSClient
+	pushClients  map[string]PushClient
+
+	log logging.Entry
+}
+
+// RegisterNotificationHandlers регистрирует отправку кодов и уведомлений по email, SMS и push.
+// Коды повторяются по Фибоначчи с шагом 20 секунд, уведомления — экспоненциально с разбросом, с шагом 10 секунд.
+func RegisterNotificationHandlers(r *Registry, cfg NotificationsConfig) {
+	n := &notifications{
+		userInfoRepo: cfg.UserInfoRepository,
+		devicesRepo:  cfg.UserDevicesRepository,
+		tasksRepo:    cfg.TasksRepository,
+		prefsRepo:    cfg.PreferencesRepository,
+		templates:    cfg.Templates,
+		emailClient:  cfg.EmailClient,
+		smsClient:    cfg.SMSClient,
+		pushClients:  cfg.PushClients,
+		log: logging.GetLoggerFromContext(context.Background()).WithFields(logging.Fields{
+			moduleFieldName: executorModuleName,
+		}),
+	}
+
+	codeBackoff := entities.FibonacciBackoff(20 * time.Second)
+	notificationBackoff := entities.ExponentialJitterBackoff(10 * time.Second)
+
+	Register(r, entities.TaskKindSendCodeOnEmail, Handler[entities.EmailTaskPayload]{
+		Handle:      n.handleEmailTask,
+		Timeout:     notificationTimeout,
+		MaxAttempts: codeMaxAttempts,
+		Backoff:     codeBackoff,
+	})
+	Register(r, entities.TaskKindSendCodeOnPhone, Handler[entities.SMSTaskPayload]{
+		Handle:      n.handleSMSTask,
+		Timeout:     notificationTimeout,
+		MaxAttempts: codeMaxAttempts,
+		Backoff:     codeBackoff,
+	})
+	Register(r, entities.TaskKindSendNotificationEmail, Handler[entities.EmailTaskPayload]{
+		Handle:  n.handleEmailTask,
+		Timeout: notificationTimeout,
+		Backoff: notificationBackoff,
+	})
+	Register(r, entities.TaskKindSendNotificationPhone, Handler[entities.SMSTaskPayload]{
+		Handle:  n.handleSMSTask,
+		Timeout: notificationTimeout,
+		Backoff: notificationBackoff,
+	})
+	Register(r, entities.TaskKindSendPushNotification, Handler[entities.PushTaskPayload]{
+		Handle:  n.handlePushTask,
+		Timeout: notificationTimeout,
+	})
+	if n.devicesRepo != nil && n.tasksRepo != nil {
+		Register(r, entities.TaskKindSendReminder, Handler[entities.ReminderTaskPayload]{
+			Handle:  n.handleReminderTask,
+			Timeout: notificationTimeout,
+		})
+	}
+}
+
+func (n *notifications) handleEmailTask(ctx context.Context, t *entities.Task, p entities.EmailTaskPayload) error {
+	if p.Email == "" {
+		return fmt.Errorf("email is empty")
+	}
+
+	var user *entities.UserInfo
+	if t.TypeNm() == entities.TaskTypeSendNotificationEmail || p.Template != "" {
+		user = n.getUser(ctx, p.UserID)
+	}
+
+	// Notification tasks require verified email; verification code tasks always go through.
+	if t.TypeNm() == entities.TaskTypeSendNotificationEmail && user != nil && !user.IsEmailVerified() {
+		n.log.Warnf("Skipping email notification for user %s: email not verified", p.UserID)
+		return nil // delete the task, no retry needed
+	}
+
+	if ok, err := n.checkPreferences(ctx, p.UserID, p.Category, entities.NotificationChannelEmail); !ok {
+		return err
+	}
+
+	subject, body := p.Subject, p.Body
+	if p.Template != "" {
+		msg, err := n.render(user, p.Template, p.Vars, p.Code)
+		if err != nil {
+			return err
+		}
+		subject, body = msg.Subject, msg.HTML
+		if body == "" {
+			body = msg.Text
+		}
+	}
+	if subject == "" {
+		subject = "BodyFuel"
+	}
+
+	return n.emailClient.SendEmail(p.Email, subject, body)
+}
+
+func (n *notifications) handleSMSTask(ctx context.Context, t *entities.Task, p entities.SMSTaskPayload) error {
+	if p.Phone == "" {
+		return fmt.Errorf("phone is empty")
+	}
+
+	var user *entities.UserInfo
+	if t.TypeNm() == entities.TaskTypeSendNotificationPhone || p.Template != "" {
+		user = n.getUser(ctx, p.UserID)
+	}
+
+	// Notification tasks require verified phone; verification code tasks always go through.
+	if t.TypeNm() == entities.TaskTypeSendNotificationPhone && user != nil && !user.IsPhoneVerified() {
+		n.log.Warnf("Skipping SMS notification for user %s: phone not verified", p.UserID)
+		return nil // delete the task, no retry needed
+	}
+
+	if ok, err := n.checkPreferences(ctx, p.UserID, p.Category, entities.NotificationChannelSMS); !ok {
+		return err
+	}
+
+	body := p.Body
+	if p.Template != "" {
+		msg, err := n.render(user, p.Template, p.Vars, p.Code)
+		if err != nil {
+			return err
+		}
+		body = msg.Text
+	}
+
+	return n.smsClient.SendSMS(p.Phone, body)
+}
+
+func (n *notifications) handlePushTask(ctx context.Context, _ *entities.Task, p entities.PushTaskPayload) error {
+	if p.DeviceToken == "" {
+		return fmt.Errorf("device token is empty")
+	}
+
+	platform := p.Platform
+	if platform == "" {
+		platform = entities.DevicePlatformIOS
+	}
+	client := n.pushClients[platform]
+	if client == nil {
+		// конфигурация не поменяется между попытками: задача сразу уходит в dead-letter
+		return Permanent(fmt.Errorf("push client for platform %q is not configured", platform))
+	}
+
+	if ok, err := n.checkPreferences(ctx, p.UserID, p.Category, entities.NotificationChannelPush); !ok {
+		return err
+	}
+
+	title, body := p.Title, p.Body
+	if p.Template != "" {
+		msg, err := n.render(n.getUser(ctx, p.UserID), p.Template, p.Vars, "")
+		if err != nil {
+			return err
+		}
+		title, body = msg.Subject, msg.Text
+	}
+	// Фоновый push приходит без текста, заголовок по умолчанию нужен только видимому.
+	if title == "" && (body != "" || !p.ContentAvailable) {
+		title = "BodyFuel"
+	}
+
+	err := client.Send(p.DeviceToken, push.Payload{
+		Title:             title,
+		Body:              body,
+		Badge:             p.Badge,
+		Category:          p.ActionCategory,
+		ThreadID:          p.ThreadID,
+		CollapseID:        p.CollapseID,
+		InterruptionLevel: push.InterruptionLevel(p.InterruptionLevel),
+		ContentAvailable:  p.ContentAvailable,
+		Data:              p.Data,
+	})
+	if errors.Is(err, push.ErrInvalidDeviceToken) {
+		return n.dropDevice(ctx, p.DeviceToken, err)
+	}
+	return err
+}
+
+// dropDevice удаляет устройство, токен которого провайдер push больше не принимает. Задача после этого
+// завершается: повтор получит тот же ответ. Если удалить не удалось, задача повторится.
+func (n *notifications) dropDevice(ctx context.Context, deviceToken string, cause error) error {
+	if n.devicesRepo == nil {
+		return Permanent(cause)
+	}
+
+	if err := n.devicesRepo.DeleteByToken(ctx, deviceToken); err != nil {
+		return fmt.Errorf("delete device with invalid token: %w", err)
+	}
+
+	n.log.Warnf("Deleted device after push failure: %s", cause)
+	return nil
+}
+
+// handleReminderTask раскладывает напоминание в push-задачи по всем устройствам пользователя:
+// каждое устройство повторяется отдельно и не задерживает остальные. Ключ дедупликации привязан
+// к напоминанию, поэтому повтор после ошибки не ставит push устройствам, которым он уже поставлен.
+// В тихие часы напоминание целиком откладывается до их конца.
+func (n *notifications) handleReminderTask(ctx context.Context, t *entities.Task, p entities.ReminderTaskPayload) error {
+	if ok, err := n.checkPreferences(ctx, p.UserID, entities.NotificationCategoryReminders, entities.NotificationChannelPush); !ok {
+		return err
+	}
+
+	devices, err := n.devicesRepo.List(ctx, dto.UserDeviceFilter{UserID: &p.UserID})
+	if err != nil {
+		return fmt.Errorf("list user devices: %w", err)
+	}
+
+	for _, device := range devices {
+		task := entities.TaskKindSendPushNotification.NewTask(entities.PushTaskPayload{
+			UserID:      p.UserID,
+			DeviceToken: device.DeviceToken(),
+			Platform:    device.Platform(),
+			Template:    p.Template,
+			Vars:        p.Vars,
+			Title:       p.Title,
+			Body:        p.Body,
+			Category:    entities.NotificationCategoryReminders,
+			PushOptions: p.PushOptions,
+		}, entities.TaskSchedule{DedupKey: fmt.Sprintf("reminder:%s:%s", t.UUID(), device.ID())})
+		if err = n.tasksRepo.Create(ctx, task); err != nil {
+			return fmt.Errorf("create push task: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkPreferences сверяет уведомление с настройками пользователя на момент отправки. Возвращает false,
+// если отправлять сейчас не нужно: канал выключен — задача завершается без отправки (nil), тихие часы —
+// задача откладывается до их конца (Defer). Коды подтверждения идут без категории и не проверяются.
+func (n *notifications) checkPreferences(
+	ctx context.Context,
+	userID uuid.UUID,
+	cat entities.NotificationCategory,
+	ch entities.NotificationChannel,
+) (bool, error) {
+	if n.prefsRepo == nil || cat == "" {
+		return true, nil
+	}
+
+	// Пользователя уже может не быть (письмо об удалении аккаунта): тогда действуют настройки по умолчанию.
+	prefs, err := n.prefsRepo.Get(ctx, userID)
+	if err != nil && !errors.Is(err, errs.ErrUserInfoNotFound) {
+		return false, fmt.Errorf("get notification preferences: %w", err)
+	}
+
+	if !prefs.Allows(cat, ch) {
+		n.log.Infof("Skipping %s %s notification for user %s: disabled in preferences", cat, ch, userID)
+		return false, nil
+	}
+
+	now := time.Now()
+	if at := prefs.DeliverAt(cat, now); at.After(now) {
+		return false, Defer(at)
+	}
+
+	return true, nil
+}
+
+// getUser возвращает получателя или nil, если его не удалось загрузить: тогда проверка подтверждения
+// контакта пропускается, а шаблон рендерится на языке по умолчанию.
+func (n *notifications) getUser(ctx context.Context, userID uuid.UUID) *entities.UserInfo {
+	if n.userInfoRepo == nil {
+		return nil
+	}
+
+	user, err := n.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// render рендерит шаблон на языке получателя. Код из задачи передаётся шаблону переменной code.
+// Ошибка шаблона не исправится повтором, поэтому задача сразу уходит в dead-letter.
+func (n *notifications) render(
+	user *entities.UserInfo,
+	id entities.NotificationTemplate,
+	vars map[string]string,
+	code string,
+) (templates.Message, error) {
+	if n.templates == nil {
+		return templates.Message{}, fmt.Errorf("templates are not configured")
+	}
+
+	locale := entities.DefaultUserLocale
+	if user != nil {
+		locale = user.Locale()
+	}
+
+	if code != "" {
+		withCode := make(map[string]string, len(vars)+1)
+		maps.Copy(withCode, vars)
+		withCode["code"] = code
+		vars = withCode
+	}
+
+	msg, err := n.templates.Render(id.String(), locale, vars)
+	if err != nil {
+		return templates.Message{}, Permanent(fmt.Errorf("render template: %w", err))
+	}
+	return msg, nil
+}
@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"backend/internal/domain/entities"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts — лимит попыток для задач, созданных без своего лимита, если обработчик не задал другой.
+	DefaultMaxAttempts = 3
+
+	defaultBackoffBase = 20 * time.Second
+)
+
+// Handler — обработчик задач одного типа. Политика повторов своя у каждого типа: для задачи,
+// созданной без своего лимита, действует MaxAttempts обработчика.
+type Handler[P entities.TaskPayload] struct {
+	Handle      func(ctx context.Context, t *entities.Task, p P) error
+	Timeout     time.Duration        // 0 — 15 секунд; в любом случае не больше ¾ аренды
+	MaxAttempts int                  // 0 — DefaultMaxAttempts
+	Backoff     entities.TaskBackoff // nil — линейный с шагом 20 секунд
+}
+
+type registeredHandler struct {
+	handle      func(ctx context.Context, t *entities.Task) error
+	timeout     time.Duration
+	maxAttempts int
+	backoff     entities.TaskBackoff
+}
+
+// Registry хранит обработчики по типам задач. Каждая подсистема регистрирует свои при сборке приложения,
+// исполнитель о конкретных типах не знает. Задачи без обработчика уходят в dead-letter: после выкладки
+// обработчика их можно перезапустить.
+type Registry struct {
+	handlers map[entities.TaskType]*registeredHandler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[entities.TaskType]*registeredHandler)}
+}
+
+// Register привязывает обработчик к типу задачи. Схема нагрузки обработчика совпадает со схемой типа
+// по построению. Повторная регистрация типа — ошибка сборки приложения, поэтому паникует.
+func Register[P entities.TaskPayload](r *Registry, kind entities.TaskKind[P], h Handler[P]) {
+	if _, ok := r.handlers[kind.Type()]; ok {
+		panic(fmt.Sprintf("executor: handler for %q registered twice", kind.Type()))
+	}
+
+	entry := &registeredHandler{
+		handle: func(ctx context.Context, t *entities.Task) error {
+			p, err := kind.Payload(t)
+			if err != nil {
+				return Permanent(err)
+			}
+			return h.Handle(ctx, t, p)
+		},
+		timeout:     h.Timeout,
+		maxAttempts: h.MaxAttempts,
+		backoff:     h.Backoff,
+	}
+	if entry.timeout <= 0 {
+		entry.timeout = taskTimeout
+	}
+	if entry.maxAttempts <= 0 {
+		entry.maxAttempts = DefaultMaxAttempts
+	}
+	if entry.backoff == nil {
+		entry.backoff = entities.LinearBackoff(defaultBackoffBase)
+	}
+
+	r.handlers[kind.Type()] = entry
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку, после которой повторять задачу бессмысленно (например, нагрузка не по схеме):
+// задача сразу уходит в dead-letter, не расходуя оставшиеся попытки.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+type deferredError struct {
+	until time.Time
+}
+
+func (e *deferredError) Error() string {
+	return fmt.Sprintf("deferred until %s", e.until.Format(time.RFC3339))
+}
+
+// Defer откладывает задачу до until, не расходуя попытку: например, уведомление в тихие часы пользователя
+// уходит, когда они закончатся.
+func Defer(until time.Time) error {
+	return &deferredError{until: until}
+}
+
+func deferredUntil(err error) (time.Time, bool) {
+	var d *deferredError
+	if !errors.As(err, &d) {
+		return time.Time{}, false
+	}
+	return d.until, true
+}
+
+func (r *Registry) lookup(typ entities.TaskType) (*registeredHandler, bool) {
+	h, ok := r.handlers[typ]
+	return h, ok
+}
+
+// Types возвращает зарегистрированные типы задач.
+func (r *Registry) Types() []entities.TaskType {
+	types := make([]entities.TaskType, 0, len(r.handlers))
+	for typ := range r.handlers {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+
+	return types
+}
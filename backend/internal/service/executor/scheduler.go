@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"backend/internal/domain/entities"
+	"backend/internal/dto"
+	errs "backend/internal/errors"
+	"backend/pkg/cron"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// scheduleInterval — как часто проверяются расписания; cron считает минутами
+	scheduleInterval = 30 * time.Second
+	// DefaultMisfireGrace — насколько поздно ещё выполняется пропущенное срабатывание, например
+	// после простоя всех реплик. Более старые срабатывания пропускаются.
+	DefaultMisfireGrace = time.Hour
+)
+
+type (
+	TransactionManager interface {
+		Do(ctx context.Context, fn func(ctx context.Context) error) error
+	}
+
+	RecurringTasksRepository interface {
+		List(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error)
+		TryLock(ctx context.Context, id uuid.UUID) (*entities.RecurringTask, error)
+		Update(ctx context.Context, t *entities.RecurringTask) error
+	}
+
+	UserTimezonesRepository interface {
+		ListTimezones(ctx context.Context) ([]string, error)
+	}
+)
+
+// schedule раз в scheduleInterval создаёт задачи по расписаниям.
+func (s *Service) schedule(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkSchedules(ctx, time.Now())
+		}
+	}
+}
+
+// checkSchedules обрабатывает каждое включённое расписание в своей транзакции, чтобы ошибка
+// одного не откатывала остальные.
+func (s *Service) checkSchedules(ctx context.Context, now time.Time) {
+	enabled := true
+	list, err := s.recurringTasksRepository.List(ctx, dto.RecurringTasksFilter{Enabled: &enabled})
+	if err != nil {
+		s.log.Errorf("List recurring tasks: %v", err)
+		return
+	}
+
+	// пояса пользователей читаются один раз за проверку и только если есть пользовательские расписания
+	var (
+		timezones       []string
+		timezonesLoaded bool
+	)
+	for _, rt := range list {
+		if !rt.CheckedUntil().Before(now) {
+			continue
+		}
+		if rt.IsPerUser() && !timezonesLoaded {
+			if timezones, err = s.userTimezonesRepository.ListTimezones(ctx); err != nil {
+				s.log.Errorf("List user timezones: %v", err)
+				return
+			}
+			timezonesLoaded = true
+		}
+
+		if err = s.runSchedule(ctx, rt.ID(), timezones, now); err != nil && ctx.Err() == nil {
+			s.log.Errorf("Run recurring task %s: %v", rt.Name(), err)
+		}
+	}
+}
+
+// runSchedule под блокировкой строки создаёт задачи за срабатывание и сдвигает checked_until в той же
+// транзакции: если другая реплика уже держит расписание, оно пропускается, а после коммита то же
+// срабатывание в окно следующей проверки уже не попадёт.
+func (s *Service) runSchedule(ctx context.Context, id uuid.UUID, timezones []string, now time.Time) error {
+	return s.txm.Do(ctx, func(ctx context.Context) error {
+		rt, err := s.recurringTasksRepository.TryLock(ctx, id)
+		if errors.Is(err, errs.ErrRecurringTaskNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("lock: %w", err)
+		}
+		if !rt.IsEnabled() || !rt.CheckedUntil().Before(now) {
+			return nil
+		}
+
+		// задачи типа, которого этот экземпляр не знает, создаст реплика с более новым кодом
+		if _, ok := s.handlers.lookup(rt.TypeNm()); !ok {
+			s.log.Warnf("Recurring task %s: no handler for task type %q, skipping", rt.Name(), rt.TypeNm())
+			return nil
+		}
+
+		firedAt, n, err := s.fire(ctx, rt, timezones, now)
+		switch {
+		case errors.Is(err, errs.ErrInvalidRecurringTask):
+			// неверное расписание не исправится само: срабатывание пропускается, чтобы не копить окно
+			s.log.Errorf("Recurring task %s: %v", rt.Name(), err)
+		case err != nil:
+			return err
+		case n > 0:
+			rt.MarkRun(firedAt)
+			s.log.Infof("Recurring task %s fired at %s: %d tasks of type %s created", rt.Name(), firedAt, n, rt.TypeNm())
+		}
+
+		rt.MarkChecked(now)
+
+		return s.recurringTasksRepository.Update(ctx, rt)
+	})
+}
+
+// fire создаёт задачи за последнее срабатывание в окне (checked_until, now], но не раньше now-misfireGrace:
+// пропущенные срабатывания не догоняются по одному. Возвращает время срабатывания и число задач.
+func (s *Service) fire(ctx context.Context, rt *entities.RecurringTask, timezones []string, now time.Time) (time.Time, int, error) {
+	sched, err := cron.Parse(rt.Cron())
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: cron %q: %v", errs.ErrInvalidRecurringTask, rt.Cron(), err)
+	}
+	// нагрузка проверяется по схеме типа до создания задач; user_id добавится позже
+	payload, err := entities.DecodeTaskPayload(rt.TypeNm(), rt.Attribute())
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: attribute: %v", errs.ErrInvalidRecurringTask, err)
+	}
+
+	from := rt.CheckedUntil()
+	if earliest := now.Add(-s.misfireGrace); from.Before(earliest) {
+		from = earliest
+	}
+
+	if !rt.IsPerUser() {
+		loc, err := time.LoadLocation(rt.Timezone())
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("%w: timezone %q: %v", errs.ErrInvalidRecurringTask, rt.Timezone(), err)
+		}
+
+		firedAt, ok := sched.Latest(from.In(loc), now.In(loc))
+		if !ok {
+			return time.Time{}, 0, nil
+		}
+
+		t := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
+			TypeNm:      rt.TypeNm(),
+			MaxAttempts: rt.MaxAttempts(),
+			Payload:     payload,
+		}))
+		if err = s.tasksRepository.Create(ctx, t); err != nil {
+			return time.Time{}, 0, fmt.Errorf("create task: %w", err)
+		}
+
+		return firedAt, 1, nil
+	}
+
+	// пользовательское расписание срабатывает в каждом поясе в своё время: в 13:00 по Москве
+	// задачи получат пользователи из Europe/Moscow, а из Europe/Samara — на час раньше
+	var (
+		due     []string
+		firedAt time.Time
+	)
+	for _, tz := range timezones {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			s.log.Warnf("Recurring task %s: unknown user timezone %q: %v", rt.Name(), tz, err)
+			continue
+		}
+		if at, ok := sched.Latest(from.In(loc), now.In(loc)); ok {
+			due = append(due, tz)
+			if at.After(firedAt) {
+				firedAt = at
+			}
+		}
+	}
+	if len(due) == 0 {
+		return time.Time{}, 0, nil
+	}
+
+	n, err := s.tasksRepository.CreateForUsers(ctx, dto.UserTasksSpec{
+		TypeNm:      rt.TypeNm(),
+		MaxAttempts: rt.MaxAttempts(),
+		Attribute:   rt.Attribute(),
+		Timezones:   due,
+	})
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("create tasks for users: %w", err)
+	}
+
+	return firedAt, n, nil
+}
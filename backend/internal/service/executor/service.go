@@ -3,13 +3,14 @@ package executor
 import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"backend/pkg/logging"
-	"backend/pkg/notifications/apns"
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"os"
 	"runtime/debug"
+	"slices"
 	"sync"
 	"time"
 
@@ -20,57 +21,91 @@ const (
 	moduleFieldName    = "module"
 	executorModuleName = "executor"
 
+	// taskTimeout — таймаут обработчика по умолчанию
 	taskTimeout = 15 * time.Second
-)
 
-type (
-	TransactionManager interface {
-		Do(ctx context.Context, fn func(ctx context.Context) error) error
-	}
+	// DefaultBatchSize — сколько задач пул забирает за один запрос.
+	DefaultBatchSize = 10
+	// DefaultLeaseTimeout — на сколько задача закрепляется за экземпляром. Если он упал, не закончив задачу,
+	// по истечении аренды её заберёт другой.
+	DefaultLeaseTimeout = 5 * time.Minute
+	// DefaultWorkers — размер общего пула для типов задач без своего пула.
+	DefaultWorkers = 4
 
-	TasksRepository interface {
-		Get(ctx context.Context, f dto.TasksFilter, withBlock bool) (*entities.Task, error)
-		Delete(ctx context.Context, ids []uuid.UUID) error
-		Update(ctx context.Context, t *entities.Task) error
-	}
+	defaultPoolName = "default"
 
-	UserInfoRepository interface {
-		Get(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error)
-	}
+	// listenRetryDelay — пауза перед повторной подпиской после обрыва соединения
+	listenRetryDelay = 5 * time.Second
+
+	// DefaultHistoryRetention — сколько хранится история попыток.
+	DefaultHistoryRetention = 30 * 24 * time.Hour
+	// historyCleanupInterval — как часто удаляется устаревшая история
+	historyCleanupInterval = time.Hour
+)
 
-	EmailClient interface {
-		SendEmail(to, subject, body string) error
+type (
+	TasksRepository interface {
+		Create(ctx context.Context, t *entities.Task) error
+		CreateForUsers(ctx context.Context, spec dto.UserTasksSpec) (int, error)
+		Claim(ctx context.Context, f dto.TasksFilter, owner string, lease time.Duration) ([]*entities.Task, error)
+		// Release и Complete записывают результат, только пока задачу держит owner, иначе возвращают
+		// errs.ErrTaskLeaseLost
+		Release(ctx context.Context, t *entities.Task, owner string) error
+		Complete(ctx context.Context, id uuid.UUID, owner string) error
+		DeleteExpiredDedupKeys(ctx context.Context) (int, error)
 	}
 
-	SMSClient interface {
-		SendSMS(to, body string) error
+	// TasksListener сообщает о новых задачах, не дожидаясь очередного опроса.
+	TasksListener interface {
+		Listen(ctx context.Context, fn func(entities.TaskType)) error
 	}
 
-	PushClient interface {
-		Send(deviceToken string, p apns.Payload) error
+	TaskAttemptsRepository interface {
+		Create(ctx context.Context, a *entities.TaskAttempt) error
+		DeleteBefore(ctx context.Context, before time.Time) (int, error)
 	}
 )
 
-type handleTaskFunc func(ctx context.Context, t *entities.Task) error
-
 type Config struct {
-	TransactionManager TransactionManager
-	TasksRepository    TasksRepository
-	UserInfoRepository UserInfoRepository
-	EmailClient        EmailClient
-	SMSClient          SMSClient
-	PushClient         PushClient
-	QueryDelay         time.Duration
+	TasksRepository        TasksRepository
+	TaskAttemptsRepository TaskAttemptsRepository // nil — история попыток не пишется
+	TasksListener          TasksListener          // nil — задачи находятся только опросом раз в QueryDelay
+	Handlers               *Registry
+	QueryDelay             time.Duration
+	BatchSize              int           // 0 — DefaultBatchSize
+	LeaseTimeout           time.Duration // 0 — DefaultLeaseTimeout
+	Workers                int           // 0 — DefaultWorkers
+	HistoryRetention       time.Duration // 0 — DefaultHistoryRetention
+	// Расписания проверяются, только если заданы все три зависимости.
+	TransactionManager       TransactionManager
+	RecurringTasksRepository RecurringTasksRepository
+	UserTimezonesRepository  UserTimezonesRepository
+	MisfireGrace             time.Duration // 0 — DefaultMisfireGrace
+	// TypeWorkers выделяет типам задач собственные пулы воркеров, чтобы, например, долгая выгрузка
+	// аккаунтов не задерживала коды подтверждения. Остальные типы обрабатывает общий пул.
+	TypeWorkers map[entities.TaskType]int
 }
 
 type Service struct {
-	txm             TransactionManager
-	tasksRepository TasksRepository
-	userInfoRepo    UserInfoRepository
-	emailClient     EmailClient
-	smsClient       SMSClient
-	pushClient      PushClient
-	queryDelay      time.Duration
+	tasksRepository        TasksRepository
+	taskAttemptsRepository TaskAttemptsRepository
+	tasksListener          TasksListener
+	handlers               *Registry
+	queryDelay             time.Duration
+	batchSize              int
+	leaseTimeout           time.Duration
+	historyRetention       time.Duration
+
+	txm                      TransactionManager
+	recurringTasksRepository RecurringTasksRepository
+	userTimezonesRepository  UserTimezonesRepository
+	misfireGrace             time.Duration
+
+	// maxTaskTimeout — потолок таймаута обработчика: задача должна закончиться раньше аренды
+	maxTaskTimeout time.Duration
+	pools          []*workerPool
+	// owner — имя экземпляра в locked_by захваченных задач
+	owner string
 
 	cancelFn context.CancelFunc
 	wg       sync.WaitGroup
@@ -78,16 +113,136 @@ type Service struct {
 	log logging.Entry
 }
 
+// workerPool — воркеры для своих типов задач. Пул сам забирает задачи из очереди, но не больше,
+// чем у него свободных воркеров, поэтому захваченные задачи не ждут своей очереди, пока тикает аренда.
+type workerPool struct {
+	name string
+	// types — типы задач пула; у общего пула пусто, а в exclude перечислены типы с отдельными пулами
+	types   []entities.TaskType
+	exclude []entities.TaskType
+	slots   chan struct{}
+	// wake будит пул вне очереди таймера; уведомления, пришедшие пока пул занят, схлопываются в одно
+	wake chan struct{}
+}
+
+func newWorkerPool(name string, size int) *workerPool {
+	return &workerPool{
+		name:  name,
+		slots: make(chan struct{}, size),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+func (p *workerPool) handles(typ entities.TaskType) bool {
+	if len(p.types) != 0 {
+		return slices.Contains(p.types, typ)
+	}
+
+	return !slices.Contains(p.exclude, typ)
+}
+
+func (p *workerPool) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// acquire ждёт хотя бы одного свободного воркера и занимает до max воркеров.
+// Возвращает 0, если контекст отменён.
+func (p *workerPool) acquire(ctx context.Context, max int) int {
+	select {
+	case <-ctx.Done():
+		return 0
+	case p.slots <- struct{}{}:
+	}
+
+	n := 1
+	for n < max {
+		select {
+		case p.slots <- struct{}{}:
+			n++
+		default:
+			return n
+		}
+	}
+
+	return n
+}
+
+func (p *workerPool) release(n int) {
+	for i := 0; i < n; i++ {
+		<-p.slots
+	}
+}
+
 func NewService(cfg *Config) *Service {
-	return &Service{
-		txm:             cfg.TransactionManager,
-		tasksRepository: cfg.TasksRepository,
-		userInfoRepo:    cfg.UserInfoRepository,
-		emailClient:     cfg.EmailClient,
-		smsClient:       cfg.SMSClient,
-		pushClient:      cfg.PushClient,
-		queryDelay:      cfg.QueryDelay,
+	s := &Service{
+		tasksRepository:        cfg.TasksRepository,
+		taskAttemptsRepository: cfg.TaskAttemptsRepository,
+		tasksListener:          cfg.TasksListener,
+		handlers:               cfg.Handlers,
+		queryDelay:             cfg.QueryDelay,
+		batchSize:              cfg.BatchSize,
+		leaseTimeout:           cfg.LeaseTimeout,
+		historyRetention:       cfg.HistoryRetention,
+		owner:                  newOwnerName(),
+
+		txm:                      cfg.TransactionManager,
+		recurringTasksRepository: cfg.RecurringTasksRepository,
+		userTimezonesRepository:  cfg.UserTimezonesRepository,
+		misfireGrace:             cfg.MisfireGrace,
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = DefaultBatchSize
+	}
+	if s.leaseTimeout <= 0 {
+		s.leaseTimeout = DefaultLeaseTimeout
+	}
+	if s.historyRetention <= 0 {
+		s.historyRetention = DefaultHistoryRetention
+	}
+	if s.misfireGrace <= 0 {
+		s.misfireGrace = DefaultMisfireGrace
 	}
+	if s.handlers == nil {
+		s.handlers = NewRegistry()
+	}
+	// задача должна закончиться раньше аренды, иначе её параллельно заберёт другой экземпляр
+	s.maxTaskTimeout = s.leaseTimeout * 3 / 4
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	common := newWorkerPool(defaultPoolName, workers)
+
+	types := make([]entities.TaskType, 0, len(cfg.TypeWorkers))
+	for typ, size := range cfg.TypeWorkers {
+		if size > 0 {
+			types = append(types, typ)
+		}
+	}
+	slices.Sort(types)
+
+	for _, typ := range types {
+		p := newWorkerPool(typ.String(), cfg.TypeWorkers[typ])
+		p.types = []entities.TaskType{typ}
+		s.pools = append(s.pools, p)
+		common.exclude = append(common.exclude, typ)
+	}
+	s.pools = append(s.pools, common)
+
+	return s
+}
+
+func newOwnerName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "executor"
+	}
+
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
 }
 
 func (s *Service) Run() error {
@@ -98,23 +253,68 @@ func (s *Service) Run() error {
 		moduleFieldName: executorModuleName,
 	})
 
+	for _, p := range s.pools {
+		for _, typ := range p.types {
+			if _, ok := s.handlers.lookup(typ); !ok {
+				s.log.Warnf("Worker pool %s is configured for task type without handler", p.name)
+			}
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.log.Errorf("Recovered in executor pool %s: %v; stack: %s", p.name, r, debug.Stack())
+				}
+				s.wg.Done()
+			}()
+			s.run(ctx, p)
+		}()
+	}
+
+	if s.tasksListener != nil {
+		s.wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.log.Errorf("Recovered in executor listener: %v; stack: %s", r, debug.Stack())
+				}
+				s.wg.Done()
+			}()
+			s.listen(ctx)
+		}()
+	}
+
 	s.wg.Add(1)
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				s.log.Errorf("Recovered in executor service: %v; stack: %s", r, debug.Stack())
+				s.log.Errorf("Recovered in executor history cleanup: %v; stack: %s", r, debug.Stack())
 			}
 			s.wg.Done()
 		}()
-		s.run(ctx)
+		s.cleanupHistory(ctx)
 	}()
 
-	s.log.Infof("Started task executor service")
+	if s.txm != nil && s.recurringTasksRepository != nil && s.userTimezonesRepository != nil {
+		s.wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.log.Errorf("Recovered in executor scheduler: %v; stack: %s", r, debug.Stack())
+				}
+				s.wg.Done()
+			}()
+			s.schedule(ctx)
+		}()
+	}
+
+	s.log.Infof("Started task executor service %s with %d worker pools, handlers: %v", s.owner, len(s.pools), s.handlers.Types())
 
 	return nil
 }
 
-func (s *Service) run(ctx context.Context) {
+func (s *Service) run(ctx context.Context, p *workerPool) {
 	ticker := time.NewTicker(s.queryDelay)
 	defer ticker.Stop()
 
@@ -123,163 +323,213 @@ func (s *Service) run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			// Обрабатываем задачи пока они есть
-			for {
-				if err := s.processTask(ctx); err != nil {
-					if !errors.Is(err, sql.ErrNoRows) {
-						s.log.Errorf("Process task: %v", err)
-					}
-					break
-				}
-			}
+			s.drain(ctx, p)
+		case <-p.wake:
+			s.drain(ctx, p)
 		}
 	}
 }
 
-func (s *Service) processTask(ctx context.Context) error {
-	ctx, cancel := context.WithTimeout(ctx, taskTimeout)
-	defer cancel()
-
-	return s.txm.Do(ctx, func(ctx context.Context) error {
-		now := time.Now()
-		task, err := s.tasksRepository.Get(ctx, dto.TasksFilter{
-			States:  []entities.TaskState{entities.TaskStateRunning},
-			RetryAt: &now,
-		}, true)
-		if err != nil {
-			return err
+// listen держит подписку на новые задачи; таймер в run остаётся запасным путём, если она оборвалась.
+func (s *Service) listen(ctx context.Context) {
+	for {
+		err := s.tasksListener.Listen(ctx, s.wake)
+		if ctx.Err() != nil {
+			return
 		}
+		s.log.Errorf("Listen for new tasks: %v", err)
 
-		return s.handleTask(ctx, task)
-	})
-}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
 
-func (s *Service) handleTask(ctx context.Context, t *entities.Task) error {
-	var fn handleTaskFunc
-
-	switch t.TypeNm() {
-	case entities.TaskTypeSendCodeOnEmail, entities.TaskTypeSendNotificationEmail:
-		fn = s.handleEmailTask
-	case entities.TaskTypeSendCodeOnPhone, entities.TaskTypeSendNotificationPhone:
-		fn = s.handleSMSTask
-	case entities.TaskTypeSendPushNotification:
-		fn = s.handlePushTask
-	default:
-		s.log.Warnf("Unknown task type %q (id=%s), deleting", t.TypeNm(), t.UUID())
-		return s.tasksRepository.Delete(ctx, []uuid.UUID{t.UUID()})
+		// пока подписки не было, уведомления терялись — проверяем очередь сразу
+		for _, p := range s.pools {
+			p.notify()
+		}
 	}
+}
 
-	if err := fn(ctx, t); err != nil {
-		s.log.Errorf("Handle task %s (%s): %v", t.UUID(), t.TypeNm(), err)
+// cleanupHistory раз в час удаляет историю попыток старше срока хранения и истёкшие ключи дедупликации.
+// Реплики делают это одновременно, но повторное удаление ничего не ломает.
+func (s *Service) cleanupHistory(ctx context.Context) {
+	ticker := time.NewTicker(historyCleanupInterval)
+	defer ticker.Stop()
 
-		t.CalculateNextRetryAt()
+	for {
+		if s.taskAttemptsRepository != nil {
+			n, err := s.taskAttemptsRepository.DeleteBefore(ctx, time.Now().Add(-s.historyRetention))
+			if err != nil && ctx.Err() == nil {
+				s.log.Errorf("Cleanup task attempts history: %v", err)
+			} else if n > 0 {
+				s.log.Infof("Deleted %d task attempts older than %s", n, s.historyRetention)
+			}
+		}
 
-		if t.IsLimitAttemptsExceeded() {
-			t.Failed()
-			s.log.Errorf("Task %s exceeded max attempts, marking as failed", t.UUID())
+		n, err := s.tasksRepository.DeleteExpiredDedupKeys(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Errorf("Cleanup expired task dedup keys: %v", err)
+		} else if n > 0 {
+			s.log.Infof("Deleted %d expired task dedup keys", n)
 		}
 
-		return s.tasksRepository.Update(ctx, t)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
-
-	return s.tasksRepository.Delete(ctx, []uuid.UUID{t.UUID()})
 }
 
-func (s *Service) handleEmailTask(ctx context.Context, t *entities.Task) error {
-	attr, ok := t.Attribute().(entities.TaskAttribute)
-	if !ok {
-		return fmt.Errorf("invalid attribute type for email task")
+// wake будит пул, который обрабатывает задачи этого типа. Пулы с отдельными типами идут первыми, общий — последним.
+func (s *Service) wake(typ entities.TaskType) {
+	for _, p := range s.pools {
+		if p.handles(typ) {
+			p.notify()
+			return
+		}
 	}
+}
 
-	if attr.Email == "" {
-		return fmt.Errorf("email is empty")
-	}
+// drain забирает задачи пачками, пока очередь не опустеет, и раздаёт их свободным воркерам пула.
+func (s *Service) drain(ctx context.Context, p *workerPool) {
+	for {
+		n := p.acquire(ctx, s.batchSize)
+		if n == 0 {
+			return
+		}
 
-	// Notification tasks require verified email; verification code tasks always go through.
-	if t.TypeNm() == entities.TaskTypeSendNotificationEmail && s.userInfoRepo != nil {
-		user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &attr.UserID}, false)
-		if err == nil && !user.IsEmailVerified() {
-			s.log.Warnf("Skipping email notification for user %s: email not verified", attr.UserID)
-			return nil // delete the task, no retry needed
+		tasks, err := s.claimTasks(ctx, p, n)
+		if err != nil {
+			p.release(n)
+			s.log.Errorf("Claim tasks for pool %s: %v", p.name, err)
+			return
+		}
+		p.release(n - len(tasks))
+
+		for _, t := range tasks {
+			s.wg.Add(1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						s.log.Errorf("Recovered in executor task %s: %v; stack: %s", t.UUID(), r, debug.Stack())
+					}
+					p.release(1)
+					s.wg.Done()
+				}()
+				s.processTask(ctx, t)
+			}()
 		}
-	}
 
-	subject := attr.Subject
-	if subject == "" {
-		subject = "BodyFuel"
+		if len(tasks) < n {
+			return
+		}
 	}
+}
 
-	body := attr.Body
-	if body == "" {
-		body = attr.Message
-	}
-	if body == "" {
-		body = string(t.Message())
-	}
+func (s *Service) claimTasks(ctx context.Context, p *workerPool, limit int) ([]*entities.Task, error) {
+	now := time.Now()
 
-	return s.emailClient.SendEmail(attr.Email, subject, body)
+	return s.tasksRepository.Claim(ctx, dto.TasksFilter{
+		Types:        p.types,
+		ExcludeTypes: p.exclude,
+		States:       []entities.TaskState{entities.TaskStateRunning},
+		RetryAt:      &now,
+		Limit:        &limit,
+	}, s.owner, s.leaseTimeout)
 }
 
-func (s *Service) handleSMSTask(ctx context.Context, t *entities.Task) error {
-	attr, ok := t.Attribute().(entities.TaskAttribute)
-	if !ok {
-		return fmt.Errorf("invalid attribute type for sms task")
+func (s *Service) processTask(ctx context.Context, t *entities.Task) {
+	err := s.handleTask(ctx, t)
+	switch {
+	case errors.Is(err, errs.ErrTaskLeaseLost):
+		// аренда истекла во время выполнения, задачу уже забрал другой экземпляр
+		s.log.Warnf("Task %s (%s): lease lost, result discarded", t.UUID(), t.TypeNm())
+	case err != nil:
+		s.log.Errorf("Finish task %s (%s): %v", t.UUID(), t.TypeNm(), err)
 	}
+}
 
-	if attr.Phone == "" {
-		return fmt.Errorf("phone is empty")
-	}
+func (s *Service) handleTask(ctx context.Context, t *entities.Task) error {
+	// результат записывается и после отмены контекста, иначе задача провисит до конца аренды
+	finishCtx := context.WithoutCancel(ctx)
 
-	// Notification tasks require verified phone; verification code tasks always go through.
-	if t.TypeNm() == entities.TaskTypeSendNotificationPhone && s.userInfoRepo != nil {
-		user, err := s.userInfoRepo.Get(ctx, dto.UserInfoFilter{ID: &attr.UserID}, false)
-		if err == nil && !user.IsPhoneVerified() {
-			s.log.Warnf("Skipping SMS notification for user %s: phone not verified", attr.UserID)
-			return nil // delete the task, no retry needed
-		}
+	h, ok := s.handlers.lookup(t.TypeNm())
+	if !ok {
+		// задача остаётся в dead-letter: после выкладки обработчика её можно перезапустить
+		s.log.Errorf("Unknown task type %q (id=%s), moving to dead-letter", t.TypeNm(), t.UUID())
+		t.Failed(fmt.Sprintf("no handler for task type %q", t.TypeNm()))
+		return s.tasksRepository.Release(finishCtx, t, s.owner)
 	}
 
-	body := attr.Body
-	if body == "" {
-		body = attr.Message
-	}
-	if body == "" {
-		body = string(t.Message())
-	}
-	if attr.Code != "" {
-		body = fmt.Sprintf("%s Ваш код: %s", body, attr.Code)
+	if t.MaxAttempts() <= 0 {
+		t.SetMaxAttempts(h.maxAttempts)
 	}
 
-	return s.smsClient.SendSMS(attr.Phone, body)
-}
+	handleCtx, cancel := context.WithTimeout(ctx, min(h.timeout, s.maxTaskTimeout))
+	defer cancel()
 
-func (s *Service) handlePushTask(ctx context.Context, t *entities.Task) error {
-	attr, ok := t.Attribute().(entities.TaskAttribute)
-	if !ok {
-		return fmt.Errorf("invalid attribute type for push task")
+	startedAt := time.Now()
+	err := h.handle(handleCtx, t)
+	if err == nil {
+		s.recordAttempt(finishCtx, t, t.Attempts()+1, entities.TaskAttemptSucceeded, nil, startedAt)
+		return s.tasksRepository.Complete(finishCtx, t.UUID(), s.owner)
 	}
 
-	if attr.DeviceToken == "" {
-		return fmt.Errorf("device token is empty")
+	// исполнитель останавливается: попытка не засчитывается, задача только освобождается,
+	// чтобы её сразу забрал другой экземпляр
+	if errors.Is(ctx.Err(), context.Canceled) {
+		s.log.Warnf("Task %s (%s) interrupted by shutdown, releasing", t.UUID(), t.TypeNm())
+		return s.tasksRepository.Release(finishCtx, t, s.owner)
 	}
 
-	title := attr.Title
-	if title == "" {
-		title = "BodyFuel"
+	if until, ok := deferredUntil(err); ok {
+		s.log.Infof("Task %s (%s) deferred until %s", t.UUID(), t.TypeNm(), until.Format(time.RFC3339))
+		t.Postpone(until)
+		return s.tasksRepository.Release(finishCtx, t, s.owner)
 	}
 
-	body := attr.Body
-	if body == "" {
-		body = attr.Message
+	s.log.Errorf("Handle task %s (%s): %v", t.UUID(), t.TypeNm(), err)
+
+	t.ScheduleRetry(h.backoff)
+
+	outcome := entities.TaskAttemptFailed
+	switch {
+	case IsPermanent(err):
+		t.Failed(fmt.Sprintf("permanent error: %v", err))
+	case t.IsLimitAttemptsExceeded():
+		t.Failed(fmt.Sprintf("max attempts (%d) exceeded, last error: %v", t.MaxAttempts(), err))
 	}
-	if body == "" {
-		body = string(t.Message())
+	if t.IsFailed() {
+		outcome = entities.TaskAttemptDeadLettered
+		s.log.Errorf("Task %s moved to dead-letter: %s", t.UUID(), t.FailureReason())
 	}
 
-	return s.pushClient.Send(attr.DeviceToken, apns.Payload{
-		Title: title,
-		Body:  body,
-	})
+	s.recordAttempt(finishCtx, t, t.Attempts(), outcome, err, startedAt)
+
+	return s.tasksRepository.Release(finishCtx, t, s.owner)
+}
+
+// recordAttempt пишет попытку в историю. Ошибка записи только логируется: история не должна
+// мешать выполнению задач.
+func (s *Service) recordAttempt(ctx context.Context, t *entities.Task, attempt int, outcome entities.TaskAttemptOutcome, err error, startedAt time.Time) {
+	if s.taskAttemptsRepository == nil {
+		return
+	}
+
+	a := entities.NewTaskAttempt(entities.WithTaskAttemptInitSpec(entities.TaskAttemptInitSpec{
+		Task:      t,
+		Attempt:   attempt,
+		Owner:     s.owner,
+		Outcome:   outcome,
+		Err:       err,
+		StartedAt: startedAt,
+	}))
+	if cerr := s.taskAttemptsRepository.Create(ctx, a); cerr != nil {
+		s.log.Warnf("Record attempt of task %s: %v", t.UUID(), cerr)
+	}
 }
 
 func (s *Service) Close() error {
@@ -3,11 +3,14 @@ package executor
 import (
 	"backend/internal/domain/entities"
 	"backend/internal/dto"
+	errs "backend/internal/errors"
 	"backend/pkg/logging"
 	"backend/pkg/notifications/apns"
+	"backend/pkg/notifications/push"
+	"backend/pkg/templates"
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -18,30 +21,49 @@ import (
 
 // ── mocks ──────────────────────────────────────────────────────────────────
 
-type mockTxManager struct{}
-
-func (m *mockTxManager) Do(ctx context.Context, fn func(context.Context) error) error {
-	return fn(ctx)
-}
-
 type mockTasksRepo struct{ mock.Mock }
 
-func (m *mockTasksRepo) Get(ctx context.Context, f dto.TasksFilter, withBlock bool) (*entities.Task, error) {
-	args := m.Called(ctx, f, withBlock)
+func (m *mockTasksRepo) Claim(ctx context.Context, f dto.TasksFilter, owner string, lease time.Duration) ([]*entities.Task, error) {
+	args := m.Called(ctx, f, owner, lease)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*entities.Task), args.Error(1)
+	return args.Get(0).([]*entities.Task), args.Error(1)
+}
+
+func (m *mockTasksRepo) Complete(ctx context.Context, id uuid.UUID, owner string) error {
+	return m.Called(ctx, id, owner).Error(0)
 }
 
-func (m *mockTasksRepo) Delete(ctx context.Context, ids []uuid.UUID) error {
-	return m.Called(ctx, ids).Error(0)
+func (m *mockTasksRepo) Release(ctx context.Context, t *entities.Task, owner string) error {
+	return m.Called(ctx, t, owner).Error(0)
 }
 
-func (m *mockTasksRepo) Update(ctx context.Context, t *entities.Task) error {
+func (m *mockTasksRepo) Create(ctx context.Context, t *entities.Task) error {
 	return m.Called(ctx, t).Error(0)
 }
 
+func (m *mockTasksRepo) DeleteExpiredDedupKeys(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTasksRepo) CreateForUsers(ctx context.Context, spec dto.UserTasksSpec) (int, error) {
+	args := m.Called(ctx, spec)
+	return args.Int(0), args.Error(1)
+}
+
+type mockTaskAttemptsRepo struct{ mock.Mock }
+
+func (m *mockTaskAttemptsRepo) Create(ctx context.Context, a *entities.TaskAttempt) error {
+	return m.Called(ctx, a).Error(0)
+}
+
+func (m *mockTaskAttemptsRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(ctx, before)
+	return args.Int(0), args.Error(1)
+}
+
 type mockUserInfoRepo struct{ mock.Mock }
 
 func (m *mockUserInfoRepo) Get(ctx context.Context, f dto.UserInfoFilter, withBlock bool) (*entities.UserInfo, error) {
@@ -66,18 +88,20 @@ func (m *mockSMSClient) SendSMS(to, body string) error {
 
 type mockPushClient struct{ mock.Mock }
 
-func (m *mockPushClient) Send(deviceToken string, p apns.Payload) error {
+func (m *mockPushClient) Send(deviceToken string, p push.Payload) error {
 	return m.Called(deviceToken, p).Error(0)
 }
 
+type mockLiveActivityClient struct{ mock.Mock }
+
+func (m *mockLiveActivityClient) SendLiveActivity(pushToken string, p apns.LiveActivityPayload) error {
+	return m.Called(pushToken, p).Error(0)
+}
+
 // ── helpers ────────────────────────────────────────────────────────────────
 
-func newTaskWithAttr(taskType entities.TaskType, attr entities.TaskAttribute) *entities.Task {
-	return entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-		TypeNm:      taskType,
-		MaxAttempts: 3,
-		Attribute:   attr,
-	}))
+func newTask[P entities.TaskPayload](kind entities.TaskKind[P], p P) *entities.Task {
+	return kind.NewTask(p, entities.TaskSchedule{MaxAttempts: 3})
 }
 
 func newVerifiedUser(userID uuid.UUID) *entities.UserInfo {
@@ -99,15 +123,67 @@ func newUnverifiedUser(userID uuid.UUID) *entities.UserInfo {
 	}))
 }
 
-func newService(tasksRepo *mockTasksRepo, userInfoRepo *mockUserInfoRepo, email *mockEmailClient, sms *mockSMSClient, push *mockPushClient) *Service {
+func newNotificationHandlers(userInfoRepo UserInfoRepository, email EmailClient, sms SMSClient, pushClient PushClient) *Registry {
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{
+		UserInfoRepository: userInfoRepo,
+		EmailClient:        email,
+		SMSClient:          sms,
+		PushClients:        iosPushClients(pushClient),
+	})
+	return r
+}
+
+func newTemplateHandlers(t *testing.T, userInfoRepo UserInfoRepository, email EmailClient, sms SMSClient) *Registry {
+	renderer, err := templates.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{
+		UserInfoRepository: userInfoRepo,
+		EmailClient:        email,
+		SMSClient:          sms,
+		Templates:          renderer,
+	})
+	return r
+}
+
+func newUserWithLocale(userID uuid.UUID, locale string) *entities.UserInfo {
+	now := time.Now()
+	return entities.NewUserInfo(entities.WithUserInfoRestoreSpec(entities.UserInfoRestoreSpec{
+		ID:              userID,
+		Email:           "user@example.com",
+		Phone:           "+79991234567",
+		Locale:          locale,
+		EmailVerifiedAt: &now,
+		PhoneVerifiedAt: &now,
+	}))
+}
+
+// iosPushClients — провайдеры push, где есть только APNs.
+func iosPushClients(c PushClient) map[string]PushClient {
+	return map[string]PushClient{entities.DevicePlatformIOS: c}
+}
+
+func runHandler(ctx context.Context, r *Registry, task *entities.Task) error {
+	h, ok := r.lookup(task.TypeNm())
+	if !ok {
+		return errors.New("no handler")
+	}
+	return h.handle(ctx, task)
+}
+
+func newService(tasksRepo *mockTasksRepo, handlers *Registry) *Service {
 	return &Service{
-		txm:             &mockTxManager{},
 		tasksRepository: tasksRepo,
-		userInfoRepo:    userInfoRepo,
-		emailClient:     email,
-		smsClient:       sms,
-		pushClient:      push,
+		handlers:        handlers,
 		queryDelay:      time.Minute,
+		batchSize:       DefaultBatchSize,
+		leaseTimeout:    DefaultLeaseTimeout,
+		maxTaskTimeout:  DefaultLeaseTimeout * 3 / 4,
+		owner:           "test-executor",
 		log:             logging.GetLoggerFromContext(context.Background()),
 	}
 }
@@ -118,7 +194,7 @@ func TestHandleEmailTask_Success(t *testing.T) {
 	ctx := context.Background()
 	userID := uuid.New()
 
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
 		UserID:  userID,
 		Email:   "test@example.com",
 		Subject: "Code",
@@ -128,8 +204,8 @@ func TestHandleEmailTask_Success(t *testing.T) {
 	emailMock := &mockEmailClient{}
 	emailMock.On("SendEmail", "test@example.com", "Code", "Your code: 123456").Return(nil)
 
-	svc := newService(&mockTasksRepo{}, nil, emailMock, nil, nil)
-	err := svc.handleEmailTask(ctx, task)
+	handlers := newNotificationHandlers(nil, emailMock, nil, nil)
+	err := runHandler(ctx, handlers, task)
 
 	assert.NoError(t, err)
 	emailMock.AssertExpectations(t)
@@ -137,12 +213,12 @@ func TestHandleEmailTask_Success(t *testing.T) {
 
 func TestHandleEmailTask_EmptyEmail_Error(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
 		Email: "",
 	})
 
-	svc := newService(&mockTasksRepo{}, nil, &mockEmailClient{}, nil, nil)
-	err := svc.handleEmailTask(ctx, task)
+	handlers := newNotificationHandlers(nil, &mockEmailClient{}, nil, nil)
+	err := runHandler(ctx, handlers, task)
 	assert.Error(t, err)
 }
 
@@ -150,7 +226,7 @@ func TestHandleEmailTask_NotificationBlocked_EmailNotVerified(t *testing.T) {
 	ctx := context.Background()
 	userID := uuid.New()
 
-	task := newTaskWithAttr(entities.TaskTypeSendNotificationEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendNotificationEmail, entities.EmailTaskPayload{
 		UserID: userID,
 		Email:  "test@example.com",
 		Body:   "You have a new workout",
@@ -161,8 +237,8 @@ func TestHandleEmailTask_NotificationBlocked_EmailNotVerified(t *testing.T) {
 		Return(newUnverifiedUser(userID), nil)
 
 	emailMock := &mockEmailClient{} // SendEmail must NOT be called
-	svc := newService(&mockTasksRepo{}, userInfoRepo, emailMock, nil, nil)
-	err := svc.handleEmailTask(ctx, task)
+	handlers := newNotificationHandlers(userInfoRepo, emailMock, nil, nil)
+	err := runHandler(ctx, handlers, task)
 
 	assert.NoError(t, err) // nil = delete the task silently
 	emailMock.AssertNotCalled(t, "SendEmail")
@@ -172,7 +248,7 @@ func TestHandleEmailTask_NotificationAllowed_EmailVerified(t *testing.T) {
 	ctx := context.Background()
 	userID := uuid.New()
 
-	task := newTaskWithAttr(entities.TaskTypeSendNotificationEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendNotificationEmail, entities.EmailTaskPayload{
 		UserID:  userID,
 		Email:   "test@example.com",
 		Subject: "New workout",
@@ -186,8 +262,8 @@ func TestHandleEmailTask_NotificationAllowed_EmailVerified(t *testing.T) {
 	emailMock := &mockEmailClient{}
 	emailMock.On("SendEmail", "test@example.com", "New workout", "Ready!").Return(nil)
 
-	svc := newService(&mockTasksRepo{}, userInfoRepo, emailMock, nil, nil)
-	err := svc.handleEmailTask(ctx, task)
+	handlers := newNotificationHandlers(userInfoRepo, emailMock, nil, nil)
+	err := runHandler(ctx, handlers, task)
 
 	assert.NoError(t, err)
 	emailMock.AssertExpectations(t)
@@ -195,7 +271,7 @@ func TestHandleEmailTask_NotificationAllowed_EmailVerified(t *testing.T) {
 
 func TestHandleEmailTask_DefaultSubject(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
 		Email:   "x@example.com",
 		Subject: "", // empty → default "BodyFuel"
 		Body:    "hello",
@@ -204,8 +280,8 @@ func TestHandleEmailTask_DefaultSubject(t *testing.T) {
 	emailMock := &mockEmailClient{}
 	emailMock.On("SendEmail", "x@example.com", "BodyFuel", "hello").Return(nil)
 
-	svc := newService(&mockTasksRepo{}, nil, emailMock, nil, nil)
-	err := svc.handleEmailTask(ctx, task)
+	handlers := newNotificationHandlers(nil, emailMock, nil, nil)
+	err := runHandler(ctx, handlers, task)
 	assert.NoError(t, err)
 	emailMock.AssertExpectations(t)
 }
@@ -214,27 +290,89 @@ func TestHandleEmailTask_DefaultSubject(t *testing.T) {
 
 func TestHandleSMSTask_Success(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnPhone, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendCodeOnPhone, entities.SMSTaskPayload{
 		Phone: "+79991234567",
-		Body:  "Your code",
+		Body:  "Your code 654321",
 		Code:  "654321",
 	})
 
 	smsMock := &mockSMSClient{}
-	smsMock.On("SendSMS", "+79991234567", "Your code Ваш код: 654321").Return(nil)
+	smsMock.On("SendSMS", "+79991234567", "Your code 654321").Return(nil)
+
+	handlers := newNotificationHandlers(nil, nil, smsMock, nil)
+	err := runHandler(ctx, handlers, task)
+
+	assert.NoError(t, err)
+	smsMock.AssertExpectations(t)
+}
+
+// ── templates ──────────────────────────────────────────────────────────────
+
+func TestHandleEmailTask_TemplateRenderedInUserLocale(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
+		UserID:   userID,
+		Email:    "user@example.com",
+		Template: entities.NotificationTemplateVerificationCode,
+		Code:     "123456",
+	})
+
+	userInfoRepo := &mockUserInfoRepo{}
+	userInfoRepo.On("Get", mock.Anything, mock.Anything, false).Return(newUserWithLocale(userID, "en"), nil)
+
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", "user@example.com", "BodyFuel — confirm your email",
+		"<p>Your verification code: <strong>123456</strong></p>").Return(nil)
+
+	err := runHandler(ctx, newTemplateHandlers(t, userInfoRepo, emailMock, nil), task)
+
+	assert.NoError(t, err)
+	emailMock.AssertExpectations(t)
+}
+
+func TestHandleSMSTask_TemplateFallsBackToDefaultLocale(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	task := newTask(entities.TaskKindSendCodeOnPhone, entities.SMSTaskPayload{
+		UserID:   userID,
+		Phone:    "+79991234567",
+		Template: entities.NotificationTemplateLoginCode,
+		Code:     "654321",
+	})
+
+	userInfoRepo := &mockUserInfoRepo{}
+	userInfoRepo.On("Get", mock.Anything, mock.Anything, false).Return(newUserWithLocale(userID, "de"), nil)
+
+	smsMock := &mockSMSClient{}
+	smsMock.On("SendSMS", "+79991234567", "BodyFuel: код для входа 654321").Return(nil)
 
-	svc := newService(&mockTasksRepo{}, nil, nil, smsMock, nil)
-	err := svc.handleSMSTask(ctx, task)
+	err := runHandler(ctx, newTemplateHandlers(t, userInfoRepo, nil, smsMock), task)
 
 	assert.NoError(t, err)
 	smsMock.AssertExpectations(t)
 }
 
+func TestHandleEmailTask_UnknownTemplate_Permanent(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
+		Email:    "user@example.com",
+		Template: "no_such_template",
+		Code:     "123456",
+	})
+
+	emailMock := &mockEmailClient{}
+	err := runHandler(ctx, newTemplateHandlers(t, nil, emailMock, nil), task)
+
+	assert.True(t, IsPermanent(err))
+	emailMock.AssertNotCalled(t, "SendEmail")
+}
+
 func TestHandleSMSTask_EmptyPhone_Error(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnPhone, entities.TaskAttribute{Phone: ""})
-	svc := newService(&mockTasksRepo{}, nil, nil, &mockSMSClient{}, nil)
-	err := svc.handleSMSTask(ctx, task)
+	task := newTask(entities.TaskKindSendCodeOnPhone, entities.SMSTaskPayload{Phone: ""})
+	handlers := newNotificationHandlers(nil, nil, &mockSMSClient{}, nil)
+	err := runHandler(ctx, handlers, task)
 	assert.Error(t, err)
 }
 
@@ -242,7 +380,7 @@ func TestHandleSMSTask_NotificationBlocked_PhoneNotVerified(t *testing.T) {
 	ctx := context.Background()
 	userID := uuid.New()
 
-	task := newTaskWithAttr(entities.TaskTypeSendNotificationPhone, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendNotificationPhone, entities.SMSTaskPayload{
 		UserID: userID,
 		Phone:  "+79991234567",
 		Body:   "You have a new workout",
@@ -253,8 +391,8 @@ func TestHandleSMSTask_NotificationBlocked_PhoneNotVerified(t *testing.T) {
 		Return(newUnverifiedUser(userID), nil)
 
 	smsMock := &mockSMSClient{}
-	svc := newService(&mockTasksRepo{}, userInfoRepo, nil, smsMock, nil)
-	err := svc.handleSMSTask(ctx, task)
+	handlers := newNotificationHandlers(userInfoRepo, nil, smsMock, nil)
+	err := runHandler(ctx, handlers, task)
 
 	assert.NoError(t, err)
 	smsMock.AssertNotCalled(t, "SendSMS")
@@ -264,17 +402,17 @@ func TestHandleSMSTask_NotificationBlocked_PhoneNotVerified(t *testing.T) {
 
 func TestHandlePushTask_Success(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendPushNotification, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
 		DeviceToken: "device-abc",
 		Title:       "Workout",
 		Body:        "Your workout is ready!",
 	})
 
 	pushMock := &mockPushClient{}
-	pushMock.On("Send", "device-abc", apns.Payload{Title: "Workout", Body: "Your workout is ready!"}).Return(nil)
+	pushMock.On("Send", "device-abc", push.Payload{Title: "Workout", Body: "Your workout is ready!"}).Return(nil)
 
-	svc := newService(&mockTasksRepo{}, nil, nil, nil, pushMock)
-	err := svc.handlePushTask(ctx, task)
+	handlers := newNotificationHandlers(nil, nil, nil, pushMock)
+	err := runHandler(ctx, handlers, task)
 
 	assert.NoError(t, err)
 	pushMock.AssertExpectations(t)
@@ -282,34 +420,347 @@ func TestHandlePushTask_Success(t *testing.T) {
 
 func TestHandlePushTask_EmptyDeviceToken_Error(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendPushNotification, entities.TaskAttribute{DeviceToken: ""})
-	svc := newService(&mockTasksRepo{}, nil, nil, nil, &mockPushClient{})
-	err := svc.handlePushTask(ctx, task)
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{DeviceToken: ""})
+	handlers := newNotificationHandlers(nil, nil, nil, &mockPushClient{})
+	err := runHandler(ctx, handlers, task)
 	assert.Error(t, err)
 }
 
 func TestHandlePushTask_DefaultTitle(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendPushNotification, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
 		DeviceToken: "tok",
 		Title:       "", // empty → "BodyFuel"
 		Body:        "msg",
 	})
 
 	pushMock := &mockPushClient{}
-	pushMock.On("Send", "tok", apns.Payload{Title: "BodyFuel", Body: "msg"}).Return(nil)
+	pushMock.On("Send", "tok", push.Payload{Title: "BodyFuel", Body: "msg"}).Return(nil)
+
+	handlers := newNotificationHandlers(nil, nil, nil, pushMock)
+	err := runHandler(ctx, handlers, task)
+	assert.NoError(t, err)
+	pushMock.AssertExpectations(t)
+}
+
+func TestHandlePushTask_PassesOptions(t *testing.T) {
+	ctx := context.Background()
+	badge := 3
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "tok",
+		Title:       "Workout",
+		Body:        "Ready",
+		PushOptions: entities.PushOptions{
+			Badge:             &badge,
+			ActionCategory:    "WORKOUT",
+			ThreadID:          "workouts",
+			CollapseID:        "workout-1",
+			InterruptionLevel: "time-sensitive",
+			Data:              map[string]string{"deeplink": "bodyfuel://workouts/1"},
+		},
+	})
+
+	pushMock := &mockPushClient{}
+	pushMock.On("Send", "tok", push.Payload{
+		Title:             "Workout",
+		Body:              "Ready",
+		Badge:             &badge,
+		Category:          "WORKOUT",
+		ThreadID:          "workouts",
+		CollapseID:        "workout-1",
+		InterruptionLevel: push.InterruptionLevelTimeSensitive,
+		Data:              map[string]string{"deeplink": "bodyfuel://workouts/1"},
+	}).Return(nil)
+
+	err := runHandler(ctx, newNotificationHandlers(nil, nil, nil, pushMock), task)
+
+	assert.NoError(t, err)
+	pushMock.AssertExpectations(t)
+}
+
+func TestHandlePushTask_BackgroundWithoutTitle(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "tok",
+		PushOptions: entities.PushOptions{ContentAvailable: true},
+	})
+
+	pushMock := &mockPushClient{}
+	pushMock.On("Send", "tok", push.Payload{ContentAvailable: true}).Return(nil)
+
+	err := runHandler(ctx, newNotificationHandlers(nil, nil, nil, pushMock), task)
 
-	svc := newService(&mockTasksRepo{}, nil, nil, nil, pushMock)
-	err := svc.handlePushTask(ctx, task)
 	assert.NoError(t, err)
 	pushMock.AssertExpectations(t)
 }
 
+func TestHandlePushTask_InvalidToken_DeletesDevice(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "dead-token",
+		Body:        "msg",
+	})
+
+	pushMock := &mockPushClient{}
+	pushMock.On("Send", "dead-token", mock.Anything).
+		Return(fmt.Errorf("%w: Unregistered (410)", push.ErrInvalidDeviceToken))
+
+	devicesRepo := &mockUserDevicesRepo{}
+	devicesRepo.On("DeleteByToken", mock.Anything, "dead-token").Return(nil).Once()
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{UserDevicesRepository: devicesRepo, PushClients: iosPushClients(pushMock)})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	devicesRepo.AssertExpectations(t)
+}
+
+func TestHandlePushTask_InvalidToken_DeleteFailsRetries(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "dead-token",
+		Body:        "msg",
+	})
+
+	pushMock := &mockPushClient{}
+	pushMock.On("Send", "dead-token", mock.Anything).
+		Return(fmt.Errorf("%w: BadDeviceToken (400)", push.ErrInvalidDeviceToken))
+
+	devicesRepo := &mockUserDevicesRepo{}
+	devicesRepo.On("DeleteByToken", mock.Anything, "dead-token").Return(errors.New("db down")).Once()
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{UserDevicesRepository: devicesRepo, PushClients: iosPushClients(pushMock)})
+	err := runHandler(ctx, r, task)
+
+	assert.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestHandlePushTask_InvalidTokenWithoutDevicesRepo_Permanent(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "dead-token",
+		Body:        "msg",
+	})
+
+	pushMock := &mockPushClient{}
+	pushMock.On("Send", "dead-token", mock.Anything).
+		Return(fmt.Errorf("%w: Unregistered (410)", push.ErrInvalidDeviceToken))
+
+	err := runHandler(ctx, newNotificationHandlers(nil, nil, nil, pushMock), task)
+
+	assert.True(t, IsPermanent(err))
+}
+
+func TestHandlePushTask_RoutesByPlatform(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "fcm-token",
+		Platform:    entities.DevicePlatformAndroid,
+		Title:       "Workout",
+		Body:        "Your workout is ready!",
+	})
+
+	apnsMock := &mockPushClient{}
+	fcmMock := &mockPushClient{}
+	fcmMock.On("Send", "fcm-token", push.Payload{Title: "Workout", Body: "Your workout is ready!"}).Return(nil).Once()
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{PushClients: map[string]PushClient{
+		entities.DevicePlatformIOS:     apnsMock,
+		entities.DevicePlatformAndroid: fcmMock,
+	}})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	fcmMock.AssertExpectations(t)
+	apnsMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestHandlePushTask_PlatformWithoutClient_Permanent(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "fcm-token",
+		Platform:    entities.DevicePlatformAndroid,
+		Body:        "msg",
+	})
+
+	apnsMock := &mockPushClient{}
+	err := runHandler(ctx, newNotificationHandlers(nil, nil, nil, apnsMock), task)
+
+	assert.ErrorContains(t, err, `platform "android"`)
+	assert.True(t, IsPermanent(err))
+	apnsMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestHandleTask_PushPlatformWithoutClient_DeadLettered(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "fcm-token",
+		Platform:    entities.DevicePlatformAndroid,
+		Body:        "msg",
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, task, "test-executor").Return(nil).Once()
+
+	svc := newService(tasksRepo, newNotificationHandlers(nil, nil, nil, &mockPushClient{}))
+	err := svc.handleTask(ctx, task)
+
+	assert.NoError(t, err)
+	assert.True(t, task.IsFailed())
+	assert.Equal(t, 1, task.Attempts())
+	assert.Contains(t, task.FailureReason(), "permanent error")
+	tasksRepo.AssertExpectations(t)
+}
+
+func TestHandlePushTask_AndroidInvalidToken_DeletesDevice(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		DeviceToken: "dead-fcm-token",
+		Platform:    entities.DevicePlatformAndroid,
+		Body:        "msg",
+	})
+
+	fcmMock := &mockPushClient{}
+	fcmMock.On("Send", "dead-fcm-token", mock.Anything).
+		Return(fmt.Errorf("%w: UNREGISTERED (404)", push.ErrInvalidDeviceToken))
+
+	devicesRepo := &mockUserDevicesRepo{}
+	devicesRepo.On("DeleteByToken", mock.Anything, "dead-fcm-token").Return(nil).Once()
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{
+		UserDevicesRepository: devicesRepo,
+		PushClients:           map[string]PushClient{entities.DevicePlatformAndroid: fcmMock},
+	})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	devicesRepo.AssertExpectations(t)
+}
+
+// ── handleLiveActivityTask ─────────────────────────────────────────────────
+
+func TestHandleLiveActivityTask_Update(t *testing.T) {
+	ctx := context.Background()
+	exerciseID := uuid.New()
+	ts := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	state := entities.LiveActivityState{
+		Status:            entities.WorkoutStatusInActive,
+		CurrentExerciseID: &exerciseID,
+		ExerciseIndex:     2,
+		ExercisesTotal:    5,
+	}
+	task := newTask(entities.TaskKindSendLiveActivity, entities.LiveActivityTaskPayload{
+		WorkoutID: uuid.New(),
+		PushToken: "activity-token",
+		State:     state,
+		Timestamp: ts,
+	})
+
+	client := &mockLiveActivityClient{}
+	client.On("SendLiveActivity", "activity-token", apns.LiveActivityPayload{
+		Event:        apns.LiveActivityEventUpdate,
+		ContentState: state,
+		Timestamp:    ts,
+	}).Return(nil).Once()
+
+	r := NewRegistry()
+	RegisterLiveActivityHandlers(r, LiveActivityConfig{Client: client})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestHandleLiveActivityTask_EndedWorkoutEndsActivity(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	task := newTask(entities.TaskKindSendLiveActivity, entities.LiveActivityTaskPayload{
+		WorkoutID: uuid.New(),
+		PushToken: "activity-token",
+		State:     entities.LiveActivityState{Status: entities.WorkoutStatusDone, ExercisesTotal: 5, CompletedExercises: 5},
+		Timestamp: ts,
+	})
+
+	var sent apns.LiveActivityPayload
+	client := &mockLiveActivityClient{}
+	client.On("SendLiveActivity", "activity-token", mock.Anything).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(apns.LiveActivityPayload) }).
+		Return(nil).Once()
+
+	r := NewRegistry()
+	RegisterLiveActivityHandlers(r, LiveActivityConfig{Client: client})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, apns.LiveActivityEventEnd, sent.Event)
+	if assert.NotNil(t, sent.DismissalDate) {
+		assert.Equal(t, ts.Add(liveActivityDismissAfter), *sent.DismissalDate)
+	}
+}
+
+func TestHandleLiveActivityTask_EmptyToken_Permanent(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendLiveActivity, entities.LiveActivityTaskPayload{WorkoutID: uuid.New()})
+
+	r := NewRegistry()
+	RegisterLiveActivityHandlers(r, LiveActivityConfig{Client: &mockLiveActivityClient{}})
+	err := runHandler(ctx, r, task)
+
+	assert.True(t, IsPermanent(err))
+}
+
+func TestHandleLiveActivityTask_InvalidToken_DeletesActivity(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendLiveActivity, entities.LiveActivityTaskPayload{
+		WorkoutID: uuid.New(),
+		PushToken: "dead-token",
+		Timestamp: time.Now(),
+	})
+
+	client := &mockLiveActivityClient{}
+	client.On("SendLiveActivity", "dead-token", mock.Anything).
+		Return(fmt.Errorf("%w: Unregistered (410)", push.ErrInvalidDeviceToken))
+
+	repo := &mockUserDevicesRepo{}
+	repo.On("DeleteByToken", mock.Anything, "dead-token").Return(nil).Once()
+
+	r := NewRegistry()
+	RegisterLiveActivityHandlers(r, LiveActivityConfig{Repository: repo, Client: client})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestHandleLiveActivityTask_InvalidTokenWithoutRepo_Permanent(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendLiveActivity, entities.LiveActivityTaskPayload{
+		WorkoutID: uuid.New(),
+		PushToken: "dead-token",
+		Timestamp: time.Now(),
+	})
+
+	client := &mockLiveActivityClient{}
+	client.On("SendLiveActivity", "dead-token", mock.Anything).
+		Return(fmt.Errorf("%w: BadDeviceToken (400)", push.ErrInvalidDeviceToken))
+
+	r := NewRegistry()
+	RegisterLiveActivityHandlers(r, LiveActivityConfig{Client: client})
+	err := runHandler(ctx, r, task)
+
+	assert.True(t, IsPermanent(err))
+}
+
 // ── handleTask routing ─────────────────────────────────────────────────────
 
 func TestHandleTask_DeletesOnSuccess(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
 		Email: "x@example.com",
 		Body:  "code",
 	})
@@ -318,18 +769,18 @@ func TestHandleTask_DeletesOnSuccess(t *testing.T) {
 	emailMock.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	tasksRepo := &mockTasksRepo{}
-	tasksRepo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	tasksRepo.On("Complete", mock.Anything, mock.Anything, "test-executor").Return(nil)
 
-	svc := newService(tasksRepo, nil, emailMock, nil, nil)
+	svc := newService(tasksRepo, newNotificationHandlers(nil, emailMock, nil, nil))
 	err := svc.handleTask(ctx, task)
 
 	assert.NoError(t, err)
-	tasksRepo.AssertCalled(t, "Delete", mock.Anything, mock.Anything)
+	tasksRepo.AssertCalled(t, "Complete", mock.Anything, mock.Anything, "test-executor")
 }
 
 func TestHandleTask_UpdatesOnFailure(t *testing.T) {
 	ctx := context.Background()
-	task := newTaskWithAttr(entities.TaskTypeSendCodeOnEmail, entities.TaskAttribute{
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
 		Email: "x@example.com",
 		Body:  "code",
 	})
@@ -338,44 +789,1071 @@ func TestHandleTask_UpdatesOnFailure(t *testing.T) {
 	emailMock.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("smtp error"))
 
 	tasksRepo := &mockTasksRepo{}
-	tasksRepo.On("Update", mock.Anything, mock.AnythingOfType("*entities.Task")).Return(nil)
+	tasksRepo.On("Release", mock.Anything, mock.AnythingOfType("*entities.Task"), "test-executor").Return(nil)
 
-	svc := newService(tasksRepo, nil, emailMock, nil, nil)
+	svc := newService(tasksRepo, newNotificationHandlers(nil, emailMock, nil, nil))
 	err := svc.handleTask(ctx, task)
 
 	assert.NoError(t, err)
-	tasksRepo.AssertCalled(t, "Update", mock.Anything, mock.Anything)
+	tasksRepo.AssertCalled(t, "Release", mock.Anything, mock.Anything, "test-executor")
+}
+
+func TestHandleTask_LeaseLost_ReturnsError(t *testing.T) {
+	ctx := context.Background()
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
+		Email: "x@example.com",
+		Body:  "code",
+	})
+
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Complete", mock.Anything, task.UUID(), "test-executor").Return(errs.ErrTaskLeaseLost).Once()
+
+	svc := newService(tasksRepo, newNotificationHandlers(nil, emailMock, nil, nil))
+	err := svc.handleTask(ctx, task)
+
+	assert.ErrorIs(t, err, errs.ErrTaskLeaseLost)
+	tasksRepo.AssertExpectations(t)
 }
 
-func TestHandleTask_UnknownType_Deleted(t *testing.T) {
+func TestHandleTask_UnknownType_DeadLettered(t *testing.T) {
 	ctx := context.Background()
 	task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
 		TypeNm:      "unknown_task_type",
 		MaxAttempts: 3,
-		Attribute:   entities.TaskAttribute{},
 	}))
 
 	tasksRepo := &mockTasksRepo{}
-	tasksRepo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
 
-	svc := newService(tasksRepo, nil, nil, nil, nil)
+	svc := newService(tasksRepo, newNotificationHandlers(nil, nil, nil, nil))
 	err := svc.handleTask(ctx, task)
 
 	assert.NoError(t, err)
-	tasksRepo.AssertCalled(t, "Delete", mock.Anything, mock.Anything)
+	assert.True(t, task.IsFailed())
+	assert.Contains(t, task.FailureReason(), "no handler")
+	tasksRepo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, "test-executor")
+}
+
+type testPayload struct {
+	Value string `json:"value"`
 }
 
-// ── processTask ────────────────────────────────────────────────────────────
+func (p testPayload) Redacted() entities.TaskPayload { return p }
+
+var testTaskKind = entities.NewTaskKind[testPayload]("executor_test_task")
 
-func TestProcessTask_NoRows_StopsLoop(t *testing.T) {
+func TestHandleTask_RegisteredHandlerGetsTypedPayload(t *testing.T) {
 	ctx := context.Background()
 
+	var got testPayload
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(_ context.Context, _ *entities.Task, p testPayload) error {
+			got = p
+			return nil
+		},
+	})
+
 	tasksRepo := &mockTasksRepo{}
-	tasksRepo.On("Get", mock.Anything, mock.Anything, true).Return(nil, sql.ErrNoRows)
+	tasksRepo.On("Complete", mock.Anything, mock.Anything, "test-executor").Return(nil)
 
-	svc := newService(tasksRepo, nil, nil, nil, nil)
-	err := svc.processTask(ctx)
+	svc := newService(tasksRepo, r)
+	err := svc.handleTask(ctx, testTaskKind.NewTask(testPayload{Value: "42"}, entities.TaskSchedule{}))
 
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", got.Value)
+	tasksRepo.AssertNumberOfCalls(t, "Complete", 1)
+}
+
+func TestHandleTask_AppliesHandlerPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error {
+			return errors.New("boom")
+		},
+		MaxAttempts: 2,
+		Backoff:     func(attempt int) time.Duration { return time.Duration(attempt) * time.Hour },
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	svc := newService(tasksRepo, r)
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{})
+
+	assert.NoError(t, svc.handleTask(ctx, task))
+	assert.Equal(t, 2, task.MaxAttempts(), "task without own limit gets the handler's")
+	assert.Equal(t, 1, task.Attempts())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), task.RetryAt(), time.Minute)
+	assert.False(t, task.IsFailed())
+
+	assert.NoError(t, svc.handleTask(ctx, task))
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), task.RetryAt(), time.Minute)
+	assert.True(t, task.IsFailed())
+}
+
+func TestHandleTask_OwnLimitOverridesHandlerPolicy(t *testing.T) {
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error {
+			return errors.New("boom")
+		},
+		MaxAttempts: 10,
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{MaxAttempts: 1})
+	assert.NoError(t, newService(tasksRepo, r).handleTask(context.Background(), task))
+
+	assert.Equal(t, 1, task.MaxAttempts())
+	assert.True(t, task.IsFailed())
+}
+
+func TestHandleTask_HandlerTimeoutCountsAsAttempt(t *testing.T) {
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(ctx context.Context, _ *entities.Task, _ testPayload) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Timeout: 10 * time.Millisecond,
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{})
+	assert.NoError(t, newService(tasksRepo, r).handleTask(context.Background(), task))
+
+	assert.Equal(t, 1, task.Attempts())
+}
+
+func TestHandleTask_PermanentErrorDeadLettersImmediately(t *testing.T) {
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error {
+			return Permanent(errors.New("bad payload"))
+		},
+		MaxAttempts: 5,
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{})
+	assert.NoError(t, newService(tasksRepo, r).handleTask(context.Background(), task))
+
+	assert.True(t, task.IsFailed())
+	assert.Equal(t, 1, task.Attempts())
+	assert.Equal(t, "permanent error: bad payload", task.FailureReason())
+}
+
+func TestHandleTask_LimitExceeded_ReasonHasLastError(t *testing.T) {
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error {
+			return errors.New("apns: BadDeviceToken")
+		},
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{MaxAttempts: 1})
+	assert.NoError(t, newService(tasksRepo, r).handleTask(context.Background(), task))
+
+	assert.True(t, task.IsFailed())
+	assert.Equal(t, "max attempts (1) exceeded, last error: apns: BadDeviceToken", task.FailureReason())
+}
+
+func TestHandleTask_RecordsAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	fail := true
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error {
+			if fail {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+	tasksRepo.On("Complete", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	var recorded []*entities.TaskAttempt
+	attemptsRepo := &mockTaskAttemptsRepo{}
+	attemptsRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = append(recorded, args.Get(1).(*entities.TaskAttempt))
+	}).Return(nil)
+
+	svc := newService(tasksRepo, r)
+	svc.taskAttemptsRepository = attemptsRepo
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{})
+
+	assert.NoError(t, svc.handleTask(ctx, task))
+	fail = false
+	assert.NoError(t, svc.handleTask(ctx, task))
+
+	if assert.Len(t, recorded, 2) {
+		assert.Equal(t, task.UUID(), recorded[0].TaskID())
+		assert.Equal(t, testTaskKind.Type(), recorded[0].TaskType())
+		assert.Equal(t, 1, recorded[0].Attempt())
+		assert.Equal(t, entities.TaskAttemptFailed, recorded[0].Outcome())
+		assert.Equal(t, "boom", recorded[0].Error())
+		assert.Equal(t, "test-executor", recorded[0].Owner())
+
+		assert.Equal(t, 2, recorded[1].Attempt())
+		assert.Equal(t, entities.TaskAttemptSucceeded, recorded[1].Outcome())
+		assert.Empty(t, recorded[1].Error())
+	}
+}
+
+func TestHandleTask_RecordsDeadLetteredAttempt(t *testing.T) {
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error {
+			return errors.New("boom")
+		},
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	attemptsRepo := &mockTaskAttemptsRepo{}
+	attemptsRepo.On("Create", mock.Anything, mock.MatchedBy(func(a *entities.TaskAttempt) bool {
+		return a.Outcome() == entities.TaskAttemptDeadLettered
+	})).Return(nil)
+
+	svc := newService(tasksRepo, r)
+	svc.taskAttemptsRepository = attemptsRepo
+
+	task := testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{MaxAttempts: 1})
+	assert.NoError(t, svc.handleTask(context.Background(), task))
+	attemptsRepo.AssertExpectations(t)
+}
+
+func TestHandleTask_HistoryErrorDoesNotFailTask(t *testing.T) {
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{
+		Handle: func(context.Context, *entities.Task, testPayload) error { return nil },
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Complete", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	attemptsRepo := &mockTaskAttemptsRepo{}
+	attemptsRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+	svc := newService(tasksRepo, r)
+	svc.taskAttemptsRepository = attemptsRepo
+
+	assert.NoError(t, svc.handleTask(context.Background(), testTaskKind.NewTask(testPayload{}, entities.TaskSchedule{})))
+	tasksRepo.AssertNumberOfCalls(t, "Complete", 1)
+}
+
+func TestRegister_DuplicateTypePanics(t *testing.T) {
+	r := NewRegistry()
+	h := Handler[testPayload]{Handle: func(context.Context, *entities.Task, testPayload) error { return nil }}
+	Register(r, testTaskKind, h)
+
+	assert.Panics(t, func() { Register(r, testTaskKind, h) })
+	assert.Equal(t, []entities.TaskType{testTaskKind.Type()}, r.Types())
+}
+
+func TestRegister_DefaultTimeout(t *testing.T) {
+	handle := func(context.Context, *entities.Task, testPayload) error { return nil }
+
+	r := NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{Handle: handle})
+	h, ok := r.lookup(testTaskKind.Type())
+	if assert.True(t, ok) {
+		assert.Equal(t, 15*time.Second, h.timeout)
+	}
+
+	r = NewRegistry()
+	Register(r, testTaskKind, Handler[testPayload]{Handle: handle, Timeout: 2 * time.Minute})
+	h, ok = r.lookup(testTaskKind.Type())
+	if assert.True(t, ok) {
+		assert.Equal(t, 2*time.Minute, h.timeout)
+	}
+}
+
+// ── handleTask: shutdown ───────────────────────────────────────────────────
+
+func TestHandleTask_Shutdown_ReleasesWithoutAttempt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{
+		Email: "x@example.com",
+		Body:  "code",
+	})
+
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(context.Canceled)
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), task, "test-executor").Return(nil)
+
+	svc := newService(tasksRepo, newNotificationHandlers(nil, emailMock, nil, nil))
+	err := svc.handleTask(ctx, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, task.Attempts())
+	tasksRepo.AssertExpectations(t)
+}
+
+// ── worker pools ───────────────────────────────────────────────────────────
+
+func TestNewService_PoolsPerType(t *testing.T) {
+	svc := NewService(&Config{
+		Workers: 3,
+		TypeWorkers: map[entities.TaskType]int{
+			entities.TaskTypeExportUserData:  1,
+			entities.TaskTypeSendCodeOnEmail: 2,
+			entities.TaskTypeDeleteAccount:   0, // 0 — остаётся в общем пуле
+		},
+	})
+
+	if assert.Len(t, svc.pools, 3) {
+		assert.Equal(t, []entities.TaskType{entities.TaskTypeExportUserData}, svc.pools[0].types)
+		assert.Equal(t, 1, cap(svc.pools[0].slots))
+		assert.Equal(t, []entities.TaskType{entities.TaskTypeSendCodeOnEmail}, svc.pools[1].types)
+		assert.Equal(t, 2, cap(svc.pools[1].slots))
+
+		common := svc.pools[2]
+		assert.Empty(t, common.types)
+		assert.Equal(t, []entities.TaskType{entities.TaskTypeExportUserData, entities.TaskTypeSendCodeOnEmail}, common.exclude)
+		assert.Equal(t, 3, cap(common.slots))
+	}
+	assert.Equal(t, DefaultBatchSize, svc.batchSize)
+	assert.Equal(t, DefaultLeaseTimeout, svc.leaseTimeout)
+}
+
+func TestNewService_TaskTimeoutShorterThanLease(t *testing.T) {
+	svc := NewService(&Config{LeaseTimeout: time.Minute})
+	assert.Equal(t, 45*time.Second, svc.maxTaskTimeout)
+}
+
+func TestCleanupHistory_DeletesOlderThanRetention(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attemptsRepo := &mockTaskAttemptsRepo{}
+	attemptsRepo.On("DeleteBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Until(before) < -DefaultHistoryRetention+time.Minute
+	})).Run(func(mock.Arguments) { cancel() }).Return(12, nil).Once()
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("DeleteExpiredDedupKeys", mock.Anything).Return(0, nil).Once()
+
+	svc := newService(tasksRepo, NewRegistry())
+	svc.taskAttemptsRepository = attemptsRepo
+	svc.historyRetention = DefaultHistoryRetention
+
+	svc.cleanupHistory(ctx)
+	attemptsRepo.AssertExpectations(t)
+}
+
+func TestCleanupHistory_DeletesExpiredDedupKeysWithoutHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("DeleteExpiredDedupKeys", mock.Anything).Run(func(mock.Arguments) { cancel() }).Return(4, nil).Once()
+
+	newService(tasksRepo, NewRegistry()).cleanupHistory(ctx)
+	tasksRepo.AssertExpectations(t)
+}
+
+func TestDrain_ClaimsNoMoreThanFreeWorkers(t *testing.T) {
+	ctx := context.Background()
+
+	pool := newWorkerPool(defaultPoolName, 2)
+	pool.slots <- struct{}{} // один воркер занят
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Claim", mock.Anything, mock.MatchedBy(func(f dto.TasksFilter) bool {
+		return f.Limit != nil && *f.Limit == 1 && f.RetryAt != nil &&
+			assert.ObjectsAreEqual([]entities.TaskState{entities.TaskStateRunning}, f.States)
+	}), "test-executor", DefaultLeaseTimeout).Return([]*entities.Task{}, nil).Once()
+
+	svc := newService(tasksRepo, newNotificationHandlers(nil, nil, nil, nil))
+	svc.drain(ctx, pool)
+
+	tasksRepo.AssertExpectations(t)
+	assert.Len(t, pool.slots, 1, "unused slots must be released")
+}
+
+func TestDrain_ProcessesBatchesUntilQueueEmpty(t *testing.T) {
+	ctx := context.Background()
+
+	first := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{Email: "a@example.com", Body: "1"})
+	second := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{Email: "b@example.com", Body: "2"})
+	third := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{Email: "c@example.com", Body: "3"})
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]*entities.Task{first, second}, nil).Once()
+	tasksRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]*entities.Task{third}, nil).Once()
+	// сколько воркеров освободится ко второму запросу, зависит от планировщика; очередь уже пуста
+	tasksRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]*entities.Task{}, nil).Maybe()
+	tasksRepo.On("Complete", mock.Anything, mock.Anything, "test-executor").Return(nil)
+
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	svc := newService(tasksRepo, newNotificationHandlers(nil, emailMock, nil, nil))
+	svc.batchSize = 2
+	pool := newWorkerPool(defaultPoolName, 2)
+
+	svc.drain(ctx, pool)
+	svc.wg.Wait()
+
+	tasksRepo.AssertNumberOfCalls(t, "Complete", 3)
+	emailMock.AssertNumberOfCalls(t, "SendEmail", 3)
+	assert.Len(t, pool.slots, 0)
+}
+
+func TestDrain_ClaimError_ReleasesWorkers(t *testing.T) {
+	ctx := context.Background()
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("connection refused")).Once()
+
+	svc := newService(tasksRepo, newNotificationHandlers(nil, nil, nil, nil))
+	pool := newWorkerPool(defaultPoolName, 4)
+	svc.drain(ctx, pool)
+
+	tasksRepo.AssertExpectations(t)
+	assert.Len(t, pool.slots, 0)
+}
+
+func TestDrain_Cancelled_DoesNotClaim(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pool := newWorkerPool(defaultPoolName, 1)
+	pool.slots <- struct{}{} // все воркеры заняты
+
+	tasksRepo := &mockTasksRepo{}
+	svc := newService(tasksRepo, newNotificationHandlers(nil, nil, nil, nil))
+	svc.drain(ctx, pool)
+
+	tasksRepo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ── wake-up by notification ────────────────────────────────────────────────
+
+type stubListener struct {
+	types []entities.TaskType
+}
+
+func (l *stubListener) Listen(ctx context.Context, fn func(entities.TaskType)) error {
+	for _, typ := range l.types {
+		fn(typ)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestWake_RoutesToPoolByType(t *testing.T) {
+	svc := NewService(&Config{
+		TypeWorkers: map[entities.TaskType]int{entities.TaskTypeExportUserData: 1},
+	})
+	exportPool, common := svc.pools[0], svc.pools[1]
+
+	svc.wake(entities.TaskTypeSendCodeOnEmail)
+	svc.wake(entities.TaskTypeSendCodeOnPhone) // схлопывается с предыдущим
+
+	assert.Len(t, common.wake, 1)
+	assert.Len(t, exportPool.wake, 0)
+
+	svc.wake(entities.TaskTypeExportUserData)
+	assert.Len(t, exportPool.wake, 1)
+}
+
+func TestRun_NotificationTriggersDrainBeforeTicker(t *testing.T) {
+	claimed := make(chan struct{}, 1)
+
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) {
+			select {
+			case claimed <- struct{}{}:
+			default:
+			}
+		}).
+		Return([]*entities.Task{}, nil)
+	tasksRepo.On("DeleteExpiredDedupKeys", mock.Anything).Return(0, nil).Maybe()
+
+	svc := NewService(&Config{
+		TasksRepository: tasksRepo,
+		TasksListener:   &stubListener{types: []entities.TaskType{entities.TaskTypeSendCodeOnEmail}},
+		QueryDelay:      time.Hour,
+	})
+	assert.NoError(t, svc.Run())
+	defer svc.Close()
+
+	select {
+	case <-claimed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool was not woken up by the notification")
+	}
+}
+
+// ── schedules ──────────────────────────────────────────────────────────────
+
+type stubTxManager struct{}
+
+func (stubTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type mockRecurringTasksRepo struct{ mock.Mock }
+
+func (m *mockRecurringTasksRepo) List(ctx context.Context, f dto.RecurringTasksFilter) ([]*entities.RecurringTask, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.RecurringTask), args.Error(1)
+}
+
+func (m *mockRecurringTasksRepo) TryLock(ctx context.Context, id uuid.UUID) (*entities.RecurringTask, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RecurringTask), args.Error(1)
+}
+
+func (m *mockRecurringTasksRepo) Update(ctx context.Context, t *entities.RecurringTask) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+type mockUserTimezonesRepo struct{ mock.Mock }
+
+func (m *mockUserTimezonesRepo) ListTimezones(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type mockUserDevicesRepo struct{ mock.Mock }
+
+func (m *mockUserDevicesRepo) List(ctx context.Context, f dto.UserDeviceFilter) ([]*entities.UserDevice, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.UserDevice), args.Error(1)
+}
+
+func (m *mockUserDevicesRepo) DeleteByToken(ctx context.Context, deviceToken string) error {
+	return m.Called(ctx, deviceToken).Error(0)
+}
+
+func newRecurringTask(spec entities.RecurringTaskRestoreSpec) *entities.RecurringTask {
+	spec.ID = uuid.New()
+	spec.Name = "test_schedule"
+	spec.Enabled = true
+	if spec.Timezone == "" {
+		spec.Timezone = "UTC"
+	}
+	return entities.NewRecurringTask(entities.WithRecurringTaskRestoreSpec(spec))
+}
+
+func newCleanupSchedule(cron string, checkedUntil time.Time) *entities.RecurringTask {
+	return newRecurringTask(entities.RecurringTaskRestoreSpec{
+		Cron:         cron,
+		TypeNm:       entities.TaskTypeCleanupExpiredAuth,
+		Attribute:    []byte(`{"keep_days": 7}`),
+		Scope:        entities.RecurringTaskScopeGlobal,
+		CheckedUntil: checkedUntil,
+	})
+}
+
+func newSchedulerService(tasksRepo *mockTasksRepo, recurringRepo *mockRecurringTasksRepo, tzRepo *mockUserTimezonesRepo) *Service {
+	r := NewRegistry()
+	noop := func(context.Context, *entities.Task, entities.CleanupTaskPayload) error { return nil }
+	Register(r, entities.TaskKindCleanupExpiredAuth, Handler[entities.CleanupTaskPayload]{Handle: noop})
+	Register(r, entities.TaskKindSendReminder, Handler[entities.ReminderTaskPayload]{
+		Handle: func(context.Context, *entities.Task, entities.ReminderTaskPayload) error { return nil },
+	})
+
+	svc := newService(tasksRepo, r)
+	svc.txm = stubTxManager{}
+	svc.recurringTasksRepository = recurringRepo
+	svc.userTimezonesRepository = tzRepo
+	svc.misfireGrace = DefaultMisfireGrace
+	return svc
+}
+
+func expectSchedules(repo *mockRecurringTasksRepo, rts ...*entities.RecurringTask) {
+	repo.On("List", mock.Anything, mock.MatchedBy(func(f dto.RecurringTasksFilter) bool {
+		return f.Enabled != nil && *f.Enabled
+	})).Return(rts, nil).Once()
+	for _, rt := range rts {
+		repo.On("TryLock", mock.Anything, rt.ID()).Return(rt, nil).Once()
+	}
+}
+
+func TestCheckSchedules_Global_CreatesTaskForLatestOccurrence(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("*/5 * * * *", now.Add(-12*time.Minute))
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	expectSchedules(recurringRepo, rt)
+
+	// пропущенные 9:55 и 10:00 дают одну задачу
+	tasksRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *entities.Task) bool {
+		p, err := entities.TaskKindCleanupExpiredAuth.Payload(task)
+		return err == nil && p.KeepDays == 7
+	})).Return(nil).Once()
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	tasksRepo.AssertExpectations(t)
+	recurringRepo.AssertExpectations(t)
+	assert.Equal(t, now, rt.CheckedUntil())
+	if assert.NotNil(t, rt.LastRunAt()) {
+		assert.True(t, rt.LastRunAt().Equal(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)))
+	}
+}
+
+func TestCheckSchedules_NotDue_OnlyAdvancesCheckedUntil(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("30 3 * * *", now.Add(-time.Minute))
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	expectSchedules(recurringRepo, rt)
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	recurringRepo.AssertExpectations(t)
+	assert.Equal(t, now, rt.CheckedUntil())
+	assert.Nil(t, rt.LastRunAt())
+}
+
+func TestCheckSchedules_AlreadyChecked_NotLocked(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("* * * * *", now)
+
+	recurringRepo := &mockRecurringTasksRepo{}
+	recurringRepo.On("List", mock.Anything, mock.Anything).Return([]*entities.RecurringTask{rt}, nil).Once()
+
+	newSchedulerService(&mockTasksRepo{}, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	recurringRepo.AssertNotCalled(t, "TryLock", mock.Anything, mock.Anything)
+}
+
+func TestCheckSchedules_LockedByOtherReplica_Skips(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("* * * * *", now.Add(-time.Minute))
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	recurringRepo.On("List", mock.Anything, mock.Anything).Return([]*entities.RecurringTask{rt}, nil).Once()
+	recurringRepo.On("TryLock", mock.Anything, rt.ID()).Return(nil, errs.ErrRecurringTaskNotFound).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	recurringRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCheckSchedules_CreateError_CheckedUntilNotAdvanced(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	checkedUntil := now.Add(-5 * time.Minute)
+	rt := newCleanupSchedule("* * * * *", checkedUntil)
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	expectSchedules(recurringRepo, rt)
+	tasksRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	recurringRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	assert.Equal(t, checkedUntil, rt.CheckedUntil())
+}
+
+func TestCheckSchedules_InvalidCron_AdvancesWithoutTasks(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("61 * * * *", now.Add(-time.Minute))
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	expectSchedules(recurringRepo, rt)
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	recurringRepo.AssertExpectations(t)
+	assert.Equal(t, now, rt.CheckedUntil())
+}
+
+func TestCheckSchedules_UnknownType_LeftForNewerReplica(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newRecurringTask(entities.RecurringTaskRestoreSpec{
+		Cron:         "* * * * *",
+		TypeNm:       "future_task",
+		Scope:        entities.RecurringTaskScopeGlobal,
+		CheckedUntil: now.Add(-time.Minute),
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	expectSchedules(recurringRepo, rt)
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	recurringRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCheckSchedules_MisfireOlderThanGrace_Skipped(t *testing.T) {
+	ctx := context.Background()
+	// все реплики лежали трое суток: 9:00 сегодня старше часа и не догоняется
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("0 9 * * *", now.Add(-72*time.Hour))
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	expectSchedules(recurringRepo, rt)
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{}).checkSchedules(ctx, now)
+
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Equal(t, now, rt.CheckedUntil())
+}
+
+func TestCheckSchedules_GlobalTimezone_DSTRepeatedHourFiresOnce(t *testing.T) {
+	ctx := context.Background()
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	// 2 ноября 2025 в Нью-Йорке 1:30 наступает дважды: в 5:30 и в 6:30 UTC
+	rt := newRecurringTask(entities.RecurringTaskRestoreSpec{
+		Cron:         "30 1 * * *",
+		TypeNm:       entities.TaskTypeCleanupExpiredAuth,
+		Scope:        entities.RecurringTaskScopeGlobal,
+		Timezone:     ny.String(),
+		CheckedUntil: time.Date(2025, 11, 2, 5, 29, 0, 0, time.UTC),
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	tasksRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Twice()
+
+	svc := newSchedulerService(tasksRepo, recurringRepo, &mockUserTimezonesRepo{})
+	for _, now := range []time.Time{
+		time.Date(2025, 11, 2, 5, 31, 0, 0, time.UTC),
+		time.Date(2025, 11, 2, 6, 31, 0, 0, time.UTC),
+	} {
+		expectSchedules(recurringRepo, rt)
+		svc.checkSchedules(ctx, now)
+	}
+
+	tasksRepo.AssertExpectations(t)
+	recurringRepo.AssertExpectations(t)
+}
+
+func TestCheckSchedules_PerUser_CreatesTasksForDueTimezones(t *testing.T) {
+	ctx := context.Background()
+	// 10:00 UTC — 13:00 по Москве; в UTC и Токио 13:00 не наступило или прошло раньше
+	now := time.Date(2025, 3, 10, 10, 0, 30, 0, time.UTC)
+	rt := newRecurringTask(entities.RecurringTaskRestoreSpec{
+		Cron:         "0 13 * * *",
+		TypeNm:       entities.TaskTypeSendReminder,
+		Attribute:    []byte(`{"title": "Время обеда", "body": "Запишите приём пищи"}`),
+		Scope:        entities.RecurringTaskScopePerUser,
+		MaxAttempts:  2,
+		CheckedUntil: now.Add(-time.Minute),
+	})
+
+	tasksRepo := &mockTasksRepo{}
+	recurringRepo := &mockRecurringTasksRepo{}
+	tzRepo := &mockUserTimezonesRepo{}
+	expectSchedules(recurringRepo, rt)
+	tzRepo.On("ListTimezones", mock.Anything).Return([]string{"UTC", "Europe/Moscow", "Asia/Tokyo", "Mars/Olympus"}, nil).Once()
+	tasksRepo.On("CreateForUsers", mock.Anything, mock.MatchedBy(func(spec dto.UserTasksSpec) bool {
+		return spec.TypeNm == entities.TaskTypeSendReminder &&
+			spec.MaxAttempts == 2 &&
+			assert.ObjectsAreEqual([]string{"Europe/Moscow"}, spec.Timezones)
+	})).Return(42, nil).Once()
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	newSchedulerService(tasksRepo, recurringRepo, tzRepo).checkSchedules(ctx, now)
+
+	tasksRepo.AssertExpectations(t)
+	tzRepo.AssertExpectations(t)
+	if assert.NotNil(t, rt.LastRunAt()) {
+		assert.True(t, rt.LastRunAt().Equal(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)))
+	}
+}
+
+func TestCheckSchedules_GlobalOnly_DoesNotLoadTimezones(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 10, 3, 0, 0, time.UTC)
+	rt := newCleanupSchedule("30 3 * * *", now.Add(-time.Minute))
+
+	recurringRepo := &mockRecurringTasksRepo{}
+	tzRepo := &mockUserTimezonesRepo{}
+	expectSchedules(recurringRepo, rt)
+	recurringRepo.On("Update", mock.Anything, rt).Return(nil).Once()
+
+	newSchedulerService(&mockTasksRepo{}, recurringRepo, tzRepo).checkSchedules(ctx, now)
+
+	tzRepo.AssertNotCalled(t, "ListTimezones", mock.Anything)
+}
+
+// ── handleReminderTask ─────────────────────────────────────────────────────
+
+func TestHandleReminderTask_CreatesPushTaskPerDevice(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	devicesRepo := &mockUserDevicesRepo{}
+	devicesRepo.On("List", mock.Anything, dto.UserDeviceFilter{UserID: &userID}).Return([]*entities.UserDevice{
+		entities.NewUserDevice(entities.UserDeviceInitSpec{UserID: userID, DeviceToken: "token-1", Platform: "ios"}),
+		entities.NewUserDevice(entities.UserDeviceInitSpec{UserID: userID, DeviceToken: "token-2", Platform: "android"}),
+	}, nil).Once()
+
+	tasksRepo := &mockTasksRepo{}
+	var tokens, platforms []string
+	tasksRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		p, err := entities.TaskKindSendPushNotification.Payload(args.Get(1).(*entities.Task))
+		assert.NoError(t, err)
+		assert.Equal(t, "Время обеда", p.Title)
+		assert.Equal(t, "reminders", p.ThreadID)
+		assert.Contains(t, args.Get(1).(*entities.Task).DedupKey(), "reminder:")
+		tokens = append(tokens, p.DeviceToken)
+		platforms = append(platforms, p.Platform)
+	}).Return(nil).Twice()
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{
+		UserInfoRepository:    &mockUserInfoRepo{},
+		UserDevicesRepository: devicesRepo,
+		TasksRepository:       tasksRepo,
+		PushClients:           iosPushClients(&mockPushClient{}),
+	})
+
+	task := newTask(entities.TaskKindSendReminder, entities.ReminderTaskPayload{
+		UserID:      userID,
+		Title:       "Время обеда",
+		Body:        "Запишите приём пищи",
+		PushOptions: entities.PushOptions{ThreadID: "reminders"},
+	})
+	err := runHandler(ctx, r, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-1", "token-2"}, tokens)
+	assert.Equal(t, []string{"ios", "android"}, platforms)
+	tasksRepo.AssertExpectations(t)
+}
+
+func TestRegisterNotificationHandlers_ReminderNeedsDevices(t *testing.T) {
+	r := newNotificationHandlers(&mockUserInfoRepo{}, &mockEmailClient{}, &mockSMSClient{}, &mockPushClient{})
+
+	_, ok := r.lookup(entities.TaskTypeSendReminder)
+	assert.False(t, ok)
+}
+
+// ── notification preferences ───────────────────────────────────────────────
+
+type mockPreferencesRepo struct{ mock.Mock }
+
+func (m *mockPreferencesRepo) Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	if p := args.Get(0); p != nil {
+		return p.(*entities.NotificationPreferences), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// quietHoursAroundNow — тихие часы в UTC, в которые попадает текущий момент.
+func quietHoursAroundNow() *entities.QuietHours {
+	now := time.Now().UTC()
+	minute := now.Hour()*60 + now.Minute()
+	return &entities.QuietHours{From: (minute + 24*60 - 60) % (24 * 60), To: (minute + 60) % (24 * 60)}
+}
+
+func newPreferencesHandlers(prefsRepo NotificationPreferencesRepository, email EmailClient, pushClient PushClient) *Registry {
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{
+		PreferencesRepository: prefsRepo,
+		EmailClient:           email,
+		PushClients:           iosPushClients(pushClient),
+	})
+	return r
+}
+
+func TestHandleEmailTask_ChannelDisabled_Skipped(t *testing.T) {
+	userID := uuid.New()
+	prefsRepo := &mockPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID: userID,
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategoryWorkouts: {entities.NotificationChannelEmail: false},
+		},
+	}), nil).Once()
+
+	emailMock := &mockEmailClient{}
+	task := newTask(entities.TaskKindSendNotificationEmail, entities.EmailTaskPayload{
+		UserID:   userID,
+		Email:    "user@example.com",
+		Body:     "Новая тренировка",
+		Category: entities.NotificationCategoryWorkouts,
+	})
+
+	err := runHandler(context.Background(), newPreferencesHandlers(prefsRepo, emailMock, nil), task)
+
+	assert.NoError(t, err)
+	emailMock.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleEmailTask_SecurityIgnoresQuietHours(t *testing.T) {
+	userID := uuid.New()
+	prefsRepo := &mockPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID:     userID,
+		QuietHours: quietHoursAroundNow(),
+	}), nil).Once()
+
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", "user@example.com", "Удаление аккаунта", "body").Return(nil).Once()
+
+	task := newTask(entities.TaskKindSendNotificationEmail, entities.EmailTaskPayload{
+		UserID:   userID,
+		Email:    "user@example.com",
+		Subject:  "Удаление аккаунта",
+		Body:     "body",
+		Category: entities.NotificationCategorySecurity,
+	})
+
+	assert.NoError(t, runHandler(context.Background(), newPreferencesHandlers(prefsRepo, emailMock, nil), task))
+	emailMock.AssertExpectations(t)
+}
+
+func TestHandleEmailTask_UserGone_SentWithDefaults(t *testing.T) {
+	userID := uuid.New()
+	prefsRepo := &mockPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(nil, errs.ErrUserInfoNotFound).Once()
+
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", "user@example.com", "BodyFuel", "body").Return(nil).Once()
+
+	task := newTask(entities.TaskKindSendNotificationEmail, entities.EmailTaskPayload{
+		UserID:   userID,
+		Email:    "user@example.com",
+		Body:     "body",
+		Category: entities.NotificationCategorySecurity,
+	})
+
+	assert.NoError(t, runHandler(context.Background(), newPreferencesHandlers(prefsRepo, emailMock, nil), task))
+	emailMock.AssertExpectations(t)
+}
+
+func TestHandleEmailTask_CodeIgnoresPreferences(t *testing.T) {
+	prefsRepo := &mockPreferencesRepo{}
+	emailMock := &mockEmailClient{}
+	emailMock.On("SendEmail", "user@example.com", mock.Anything, mock.Anything).Return(nil).Once()
+
+	task := newTask(entities.TaskKindSendCodeOnEmail, entities.EmailTaskPayload{UserID: uuid.New(), Email: "user@example.com", Code: "123456"})
+
+	assert.NoError(t, runHandler(context.Background(), newPreferencesHandlers(prefsRepo, emailMock, nil), task))
+	emailMock.AssertExpectations(t)
+	prefsRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
+
+func TestHandleTask_QuietHours_PostponedWithoutAttempt(t *testing.T) {
+	userID := uuid.New()
+	quiet := quietHoursAroundNow()
+	prefsRepo := &mockPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID:     userID,
+		QuietHours: quiet,
+	}), nil).Once()
+
+	pushMock := &mockPushClient{}
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Release", mock.Anything, mock.Anything, "test-executor").Return(nil).Once()
+
+	task := newTask(entities.TaskKindSendPushNotification, entities.PushTaskPayload{
+		UserID:      userID,
+		DeviceToken: "tok",
+		Body:        "Совет дня",
+		Category:    entities.NotificationCategoryRecommendations,
+	})
+
+	svc := newService(tasksRepo, newPreferencesHandlers(prefsRepo, nil, pushMock))
+	assert.NoError(t, svc.handleTask(context.Background(), task))
+
+	pushMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	tasksRepo.AssertExpectations(t)
+	assert.Equal(t, 0, task.Attempts())
+	assert.False(t, task.IsFailed())
+	assert.Equal(t, quiet.To, task.RetryAt().UTC().Hour()*60+task.RetryAt().UTC().Minute())
+	assert.True(t, task.RetryAt().After(time.Now()))
+}
+
+func TestHandleReminderTask_PushDisabled_NoTasks(t *testing.T) {
+	userID := uuid.New()
+	prefsRepo := &mockPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID: userID,
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategoryReminders: {entities.NotificationChannelPush: false},
+		},
+	}), nil).Once()
+
+	devicesRepo := &mockUserDevicesRepo{}
+	tasksRepo := &mockTasksRepo{}
+
+	r := NewRegistry()
+	RegisterNotificationHandlers(r, NotificationsConfig{
+		UserDevicesRepository: devicesRepo,
+		TasksRepository:       tasksRepo,
+		PreferencesRepository: prefsRepo,
+		PushClients:           iosPushClients(&mockPushClient{}),
+	})
+
+	task := newTask(entities.TaskKindSendReminder, entities.ReminderTaskPayload{UserID: userID, Title: "Время обеда"})
+
+	assert.NoError(t, runHandler(context.Background(), r, task))
+	devicesRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 }
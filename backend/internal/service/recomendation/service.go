@@ -47,6 +47,10 @@ type (
 		Get(ctx context.Context, key string) (string, error)
 		Set(ctx context.Context, key, value string, ttl time.Duration) error
 	}
+
+	NotificationPreferencesRepository interface {
+		Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error)
+	}
 )
 
 // refreshCooldown is the minimum time between two OpenAI calls for the same user.
@@ -59,7 +63,10 @@ type Service struct {
 	devicesRepo    UserDevicesRepository // optional, for push notifications
 	tasksRepo      TasksRepository       // optional, for push notifications
 	ai             AIClient
-	cache          RecommendationCache // optional, nil means no cooldown
+	cache          RecommendationCache               // optional, nil means no cooldown
+	prefsRepo      NotificationPreferencesRepository // optional, nil means push is always sent
+
+	pushDedupWindow time.Duration
 }
 
 type Config struct {
@@ -69,7 +76,11 @@ type Config struct {
 	UserDevicesRepository    UserDevicesRepository // optional
 	TasksRepository          TasksRepository       // optional
 	AIClient                 AIClient
-	RecommendationCache      RecommendationCache // optional
+	RecommendationCache      RecommendationCache               // optional
+	PreferencesRepository    NotificationPreferencesRepository // optional
+	// PushDedupWindow is how long a device gets at most one "tip of the day" push
+	// (0 means entities.DefaultTaskDedupWindow).
+	PushDedupWindow time.Duration
 }
 
 func NewService(c *Config) *Service {
@@ -81,6 +92,9 @@ func NewService(c *Config) *Service {
 		tasksRepo:      c.TasksRepository,
 		ai:             c.AIClient,
 		cache:          c.RecommendationCache,
+		prefsRepo:      c.PreferencesRepository,
+
+		pushDedupWindow: c.PushDedupWindow,
 	}
 }
 
@@ -197,11 +211,31 @@ func (s *Service) Refresh(ctx context.Context, userID uuid.UUID) ([]*entities.Us
 
 // sendRecommendationPush creates push notification tasks for all user devices
 // with the most important (priority=1) recommendation as the message body.
+// Each device gets at most one such push per dedup window, however often Refresh is called.
+// Nothing is sent if the user turned recommendation pushes off; during quiet hours
+// the push is scheduled for when they end.
 func (s *Service) sendRecommendationPush(ctx context.Context, userID uuid.UUID, recs []*entities.UserRecommendation) {
 	if s.devicesRepo == nil || s.tasksRepo == nil || len(recs) == 0 {
 		return
 	}
 
+	const category = entities.NotificationCategoryRecommendations
+
+	// On a lookup failure push anyway: the executor re-checks preferences before sending.
+	var prefs *entities.NotificationPreferences
+	if s.prefsRepo != nil {
+		prefs, _ = s.prefsRepo.Get(ctx, userID)
+	}
+	if !prefs.Allows(category, entities.NotificationChannelPush) {
+		return
+	}
+
+	now := time.Now()
+	var retryAt time.Time
+	if at := prefs.DeliverAt(category, now); at.After(now) {
+		retryAt = at
+	}
+
 	// Pick highest-priority recommendation (lowest priority number = most important).
 	top := recs[0]
 	for _, r := range recs[1:] {
@@ -216,16 +250,24 @@ func (s *Service) sendRecommendationPush(ctx context.Context, userID uuid.UUID,
 	}
 
 	for _, device := range devices {
-		task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-			TypeNm:      entities.TaskTypeSendPushNotification,
-			MaxAttempts: 3,
-			Attribute: entities.TaskAttribute{
-				UserID:      userID,
-				DeviceToken: device.DeviceToken(),
-				Title:       "Совет дня",
-				Body:        top.Description(),
+		task := entities.TaskKindSendPushNotification.NewTask(entities.PushTaskPayload{
+			UserID:      userID,
+			DeviceToken: device.DeviceToken(),
+			Platform:    device.Platform(),
+			Template:    entities.NotificationTemplateRecommendationTip,
+			Vars:        map[string]string{"tip": top.Description()},
+			Category:    category,
+			// A newer tip replaces the previous one on the lock screen instead of stacking.
+			PushOptions: entities.PushOptions{
+				ThreadID:   category.String(),
+				CollapseID: "recommendation_tip",
+				Data:       map[string]string{"recommendation_id": top.ID().String()},
 			},
-		}))
+		}, entities.TaskSchedule{
+			RetryAt:     retryAt,
+			DedupKey:    fmt.Sprintf("recommendation_push:%s:%s", userID, device.ID()),
+			DedupWindow: s.pushDedupWindow,
+		})
 		_ = s.tasksRepo.Create(ctx, task)
 	}
 }
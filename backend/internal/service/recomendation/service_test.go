@@ -72,6 +72,32 @@ func (m *mockAI) GenerateRecommendations(ctx context.Context, profile ai.UserPro
 	return args.Get(0).([]ai.RecommendationItem), args.Error(1)
 }
 
+type mockDevicesRepo struct{ mock.Mock }
+
+func (m *mockDevicesRepo) List(ctx context.Context, f dto.UserDeviceFilter) ([]*entities.UserDevice, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.UserDevice), args.Error(1)
+}
+
+type mockTasksRepo struct{ mock.Mock }
+
+func (m *mockTasksRepo) Create(ctx context.Context, t *entities.Task) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+type mockPreferencesRepo struct{ mock.Mock }
+
+func (m *mockPreferencesRepo) Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.NotificationPreferences), args.Error(1)
+}
+
 // ── helpers ────────────────────────────────────────────────────
 
 func newRec(userID uuid.UUID) *entities.UserRecommendation {
@@ -281,3 +307,62 @@ func TestService_MarkRead(t *testing.T) {
 		})
 	}
 }
+
+// ── sendRecommendationPush ─────────────────────────────────────
+
+func TestService_SendRecommendationPush_DedupKeyPerDevice(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	devices := []*entities.UserDevice{
+		entities.NewUserDevice(entities.UserDeviceInitSpec{UserID: userID, DeviceToken: "token-1", Platform: "ios"}),
+		entities.NewUserDevice(entities.UserDeviceInitSpec{UserID: userID, DeviceToken: "token-2", Platform: "android"}),
+	}
+
+	devicesRepo := &mockDevicesRepo{}
+	devicesRepo.On("List", mock.Anything, dto.UserDeviceFilter{UserID: &userID}).Return(devices, nil)
+
+	var keys, platforms []string
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		task := args.Get(1).(*entities.Task)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), task.DedupUntil(), time.Minute)
+		keys = append(keys, task.DedupKey())
+		p, err := entities.TaskKindSendPushNotification.Payload(task)
+		assert.NoError(t, err)
+		platforms = append(platforms, p.Platform)
+	}).Return(nil)
+
+	svc := NewService(&Config{UserDevicesRepository: devicesRepo, TasksRepository: tasksRepo, PushDedupWindow: time.Hour})
+	recs := []*entities.UserRecommendation{newRec(userID)}
+
+	// повторный Refresh ставит задачи с теми же ключами — репозиторий их отбросит
+	svc.sendRecommendationPush(ctx, userID, recs)
+	svc.sendRecommendationPush(ctx, userID, recs)
+
+	assert.Len(t, keys, 4)
+	assert.Equal(t, keys[:2], keys[2:])
+	assert.NotEqual(t, keys[0], keys[1])
+	assert.Equal(t, []string{"ios", "android", "ios", "android"}, platforms)
+}
+
+func TestService_SendRecommendationPush_DisabledInPreferences(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	prefsRepo := &mockPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID: userID,
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategoryRecommendations: {entities.NotificationChannelPush: false},
+		},
+	}), nil).Once()
+
+	devicesRepo := &mockDevicesRepo{}
+	tasksRepo := &mockTasksRepo{}
+
+	svc := NewService(&Config{UserDevicesRepository: devicesRepo, TasksRepository: tasksRepo, PreferencesRepository: prefsRepo})
+	svc.sendRecommendationPush(ctx, userID, []*entities.UserRecommendation{newRec(userID)})
+
+	devicesRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	tasksRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -96,6 +96,10 @@ type (
 	UserFoodRepository interface {
 		List(ctx context.Context, f dto.UserFoodFilter) ([]*entities.UserFood, error)
 	}
+
+	NotificationPreferencesRepository interface {
+		Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error)
+	}
 )
 
 type Config struct {
@@ -118,6 +122,14 @@ type Config struct {
 	EnableNotifications      bool
 	BatchSize                int
 	MaxConcurrentUsers       int
+
+	// NotificationDedupWindow — сколько повторная постановка уведомлений о той же тренировке ничего не создаёт
+	// (0 — entities.DefaultTaskDedupWindow).
+	NotificationDedupWindow time.Duration
+
+	// NotificationPreferencesRepository — настройки уведомлений пользователя: выключенные каналы
+	// не ставятся, в тихие часы уведомления откладываются. Необязателен.
+	NotificationPreferencesRepository NotificationPreferencesRepository
 }
 
 type Service struct {
@@ -132,11 +144,14 @@ type Service struct {
 	userDevicesRepository     UserDevicesRepository
 	userFoodRepository        UserFoodRepository
 
+	notificationPreferencesRepository NotificationPreferencesRepository
+
 	workoutPullUserInterval  time.Duration
 	limitGenerateWorkouts    int
 	minExercisesPerWorkout   int
 	maxExercisesPerWorkout   int
 	maxRetrySendNotification int
+	notificationDedupWindow  time.Duration
 	enableNotifications      bool
 	batchSize                int
 	maxConcurrentUsers       int
@@ -201,8 +216,11 @@ func NewService(cfg *Config) *Service {
 		userDevicesRepository:     cfg.UserDevicesRepository,
 		userFoodRepository:        cfg.UserFoodRepository,
 
+		notificationPreferencesRepository: cfg.NotificationPreferencesRepository,
+
 		workoutPullUserInterval:  cfg.WorkoutPullUserInterval,
 		maxRetrySendNotification: cfg.MaxRetrySendNotification,
+		notificationDedupWindow:  cfg.NotificationDedupWindow,
 		limitGenerateWorkouts:    cfg.LimitGenerateWorkouts,
 		minExercisesPerWorkout:   cfg.MinExercisesPerWorkout,
 		maxExercisesPerWorkout:   cfg.MaxExercisesPerWorkout,
@@ -1119,64 +1137,76 @@ func (s *Service) createNotificationTaskAsync(ctx context.Context, workoutID, us
 	}
 }
 
+// createNotificationTask ставит уведомления о готовой тренировке. Ключи дедупликации привязаны к тренировке
+// и каналу, поэтому повторный вызов для той же тренировки в пределах окна уведомлений не дублирует.
+// Выключенные пользователем каналы пропускаются, в тихие часы отправка назначается на их конец.
 func (s *Service) createNotificationTask(ctx context.Context, workoutID, userID uuid.UUID) error {
-	msgBody := string(entities.TaskMessageSendAuthomaticGeneratedWorkout)
+	const category = entities.NotificationCategoryWorkouts
+
+	prefs := s.getNotificationPreferences(ctx, userID)
+
+	now := time.Now()
+	var retryAt time.Time
+	if at := prefs.DeliverAt(category, now); at.After(now) {
+		retryAt = at
+	}
+
+	schedule := func(channel string) entities.TaskSchedule {
+		return entities.TaskSchedule{
+			MaxAttempts: s.maxRetrySendNotification,
+			RetryAt:     retryAt,
+			DedupKey:    fmt.Sprintf("workout_ready:%s:%s", workoutID, channel),
+			DedupWindow: s.notificationDedupWindow,
+		}
+	}
 
 	userInfo, err := s.userInfoRepository.Get(ctx, dto.UserInfoFilter{ID: &userID}, false)
 	if err != nil {
 		s.log.Warnf("createNotificationTask: get user info: %v", err)
 	}
 
-	if userInfo != nil && userInfo.Email() != "" {
-		task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-			TypeNm:      entities.TaskTypeSendNotificationEmail,
-			Message:     entities.TaskMessageSendAuthomaticGeneratedWorkout,
-			MaxAttempts: s.maxRetrySendNotification,
-			Attribute: entities.TaskAttribute{
-				UserID:  userID,
-				Email:   userInfo.Email(),
-				Subject: "Новая тренировка готова",
-				Body:    msgBody,
-			},
-		}))
+	if userInfo != nil && userInfo.Email() != "" && prefs.Allows(category, entities.NotificationChannelEmail) {
+		task := entities.TaskKindSendNotificationEmail.NewTask(entities.EmailTaskPayload{
+			UserID:   userID,
+			Email:    userInfo.Email(),
+			Template: entities.NotificationTemplateWorkoutReady,
+			Category: category,
+		}, schedule("email"))
 		if err := s.tasksRepository.Create(ctx, task); err != nil {
 			s.log.Errorf("createNotificationTask: create email task: %v", err)
 		}
 	}
 
-	if userInfo != nil && userInfo.Phone() != "" {
-		task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-			TypeNm:      entities.TaskTypeSendNotificationPhone,
-			Message:     entities.TaskMessageSendAuthomaticGeneratedWorkout,
-			MaxAttempts: s.maxRetrySendNotification,
-			Attribute: entities.TaskAttribute{
-				UserID: userID,
-				Phone:  userInfo.Phone(),
-				Body:   msgBody,
-			},
-		}))
+	if userInfo != nil && userInfo.Phone() != "" && prefs.Allows(category, entities.NotificationChannelSMS) {
+		task := entities.TaskKindSendNotificationPhone.NewTask(entities.SMSTaskPayload{
+			UserID:   userID,
+			Phone:    userInfo.Phone(),
+			Template: entities.NotificationTemplateWorkoutReady,
+			Category: category,
+		}, schedule("sms"))
 		if err := s.tasksRepository.Create(ctx, task); err != nil {
 			s.log.Errorf("createNotificationTask: create sms task: %v", err)
 		}
 	}
 
-	if s.userDevicesRepository != nil {
+	if s.userDevicesRepository != nil && prefs.Allows(category, entities.NotificationChannelPush) {
 		devices, err := s.userDevicesRepository.List(ctx, dto.UserDeviceFilter{UserID: &userID})
 		if err != nil {
 			s.log.Warnf("createNotificationTask: get user devices: %v", err)
 		}
 		for _, device := range devices {
-			task := entities.NewTask(entities.WithTaskInitSpec(entities.TaskInitSpec{
-				TypeNm:      entities.TaskTypeSendPushNotification,
-				Message:     entities.TaskMessageSendAuthomaticGeneratedWorkout,
-				MaxAttempts: s.maxRetrySendNotification,
-				Attribute: entities.TaskAttribute{
-					UserID:      userID,
-					DeviceToken: device.DeviceToken(),
-					Title:       "Новая тренировка готова",
-					Body:        msgBody,
+			task := entities.TaskKindSendPushNotification.NewTask(entities.PushTaskPayload{
+				UserID:      userID,
+				DeviceToken: device.DeviceToken(),
+				Platform:    device.Platform(),
+				Template:    entities.NotificationTemplateWorkoutReady,
+				Category:    category,
+				PushOptions: entities.PushOptions{
+					ThreadID:   category.String(),
+					CollapseID: fmt.Sprintf("workout_ready:%s", workoutID),
+					Data:       map[string]string{"workout_id": workoutID.String()},
 				},
-			}))
+			}, schedule(device.ID().String()))
 			if err := s.tasksRepository.Create(ctx, task); err != nil {
 				s.log.Errorf("createNotificationTask: create push task: %v", err)
 			}
@@ -1186,6 +1216,21 @@ func (s *Service) createNotificationTask(ctx context.Context, workoutID, userID
 	return nil
 }
 
+// getNotificationPreferences возвращает настройки уведомлений или nil, если их не удалось получить:
+// тогда задачи ставятся как обычно, а настройки ещё раз проверит исполнитель при отправке.
+func (s *Service) getNotificationPreferences(ctx context.Context, userID uuid.UUID) *entities.NotificationPreferences {
+	if s.notificationPreferencesRepository == nil {
+		return nil
+	}
+
+	prefs, err := s.notificationPreferencesRepository.Get(ctx, userID)
+	if err != nil {
+		s.log.Warnf("get notification preferences for user %s: %v", userID, err)
+		return nil
+	}
+	return prefs
+}
+
 func (s *Service) GenerateCustomWorkout(ctx context.Context, params *dto.GenerateWorkoutParams) (*entities.Workout, error) {
 	startTime := time.Now()
 	defer func() { s.updateMetrics(time.Since(startTime), true) }()
@@ -1046,6 +1046,16 @@ func (m *mockUserDevicesRepo) List(ctx context.Context, f dto.UserDeviceFilter)
 	return args.Get(0).([]*entities.UserDevice), args.Error(1)
 }
 
+type mockNotificationPreferencesRepo struct{ mock.Mock }
+
+func (m *mockNotificationPreferencesRepo) Get(ctx context.Context, userID uuid.UUID) (*entities.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.NotificationPreferences), args.Error(1)
+}
+
 // ── selectBalancedExercisesByType ──────────────────────────────────────────
 
 func TestSelectBalancedExercisesByType_Mixed(t *testing.T) {
@@ -1393,6 +1403,54 @@ func TestCreateNotificationTask_WithPushDevice(t *testing.T) {
 	tasksRepo.AssertNumberOfCalls(t, "Create", 1) // push only
 }
 
+func TestCreateNotificationTask_DedupKeysPerWorkoutAndChannel(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	info := newUserInfo(userID)
+
+	infoRepo := &mockUserInfoRepo{}
+	infoRepo.On("Get", mock.Anything, mock.Anything, false).Return(info, nil)
+
+	device := entities.RestoreUserDevice(entities.UserDeviceRestoreSpec{
+		ID:          uuid.New(),
+		UserID:      userID,
+		DeviceToken: "push-token",
+		Platform:    "ios",
+	})
+	devicesRepo := &mockUserDevicesRepo{}
+	devicesRepo.On("List", mock.Anything, dto.UserDeviceFilter{UserID: &userID}).
+		Return([]*entities.UserDevice{device}, nil)
+
+	var keys []string
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.Task")).Run(func(args mock.Arguments) {
+		keys = append(keys, args.Get(1).(*entities.Task).DedupKey())
+	}).Return(nil)
+
+	svc := &Service{
+		userInfoRepository:    infoRepo,
+		tasksRepository:       tasksRepo,
+		userDevicesRepository: devicesRepo,
+		log:                   logging.GetLoggerFromContext(ctx),
+	}
+
+	first, second := uuid.New(), uuid.New()
+	assert.NoError(t, svc.createNotificationTask(ctx, first, userID))
+	assert.NoError(t, svc.createNotificationTask(ctx, first, userID))
+	assert.NoError(t, svc.createNotificationTask(ctx, second, userID))
+
+	// email, SMS и push на каждый вызов; повтор для той же тренировки — те же ключи
+	if assert.Len(t, keys, 9) {
+		assert.Equal(t, keys[0:3], keys[3:6])
+		assert.ElementsMatch(t, []string{
+			"workout_ready:" + first.String() + ":email",
+			"workout_ready:" + first.String() + ":sms",
+			"workout_ready:" + first.String() + ":" + device.ID().String(),
+		}, keys[0:3])
+		assert.NotContains(t, keys[6:], keys[0])
+	}
+}
+
 // ── generateWorkoutWithRetry ───────────────────────────────────────────────
 
 func TestGenerateWorkoutWithRetry_SuccessOnFirstTry(t *testing.T) {
@@ -1907,3 +1965,64 @@ func TestFilterSkippedExercises_AllowsOnce(t *testing.T) {
 	result := svc.filterSkippedExercises([]*entities.Exercise{ex}, skipMap)
 	assert.Len(t, result, 1) // skipped only once → still included
 }
+
+func TestCreateNotificationTask_RespectsPreferences(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	workoutID := uuid.New()
+
+	infoRepo := &mockUserInfoRepo{}
+	infoRepo.On("Get", mock.Anything, mock.Anything, false).Return(newUserInfo(userID), nil)
+
+	devicesRepo := &mockUserDevicesRepo{}
+	devicesRepo.On("List", mock.Anything, dto.UserDeviceFilter{UserID: &userID}).Return([]*entities.UserDevice{
+		entities.NewUserDevice(entities.UserDeviceInitSpec{UserID: userID, DeviceToken: "push-token", Platform: "android"}),
+	}, nil)
+
+	// тихие часы вокруг текущего момента, SMS о тренировках выключены
+	now := time.Now().UTC()
+	minute := now.Hour()*60 + now.Minute()
+	quiet := &entities.QuietHours{From: (minute + 23*60) % (24 * 60), To: (minute + 60) % (24 * 60)}
+	prefsRepo := &mockNotificationPreferencesRepo{}
+	prefsRepo.On("Get", mock.Anything, userID).Return(entities.NewNotificationPreferences(entities.NotificationPreferencesRestoreSpec{
+		UserID:     userID,
+		QuietHours: quiet,
+		Channels: entities.NotificationMatrix{
+			entities.NotificationCategoryWorkouts: {entities.NotificationChannelSMS: false},
+		},
+	}), nil).Once()
+
+	var types []entities.TaskType
+	tasksRepo := &mockTasksRepo{}
+	tasksRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.Task")).Run(func(args mock.Arguments) {
+		task := args.Get(1).(*entities.Task)
+		types = append(types, task.TypeNm())
+		assert.True(t, task.RetryAt().After(now))
+		assert.Equal(t, quiet.To, task.RetryAt().UTC().Hour()*60+task.RetryAt().UTC().Minute())
+		if task.TypeNm() == entities.TaskTypeSendNotificationEmail {
+			p, err := entities.TaskKindSendNotificationEmail.Payload(task)
+			assert.NoError(t, err)
+			assert.Equal(t, entities.NotificationCategoryWorkouts, p.Category)
+			assert.Equal(t, entities.NotificationTemplateWorkoutReady, p.Template)
+			assert.Empty(t, p.Body)
+		}
+		if task.TypeNm() == entities.TaskTypeSendPushNotification {
+			p, err := entities.TaskKindSendPushNotification.Payload(task)
+			assert.NoError(t, err)
+			assert.Equal(t, "workouts", p.ThreadID)
+			assert.Equal(t, entities.DevicePlatformAndroid, p.Platform)
+			assert.Equal(t, workoutID.String(), p.Data["workout_id"])
+		}
+	}).Return(nil)
+
+	svc := &Service{
+		userInfoRepository:                infoRepo,
+		tasksRepository:                   tasksRepo,
+		userDevicesRepository:             devicesRepo,
+		notificationPreferencesRepository: prefsRepo,
+		log:                               logging.GetLoggerFromContext(ctx),
+	}
+
+	assert.NoError(t, svc.createNotificationTask(ctx, workoutID, userID))
+	assert.Equal(t, []entities.TaskType{entities.TaskTypeSendNotificationEmail, entities.TaskTypeSendPushNotification}, types)
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_info.role ===
+ALTER TABLE bodyfuel.user_info
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'
+        CHECK (role IN ('user', 'coach', 'admin'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE bodyfuel.user_info
+    DROP COLUMN IF EXISTS role;
+
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === tasks: выборка задач конкретного пользователя (GET /tasks) ===
+CREATE INDEX IF NOT EXISTS idx_tasks_attribute_user_id ON bodyfuel.tasks ((attribute ->> 'user_id'));
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON bodyfuel.tasks (created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS bodyfuel.idx_tasks_created_at;
+DROP INDEX IF EXISTS bodyfuel.idx_tasks_attribute_user_id;
+
+-- +goose StatementEnd
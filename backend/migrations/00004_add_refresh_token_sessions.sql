@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_refresh_tokens: метаданные сессии ===
+ALTER TABLE bodyfuel.user_refresh_tokens
+    ADD COLUMN IF NOT EXISTS device_name  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS platform     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip           TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_agent   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE bodyfuel.user_refresh_tokens
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS platform,
+    DROP COLUMN IF EXISTS device_name;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_refresh_token_history: использованные refresh-токены ===
+-- Семейство токенов — это сессия (строка user_refresh_tokens): family_id = user_refresh_tokens.id.
+-- Хэш каждого ротированного токена остаётся здесь, пока жива сессия, чтобы повторное
+-- предъявление старого токена можно было опознать и отозвать всё семейство.
+CREATE TABLE IF NOT EXISTS bodyfuel.user_refresh_token_history (
+    token_hash TEXT PRIMARY KEY,
+    family_id  UUID        NOT NULL REFERENCES bodyfuel.user_refresh_tokens(id) ON DELETE CASCADE,
+    rotated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_refresh_token_history_family_id ON bodyfuel.user_refresh_token_history (family_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.user_refresh_token_history;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === auth_attempts: счётчики попыток входа и проверки кодов ===
+-- Используется, только если Redis не настроен. Ключ и семантика те же, что в Redis:
+-- counter растёт до истечения expires_at, после чего начинается заново.
+CREATE TABLE IF NOT EXISTS bodyfuel.auth_attempts (
+    key        TEXT PRIMARY KEY,
+    counter    BIGINT      NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_attempts_expires_at ON bodyfuel.auth_attempts (expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.auth_attempts;
+
+-- +goose StatementEnd
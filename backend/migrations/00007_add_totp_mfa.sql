@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_info: TOTP ===
+ALTER TABLE bodyfuel.user_info
+    ADD COLUMN IF NOT EXISTS totp_secret     TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS totp_last_step  BIGINT NOT NULL DEFAULT 0;
+
+-- === user_recovery_codes: одноразовые коды восстановления 2FA ===
+CREATE TABLE IF NOT EXISTS bodyfuel.user_recovery_codes (
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES bodyfuel.user_info(id) ON DELETE CASCADE,
+    code_hash  TEXT        NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.user_recovery_codes;
+
+ALTER TABLE bodyfuel.user_info
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled_at,
+    DROP COLUMN IF EXISTS totp_secret;
+
+-- +goose StatementEnd
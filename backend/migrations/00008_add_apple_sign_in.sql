@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_info: Sign in with Apple ===
+ALTER TABLE bodyfuel.user_info
+    ADD COLUMN IF NOT EXISTS apple_sub TEXT UNIQUE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE bodyfuel.user_info
+    DROP COLUMN IF EXISTS apple_sub;
+
+-- +goose StatementEnd
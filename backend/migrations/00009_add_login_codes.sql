@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_verification_codes: коды входа без пароля ===
+ALTER TABLE bodyfuel.user_verification_codes
+    DROP CONSTRAINT IF EXISTS user_verification_codes_code_type_check;
+
+ALTER TABLE bodyfuel.user_verification_codes
+    ADD CONSTRAINT user_verification_codes_code_type_check
+        CHECK (code_type IN ('email', 'phone', 'recover', 'login'));
+
+-- === user_info: подтверждённый телефон однозначно определяет пользователя при входе ===
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_info_verified_phone
+    ON bodyfuel.user_info (phone) WHERE phone_verified_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS bodyfuel.uq_user_info_verified_phone;
+
+DELETE FROM bodyfuel.user_verification_codes WHERE code_type = 'login';
+
+ALTER TABLE bodyfuel.user_verification_codes
+    DROP CONSTRAINT IF EXISTS user_verification_codes_code_type_check;
+
+ALTER TABLE bodyfuel.user_verification_codes
+    ADD CONSTRAINT user_verification_codes_code_type_check
+        CHECK (code_type IN ('email', 'phone', 'recover'));
+
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_verification_codes: смена email и телефона ===
+-- target — адрес, на который отправлен код. Для email_change/phone_change это новый адрес:
+-- он попадает в user_info только после ввода кода.
+ALTER TABLE bodyfuel.user_verification_codes
+    ADD COLUMN IF NOT EXISTS target TEXT NULL;
+
+ALTER TABLE bodyfuel.user_verification_codes
+    DROP CONSTRAINT IF EXISTS user_verification_codes_code_type_check;
+
+ALTER TABLE bodyfuel.user_verification_codes
+    ADD CONSTRAINT user_verification_codes_code_type_check
+        CHECK (code_type IN ('email', 'phone', 'recover', 'login', 'email_change', 'phone_change'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM bodyfuel.user_verification_codes WHERE code_type IN ('email_change', 'phone_change');
+
+ALTER TABLE bodyfuel.user_verification_codes
+    DROP CONSTRAINT IF EXISTS user_verification_codes_code_type_check;
+
+ALTER TABLE bodyfuel.user_verification_codes
+    ADD CONSTRAINT user_verification_codes_code_type_check
+        CHECK (code_type IN ('email', 'phone', 'recover', 'login'));
+
+ALTER TABLE bodyfuel.user_verification_codes
+    DROP COLUMN IF EXISTS target;
+
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_info: отложенное удаление аккаунта ===
+-- Пока срок не наступил, удаление можно отменить через POST /user/info/restore.
+ALTER TABLE bodyfuel.user_info
+    ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ NULL;
+
+-- === workout / workouts_exercise: каскадное удаление вместе с пользователем ===
+-- Раньше у тренировок не было внешних ключей, и после удаления пользователя они оставались в базе.
+DELETE FROM bodyfuel.workout w
+WHERE NOT EXISTS (SELECT 1 FROM bodyfuel.user_info u WHERE u.id = w.user_id);
+
+DELETE FROM bodyfuel.workouts_exercise we
+WHERE NOT EXISTS (SELECT 1 FROM bodyfuel.workout w WHERE w.id = we.workout_id);
+
+ALTER TABLE bodyfuel.workout
+    ADD CONSTRAINT fk_workout_user_id
+        FOREIGN KEY (user_id) REFERENCES bodyfuel.user_info(id) ON DELETE CASCADE;
+
+ALTER TABLE bodyfuel.workouts_exercise
+    ADD CONSTRAINT fk_workouts_exercise_workout_id
+        FOREIGN KEY (workout_id) REFERENCES bodyfuel.workout(id) ON DELETE CASCADE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE bodyfuel.workouts_exercise
+    DROP CONSTRAINT IF EXISTS fk_workouts_exercise_workout_id;
+
+ALTER TABLE bodyfuel.workout
+    DROP CONSTRAINT IF EXISTS fk_workout_user_id;
+
+ALTER TABLE bodyfuel.user_info
+    DROP COLUMN IF EXISTS deletion_scheduled_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_personal_tokens: персональные токены доступа для скриптов и интеграций ===
+-- Хранится только SHA-256 от значения токена, как у refresh-токенов.
+-- scopes — права через пробел (например "nutrition:read workouts:write").
+CREATE TABLE IF NOT EXISTS bodyfuel.user_personal_tokens (
+    id           UUID PRIMARY KEY,
+    user_id      UUID        NOT NULL REFERENCES bodyfuel.user_info(id) ON DELETE CASCADE,
+    name         TEXT        NOT NULL,
+    token_hash   TEXT        NOT NULL UNIQUE,
+    scopes       TEXT        NOT NULL DEFAULT '',
+    expires_at   TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    last_used_ip TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_personal_tokens_user_id ON bodyfuel.user_personal_tokens (user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.user_personal_tokens;
+
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === audit_event: журнал безопасности ===
+-- Записи только добавляются: UPDATE и DELETE запрещены триггером. Внешнего ключа на user_info нет —
+-- события остаются и после удаления аккаунта, чтобы по ним можно было расследовать инциденты.
+CREATE TABLE IF NOT EXISTS bodyfuel.audit_event (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NULL,
+    actor_id   UUID NULL,
+    event_type TEXT        NOT NULL,
+    ip         TEXT        NOT NULL DEFAULT '',
+    user_agent TEXT        NOT NULL DEFAULT '',
+    changes    JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    details    JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_event_user_id_created_at ON bodyfuel.audit_event (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_event_actor_id_created_at ON bodyfuel.audit_event (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_event_type_created_at ON bodyfuel.audit_event (event_type, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_event_created_at ON bodyfuel.audit_event (created_at DESC);
+
+CREATE OR REPLACE FUNCTION bodyfuel.audit_event_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_event_append_only
+    BEFORE UPDATE OR DELETE ON bodyfuel.audit_event
+    FOR EACH ROW EXECUTE FUNCTION bodyfuel.audit_event_append_only();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.audit_event;
+DROP FUNCTION IF EXISTS bodyfuel.audit_event_append_only();
+
+-- +goose StatementEnd
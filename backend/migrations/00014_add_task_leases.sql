@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === tasks: аренда задач исполнителем ===
+-- Исполнитель забирает пачку задач, записывая себя в locked_by и срок аренды в locked_until,
+-- и выполняет их уже вне транзакции. Если экземпляр упал, после locked_until задачу заберёт другой.
+ALTER TABLE bodyfuel.tasks
+    ADD COLUMN IF NOT EXISTS locked_by    TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE bodyfuel.tasks
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS locked_by;
+
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === tasks: причина попадания в dead-letter ===
+-- failed — dead-letter: задача больше не выполняется, пока её не перезапустят.
+ALTER TABLE bodyfuel.tasks
+    ADD COLUMN IF NOT EXISTS failure_reason TEXT NOT NULL DEFAULT '';
+
+-- === task_attempts: история выполнения задач ===
+-- Одна строка на каждую засчитанную попытку. Внешнего ключа на tasks нет: успешная задача
+-- удаляется из очереди, а её история остаётся до очистки по сроку хранения.
+CREATE TABLE IF NOT EXISTS bodyfuel.task_attempts (
+    id           UUID PRIMARY KEY,
+    task_id      UUID        NOT NULL,
+    task_type_nm TEXT        NOT NULL,
+    attempt      INT         NOT NULL,
+    owner        TEXT        NOT NULL DEFAULT '',
+    outcome      TEXT        NOT NULL,
+    error        TEXT        NOT NULL DEFAULT '',
+    started_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_ms  BIGINT      NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_attempts_task_id ON bodyfuel.task_attempts (task_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_task_attempts_type_started_at ON bodyfuel.task_attempts (task_type_nm, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_task_attempts_started_at ON bodyfuel.task_attempts (started_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.task_attempts;
+
+ALTER TABLE bodyfuel.tasks
+    DROP COLUMN IF EXISTS failure_reason;
+
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_info: часовой пояс пользователя ===
+-- По нему срабатывают пользовательские расписания (напоминания в 13:00 по местному времени).
+ALTER TABLE bodyfuel.user_info
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+CREATE INDEX IF NOT EXISTS idx_user_info_timezone ON bodyfuel.user_info (timezone);
+
+-- === очистка истёкших сессий и кодов по расписанию cleanup_expired_auth ===
+CREATE INDEX IF NOT EXISTS idx_user_refresh_tokens_expires_at ON bodyfuel.user_refresh_tokens (expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_verification_codes_expires_at ON bodyfuel.user_verification_codes (expires_at);
+
+-- === recurring_tasks: расписания задач ===
+-- Исполнитель раз в полминуты проверяет включённые расписания и создаёт задачи за последнее
+-- срабатывание в интервале (checked_until, now]. Строка блокируется FOR UPDATE SKIP LOCKED,
+-- а checked_until сдвигается в той же транзакции, где создаются задачи, поэтому срабатывание
+-- обрабатывает не больше одной реплики и не больше одного раза.
+-- scope: global — одна задача, cron в поясе timezone; per_user — задача каждому пользователю,
+-- cron в его часовом поясе, в attribute добавляется user_id.
+-- max_attempts = 0 — лимит попыток берётся из политики обработчика.
+CREATE TABLE IF NOT EXISTS bodyfuel.recurring_tasks (
+    id            UUID PRIMARY KEY,
+    name          TEXT    NOT NULL UNIQUE,
+    cron          TEXT    NOT NULL,
+    task_type_nm  TEXT    NOT NULL,
+    attribute     JSONB   NOT NULL DEFAULT '{}',
+    scope         TEXT    NOT NULL DEFAULT 'global',
+    timezone      TEXT    NOT NULL DEFAULT 'UTC',
+    max_attempts  INT     NOT NULL DEFAULT 0,
+    enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+    checked_until TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_run_at   TIMESTAMP WITH TIME ZONE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO bodyfuel.recurring_tasks (id, name, cron, task_type_nm, attribute, scope, timezone, enabled) VALUES
+    (gen_random_uuid(), 'cleanup_expired_auth', '30 3 * * *', 'cleanup_expired_auth_task',
+     '{"keep_days": 7}', 'global', 'UTC', TRUE),
+    (gen_random_uuid(), 'meal_reminder', '0 13 * * *', 'send_reminder_task',
+     '{"title": "Время обеда", "body": "Не забудьте записать приём пищи в дневник"}', 'per_user', 'UTC', FALSE)
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.recurring_tasks;
+
+DROP INDEX IF EXISTS bodyfuel.idx_user_verification_codes_expires_at;
+DROP INDEX IF EXISTS bodyfuel.idx_user_refresh_tokens_expires_at;
+DROP INDEX IF EXISTS bodyfuel.idx_user_info_timezone;
+
+ALTER TABLE bodyfuel.user_info
+    DROP COLUMN IF EXISTS timezone;
+
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === tasks: ключ дедупликации, по которому задача создана ===
+ALTER TABLE bodyfuel.tasks
+    ADD COLUMN IF NOT EXISTS dedup_key TEXT NOT NULL DEFAULT '';
+
+-- === task_dedup_keys: занятые ключи дедупликации ===
+-- Ключи хранятся отдельно от tasks: успешная задача удаляется из очереди, а ключ должен действовать
+-- до expires_at. Задача с ключом вставляется одним запросом вместе с ключом: если ключ того же типа
+-- уже занят и не истёк, не вставляется ни то, ни другое. Истёкшие ключи исполнитель удаляет раз в час.
+CREATE TABLE IF NOT EXISTS bodyfuel.task_dedup_keys (
+    task_type_nm TEXT NOT NULL,
+    dedup_key    TEXT NOT NULL,
+    task_id      UUID NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (task_type_nm, dedup_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dedup_keys_expires_at ON bodyfuel.task_dedup_keys (expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.task_dedup_keys;
+
+ALTER TABLE bodyfuel.tasks
+    DROP COLUMN IF EXISTS dedup_key;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_notification_preferences: настройки уведомлений ===
+-- Строка появляется, когда пользователь впервые меняет настройки; без неё разрешено всё.
+-- channels — {"категория": {"канал": false}}: отсутствующие пары включены.
+-- quiet_hours_from / quiet_hours_to — тихие часы в минутах от полуночи по user_info.timezone,
+-- NULL — тихих часов нет. from > to — интервал через полночь.
+CREATE TABLE IF NOT EXISTS bodyfuel.user_notification_preferences (
+    user_id          UUID PRIMARY KEY REFERENCES bodyfuel.user_info (id) ON DELETE CASCADE,
+    channels         JSONB    NOT NULL DEFAULT '{}',
+    quiet_hours_from SMALLINT,
+    quiet_hours_to   SMALLINT,
+    updated_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((quiet_hours_from IS NULL) = (quiet_hours_to IS NULL))
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.user_notification_preferences;
+
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_info: язык уведомлений ===
+ALTER TABLE bodyfuel.user_info
+    ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT 'ru';
+
+-- === recurring_tasks: напоминание об обеде рендерится из шаблона на языке пользователя ===
+-- Расписание, текст которого уже поменяли через API, не трогается.
+UPDATE bodyfuel.recurring_tasks
+SET attribute  = '{"template": "meal_reminder"}',
+    updated_at = NOW()
+WHERE name = 'meal_reminder'
+  AND attribute = '{"title": "Время обеда", "body": "Не забудьте записать приём пищи в дневник"}'::jsonb;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE bodyfuel.recurring_tasks
+SET attribute  = '{"title": "Время обеда", "body": "Не забудьте записать приём пищи в дневник"}',
+    updated_at = NOW()
+WHERE name = 'meal_reminder'
+  AND attribute = '{"template": "meal_reminder"}'::jsonb;
+
+ALTER TABLE bodyfuel.user_info
+    DROP COLUMN IF EXISTS locale;
+
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === user_live_activities: push-токены Live Activity тренировок ===
+-- Токен выдаёт iOS при запуске активности; по нему приходят обновления статуса и текущего упражнения.
+-- Строки удаляются, когда тренировка завершена, или когда APNs перестаёт принимать токен.
+CREATE TABLE IF NOT EXISTS bodyfuel.user_live_activities (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES bodyfuel.user_info (id) ON DELETE CASCADE,
+    workout_id UUID NOT NULL REFERENCES bodyfuel.workout (id) ON DELETE CASCADE,
+    push_token TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (workout_id, push_token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_live_activities_push_token ON bodyfuel.user_live_activities (push_token);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bodyfuel.user_live_activities;
+
+-- +goose StatementEnd
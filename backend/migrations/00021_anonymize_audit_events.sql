@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- === audit_event: обезличивание при удалении аккаунта ===
+-- Журнал по-прежнему append-only, кроме одного случая: AccountRepo.Purge включает
+-- bodyfuel.audit_anonymize до конца своей транзакции и стирает IP, User-Agent, changes и details
+-- событий удалённого пользователя. Тип, время и ID событий остаются для расследований; любое другое
+-- изменение и удаление по-прежнему запрещены.
+CREATE OR REPLACE FUNCTION bodyfuel.audit_event_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND COALESCE(current_setting('bodyfuel.audit_anonymize', true), '') = 'on'
+        AND NEW.id = OLD.id
+        AND NEW.user_id IS NOT DISTINCT FROM OLD.user_id
+        AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+        AND NEW.event_type = OLD.event_type
+        AND NEW.created_at = OLD.created_at
+        AND (NEW.ip = OLD.ip OR NEW.ip = '')
+        AND (NEW.user_agent = OLD.user_agent OR NEW.user_agent = '')
+        AND (NEW.changes = OLD.changes OR NEW.changes = '{}'::jsonb)
+        AND (NEW.details = OLD.details OR NEW.details = '{}'::jsonb)
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION bodyfuel.audit_event_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose StatementEnd
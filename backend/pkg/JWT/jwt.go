@@ -5,23 +5,84 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET"))
+// GenerateJWT выпускает access-токен, подписанный текущим ключом (его kid попадает в заголовок).
+// sessionID — ID сессии (refresh-токена), к которой привязан токен; uuid.Nil означает токен без сессии.
+func GenerateJWT(user *entities.UserInfo, sessionID uuid.UUID) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	key, err := ks.signingKey(now)
+	if err != nil {
+		return "", err
+	}
 
-func GenerateJWT(user *entities.UserInfo) (string, error) {
 	claims := jwt.MapClaims{
+		"iss":      ks.issuer,
+		"aud":      ks.audience,
+		"sub":      user.ID().String(),
+		"iat":      now.Unix(),
+		"exp":      now.Add(ks.accessTTL).Unix(),
 		"user_id":  user.ID(),
 		"username": user.Username(),
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+		"role":     user.Role().String(),
 	}
+	if sessionID != uuid.Nil {
+		claims["sid"] = sessionID.String()
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+// parseToken проверяет access-токен: подпись по kid из заголовка, срок жизни, iss и aud.
+func parseToken(tokenString string) (jwt.MapClaims, error) {
+	return parseTokenForAudience(tokenString, func(ks *keySet) string { return ks.audience })
+}
+
+func parseTokenForAudience(tokenString string, audience func(ks *keySet) string) (jwt.MapClaims, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}))
+	token, err := parser.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := ks.verificationKey(kid, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %s", t.Method.Alg(), kid)
+		}
+		return key.private.Public(), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	if !claims.VerifyIssuer(ks.issuer, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+	if !claims.VerifyAudience(audience(ks), true) {
+		return nil, fmt.Errorf("invalid token audience")
+	}
+
+	return claims, nil
 }
 
 func JWTAuthMiddleware() gin.HandlerFunc {
@@ -34,32 +95,73 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
+		// персональные токены скриптов и интеграций проверяются по базе, а не по подписи
+		if IsPersonalToken(tokenString) {
+			if !authenticatePersonalToken(c, tokenString) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
 			}
-			return jwtKey, nil
-		})
+			c.Next()
+			return
+		}
 
-		if err != nil || !token.Valid {
+		claims, err := parseToken(tokenString)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		userID, ok := claims["user_id"].(string)
+		if !ok || userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing user_id in token"})
+			return
+		}
+
+		// токены, выпущенные до появления ролей, не содержат claim role
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = entities.UserRoleUser.String()
+		}
+
+		sid, _ := claims["sid"].(string)
 
-			userID, ok := claims["user_id"].(string)
-			if !ok || userID == "" {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing user_id in token"})
+		// подпись не говорит, жива ли сессия: отозванный или выданный до смены роли токен
+		// иначе действовал бы до exp
+		if v := currentSessionVerifier(); v != nil {
+			uid, uerr := uuid.Parse(userID)
+			sessionID, serr := uuid.Parse(sid)
+			if uerr != nil || serr != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token is not bound to a session, log in again"})
 				return
 			}
+			if role, err = verifySession(c.Request.Context(), v, uid, sessionID); err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+				return
+			}
+		}
 
-			c.Set("user_id", userID)
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			return
+		c.Set("user_id", userID)
+		c.Set("role", role)
+		if sid != "" {
+			c.Set("session_id", sid)
 		}
 
 		c.Next()
 	}
 }
+
+// RequireRoles пропускает запрос дальше, только если роль из токена входит в список разрешённых.
+// Должен подключаться после JWTAuthMiddleware.
+func RequireRoles(roles ...entities.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if r.String() == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
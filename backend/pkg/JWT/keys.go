@@ -0,0 +1,242 @@
+package JWT
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	defaultIssuer    = "bodyfuel"
+	defaultAudience  = "bodyfuel-api"
+	defaultAccessTTL = 24 * time.Hour
+	minRSAKeyBits    = 2048
+)
+
+var (
+	ErrNoKeysConfigured = errors.New("jwt: no signing keys configured")
+	ErrNoActiveKey      = errors.New("jwt: no active signing key")
+	ErrUnknownKeyID     = errors.New("jwt: unknown key id")
+)
+
+// Config — настройки выпуска и проверки access-токенов.
+type Config struct {
+	Issuer    string        `yaml:"issuer" env:"ISSUER" envDefault:"bodyfuel"`
+	Audience  string        `yaml:"audience" env:"AUDIENCE" envDefault:"bodyfuel-api"`
+	AccessTTL time.Duration `yaml:"access_ttl" env:"ACCESS_TTL" envDefault:"24h"`
+	Keys      []KeyConfig   `yaml:"keys"`
+	// SessionCacheTTL — сколько middleware кэширует проверку сессии (см. ConfigureSessions)
+	SessionCacheTTL time.Duration `yaml:"session_cache_ttl" env:"SESSION_CACHE_TTL" envDefault:"30s"`
+}
+
+// KeyConfig описывает один ключ подписи. Ключ подписывает токены с момента ActiveFrom, пока не активируется
+// более новый ключ, и принимается при проверке до RetireAt. RetireAt нужно ставить не раньше, чем
+// активация следующего ключа + AccessTTL, иначе выданные старым ключом токены перестанут проходить проверку.
+type KeyConfig struct {
+	ID             string    `yaml:"kid"`
+	Algorithm      string    `yaml:"alg"`
+	PrivateKeyPath string    `yaml:"private_key_path"`
+	PrivateKey     string    `yaml:"private_key"`
+	ActiveFrom     time.Time `yaml:"active_from"`
+	RetireAt       time.Time `yaml:"retire_at"`
+}
+
+type signingKey struct {
+	id         string
+	method     jwt.SigningMethod
+	private    crypto.Signer
+	activeFrom time.Time
+	retireAt   time.Time
+}
+
+func (k *signingKey) retired(now time.Time) bool {
+	return !k.retireAt.IsZero() && !now.Before(k.retireAt)
+}
+
+func (k *signingKey) canSign(now time.Time) bool {
+	return !now.Before(k.activeFrom) && !k.retired(now)
+}
+
+type keySet struct {
+	issuer    string
+	audience  string
+	accessTTL time.Duration
+	keys      []*signingKey
+}
+
+var (
+	mu     sync.RWMutex
+	active *keySet
+)
+
+// Configure загружает ключи подписи. Вызывается один раз при старте приложения; без хотя бы одного
+// действующего ключа возвращает ошибку, и приложение не должно запускаться.
+func Configure(cfg Config) error {
+	if len(cfg.Keys) == 0 {
+		return ErrNoKeysConfigured
+	}
+
+	ks := &keySet{
+		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		accessTTL: cfg.AccessTTL,
+		keys:      make([]*signingKey, 0, len(cfg.Keys)),
+	}
+	if ks.issuer == "" {
+		ks.issuer = defaultIssuer
+	}
+	if ks.audience == "" {
+		ks.audience = defaultAudience
+	}
+	if ks.accessTTL <= 0 {
+		ks.accessTTL = defaultAccessTTL
+	}
+
+	seen := make(map[string]struct{}, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		if kc.ID == "" {
+			return fmt.Errorf("jwt: key without kid")
+		}
+		if _, ok := seen[kc.ID]; ok {
+			return fmt.Errorf("jwt: duplicate kid %q", kc.ID)
+		}
+		seen[kc.ID] = struct{}{}
+
+		k, err := loadKey(kc)
+		if err != nil {
+			return fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+		}
+		ks.keys = append(ks.keys, k)
+	}
+
+	if _, err := ks.signingKey(time.Now()); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	active = ks
+	mu.Unlock()
+
+	return nil
+}
+
+func currentKeySet() (*keySet, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if active == nil {
+		return nil, ErrNoKeysConfigured
+	}
+	return active, nil
+}
+
+// signingKey выбирает ключ с самым поздним ActiveFrom среди уже активных и не выведенных из оборота.
+func (ks *keySet) signingKey(now time.Time) (*signingKey, error) {
+	var current *signingKey
+	for _, k := range ks.keys {
+		if !k.canSign(now) {
+			continue
+		}
+		if current == nil || k.activeFrom.After(current.activeFrom) {
+			current = k
+		}
+	}
+	if current == nil {
+		return nil, ErrNoActiveKey
+	}
+	return current, nil
+}
+
+// verificationKey возвращает публичный ключ по kid. Ключи, чей ActiveFrom ещё не наступил, тоже принимаются:
+// они уже опубликованы в JWKS, и другой инстанс с более свежими часами мог начать ими подписывать.
+func (ks *keySet) verificationKey(kid string, now time.Time) (*signingKey, error) {
+	for _, k := range ks.keys {
+		if k.id == kid && !k.retired(now) {
+			return k, nil
+		}
+	}
+	return nil, ErrUnknownKeyID
+}
+
+func loadKey(kc KeyConfig) (*signingKey, error) {
+	data := []byte(kc.PrivateKey)
+	if len(data) == 0 {
+		if kc.PrivateKeyPath == "" {
+			return nil, fmt.Errorf("either private_key or private_key_path is required")
+		}
+
+		var err error
+		data, err = os.ReadFile(kc.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read private key: %w", err)
+		}
+	}
+
+	private, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &signingKey{
+		id:         kc.ID,
+		private:    private,
+		activeFrom: kc.ActiveFrom,
+		retireAt:   kc.RetireAt,
+	}
+
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		if kc.Algorithm != "" && kc.Algorithm != AlgRS256 {
+			return nil, fmt.Errorf("RSA key cannot be used with alg %q", kc.Algorithm)
+		}
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		k.method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		if kc.Algorithm != "" && kc.Algorithm != AlgEdDSA {
+			return nil, fmt.Errorf("Ed25519 key cannot be used with alg %q", kc.Algorithm)
+		}
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+
+	return k, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
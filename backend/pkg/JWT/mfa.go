@@ -0,0 +1,68 @@
+package JWT
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	MFAChallengeTTL = 5 * time.Minute
+
+	// mfaAudienceSuffix отделяет challenge-токены от access-токенов: JWTAuthMiddleware их не примет.
+	mfaAudienceSuffix = ":mfa"
+)
+
+// MFAChallenge — то, что нужно помнить между первым (пароль) и вторым (TOTP) шагом входа.
+type MFAChallenge struct {
+	UserID     uuid.UUID
+	DeviceName string
+	Platform   string
+}
+
+// GenerateMFAChallenge выпускает короткоживущий токен второго шага входа, подписанный текущим ключом.
+func GenerateMFAChallenge(c MFAChallenge) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	key, err := ks.signingKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, jwt.MapClaims{
+		"iss": ks.issuer,
+		"aud": ks.audience + mfaAudienceSuffix,
+		"sub": c.UserID.String(),
+		"iat": now.Unix(),
+		"exp": now.Add(MFAChallengeTTL).Unix(),
+		"dev": c.DeviceName,
+		"plt": c.Platform,
+	})
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// ParseMFAChallenge проверяет challenge-токен и возвращает его содержимое.
+func ParseMFAChallenge(tokenString string) (MFAChallenge, error) {
+	claims, err := parseTokenForAudience(tokenString, func(ks *keySet) string { return ks.audience + mfaAudienceSuffix })
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return MFAChallenge{}, fmt.Errorf("invalid mfa challenge subject: %w", err)
+	}
+
+	c := MFAChallenge{UserID: userID}
+	c.DeviceName, _ = claims["dev"].(string)
+	c.Platform, _ = claims["plt"].(string)
+	return c, nil
+}
@@ -0,0 +1,85 @@
+package JWT
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PersonalTokenPrefix отличает персональный токен доступа от JWT в заголовке Authorization.
+const PersonalTokenPrefix = "bfp_"
+
+// Ключи контекста gin, которые JWTAuthMiddleware заполняет для запросов с персональным токеном.
+const (
+	personalTokenIDKey     = "personal_token_id"
+	personalTokenScopesKey = "personal_token_scopes"
+)
+
+// PersonalTokenIdentity — владелец и права персонального токена.
+type PersonalTokenIdentity struct {
+	TokenID uuid.UUID
+	UserID  uuid.UUID
+	Role    string
+	Scopes  []string
+}
+
+// PersonalTokenVerifier находит персональный токен по значению и отмечает его использование.
+type PersonalTokenVerifier interface {
+	VerifyPersonalToken(ctx context.Context, rawToken, ip string) (PersonalTokenIdentity, error)
+}
+
+var (
+	personalTokensMu       sync.RWMutex
+	personalTokensVerifier PersonalTokenVerifier
+)
+
+// ConfigurePersonalTokens включает приём персональных токенов в JWTAuthMiddleware.
+// Без вызова такие токены отклоняются как недействительные.
+func ConfigurePersonalTokens(v PersonalTokenVerifier) {
+	personalTokensMu.Lock()
+	defer personalTokensMu.Unlock()
+	personalTokensVerifier = v
+}
+
+func currentPersonalTokenVerifier() PersonalTokenVerifier {
+	personalTokensMu.RLock()
+	defer personalTokensMu.RUnlock()
+	return personalTokensVerifier
+}
+
+// IsPersonalToken сообщает, что запрос пришёл с персональным токеном, а не с JWT.
+func IsPersonalToken(tokenString string) bool {
+	return strings.HasPrefix(tokenString, PersonalTokenPrefix)
+}
+
+// PersonalTokenScopes возвращает права персонального токена запроса. ok = false — запрос с JWT,
+// для него права не ограничиваются.
+func PersonalTokenScopes(c *gin.Context) (scopes []string, ok bool) {
+	v, ok := c.Get(personalTokenScopesKey)
+	if !ok {
+		return nil, false
+	}
+	scopes, _ = v.([]string)
+	return scopes, true
+}
+
+func authenticatePersonalToken(c *gin.Context, tokenString string) bool {
+	verifier := currentPersonalTokenVerifier()
+	if verifier == nil {
+		return false
+	}
+
+	identity, err := verifier.VerifyPersonalToken(c.Request.Context(), tokenString, c.ClientIP())
+	if err != nil {
+		return false
+	}
+
+	c.Set("user_id", identity.UserID.String())
+	c.Set("role", identity.Role)
+	c.Set(personalTokenIDKey, identity.TokenID.String())
+	c.Set(personalTokenScopesKey, identity.Scopes)
+	return true
+}
@@ -0,0 +1,95 @@
+package JWT
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSessionCacheTTL — сколько middleware помнит проверенную сессию. За это время отзыв сессии
+// или смена роли на других экземплярах ещё не видны.
+const DefaultSessionCacheTTL = 30 * time.Second
+
+// maxCachedSessions — после скольких записей кэш при очередной вставке чистится от устаревших.
+const maxCachedSessions = 10000
+
+// SessionVerifier проверяет, что сессия access-токена (claim sid) не отозвана, и возвращает
+// текущую роль пользователя.
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, userID, sessionID uuid.UUID) (role string, err error)
+}
+
+type cachedSession struct {
+	userID    uuid.UUID
+	role      string
+	expiresAt time.Time
+}
+
+var (
+	sessionsMu       sync.RWMutex
+	sessionsVerifier SessionVerifier
+	sessionsCacheTTL time.Duration
+	sessionsCache    map[uuid.UUID]cachedSession
+)
+
+// ConfigureSessions включает в JWTAuthMiddleware проверку сессии access-токена: токен отозванной
+// сессии и токен без sid отклоняются, роль берётся из ответа verifier, а не из claim role.
+// Результат проверки кэшируется на cacheTTL (0 — DefaultSessionCacheTTL).
+func ConfigureSessions(v SessionVerifier, cacheTTL time.Duration) {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultSessionCacheTTL
+	}
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sessionsVerifier = v
+	sessionsCacheTTL = cacheTTL
+	sessionsCache = make(map[uuid.UUID]cachedSession)
+}
+
+func currentSessionVerifier() SessionVerifier {
+	sessionsMu.RLock()
+	defer sessionsMu.RUnlock()
+	return sessionsVerifier
+}
+
+// ForgetSession убирает сессию из кэша, чтобы её отзыв на этом экземпляре действовал сразу.
+func ForgetSession(sessionID uuid.UUID) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	delete(sessionsCache, sessionID)
+}
+
+// verifySession возвращает текущую роль владельца сессии. Ошибка — сессия отозвана, истекла или
+// принадлежит другому пользователю.
+func verifySession(ctx context.Context, v SessionVerifier, userID, sessionID uuid.UUID) (string, error) {
+	now := time.Now()
+
+	sessionsMu.RLock()
+	cached, ok := sessionsCache[sessionID]
+	sessionsMu.RUnlock()
+	if ok && cached.userID == userID && now.Before(cached.expiresAt) {
+		return cached.role, nil
+	}
+
+	role, err := v.VerifySession(ctx, userID, sessionID)
+	if err != nil {
+		ForgetSession(sessionID)
+		return "", err
+	}
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	if len(sessionsCache) >= maxCachedSessions {
+		for id, s := range sessionsCache {
+			if !now.Before(s.expiresAt) {
+				delete(sessionsCache, id)
+			}
+		}
+	}
+	sessionsCache[sessionID] = cachedSession{userID: userID, role: role, expiresAt: now.Add(sessionsCacheTTL)}
+
+	return role, nil
+}
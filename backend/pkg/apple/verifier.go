@@ -0,0 +1,234 @@
+// Package apple проверяет identity token'ы Sign in with Apple по публичным ключам Apple (JWKS).
+package apple
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	DefaultIssuer  = "https://appleid.apple.com"
+	DefaultJWKSURL = "https://appleid.apple.com/auth/keys"
+
+	privateRelayDomain = "@privaterelay.appleid.com"
+
+	// keysTTL — как долго ключи считаются свежими. Неизвестный kid вызывает внеочередную загрузку,
+	// но не чаще keysMinRefresh, чтобы мусорные токены не превращались в запросы к Apple.
+	keysTTL        = 24 * time.Hour
+	keysMinRefresh = time.Minute
+)
+
+var (
+	ErrNotConfigured        = errors.New("sign in with apple is not configured")
+	ErrInvalidIdentityToken = errors.New("apple identity token is invalid")
+)
+
+type Config struct {
+	// ClientIDs — допустимые aud: bundle ID приложения и, если есть, Services ID для веба.
+	ClientIDs []string `yaml:"client_ids" env:"CLIENT_IDS" env-separator:","`
+	JWKSURL   string   `yaml:"jwks_url" env:"JWKS_URL"`
+	Issuer    string   `yaml:"issuer" env:"ISSUER"`
+}
+
+// Claims — то, что BodyFuel берёт из identity token'а.
+type Claims struct {
+	Subject        string
+	Email          string
+	EmailVerified  bool
+	IsPrivateEmail bool
+}
+
+type Verifier struct {
+	clientIDs  []string
+	issuer     string
+	jwksURL    string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewVerifier возвращает ErrNotConfigured, если не задан ни один client ID.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if len(cfg.ClientIDs) == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	v := &Verifier{
+		clientIDs:  cfg.ClientIDs,
+		issuer:     cfg.Issuer,
+		jwksURL:    cfg.JWKSURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	if v.issuer == "" {
+		v.issuer = DefaultIssuer
+	}
+	if v.jwksURL == "" {
+		v.jwksURL = DefaultJWKSURL
+	}
+
+	return v, nil
+}
+
+// Verify проверяет подпись, iss, aud и срок жизни токена. Если клиент передал nonce, в токене должен
+// быть его SHA-256 (так его кладёт ASAuthorizationAppleIDRequest).
+func (v *Verifier) Verify(ctx context.Context, idToken, nonce string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	_, err := parser.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIdentityToken, err)
+	}
+
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidIdentityToken)
+	}
+	if !v.audienceAllowed(claims) {
+		return Claims{}, fmt.Errorf("%w: unexpected audience", ErrInvalidIdentityToken)
+	}
+
+	if nonce != "" {
+		sum := sha256.Sum256([]byte(nonce))
+		if got, _ := claims["nonce"].(string); got != hex.EncodeToString(sum[:]) {
+			return Claims{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIdentityToken)
+		}
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return Claims{}, fmt.Errorf("%w: missing subject", ErrInvalidIdentityToken)
+	}
+
+	c := Claims{
+		Subject:        sub,
+		EmailVerified:  boolClaim(claims["email_verified"]),
+		IsPrivateEmail: boolClaim(claims["is_private_email"]),
+	}
+	c.Email, _ = claims["email"].(string)
+	c.Email = strings.ToLower(c.Email)
+	if IsPrivateRelayEmail(c.Email) {
+		c.IsPrivateEmail = true
+	}
+
+	return c, nil
+}
+
+// IsPrivateRelayEmail — адрес из «Скрыть e-mail»: Apple пересылает письма на настоящий ящик пользователя.
+func IsPrivateRelayEmail(email string) bool {
+	return strings.HasSuffix(strings.ToLower(email), privateRelayDomain)
+}
+
+func (v *Verifier) audienceAllowed(claims jwt.MapClaims) bool {
+	for _, id := range v.clientIDs {
+		if claims.VerifyAudience(id, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > keysTTL
+	if ok && !stale {
+		return key, nil
+	}
+	if !stale && time.Since(v.fetchedAt) < keysMinRefresh {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		// Apple недоступен — продолжаем работать на закэшированных ключах
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build jwks request: %w", err)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch apple jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch apple jwks: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode apple jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %s modulus: %w", k.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %s exponent: %w", k.KeyID, err)
+		}
+		keys[k.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	return keys, nil
+}
+
+// boolClaim читает булев claim: Apple отдаёт email_verified и is_private_email то как bool, то как строку.
+func boolClaim(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	default:
+		return false
+	}
+}
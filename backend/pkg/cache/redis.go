@@ -50,6 +50,32 @@ func (c *Client) Del(ctx context.Context, keys ...string) error {
 	return c.rdb.Del(ctx, keys...).Err()
 }
 
+// incrScript ставит TTL только новому ключу, чтобы повторные инкременты не сдвигали окно.
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Incr atomically increments a counter. The ttl is applied only when the key is created.
+func (c *Client) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, c.rdb, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+// TTL returns the remaining time to live of a key, or 0 if the key does not exist or never expires.
+func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	d, err := c.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
+
 func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 	n, err := c.rdb.Exists(ctx, key).Result()
 	return n > 0, err
@@ -0,0 +1,297 @@
+// Package cron разбирает расписания в формате crontab из пяти полей (минута, час, день месяца, месяц,
+// день недели) и вычисляет моменты срабатывания в заданном часовом поясе.
+//
+// Поддерживаются *, списки (1,15), диапазоны (1-5), шаги (*/10, 8-20/2, 5/15), названия месяцев
+// и дней недели (jan, mon), воскресенье как 0 и 7, а также @yearly, @monthly, @weekly, @daily и @hourly.
+// Если ограничены и день месяца, и день недели, достаточно совпадения любого из них, как в Vixie cron.
+//
+// Переход на летнее время: время, которого в этот день нет, пропускается. При переходе на зимнее
+// время расписание с конкретными часами срабатывает в повторившемся часе один раз, а с часом * — в обоих.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchYears — дальше этого расписание считается несрабатывающим (например, 30 февраля)
+const searchYears = 5
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// dowField допускает 7 как второе обозначение воскресенья
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule — разобранное расписание. Биты масок соответствуют допустимым значениям полей.
+type Schedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	// domStar, dowStar — поле задано через *, тогда дни проверяются по И, а не по ИЛИ
+	domStar, dowStar bool
+	// hourStar — расписание срабатывает каждый час, в том числе дважды в повторившемся
+	hourStar bool
+}
+
+// Parse разбирает расписание из пяти полей или дескриптор вида @daily.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	expr := spec
+	if strings.HasPrefix(expr, "@") {
+		d, ok := descriptors[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("unknown descriptor %q", expr)
+		}
+		expr = d
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d in %q", len(parts), spec)
+	}
+
+	s := &Schedule{
+		spec:     spec,
+		domStar:  strings.HasPrefix(parts[2], "*"),
+		dowStar:  strings.HasPrefix(parts[4], "*"),
+		hourStar: parts[1] == "*",
+	}
+
+	var err error
+	if s.minute, err = minuteField.parse(parts[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(parts[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(parts[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(parts[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(parts[4]); err != nil {
+		return nil, err
+	}
+	// 7 — то же воскресенье, что и 0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	return s, nil
+}
+
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(expr, ",") {
+		bits, err := f.parseRange(part)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", f.name, err)
+		}
+		mask |= bits
+	}
+
+	return mask, nil
+}
+
+// parseRange разбирает один элемент списка: *, N, N-M с необязательным шагом /S.
+// N/S означает от N до конца диапазона с шагом S.
+func (f field) parseRange(expr string) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepExpr); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepExpr)
+		}
+	}
+
+	var lo, hi int
+	switch {
+	case rangeExpr == "*":
+		lo, hi = f.min, f.max
+	case strings.Contains(rangeExpr, "-"):
+		loExpr, hiExpr, _ := strings.Cut(rangeExpr, "-")
+		var err error
+		if lo, err = f.value(loExpr); err != nil {
+			return 0, err
+		}
+		if hi, err = f.value(hiExpr); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid range %q", rangeExpr)
+		}
+	default:
+		var err error
+		if lo, err = f.value(rangeExpr); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if hasStep {
+			hi = f.max
+		}
+	}
+
+	var mask uint64
+	for v := lo; v <= hi; v += step {
+		mask |= 1 << uint(v)
+	}
+
+	return mask, nil
+}
+
+func (f field) value(expr string) (int, error) {
+	if v, ok := f.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", expr)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// Next возвращает первый момент срабатывания строго после t в часовом поясе t.
+// Нулевое время — расписание не срабатывает в ближайшие 5 лет.
+func (s *Schedule) Next(t time.Time) time.Time {
+	for {
+		t = s.next(t)
+		// второй раз то же настенное время наступает при переходе на зимнее время
+		if t.IsZero() || s.hourStar || !sameWallClock(t, t.Add(-time.Hour)) {
+			return t
+		}
+	}
+}
+
+func sameWallClock(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd && a.Hour() == b.Hour() && a.Minute() == b.Minute()
+}
+
+func (s *Schedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	// следующая целая минута
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// added — время уже сдвигалось, и младшие поля сброшены в начало
+	added := false
+	yearLimit := t.Year() + searchYears
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// полночь могла сместиться из-за перехода на летнее или зимнее время
+		if h := t.Hour(); h != 0 {
+			if h > 12 {
+				t = t.Add(time.Duration(24-h) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(h) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		added = true
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+// Latest возвращает последний момент срабатывания в полуинтервале (after, until].
+// Перебирает срабатывания по одному, поэтому интервал не должен быть большим.
+func (s *Schedule) Latest(after, until time.Time) (time.Time, bool) {
+	var (
+		last  time.Time
+		found bool
+	)
+	for t := s.Next(after); !t.IsZero() && !t.After(until); t = s.Next(t) {
+		last, found = t, true
+	}
+
+	return last, found
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
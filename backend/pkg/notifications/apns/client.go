@@ -1,10 +1,14 @@
 package apns
 
 import (
+	"backend/pkg/notifications/push"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
 	apns2 "github.com/sideshow/apns2"
+	"github.com/sideshow/apns2/payload"
 	"github.com/sideshow/apns2/token"
 )
 
@@ -16,9 +20,22 @@ type Config struct {
 	Sandbox  bool
 }
 
-type Payload struct {
-	Title string
-	Body  string
+// LiveActivityEvent — что сделать с Live Activity: обновить содержимое или закрыть.
+type LiveActivityEvent string
+
+const (
+	LiveActivityEventUpdate LiveActivityEvent = "update"
+	LiveActivityEventEnd    LiveActivityEvent = "end"
+)
+
+// LiveActivityPayload — обновление Live Activity. ContentState сериализуется в JSON и должен совпадать
+// с ContentState активности в приложении. Timestamp обязателен: iOS игнорирует обновления старше
+// показанного. DismissalDate — когда убрать закрытую активность с экрана блокировки.
+type LiveActivityPayload struct {
+	Event         LiveActivityEvent
+	ContentState  any
+	Timestamp     time.Time
+	DismissalDate *time.Time
 }
 
 type Client struct {
@@ -51,18 +68,9 @@ func NewClient(cfg Config) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) Send(deviceToken string, p Payload) error {
-	payload := map[string]any{
-		"aps": map[string]any{
-			"alert": map[string]string{
-				"title": p.Title,
-				"body":  p.Body,
-			},
-			"sound": "default",
-		},
-	}
-
-	raw, err := json.Marshal(payload)
+// Send отправляет push. Push с ContentAvailable без текста уходит с push-type background и приоритетом 5.
+func (c *Client) Send(deviceToken string, p push.Payload) error {
+	raw, err := json.Marshal(buildPayload(p))
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
@@ -70,7 +78,13 @@ func (c *Client) Send(deviceToken string, p Payload) error {
 	notification := &apns2.Notification{
 		DeviceToken: deviceToken,
 		Topic:       c.bundleID,
+		CollapseID:  p.CollapseID,
 		Payload:     raw,
+		PushType:    apns2.PushTypeAlert,
+	}
+	if p.Background() {
+		notification.PushType = apns2.PushTypeBackground
+		notification.Priority = apns2.PriorityLow
 	}
 
 	resp, err := c.client.Push(notification)
@@ -78,9 +92,73 @@ func (c *Client) Send(deviceToken string, p Payload) error {
 		return fmt.Errorf("push notification: %w", err)
 	}
 
-	if !resp.Sent() {
-		return fmt.Errorf("apns error: %s (%d)", resp.Reason, resp.StatusCode)
+	return responseError(resp)
+}
+
+// SendLiveActivity отправляет push типа liveactivity на токен активности. Недействительный токен —
+// push.ErrInvalidDeviceToken, как у Send.
+func (c *Client) SendLiveActivity(pushToken string, p LiveActivityPayload) error {
+	aps := map[string]any{
+		"event":         p.Event,
+		"content-state": p.ContentState,
+		"timestamp":     p.Timestamp.Unix(),
+	}
+	if p.DismissalDate != nil {
+		aps["dismissal-date"] = p.DismissalDate.Unix()
+	}
+
+	raw, err := json.Marshal(map[string]any{"aps": aps})
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	resp, err := c.client.Push(&apns2.Notification{
+		DeviceToken: pushToken,
+		Topic:       c.bundleID + ".push-type.liveactivity",
+		Payload:     raw,
+		PushType:    apns2.PushTypeLiveActivity,
+		Priority:    apns2.PriorityHigh,
+	})
+	if err != nil {
+		return fmt.Errorf("push live activity: %w", err)
+	}
+
+	return responseError(resp)
+}
+
+func responseError(resp *apns2.Response) error {
+	if resp.Sent() {
+		return nil
 	}
+	if resp.Reason == apns2.ReasonUnregistered || resp.Reason == apns2.ReasonBadDeviceToken ||
+		resp.StatusCode == http.StatusGone {
+		return fmt.Errorf("%w: %s (%d)", push.ErrInvalidDeviceToken, resp.Reason, resp.StatusCode)
+	}
+	return fmt.Errorf("apns error: %s (%d)", resp.Reason, resp.StatusCode)
+}
 
-	return nil
+func buildPayload(p push.Payload) *payload.Payload {
+	b := payload.NewPayload()
+	if !p.Background() {
+		b.AlertTitle(p.Title).AlertBody(p.Body).Sound("default")
+	}
+	if p.ContentAvailable {
+		b.ContentAvailable()
+	}
+	if p.Badge != nil {
+		b.Badge(*p.Badge)
+	}
+	if p.Category != "" {
+		b.Category(p.Category)
+	}
+	if p.ThreadID != "" {
+		b.ThreadID(p.ThreadID)
+	}
+	if p.InterruptionLevel != "" {
+		b.InterruptionLevel(payload.EInterruptionLevel(p.InterruptionLevel))
+	}
+	for k, v := range p.Data {
+		b.Custom(k, v)
+	}
+	return b
 }
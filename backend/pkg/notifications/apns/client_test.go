@@ -0,0 +1,230 @@
+package apns
+
+import (
+	"backend/pkg/notifications/push"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBundleID = "com.bodyfuel.app"
+
+// apnsStub — локальная замена api.push.apple.com: запоминает последний запрос и отвечает заданным
+// статусом и причиной.
+type apnsStub struct {
+	server *httptest.Server
+
+	path    string
+	headers http.Header
+	payload map[string]any
+
+	status int
+	reason string
+}
+
+func newAPNsStub(t *testing.T) *apnsStub {
+	stub := &apnsStub{status: http.StatusOK}
+	stub.server = httptest.NewServer(http.HandlerFunc(stub.handle))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (s *apnsStub) handle(w http.ResponseWriter, r *http.Request) {
+	s.path = r.URL.Path
+	s.headers = r.Header.Clone()
+
+	raw, _ := io.ReadAll(r.Body)
+	s.payload = nil
+	if err := json.Unmarshal(raw, &s.payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("apns-id", "6D3C5E0C-3F5A-4B6B-9B0F-1A2B3C4D5E6F")
+	w.WriteHeader(s.status)
+	if s.reason != "" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"reason": s.reason})
+	}
+}
+
+func (s *apnsStub) reply(status int, reason string) {
+	s.status, s.reason = status, reason
+}
+
+// aps возвращает словарь aps последнего запроса.
+func (s *apnsStub) aps(t *testing.T) map[string]any {
+	aps, ok := s.payload["aps"].(map[string]any)
+	if !ok {
+		t.Fatalf("payload without aps: %v", s.payload)
+	}
+	return aps
+}
+
+// client пишет ключ .p8 во временный файл и создаёт клиент, направленный на заглушку.
+func (s *apnsStub) client(t *testing.T) *Client {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "AuthKey.p8")
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(Config{KeyPath: path, KeyID: "KEY123", TeamID: "TEAM123", BundleID: testBundleID, Sandbox: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.client.Host = s.server.URL
+	c.client.HTTPClient = s.server.Client()
+	return c
+}
+
+func TestClient_Send_Alert(t *testing.T) {
+	stub := newAPNsStub(t)
+	badge := 3
+
+	err := stub.client(t).Send("device-1", push.Payload{
+		Title:             "Тренировка",
+		Body:              "Пора начинать",
+		Badge:             &badge,
+		Category:          "WORKOUT_REMINDER",
+		ThreadID:          "workouts",
+		CollapseID:        "workout-42",
+		InterruptionLevel: push.InterruptionLevelTimeSensitive,
+		Data:              map[string]string{"link": "bodyfuel://workouts/42"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/3/device/device-1", stub.path)
+	assert.Equal(t, testBundleID, stub.headers.Get("apns-topic"))
+	assert.Equal(t, "alert", stub.headers.Get("apns-push-type"))
+	assert.Equal(t, "workout-42", stub.headers.Get("apns-collapse-id"))
+	assert.Empty(t, stub.headers.Get("apns-priority"))
+	assert.True(t, strings.HasPrefix(stub.headers.Get("authorization"), "bearer "))
+
+	assert.Equal(t, map[string]any{
+		"alert":              map[string]any{"title": "Тренировка", "body": "Пора начинать"},
+		"sound":              "default",
+		"badge":              float64(3),
+		"category":           "WORKOUT_REMINDER",
+		"thread-id":          "workouts",
+		"interruption-level": "time-sensitive",
+	}, stub.aps(t))
+	assert.Equal(t, "bodyfuel://workouts/42", stub.payload["link"])
+}
+
+func TestClient_Send_AlertWithContentAvailable(t *testing.T) {
+	stub := newAPNsStub(t)
+
+	err := stub.client(t).Send("device-1", push.Payload{Title: "Рекомендации готовы", ContentAvailable: true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "alert", stub.headers.Get("apns-push-type"))
+	aps := stub.aps(t)
+	assert.Equal(t, float64(1), aps["content-available"])
+	assert.Equal(t, map[string]any{"title": "Рекомендации готовы"}, aps["alert"])
+}
+
+func TestClient_Send_Background(t *testing.T) {
+	stub := newAPNsStub(t)
+
+	err := stub.client(t).Send("device-1", push.Payload{
+		ContentAvailable: true,
+		Data:             map[string]string{"sync": "recommendations"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "background", stub.headers.Get("apns-push-type"))
+	assert.Equal(t, "5", stub.headers.Get("apns-priority"))
+	assert.Equal(t, map[string]any{"content-available": float64(1)}, stub.aps(t))
+	assert.Equal(t, "recommendations", stub.payload["sync"])
+}
+
+func TestClient_Send_ResponseError(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		reason       string
+		invalidToken bool
+	}{
+		{name: "unregistered", status: http.StatusGone, reason: "Unregistered", invalidToken: true},
+		{name: "gone without reason", status: http.StatusGone, invalidToken: true},
+		{name: "bad device token", status: http.StatusBadRequest, reason: "BadDeviceToken", invalidToken: true},
+		{name: "payload too large", status: http.StatusRequestEntityTooLarge, reason: "PayloadTooLarge"},
+		{name: "bad collapse id", status: http.StatusBadRequest, reason: "BadCollapseId"},
+		{name: "missing topic", status: http.StatusBadRequest, reason: "MissingTopic"},
+		{name: "expired provider token", status: http.StatusForbidden, reason: "ExpiredProviderToken"},
+		{name: "too many requests", status: http.StatusTooManyRequests, reason: "TooManyRequests"},
+		{name: "internal server error", status: http.StatusInternalServerError, reason: "InternalServerError"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newAPNsStub(t)
+			stub.reply(tt.status, tt.reason)
+
+			err := stub.client(t).Send("device-1", push.Payload{Title: "Hi"})
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.invalidToken, errors.Is(err, push.ErrInvalidDeviceToken))
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+}
+
+func TestClient_SendLiveActivity(t *testing.T) {
+	stub := newAPNsStub(t)
+	now := time.Unix(1700000000, 0)
+	dismissal := now.Add(time.Hour)
+
+	err := stub.client(t).SendLiveActivity("activity-1", LiveActivityPayload{
+		Event:         LiveActivityEventEnd,
+		ContentState:  map[string]any{"status": "done"},
+		Timestamp:     now,
+		DismissalDate: &dismissal,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/3/device/activity-1", stub.path)
+	assert.Equal(t, testBundleID+".push-type.liveactivity", stub.headers.Get("apns-topic"))
+	assert.Equal(t, "liveactivity", stub.headers.Get("apns-push-type"))
+	assert.Equal(t, "10", stub.headers.Get("apns-priority"))
+	assert.Equal(t, map[string]any{
+		"event":          "end",
+		"content-state":  map[string]any{"status": "done"},
+		"timestamp":      float64(now.Unix()),
+		"dismissal-date": float64(dismissal.Unix()),
+	}, stub.aps(t))
+}
+
+func TestClient_SendLiveActivity_Unregistered_InvalidToken(t *testing.T) {
+	stub := newAPNsStub(t)
+	stub.reply(http.StatusGone, "Unregistered")
+
+	err := stub.client(t).SendLiveActivity("activity-1", LiveActivityPayload{
+		Event:     LiveActivityEventUpdate,
+		Timestamp: time.Now(),
+	})
+
+	assert.ErrorIs(t, err, push.ErrInvalidDeviceToken)
+}
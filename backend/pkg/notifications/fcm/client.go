@@ -0,0 +1,305 @@
+// Package fcm отправляет push на Android через Firebase Cloud Messaging HTTP v1 API.
+package fcm
+
+import (
+	"backend/pkg/notifications/push"
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	DefaultEndpoint = "https://fcm.googleapis.com"
+	DefaultTokenURL = "https://oauth2.googleapis.com/token"
+
+	messagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+	// tokenLifetime — срок жизни assertion и access token; токен обновляется за tokenRefreshMargin до истечения.
+	tokenLifetime      = time.Hour
+	tokenRefreshMargin = time.Minute
+)
+
+var ErrNotConfigured = errors.New("fcm is not configured")
+
+// Config — ключ сервисного аккаунта Firebase. ProjectID по умолчанию берётся из ключа. Endpoint
+// заменяет https://fcm.googleapis.com, а token_uri в ключе — адрес выдачи токенов: так клиент
+// направляется на локальную заглушку.
+type Config struct {
+	CredentialsPath string
+	ProjectID       string
+	Endpoint        string
+}
+
+// serviceAccount — поля JSON-ключа сервисного аккаунта, нужные для OAuth 2.0.
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type Client struct {
+	sendURL     string
+	tokenURL    string
+	clientEmail string
+	signKey     *rsa.PrivateKey
+	httpClient  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewClient читает ключ сервисного аккаунта. Без CredentialsPath возвращает ErrNotConfigured.
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.CredentialsPath == "" {
+		return nil, ErrNotConfigured
+	}
+
+	raw, err := os.ReadFile(cfg.CredentialsPath)
+	if err != nil {
+		return nil, fmt.Errorf("read fcm credentials: %w", err)
+	}
+
+	var sa serviceAccount
+	if err = json.Unmarshal(raw, &sa); err != nil {
+		return nil, fmt.Errorf("parse fcm credentials: %w", err)
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sa.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parse fcm private key: %w", err)
+	}
+
+	projectID := cfg.ProjectID
+	if projectID == "" {
+		projectID = sa.ProjectID
+	}
+	if projectID == "" || sa.ClientEmail == "" {
+		return nil, fmt.Errorf("fcm credentials: project_id and client_email are required")
+	}
+
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	tokenURL := sa.TokenURI
+	if tokenURL == "" {
+		tokenURL = DefaultTokenURL
+	}
+
+	return &Client{
+		sendURL:     fmt.Sprintf("%s/v1/projects/%s/messages:send", endpoint, url.PathEscape(projectID)),
+		tokenURL:    tokenURL,
+		clientEmail: sa.ClientEmail,
+		signKey:     key,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Send отправляет push на registration token устройства. Фоновый push уходит data-сообщением
+// без notification и с обычным приоритетом. Недействительный токен — push.ErrInvalidDeviceToken.
+func (c *Client) Send(deviceToken string, p push.Payload) error {
+	body, err := json.Marshal(map[string]any{"message": buildMessage(deviceToken, p)})
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	accessToken, err := c.token()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.sendURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Токен отозван раньше срока: следующая попытка получит новый.
+		c.resetToken()
+	}
+
+	return responseError(resp)
+}
+
+// message — Message из FCM HTTP v1 API, только используемые поля.
+type message struct {
+	Token        string            `json:"token"`
+	Notification *notification     `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      androidConfig     `json:"android"`
+}
+
+type notification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type androidConfig struct {
+	Priority     string               `json:"priority"`
+	CollapseKey  string               `json:"collapse_key,omitempty"`
+	Notification *androidNotification `json:"notification,omitempty"`
+}
+
+type androidNotification struct {
+	Tag                  string `json:"tag,omitempty"`
+	ClickAction          string `json:"click_action,omitempty"`
+	NotificationCount    *int   `json:"notification_count,omitempty"`
+	NotificationPriority string `json:"notification_priority,omitempty"`
+	Sound                string `json:"sound,omitempty"`
+}
+
+// buildMessage переносит параметры APNs на ближайшие аналоги Android: CollapseID — collapse_key
+// и tag (новое уведомление заменяет показанное), Category — click_action, Badge — notification_count,
+// InterruptionLevel — notification_priority. ThreadID в Android не используется: уведомления
+// группирует канал приложения.
+func buildMessage(deviceToken string, p push.Payload) message {
+	m := message{
+		Token:   deviceToken,
+		Data:    p.Data,
+		Android: androidConfig{Priority: "HIGH", CollapseKey: p.CollapseID},
+	}
+	if p.Background() {
+		m.Android.Priority = "NORMAL"
+		return m
+	}
+
+	m.Notification = &notification{Title: p.Title, Body: p.Body}
+	m.Android.Notification = &androidNotification{
+		Tag:                  p.CollapseID,
+		ClickAction:          p.Category,
+		NotificationCount:    p.Badge,
+		NotificationPriority: notificationPriority(p.InterruptionLevel),
+		Sound:                "default",
+	}
+	return m
+}
+
+func notificationPriority(l push.InterruptionLevel) string {
+	switch l {
+	case push.InterruptionLevelPassive:
+		return "PRIORITY_LOW"
+	case push.InterruptionLevelTimeSensitive:
+		return "PRIORITY_HIGH"
+	case push.InterruptionLevelCritical:
+		return "PRIORITY_MAX"
+	default:
+		return ""
+	}
+}
+
+// token возвращает access token сервисного аккаунта, при необходимости обменивая подписанный
+// JWT на новый (OAuth 2.0 JWT bearer grant).
+func (c *Client) token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.accessToken != "" && now.Before(c.expiresAt.Add(-tokenRefreshMargin)) {
+		return c.accessToken, nil
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.clientEmail,
+		"scope": messagingScope,
+		"aud":   c.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(tokenLifetime).Unix(),
+	}).SignedString(c.signKey)
+	if err != nil {
+		return "", fmt.Errorf("sign fcm assertion: %w", err)
+	}
+
+	resp, err := c.httpClient.PostForm(c.tokenURL, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", fmt.Errorf("fetch fcm access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return "", fmt.Errorf("fetch fcm access token: status %d: %s", resp.StatusCode, raw)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode fcm access token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("fetch fcm access token: empty token")
+	}
+
+	c.accessToken = body.AccessToken
+	c.expiresAt = now.Add(time.Duration(body.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+func (c *Client) resetToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = ""
+}
+
+// responseError разбирает ошибку FCM. UNREGISTERED (и 404 без подробностей) — токен больше не действует.
+// INVALID_ARGUMENT означает недействительный токен, только если FCM указал на поле message.token:
+// та же ошибка приходит на неверное сообщение, и тогда удалять устройство нельзя.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Details []struct {
+				ErrorCode       string `json:"errorCode"`
+				FieldViolations []struct {
+					Field string `json:"field"`
+				} `json:"fieldViolations"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body)
+
+	code := body.Error.Status
+	badToken := false
+	for _, d := range body.Error.Details {
+		if d.ErrorCode != "" {
+			code = d.ErrorCode
+		}
+		for _, v := range d.FieldViolations {
+			badToken = badToken || v.Field == "message.token"
+		}
+	}
+
+	if code == "UNREGISTERED" || resp.StatusCode == http.StatusNotFound || (code == "INVALID_ARGUMENT" && badToken) {
+		return fmt.Errorf("%w: %s (%d)", push.ErrInvalidDeviceToken, code, resp.StatusCode)
+	}
+	return fmt.Errorf("fcm error: %s (%d): %s", code, resp.StatusCode, body.Error.Message)
+}
@@ -0,0 +1,261 @@
+package fcm
+
+import (
+	"backend/pkg/notifications/push"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testProjectID   = "bodyfuel-test"
+	testClientEmail = "fcm@bodyfuel-test.iam.gserviceaccount.com"
+)
+
+// fcmStub — локальная замена oauth2.googleapis.com и fcm.googleapis.com: выдаёт access token'ы
+// token-1, token-2, … и отвечает на отправку заданным статусом и телом.
+type fcmStub struct {
+	t      *testing.T
+	key    *rsa.PrivateKey
+	server *httptest.Server
+
+	tokens   int
+	sendPath string
+	auth     []string
+	message  map[string]any
+
+	status int
+	body   string
+}
+
+func newFCMStub(t *testing.T) *fcmStub {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &fcmStub{t: t, key: key, status: http.StatusOK, body: `{"name":"projects/bodyfuel-test/messages/1"}`}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", stub.handleToken)
+	mux.HandleFunc("/v1/", stub.handleSend)
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func (s *fcmStub) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		http.Error(w, "unsupported grant_type", http.StatusBadRequest)
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims, func(tok *jwt.Token) (any, error) {
+		if _, ok := tok.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", tok.Header["alg"])
+		}
+		return &s.key.PublicKey, nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	assert.Equal(s.t, testClientEmail, claims["iss"])
+	assert.Equal(s.t, s.server.URL+"/token", claims["aud"])
+	assert.Equal(s.t, messagingScope, claims["scope"])
+
+	s.tokens++
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": fmt.Sprintf("token-%d", s.tokens),
+		"expires_in":   3600,
+		"token_type":   "Bearer",
+	})
+}
+
+func (s *fcmStub) handleSend(w http.ResponseWriter, r *http.Request) {
+	s.sendPath = r.URL.Path
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+
+	raw, _ := io.ReadAll(r.Body)
+	var body struct {
+		Message map[string]any `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.message = body.Message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(s.status)
+	_, _ = io.WriteString(w, s.body)
+}
+
+func (s *fcmStub) reply(status int, body string) {
+	s.status, s.body = status, body
+}
+
+// client пишет ключ сервисного аккаунта во временный файл и создаёт клиент, направленный на заглушку.
+func (s *fcmStub) client(t *testing.T) *Client {
+	der := x509.MarshalPKCS1PrivateKey(s.key)
+	credentials, err := json.Marshal(serviceAccount{
+		ProjectID:   testProjectID,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})),
+		ClientEmail: testClientEmail,
+		TokenURI:    s.server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	if err = os.WriteFile(path, credentials, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(Config{CredentialsPath: path, Endpoint: s.server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNewClient_NotConfigured(t *testing.T) {
+	_, err := NewClient(Config{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestClient_Send_ExchangesAndCachesToken(t *testing.T) {
+	stub := newFCMStub(t)
+	c := stub.client(t)
+
+	assert.NoError(t, c.Send("device-1", push.Payload{Title: "Hi"}))
+	assert.NoError(t, c.Send("device-2", push.Payload{Title: "Hi again"}))
+
+	assert.Equal(t, 1, stub.tokens)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1"}, stub.auth)
+	assert.Equal(t, "/v1/projects/"+testProjectID+"/messages:send", stub.sendPath)
+}
+
+func TestClient_Send_MessageShape(t *testing.T) {
+	stub := newFCMStub(t)
+	badge := 3
+
+	err := stub.client(t).Send("device-1", push.Payload{
+		Title:             "Тренировка",
+		Body:              "Пора начинать",
+		Badge:             &badge,
+		Category:          "WORKOUT_REMINDER",
+		ThreadID:          "workouts",
+		CollapseID:        "workout-42",
+		InterruptionLevel: push.InterruptionLevelTimeSensitive,
+		Data:              map[string]string{"link": "bodyfuel://workouts/42"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"token":        "device-1",
+		"notification": map[string]any{"title": "Тренировка", "body": "Пора начинать"},
+		"data":         map[string]any{"link": "bodyfuel://workouts/42"},
+		"android": map[string]any{
+			"priority":     "HIGH",
+			"collapse_key": "workout-42",
+			"notification": map[string]any{
+				"tag":                   "workout-42",
+				"click_action":          "WORKOUT_REMINDER",
+				"notification_count":    float64(3),
+				"notification_priority": "PRIORITY_HIGH",
+				"sound":                 "default",
+			},
+		},
+	}, stub.message)
+}
+
+func TestClient_Send_BackgroundIsDataOnly(t *testing.T) {
+	stub := newFCMStub(t)
+
+	err := stub.client(t).Send("device-1", push.Payload{
+		ContentAvailable: true,
+		Data:             map[string]string{"sync": "recommendations"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"token":   "device-1",
+		"data":    map[string]any{"sync": "recommendations"},
+		"android": map[string]any{"priority": "NORMAL"},
+	}, stub.message)
+}
+
+func TestClient_Send_Unregistered_InvalidToken(t *testing.T) {
+	stub := newFCMStub(t)
+	stub.reply(http.StatusNotFound, `{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",
+		"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`)
+
+	err := stub.client(t).Send("dead-token", push.Payload{Title: "Hi"})
+
+	assert.ErrorIs(t, err, push.ErrInvalidDeviceToken)
+	assert.Contains(t, err.Error(), "UNREGISTERED")
+}
+
+func TestClient_Send_InvalidArgument(t *testing.T) {
+	tests := []struct {
+		name         string
+		field        string
+		invalidToken bool
+	}{
+		{name: "registration token", field: "message.token", invalidToken: true},
+		{name: "message payload", field: "message.android.notification.notification_count", invalidToken: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newFCMStub(t)
+			stub.reply(http.StatusBadRequest, fmt.Sprintf(`{"error":{"code":400,"message":"Invalid value","status":"INVALID_ARGUMENT",
+				"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"INVALID_ARGUMENT"},
+				{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":%q}]}]}}`, tt.field))
+
+			err := stub.client(t).Send("device-1", push.Payload{Title: "Hi"})
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.invalidToken, errors.Is(err, push.ErrInvalidDeviceToken))
+			assert.Contains(t, err.Error(), "INVALID_ARGUMENT")
+		})
+	}
+}
+
+func TestClient_Send_Unauthorized_ResetsToken(t *testing.T) {
+	stub := newFCMStub(t)
+	c := stub.client(t)
+
+	stub.reply(http.StatusUnauthorized, `{"error":{"code":401,"message":"Request had invalid authentication credentials.","status":"UNAUTHENTICATED"}}`)
+	err := c.Send("device-1", push.Payload{Title: "Hi"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, push.ErrInvalidDeviceToken)
+
+	stub.reply(http.StatusOK, `{"name":"projects/bodyfuel-test/messages/2"}`)
+	assert.NoError(t, c.Send("device-1", push.Payload{Title: "Hi"}))
+
+	assert.Equal(t, 2, stub.tokens)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, stub.auth)
+}
@@ -0,0 +1,39 @@
+// Package push — общие для провайдеров push (APNs, FCM) содержимое уведомления и ошибки.
+package push
+
+import "errors"
+
+// ErrInvalidDeviceToken — провайдер больше не принимает токен (APNs Unregistered и BadDeviceToken,
+// FCM UNREGISTERED и INVALID_ARGUMENT по полю токена): приложение удалено или токен выдан другому окружению. Повторять отправку бессмысленно.
+var ErrInvalidDeviceToken = errors.New("push device token is no longer valid")
+
+// InterruptionLevel — как устройство показывает уведомление (Focus, звук, экран блокировки).
+type InterruptionLevel string
+
+const (
+	InterruptionLevelPassive       InterruptionLevel = "passive"
+	InterruptionLevelActive        InterruptionLevel = "active"
+	InterruptionLevelTimeSensitive InterruptionLevel = "time-sensitive"
+	InterruptionLevelCritical      InterruptionLevel = "critical"
+)
+
+// Payload — содержимое push. ContentAvailable без Title и Body — фоновый push: без alert и звука,
+// с низким приоритетом. Data кладётся в корень payload (APNs) или в data сообщения (FCM),
+// например ссылка для перехода в приложении.
+type Payload struct {
+	Title string
+	Body  string
+
+	Badge             *int
+	Category          string
+	ThreadID          string
+	CollapseID        string
+	InterruptionLevel InterruptionLevel
+	ContentAvailable  bool
+	Data              map[string]string
+}
+
+// Background сообщает, что push фоновый: только будит приложение, без показа уведомления.
+func (p Payload) Background() bool {
+	return p.ContentAvailable && p.Title == "" && p.Body == ""
+}
@@ -0,0 +1,87 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLength — длина префикса SHA-1 в модели k-анонимности HIBP: по префиксу выбирается диапазон,
+// в котором ищется остаток хэша.
+const prefixLength = 5
+
+// BreachedList — список SHA-1 хэшей утёкших паролей (Have I Been Pwned). В режиме каталога файлы
+// диапазонов читаются по запросу и в памяти не держатся; одиночный файл загружается целиком.
+type BreachedList struct {
+	dir    string
+	hashes map[string]struct{}
+}
+
+func LoadBreachedList(path string) (*BreachedList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached list: %w", err)
+	}
+	if info.IsDir() {
+		return &BreachedList{dir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached list: %w", err)
+	}
+	defer f.Close()
+
+	hashes := make(map[string]struct{})
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached list: %s:%d: expected a SHA-1 hex hash", path, line)
+		}
+		hashes[strings.ToUpper(hash)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("breached list: %w", err)
+	}
+
+	return &BreachedList{hashes: hashes}, nil
+}
+
+func (l *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if l.hashes != nil {
+		_, ok := l.hashes[hash]
+		return ok, nil
+	}
+
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+	f, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		s, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if strings.EqualFold(s, suffix) {
+			return true, nil
+		}
+	}
+	return false, sc.Err()
+}
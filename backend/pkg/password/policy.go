@@ -0,0 +1,152 @@
+// Package password проверяет новые пароли: длину, классы символов, совпадение с ником или email
+// и наличие в локальном списке утёкших паролей.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DefaultMinLength = 8
+	// DefaultMaxLength — bcrypt учитывает только первые 72 байта, длиннее пароль не принимается.
+	DefaultMaxLength = 72
+	maxBcryptBytes   = 72
+)
+
+var (
+	ErrTooShort        = errors.New("password is too short")
+	ErrTooLong         = errors.New("password is too long")
+	ErrTooFewClasses   = errors.New("password needs more character classes")
+	ErrMatchesIdentity = errors.New("password must not match the username or email")
+	ErrBreached        = errors.New("password appears in a known data breach")
+)
+
+// Config — политика паролей. Нулевые MinLength, MaxLength и BcryptCost заменяются значениями
+// по умолчанию; MinCharClasses = 0 — классы символов не проверяются.
+type Config struct {
+	MinLength int `yaml:"min_length" env:"MIN_LENGTH"`
+	MaxLength int `yaml:"max_length" env:"MAX_LENGTH"`
+	// MinCharClasses — сколько классов из четырёх (строчные, заглавные, цифры, остальные символы) нужно.
+	MinCharClasses int `yaml:"min_char_classes" env:"MIN_CHAR_CLASSES"`
+	// BreachedList — путь к списку SHA-1 утёкших паролей: каталог с файлами диапазонов HIBP
+	// (<5 hex>.txt со строками SUFFIX:COUNT) или один файл со строками HASH[:COUNT]. Пусто — не проверять.
+	BreachedList string `yaml:"breached_list" env:"BREACHED_LIST"`
+	// BcryptCost — стоимость bcrypt для новых хэшей. При её смене хэш пересчитывается при следующем входе.
+	BcryptCost int `yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+}
+
+type Policy struct {
+	minLength      int
+	maxLength      int
+	minCharClasses int
+	bcryptCost     int
+	breached       *BreachedList
+}
+
+func NewPolicy(cfg Config) (*Policy, error) {
+	p := &Policy{
+		minLength:      cfg.MinLength,
+		maxLength:      cfg.MaxLength,
+		minCharClasses: cfg.MinCharClasses,
+		bcryptCost:     cfg.BcryptCost,
+	}
+	if p.minLength <= 0 {
+		p.minLength = DefaultMinLength
+	}
+	if p.maxLength <= 0 || p.maxLength > DefaultMaxLength {
+		p.maxLength = DefaultMaxLength
+	}
+	if p.minLength > p.maxLength {
+		return nil, fmt.Errorf("password policy: min_length %d exceeds max_length %d", p.minLength, p.maxLength)
+	}
+	if p.minCharClasses < 0 || p.minCharClasses > 4 {
+		return nil, fmt.Errorf("password policy: min_char_classes must be 0-4, got %d", p.minCharClasses)
+	}
+	if p.bcryptCost == 0 {
+		p.bcryptCost = bcrypt.DefaultCost
+	}
+	if p.bcryptCost < bcrypt.MinCost || p.bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("password policy: bcrypt_cost must be %d-%d, got %d", bcrypt.MinCost, bcrypt.MaxCost, p.bcryptCost)
+	}
+
+	if cfg.BreachedList != "" {
+		list, err := LoadBreachedList(cfg.BreachedList)
+		if err != nil {
+			return nil, fmt.Errorf("password policy: %w", err)
+		}
+		p.breached = list
+	}
+
+	return p, nil
+}
+
+func (p *Policy) BcryptCost() int {
+	return p.bcryptCost
+}
+
+// Validate проверяет новый пароль. identity — ник, email и другие значения, которыми пароль быть не может;
+// для email запрещена и его локальная часть. Ошибка оборачивает одну из ErrTooShort, ErrTooLong,
+// ErrTooFewClasses, ErrMatchesIdentity, ErrBreached; её текст можно показать пользователю.
+func (p *Policy) Validate(password string, identity ...string) error {
+	if n := utf8.RuneCountInString(password); n < p.minLength {
+		return fmt.Errorf("%w: at least %d characters required", ErrTooShort, p.minLength)
+	} else if n > p.maxLength || len(password) > maxBcryptBytes {
+		return fmt.Errorf("%w: at most %d characters allowed", ErrTooLong, p.maxLength)
+	}
+
+	if classes := charClasses(password); classes < p.minCharClasses {
+		return fmt.Errorf("%w: use at least %d of lowercase, uppercase, digits and symbols", ErrTooFewClasses, p.minCharClasses)
+	}
+
+	for _, v := range identity {
+		if v == "" {
+			continue
+		}
+		local, _, _ := strings.Cut(v, "@")
+		if strings.EqualFold(password, v) || strings.EqualFold(password, local) {
+			return ErrMatchesIdentity
+		}
+	}
+
+	if p.breached != nil {
+		found, err := p.breached.Contains(password)
+		if err != nil {
+			return fmt.Errorf("check breached list: %w", err)
+		}
+		if found {
+			return ErrBreached
+		}
+	}
+
+	return nil
+}
+
+func charClasses(s string) int {
+	var lower, upper, digit, other bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
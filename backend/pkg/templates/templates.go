@@ -0,0 +1,144 @@
+// Package templates рендерит тексты уведомлений на языке получателя.
+//
+// Шаблоны лежат в locales/<язык>/<id>.tmpl и задают блоки "<id>.subject" (тема письма и заголовок push),
+// "<id>.text" (SMS, push и письмо без HTML) и "<id>.html" (письмо). Блоки subject и text рендерятся
+// через text/template, html — через html/template, поэтому переменные в письме экранируются.
+// Переменные передаются картой строк; обращение к непереданной переменной — ошибка рендеринга.
+// Если для языка нет шаблона или языка нет вовсе, берётся шаблон языка по умолчанию.
+package templates
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale — язык пользователя, который его не указал, и язык, на который падают недостающие шаблоны.
+const DefaultLocale = "ru"
+
+//go:embed locales
+var bundles embed.FS
+
+// Message — отрендеренный текст. Пустые поля — в шаблоне нет соответствующего блока.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type bundle struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+type Renderer struct {
+	bundles map[string]bundle
+}
+
+// New загружает встроенные шаблоны всех языков и проверяет, что для каждого id из required есть
+// шаблон языка по умолчанию.
+func New(required ...string) (*Renderer, error) {
+	locales, err := fs.ReadDir(bundles, "locales")
+	if err != nil {
+		return nil, fmt.Errorf("read locales: %w", err)
+	}
+
+	r := &Renderer{bundles: make(map[string]bundle, len(locales))}
+	for _, l := range locales {
+		if !l.IsDir() {
+			continue
+		}
+
+		pattern := "locales/" + l.Name() + "/*.tmpl"
+		text, err := texttemplate.New(l.Name()).Option("missingkey=error").ParseFS(bundles, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s templates: %w", l.Name(), err)
+		}
+		html, err := htmltemplate.New(l.Name()).Option("missingkey=error").ParseFS(bundles, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s html templates: %w", l.Name(), err)
+		}
+		r.bundles[l.Name()] = bundle{text: text, html: html}
+	}
+
+	if _, ok := r.bundles[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("no templates for default locale %q", DefaultLocale)
+	}
+	for _, id := range required {
+		if !r.has(DefaultLocale, id) {
+			return nil, fmt.Errorf("template %q is missing for default locale %q", id, DefaultLocale)
+		}
+	}
+
+	return r, nil
+}
+
+// Locales возвращает языки, для которых есть шаблоны.
+func (r *Renderer) Locales() []string {
+	locales := make([]string, 0, len(r.bundles))
+	for l := range r.bundles {
+		locales = append(locales, l)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Render рендерит шаблон id на языке locale.
+func (r *Renderer) Render(id, locale string, vars map[string]string) (Message, error) {
+	if !r.has(locale, id) {
+		locale = DefaultLocale
+	}
+	if !r.has(locale, id) {
+		return Message{}, fmt.Errorf("unknown template %q", id)
+	}
+
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	b := r.bundles[locale]
+	var (
+		msg Message
+		err error
+	)
+	if msg.Subject, err = executeText(b.text, id+".subject", vars); err != nil {
+		return Message{}, err
+	}
+	if msg.Text, err = executeText(b.text, id+".text", vars); err != nil {
+		return Message{}, err
+	}
+	if t := b.html.Lookup(id + ".html"); t != nil {
+		var sb strings.Builder
+		if err = t.Execute(&sb, vars); err != nil {
+			return Message{}, fmt.Errorf("render %s: %w", t.Name(), err)
+		}
+		msg.HTML = strings.TrimSpace(sb.String())
+	}
+
+	return msg, nil
+}
+
+func (r *Renderer) has(locale, id string) bool {
+	b, ok := r.bundles[locale]
+	if !ok {
+		return false
+	}
+	return b.text.Lookup(id+".subject") != nil || b.text.Lookup(id+".text") != nil || b.text.Lookup(id+".html") != nil
+}
+
+func executeText(tmpl *texttemplate.Template, name string, vars map[string]string) (string, error) {
+	t := tmpl.Lookup(name)
+	if t == nil {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("render %s: %w", name, err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
@@ -0,0 +1,118 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret — ключ тестовых векторов SHA-1 из RFC 6238 ("12345678901234567890") в base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238, приложение B: восьмизначные коды, у шестизначных — последние 6 цифр.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{unix: 59, code: "287082"},
+	{unix: 1111111109, code: "081804"},
+	{unix: 1111111111, code: "050471"},
+	{unix: 1234567890, code: "005924"},
+	{unix: 2000000000, code: "279037"},
+	{unix: 20000000000, code: "353130"},
+}
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	for _, v := range rfcVectors {
+		code, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "t=%d", v.unix)
+	}
+}
+
+func TestValidate_RFC6238Vectors(t *testing.T) {
+	for _, v := range rfcVectors {
+		at := time.Unix(v.unix, 0)
+		step, ok := Validate(rfcSecret, v.code, at, 0)
+		assert.True(t, ok, "t=%d", v.unix)
+		assert.Equal(t, Step(at), step)
+	}
+}
+
+func TestCode_InvalidSecret(t *testing.T) {
+	_, err := Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidate_SkewWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		step   int64
+		accept bool
+	}{
+		{name: "previous step", step: current - 1, accept: true},
+		{name: "current step", step: current, accept: true},
+		{name: "next step", step: current + 1, accept: true},
+		{name: "two steps behind", step: current - 2},
+		{name: "two steps ahead", step: current + 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, tt.step)
+			require.NoError(t, err)
+
+			step, ok := Validate(rfcSecret, code, now, 0)
+			assert.Equal(t, tt.accept, ok)
+			if tt.accept {
+				assert.Equal(t, tt.step, step)
+			}
+		})
+	}
+}
+
+func TestValidate_RejectsUsedSteps(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	code, err := Code(rfcSecret, current)
+	require.NoError(t, err)
+
+	// код текущего шага уже предъявлен
+	_, ok := Validate(rfcSecret, code, now, current)
+	assert.False(t, ok)
+
+	// предъявлен более новый код — старые шаги из окна тоже не принимаются
+	_, ok = Validate(rfcSecret, code, now, current+1)
+	assert.False(t, ok)
+
+	// предыдущий шаг использован, текущий — ещё нет
+	step, ok := Validate(rfcSecret, code, now, current-1)
+	assert.True(t, ok)
+	assert.Equal(t, current, step)
+
+	previous, err := Code(rfcSecret, current-1)
+	require.NoError(t, err)
+	_, ok = Validate(rfcSecret, previous, now, current-1)
+	assert.False(t, ok)
+}
+
+func TestValidate_Format(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	_, ok := Validate(rfcSecret, " 287082 ", now, 0)
+	assert.True(t, ok, "surrounding spaces are trimmed")
+
+	_, ok = Validate("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", now, 0)
+	assert.True(t, ok, "secret is case-insensitive")
+
+	for _, code := range []string{"", "28708", "2870820", "94287082", "000000"} {
+		_, ok = Validate(rfcSecret, code, now, 0)
+		assert.False(t, ok, "code %q", code)
+	}
+}